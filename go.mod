@@ -8,6 +8,9 @@ require (
 	github.com/dogeorg/governor v1.0.5
 	github.com/dogeorg/storelib v0.0.5
 	github.com/mattn/go-sqlite3 v1.14.28
+	github.com/pebbe/zmq4 v1.2.9
+	google.golang.org/grpc v1.64.1
+	google.golang.org/protobuf v1.34.2
 )
 
 require (
@@ -16,5 +19,8 @@ require (
 	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.4.0 // indirect
 	github.com/lib/pq v1.10.9 // indirect
 	github.com/mr-tron/base58 v1.2.0 // indirect
-	github.com/pebbe/zmq4 v1.2.9 // indirect
+	golang.org/x/net v0.26.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+	golang.org/x/text v0.17.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
 )
@@ -0,0 +1,37 @@
+package index
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dogeorg/doge"
+	walkerspec "github.com/dogeorg/dogewalker/spec"
+)
+
+// coreChainNames maps our chain params to the `chain` reported by Core's getblockchaininfo.
+var coreChainNames = map[string]string{
+	doge.DogeMainNetChain.ChainName: "main",
+	doge.DogeTestNetChain.ChainName: "test",
+	doge.DogeRegTestChain.ChainName: "regtest",
+}
+
+// VerifyChain checks that the Core node is on the expected chain, by comparing
+// the network name and genesis block hash it reports against `chain`.
+// This stops us indexing (and serving) a Bitcoin node or the wrong network.
+func VerifyChain(blockchain walkerspec.Blockchain, chain *doge.ChainParams, ctx context.Context) error {
+	info, err := blockchain.GetBlockchainInfo(ctx)
+	if err != nil {
+		return err
+	}
+	if expected, ok := coreChainNames[chain.ChainName]; ok && info.Chain != expected {
+		return fmt.Errorf("core node is on chain '%s', expected '%s' (check -chain and RPC_HOST)", info.Chain, expected)
+	}
+	genesis, err := blockchain.GetBlockHash(0, ctx)
+	if err != nil {
+		return err
+	}
+	if genesis != chain.GenesisBlock {
+		return fmt.Errorf("core node genesis block is %s, expected %s for %s (check -chain and RPC_HOST)", genesis, chain.GenesisBlock, chain.ChainName)
+	}
+	return nil
+}
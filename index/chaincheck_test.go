@@ -0,0 +1,71 @@
+package index
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dogeorg/doge"
+	walkerspec "github.com/dogeorg/dogewalker/spec"
+)
+
+// mockChain answers the RPC calls made by VerifyChain.
+type mockChain struct {
+	walkerspec.Blockchain // other methods are not called
+	chain                 string
+	genesis               string
+}
+
+func (m *mockChain) GetBlockchainInfo(ctx context.Context) (walkerspec.BlockchainInfo, error) {
+	return walkerspec.BlockchainInfo{Chain: m.chain}, nil
+}
+
+func (m *mockChain) GetBlockHash(blockHeight int64, ctx context.Context) (string, error) {
+	return m.genesis, nil
+}
+
+func TestVerifyChain(t *testing.T) {
+	tests := []struct {
+		name    string
+		node    mockChain
+		chain   *doge.ChainParams
+		wantErr string
+	}{
+		{
+			name:  "mainnet",
+			node:  mockChain{chain: "main", genesis: doge.DogeMainNetChain.GenesisBlock},
+			chain: &doge.DogeMainNetChain,
+		},
+		{
+			name:  "testnet",
+			node:  mockChain{chain: "test", genesis: doge.DogeTestNetChain.GenesisBlock},
+			chain: &doge.DogeTestNetChain,
+		},
+		{
+			name:    "testnet node for mainnet",
+			node:    mockChain{chain: "test", genesis: doge.DogeTestNetChain.GenesisBlock},
+			chain:   &doge.DogeMainNetChain,
+			wantErr: "core node is on chain 'test', expected 'main'",
+		},
+		{
+			name:    "bitcoin node",
+			node:    mockChain{chain: "main", genesis: doge.BitcoinMainChain.GenesisBlock},
+			chain:   &doge.DogeMainNetChain,
+			wantErr: "core node genesis block is 000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyChain(&tt.node, tt.chain, context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("VerifyChain: unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("VerifyChain: expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
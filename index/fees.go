@@ -0,0 +1,47 @@
+package index
+
+import (
+	"bytes"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+// blockFees totals the fees paid by a block's transactions: the value of
+// each non-coinbase transaction's inputs minus the value of all its outputs.
+//
+// Input values come from `spent` (the stored UTXOs the block spends) or from
+// earlier outputs in the same block. If an input spends an output the index
+// doesn't have (before -startingheight, non-standard, or skipped dust), the
+// total is unknown and `known` is false.
+func blockFees(block *doge.Block, spent []spec.UTXOState) (fees int64, known bool) {
+	type outpoint struct {
+		tx   string
+		vout uint32
+	}
+	values := make(map[outpoint]int64, len(spent))
+	for _, u := range spent {
+		values[outpoint{string(u.TxID), u.VOut}] = u.Value
+	}
+	for _, tx := range block.Tx {
+		if len(tx.VIn) > 0 && bytes.Equal(tx.VIn[0].TxID, Zeroes[:]) {
+			// coinbase: no real inputs, its outputs collect the fees
+		} else {
+			for _, in := range tx.VIn {
+				value, found := values[outpoint{string(in.TxID), in.VOut}]
+				if !found {
+					return 0, false
+				}
+				fees += value
+			}
+			for _, out := range tx.VOut {
+				fees -= out.Value
+			}
+		}
+		// later transactions in the block can spend these outputs
+		for vout, out := range tx.VOut {
+			values[outpoint{string(tx.TxID), uint32(vout)}] = out.Value
+		}
+	}
+	return fees, true
+}
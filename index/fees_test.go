@@ -0,0 +1,84 @@
+package index
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestBlockFees(t *testing.T) {
+	txid := func(b byte) []byte { return bytes.Repeat([]byte{b}, 32) }
+	coinbase := doge.BlockTx{
+		TxID: txid(0xC0),
+		VIn:  []doge.BlockTxIn{{TxID: Zeroes[:], VOut: 0xFFFFFFFF}},
+		VOut: []doge.BlockTxOut{p2pkhOutput(10_000 * ONE_DOGE)}, // subsidy + fees: not counted
+	}
+	// stored UTXOs: 0xA1:0 = 5 DOGE, 0xA1:1 = 3 DOGE, 0xA2:0 = 2.5 DOGE (already spent by this block)
+	spent := []spec.UTXOState{
+		{UTXO: spec.UTXO{TxID: txid(0xA1), VOut: 0, Value: 5 * ONE_DOGE}},
+		{UTXO: spec.UTXO{TxID: txid(0xA1), VOut: 1, Value: 3 * ONE_DOGE}},
+		{UTXO: spec.UTXO{TxID: txid(0xA2), VOut: 0, Value: 250_000_000}, Spent: 12},
+	}
+	tests := []struct {
+		name  string
+		tx    []doge.BlockTx
+		fees  int64
+		known bool
+	}{
+		{"Coinbase only", []doge.BlockTx{coinbase}, 0, true},
+		{
+			name: "Two inputs, change output",
+			tx: []doge.BlockTx{coinbase, {
+				TxID: txid(0xB1),
+				VIn:  []doge.BlockTxIn{{TxID: txid(0xA1), VOut: 0}, {TxID: txid(0xA1), VOut: 1}},
+				VOut: []doge.BlockTxOut{p2pkhOutput(6 * ONE_DOGE), p2pkhOutput(ONE_DOGE)},
+			}},
+			fees:  ONE_DOGE, // 8 in, 7 out
+			known: true,
+		},
+		{
+			name: "Outputs that aren't indexed still count",
+			tx: []doge.BlockTx{coinbase, {
+				TxID: txid(0xB1),
+				VIn:  []doge.BlockTxIn{{TxID: txid(0xA2), VOut: 0}},
+				VOut: []doge.BlockTxOut{p2pkhOutput(ONE_DOGE), {Value: ONE_DOGE, Script: []byte{doge.OP_RETURN}}},
+			}},
+			fees:  ONE_DOGE / 2, // 2.5 in, 2 out
+			known: true,
+		},
+		{
+			name: "Spending an output of the same block",
+			tx: []doge.BlockTx{coinbase, {
+				TxID: txid(0xB1),
+				VIn:  []doge.BlockTxIn{{TxID: txid(0xA1), VOut: 0}},
+				VOut: []doge.BlockTxOut{p2pkhOutput(4 * ONE_DOGE)},
+			}, {
+				TxID: txid(0xB2),
+				VIn:  []doge.BlockTxIn{{TxID: txid(0xB1), VOut: 0}, {TxID: txid(0xA1), VOut: 1}},
+				VOut: []doge.BlockTxOut{p2pkhOutput(6 * ONE_DOGE)},
+			}},
+			fees:  2 * ONE_DOGE, // 1 + 1
+			known: true,
+		},
+		{
+			name: "Input that isn't indexed",
+			tx: []doge.BlockTx{coinbase, {
+				TxID: txid(0xB1),
+				VIn:  []doge.BlockTxIn{{TxID: txid(0xA1), VOut: 0}, {TxID: txid(0xEE), VOut: 0}},
+				VOut: []doge.BlockTxOut{p2pkhOutput(ONE_DOGE)},
+			}},
+			fees:  0,
+			known: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fees, known := blockFees(&doge.Block{Tx: tt.tx}, spent)
+			if fees != tt.fees || known != tt.known {
+				t.Errorf("blockFees = %d, %v; want %d, %v", fees, known, tt.fees, tt.known)
+			}
+		})
+	}
+}
@@ -2,12 +2,15 @@ package index
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
+	"encoding/json"
 	"log"
 	"sync"
 	"time"
 
 	"github.com/dogeorg/doge"
+	"github.com/dogeorg/doge/koinu"
 	"github.com/dogeorg/dogewalker/walker"
 	"github.com/dogeorg/governor"
 	"github.com/dogeorg/indexer/spec"
@@ -19,6 +22,11 @@ const DUST_LIMIT = ONE_DOGE / 100   // 0.01 DOGE
 
 const trimIntervalBlocks = 1000 // Trim UTXOs every N blocks
 const maxBlockHistory = 10      // Keep last 10 blocks in memory
+const maxReorgHistory = 10      // Keep last 10 reorgs in memory
+
+const queueFullWarning = time.Minute // Warn when the block queue stays full this long
+
+const historySaveTimeout = 5 * time.Second // Save the history on shutdown within this long
 
 var Zeroes = [32]byte{}
 
@@ -26,16 +34,86 @@ var Zeroes = [32]byte{}
 type BlockHistory struct {
 	Height         int64         `json:"height"`
 	Hash           string        `json:"hash"`
-	Timestamp      time.Time     `json:"timestamp"`
+	Timestamp      time.Time     `json:"timestamp"`    // block header timestamp
+	ProcessedAt    time.Time     `json:"processed_at"` // when the Indexer committed the block
 	TxCount        int           `json:"tx_count"`
 	UTXOCreated    int           `json:"utxo_created"`
 	UTXOSpent      int           `json:"utxo_spent"`
+	CreatedValue   koinu.Koinu   `json:"created_value"` // sum of the indexed outputs created
+	SpentValue     koinu.Koinu   `json:"spent_value"`   // sum of the indexed UTXOs spent (orphan spends have no value)
 	ProcessingTime time.Duration `json:"processing_time_ms"`
 }
 
+// Reorg records an undo of indexed blocks (a chain reorganisation)
+type Reorg struct {
+	Height int64     `json:"height"`  // height the index was rolled back to
+	Depth  int64     `json:"depth"`   // blocks undone
+	UndoAt time.Time `json:"undo_at"` // when the Indexer committed the undo
+}
+
+// QueueStats describes the blocks DogeWalker has decoded ahead of the Indexer.
+// When the queue is full, DogeWalker waits: indexing (i.e. the database) is
+// the bottleneck.
+type QueueStats struct {
+	Length   int           // blocks waiting to be indexed
+	Capacity int           // most blocks DogeWalker can decode ahead
+	FullFor  time.Duration // how long the queue has been full (0 if it isn't)
+}
+
+// ShadowStatus reports whether writing to the shadow store (see shadow.go)
+// has failed. After the first failed block or undo the shadow is no longer
+// written, because later blocks would be applied on top of the gap.
+type ShadowStatus struct {
+	Failed   bool
+	FailedAt int64 // height of the block or undo that failed
+}
+
 // IndexerMonitor interface for accessing indexer state
 type IndexerMonitor interface {
 	GetBlockHistory() []BlockHistory
+	GetReorgHistory() []Reorg
+	GetQueueStats() QueueStats
+	GetShadowStatus() ShadowStatus
+}
+
+// IndexerControl interface for pausing indexing (e.g. during DB maintenance)
+// and rolling the index back (see Resync)
+type IndexerControl interface {
+	Pause()
+	Resume()
+	Paused() bool
+	Resync(ctx context.Context, height int64, hash []byte) error
+}
+
+// BlockListener is notified (on the Indexer goroutine) after each
+// block has been committed to the store.
+type BlockListener interface {
+	BlockIndexed(block *walker.ChainBlock)
+}
+
+// ActivityListener is a BlockListener that is also told which UTXOs each
+// block created and spent (on the Indexer goroutine, before BlockIndexed.)
+type ActivityListener interface {
+	BlockListener
+	BlockActivity(height int64, block *walker.ChainBlock, activity []Activity)
+}
+
+// Activity is a UTXO created or spent by an indexed block.
+type Activity struct {
+	spec.UTXO        // the UTXO created or spent
+	Tx        []byte // hash of the transaction that created or spent it
+	Spent     bool   // spent by Tx (otherwise created by it)
+}
+
+// IndexerOptions configures NewIndexer.
+type IndexerOptions struct {
+	TrimSpentAfter int64 // trim spent UTXOs older than this many blocks (0 to keep them forever)
+	SkipDust       bool  // do not index outputs below DUST_LIMIT
+	MaxUndoDepth   int64 // refuse to undo more than this many blocks (0 for no limit)
+	IndexZeroValue bool  // also index zero-value outputs with a standard script (SkipDust still skips them)
+	RecordInputs   bool  // record every transaction's input prevouts for /tx (explorer mode)
+
+	Shadow spec.Store // optional second store to also write (best-effort), e.g. while migrating
 }
 
 type Indexer struct {
@@ -44,17 +122,47 @@ type Indexer struct {
 	db             spec.Store
 	blocks         chan walker.BlockOrUndo
 	trimSpentAfter int64
+	skipDust       bool
+	indexZeroValue bool
+	recordInputs   bool
+	maxUndoDepth   int64
+	listeners      []BlockListener
+
+	// Optional shadow store (see shadow.go)
+	_shadow        spec.Store
+	shadow         spec.Store
+	shadowMismatch bool // the last comparison found a difference
+	shadowMutex    sync.Mutex
+	shadowStatus   ShadowStatus
+
+	// In-memory block and reorg history for monitoring
+	// (saved to the store when Run returns, and loaded when it first starts)
+	blockHistory  []BlockHistory
+	reorgHistory  []Reorg
+	historyMutex  sync.RWMutex
+	historyLoaded bool
+
+	// Paused by an admin (Run waits before taking the next block)
+	pauseMutex sync.Mutex
+	paused     bool
+	resumed    chan struct{} // closed by Resume
+	resynced   bool          // paused by a resync: Resume is refused until restart
+
+	// Resync requests from an admin (see resync.go)
+	resyncs chan resyncRequest
 
-	// In-memory block history for monitoring
-	blockHistory []BlockHistory
-	historyMutex sync.RWMutex
+	// Block queue occupancy (see GetQueueStats)
+	queueMutex  sync.Mutex
+	fullSince   time.Time // when the queue was first seen full (zero if it isn't)
+	lastWarning time.Time // when Run last warned about a full queue
 }
 
 // Ensure Indexer implements governor.Service
 var _ governor.Service = (*Indexer)(nil)
 
-// Ensure Indexer implements IndexerMonitor
+// Ensure Indexer implements IndexerMonitor and IndexerControl
 var _ IndexerMonitor = (*Indexer)(nil)
+var _ IndexerControl = (*Indexer)(nil)
 
 /*
  * NewIndexer creates an Indexer service that tracks the ChainState.
@@ -62,22 +170,42 @@ var _ IndexerMonitor = (*Indexer)(nil)
  * `onlyScriptType` is an optional ScriptType to index (if this is 0,
  * all standard spendable UTXOs are indexed, including multisig.
  */
-func NewIndexer(db spec.Store, blocks chan walker.BlockOrUndo, trimSpentAfter int64) *Indexer {
-	return &Indexer{_db: db, blocks: blocks, trimSpentAfter: trimSpentAfter}
+func NewIndexer(db spec.Store, blocks chan walker.BlockOrUndo, options IndexerOptions) *Indexer {
+	return &Indexer{_db: db, blocks: blocks, trimSpentAfter: options.TrimSpentAfter, skipDust: options.SkipDust, indexZeroValue: options.IndexZeroValue, recordInputs: options.RecordInputs, maxUndoDepth: options.MaxUndoDepth, _shadow: options.Shadow, resyncs: make(chan resyncRequest)}
+}
+
+// AddListener registers a BlockListener (must be called before the service starts)
+func (i *Indexer) AddListener(listener BlockListener) {
+	i.listeners = append(i.listeners, listener)
 }
 
 // Run is the entry point for the Indexer service (called by Governor)
 func (i *Indexer) Run() {
 	i.db = i._db.WithCtx(i.Context) // bind to service context
-	trimCounter := int64(0)
+	if i._shadow != nil {
+		i.shadow = i._shadow.WithCtx(i.Context)
+	}
+	if !i.historyLoaded {
+		i.loadHistory() // Governor restarts keep the in-memory history
+		i.historyLoaded = true
+	}
+	defer i.saveHistory()
 	done := i.Context.Done()
 	for !i.Stopping() {
+		i.warnIfQueueFull(time.Now())
 		var cmd walker.BlockOrUndo
 		select {
 		case cmd = <-i.blocks:
+		case req := <-i.resyncs:
+			req.result <- i.resync(req.height, req.hash)
+			continue
 		case <-done:
 			return // shutdown
 		}
+		// paused: hold this block until resumed (DogeWalker waits for us)
+		if !i.waitWhilePaused(done) {
+			return // shutdown
+		}
 		resumeHash, err := hex.DecodeString(cmd.LastProcessedBlock)
 		if err != nil {
 			log.Printf("[Indexer] cannot decode 'ResumeFromBlock' hex (from DogeWalker): %v", err)
@@ -86,80 +214,21 @@ func (i *Indexer) Run() {
 		if cmd.Block != nil {
 			// next block.
 			startTime := time.Now()
-			var removeUTXOs []spec.OutPointKey
-			var createUTXOs []spec.UTXO
-			for _, tx := range cmd.Block.Block.Tx {
-				txID := tx.TxID
-				for _, in := range tx.VIn {
-					// Ignore CoinBase input (all zeroes)
-					if !bytes.Equal(in.TxID, Zeroes[:]) {
-						removeUTXOs = append(removeUTXOs, spec.OutPoint(in.TxID, in.VOut))
-					}
-				}
-				// Go does not support uint32 with range (vout is an int)
-				// which theoretically could be a problem on a 32-bit system
-				for vout, out := range tx.VOut {
-					// Only index spendable outputs.
-					if out.Value > 0 {
-						typ, compact := doge.ClassifyScript(out.Script)
-						if typ != doge.ScriptTypeNonStandard && typ != doge.ScriptTypeNullData {
-							createUTXOs = append(createUTXOs, spec.UTXO{
-								TxID:   txID,
-								VOut:   uint32(vout),
-								Value:  out.Value,
-								Type:   typ,
-								Script: compact,
-							})
-						}
-					}
-				}
-			}
-			if removeUTXOs != nil || createUTXOs != nil {
-				// We cannot admit failure here (we would de-sync from ChainState),
-				// so keep trying until someone fixes the DB, or someone stops
-				// the Indexer and fixes a bug.
-				for !i.Stopping() {
-					err := i.db.Transact(func(tx spec.StoreTx) error {
-						if removeUTXOs != nil {
-							err := tx.RemoveUTXOs(removeUTXOs, cmd.Height)
-							if err != nil {
-								return err
-							}
-						}
-						if createUTXOs != nil {
-							err := tx.CreateUTXOs(createUTXOs, cmd.Height)
-							if err != nil {
-								return err
-							}
-						}
-						return tx.SetResumePoint(resumeHash, cmd.Height)
-					})
-					if err == nil {
-						break
-					}
-					log.Printf("[Indexer] commit failed (will retry): %v", err)
-					i.Sleep(RETRY_DELAY)
-				}
+			removeUTXOs, createUTXOs := i.blockChanges(&cmd.Block.Block)
+			var spent []spec.UTXOState
+			var storedTime time.Duration // processing time in block_stats (also written to the shadow)
+			elapsed := func() time.Duration {
+				storedTime = time.Since(startTime)
+				return storedTime
 			}
-
-			// Record block in history
-			processingTime := time.Since(startTime)
-			i.recordBlockHistory(cmd.Height, cmd.Block.Hash, len(cmd.Block.Block.Tx), len(createUTXOs), len(removeUTXOs), processingTime)
-
-			log.Printf("[%v] %v DONE", cmd.Height, cmd.Block.Hash)
-		} else if cmd.Undo != nil {
-			log.Printf("[%v] undo to: %v", cmd.Undo.LastValidHeight, cmd.Undo.LastValidHash)
-			// undo blocks.
 			// We cannot admit failure here (we would de-sync from ChainState),
 			// so keep trying until someone fixes the DB, or someone stops
 			// the Indexer and fixes a bug.
 			for !i.Stopping() {
 				err := i.db.Transact(func(tx spec.StoreTx) error {
-					err := tx.UndoAbove(cmd.Height)
-					if err != nil {
-						return err
-					}
-					return tx.SetResumePoint(resumeHash, cmd.Height)
+					var err error
+					spent, err = i.writeBlock(tx, cmd.Height, &cmd.Block.Block, resumeHash, removeUTXOs, createUTXOs, elapsed)
+					return err
 				})
 				if err == nil {
 					break
@@ -167,23 +236,361 @@ func (i *Indexer) Run() {
 				log.Printf("[Indexer] commit failed (will retry): %v", err)
 				i.Sleep(RETRY_DELAY)
 			}
+			spentValue := valueSpentAt(spent, cmd.Height)
+
+			// Record block in history
+			processingTime := time.Since(startTime)
+			i.recordBlockHistory(cmd.Height, cmd.Block, createUTXOs, len(removeUTXOs), spentValue, processingTime)
+			var activity []Activity
+			for _, listener := range i.listeners {
+				if watcher, ok := listener.(ActivityListener); ok {
+					if activity == nil {
+						activity = blockActivity(&cmd.Block.Block, createUTXOs, spent, cmd.Height)
+					}
+					watcher.BlockActivity(cmd.Height, cmd.Block, activity)
+				}
+				listener.BlockIndexed(cmd.Block)
+			}
+
+			i.shadowBlock(cmd.Height, &cmd.Block.Block, resumeHash, removeUTXOs, createUTXOs, storedTime)
+			log.Printf("[%v] %v DONE", cmd.Height, cmd.Block.Hash)
+			i.maybeTrim(cmd.Height)
+		} else if cmd.Undo != nil {
+			log.Printf("[%v] undo to: %v", cmd.Undo.LastValidHeight, cmd.Undo.LastValidHash)
+			if !i.undo(cmd.Height, resumeHash) {
+				// Refused: wait for a human. Returning would let Governor
+				// restart us, and we would index the next blocks on top
+				// of a chain we did not undo.
+				<-done
+				return
+			}
 		} else {
 			// idle: nothing to do.
 		}
-		trimCounter += 1
-		if trimCounter >= trimIntervalBlocks {
-			trimCounter = 0
-			// Trim spent UTXOs older than 'trimSpentAfter' blocks
-			trimHeight := cmd.Height - i.trimSpentAfter
-			if trimHeight > 1 {
-				log.Printf("[Indexer] trim older than: %v", trimHeight)
-				err := i.db.TrimSpentUTXOs(trimHeight)
-				if err != nil {
-					log.Printf("[Indexer] trim failed: %v", err)
-				}
+	}
+}
+
+// writeBlock applies a block's changes to the store at `height` and advances
+// the resume point; returns the stored UTXOs it spends (before marking them.)
+// `elapsed` gives the block's processing time so far, for its stats.
+func (i *Indexer) writeBlock(tx spec.StoreTx, height int64, block *doge.Block, resumeHash []byte, removeUTXOs []spec.OutPointKey, createUTXOs []spec.UTXO, elapsed func() time.Duration) (spent []spec.UTXOState, err error) {
+	// create first: inputs can spend outputs created earlier in the block
+	if createUTXOs != nil {
+		err = tx.CreateUTXOs(createUTXOs, height)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if removeUTXOs != nil {
+		// the values being spent, before RemoveUTXOs marks them
+		spent, err = tx.GetUTXOs(removeUTXOs)
+		if err != nil {
+			return nil, err
+		}
+		err = tx.RemoveUTXOs(removeUTXOs, height)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if i.recordInputs {
+		err = tx.CreateTxInputs(blockInputs(block), height)
+		if err != nil {
+			return nil, err
+		}
+	}
+	stats := spec.BlockStats{Height: height, TxCount: int64(len(block.Tx)), UTXOCreated: int64(len(createUTXOs)), UTXOSpent: int64(len(removeUTXOs)), Detailed: true}
+	stats.Fees, stats.FeesKnown = blockFees(block, spent)
+	stats.ProcessingTime = elapsed()
+	err = tx.SetBlockStats(stats)
+	if err != nil {
+		return nil, err
+	}
+	// always advance, even if the block changed no UTXOs
+	return spent, tx.SetResumePoint(resumeHash, height)
+}
+
+// maybeTrim trims spent UTXOs older than 'trimSpentAfter' blocks, once that is
+// trimIntervalBlocks above the stored trimmed-below height (so the cadence
+// doesn't depend on when the service last restarted.)
+func (i *Indexer) maybeTrim(height int64) {
+	if i.trimSpentAfter <= 0 {
+		return // keep spent UTXOs forever
+	}
+	trimHeight := height - i.trimSpentAfter
+	if trimHeight <= 1 {
+		return
+	}
+	trimmed, err := i.db.GetTrimmedBelow()
+	if err != nil {
+		log.Printf("[Indexer] get trimmed height: %v", err)
+		return
+	}
+	if trimHeight-trimmed < trimIntervalBlocks {
+		return
+	}
+	log.Printf("[Indexer] trim older than: %v", trimHeight)
+	err = i.db.Transact(func(tx spec.StoreTx) error {
+		return tx.TrimSpentUTXOs(trimHeight)
+	})
+	if err != nil {
+		log.Printf("[Indexer] trim failed: %v", err)
+		return // keep the shadow in step with the store
+	}
+	i.shadowTrim(trimHeight)
+}
+
+// undo rolls the index back to `height`, unless that is more than
+// maxUndoDepth blocks below the current height (returns false if refused.)
+func (i *Indexer) undo(height int64, resumeHash []byte) bool {
+	var current int64
+	for !i.Stopping() {
+		var err error
+		current, err = i.db.GetCurrentHeight()
+		if err == nil {
+			break
+		}
+		log.Printf("[Indexer] get current height failed (will retry): %v", err)
+		i.Sleep(RETRY_DELAY)
+	}
+	depth := current - height
+	if i.maxUndoDepth > 0 && depth > i.maxUndoDepth {
+		log.Printf("[Indexer] !!! REFUSING UNDO of %v blocks (from %v to %v): more than the maximum undo depth of %v. "+
+			"Indexing has stopped: check Core and the chain, then restart the indexer (or raise -maxundo) !!!",
+			depth, current, height, i.maxUndoDepth)
+		return false
+	}
+	// We cannot admit failure here (we would de-sync from ChainState),
+	// so keep trying until someone fixes the DB, or someone stops
+	// the Indexer and fixes a bug.
+	for !i.Stopping() {
+		err := i.db.Transact(func(tx spec.StoreTx) error {
+			err := tx.UndoAbove(height)
+			if err != nil {
+				return err
 			}
+			return tx.SetResumePoint(resumeHash, height)
+		})
+		if err == nil {
+			i.recordReorg(height, depth)
+			i.shadowUndo(height, resumeHash)
+			break
 		}
+		log.Printf("[Indexer] commit failed (will retry): %v", err)
+		i.Sleep(RETRY_DELAY)
 	}
+	return true
+}
+
+// blockChanges collects the UTXOs spent and created by a block.
+func (i *Indexer) blockChanges(block *doge.Block) (removeUTXOs []spec.OutPointKey, createUTXOs []spec.UTXO) {
+	for _, tx := range block.Tx {
+		txID := tx.TxID
+		coinbase := false
+		for _, in := range tx.VIn {
+			// Ignore CoinBase input (all zeroes)
+			if bytes.Equal(in.TxID, Zeroes[:]) {
+				coinbase = true
+			} else {
+				removeUTXOs = append(removeUTXOs, spec.OutPoint(in.TxID, in.VOut))
+			}
+		}
+		// range gives an int index: it is below len(tx.VOut), which is far
+		// below 2^32 (a block can't hold that many outputs), so uint32(vout)
+		// can't overflow, even where int is 32 bits.
+		for vout, out := range tx.VOut {
+			if i.skipDust && out.Value < DUST_LIMIT {
+				continue // spending it later is a no-op in RemoveUTXOs
+			}
+			if typ, compact, ok := i.classifyOutput(out); ok {
+				createUTXOs = append(createUTXOs, spec.UTXO{
+					TxID:     txID,
+					VOut:     uint32(vout),
+					Value:    out.Value,
+					Type:     typ,
+					Script:   compact,
+					Coinbase: coinbase,
+				})
+			}
+		}
+	}
+	return removeUTXOs, createUTXOs
+}
+
+// blockInputs lists the prevout of every non-coinbase input in the block (explorer mode.)
+func blockInputs(block *doge.Block) (inputs []spec.TxInput) {
+	for _, tx := range block.Tx {
+		for vin, in := range tx.VIn {
+			if bytes.Equal(in.TxID, Zeroes[:]) {
+				continue // coinbase: spends nothing
+			}
+			inputs = append(inputs, spec.TxInput{TxID: tx.TxID, VIn: uint32(vin), Prev: spec.OutPoint(in.TxID, in.VOut)})
+		}
+	}
+	return inputs
+}
+
+// ClassifyOutput decides whether a transaction output is indexed.
+// Only spendable outputs with a standard script are indexed;
+// returns the script type and compact script if so.
+func ClassifyOutput(out doge.BlockTxOut) (doge.ScriptType, []byte, bool) {
+	if out.Value <= 0 {
+		return doge.ScriptTypeNone, nil, false
+	}
+	typ, compact, indexed := ClassifyScript(out.Script)
+	if !indexed {
+		return doge.ScriptTypeNone, nil, false
+	}
+	return typ, compact, true
+}
+
+// classifyOutput is ClassifyOutput, except that zero-value outputs with a
+// standard script are indexed if IndexZeroValue is set (e.g. protocol markers.)
+func (i *Indexer) classifyOutput(out doge.BlockTxOut) (doge.ScriptType, []byte, bool) {
+	if out.Value == 0 && i.indexZeroValue {
+		return ClassifyScript(out.Script)
+	}
+	return ClassifyOutput(out)
+}
+
+// ClassifyScript classifies a scriptPubKey the same way as ClassifyOutput,
+// but also returns the type of scripts that are not indexed (for -classify.)
+// Non-standard scripts (including ones that only look like MultiSig) and
+// OP_RETURN data are not indexed.
+func ClassifyScript(script []byte) (doge.ScriptType, []byte, bool) {
+	typ, compact := doge.ClassifyScript(script)
+	return typ, compact, IsIndexedType(typ)
+}
+
+// IsIndexedType reports whether outputs of a script type are indexed.
+func IsIndexedType(typ doge.ScriptType) bool {
+	return typ != doge.ScriptTypeNonStandard && typ != doge.ScriptTypeNullData
+}
+
+// Pause stops indexing before the next block is written (called on any goroutine.)
+// DogeWalker blocks until indexing resumes.
+func (i *Indexer) Pause() {
+	i.pauseMutex.Lock()
+	defer i.pauseMutex.Unlock()
+	if !i.paused {
+		i.paused = true
+		i.resumed = make(chan struct{})
+		log.Printf("[Indexer] paused")
+	}
+}
+
+// Resume continues indexing after Pause (called on any goroutine.)
+func (i *Indexer) Resume() {
+	i.pauseMutex.Lock()
+	defer i.pauseMutex.Unlock()
+	if i.resynced {
+		log.Printf("[Indexer] cannot resume after a resync: restart the indexer")
+		return
+	}
+	if i.paused {
+		i.paused = false
+		close(i.resumed)
+		log.Printf("[Indexer] resumed")
+	}
+}
+
+// Paused reports whether indexing is paused.
+func (i *Indexer) Paused() bool {
+	i.pauseMutex.Lock()
+	defer i.pauseMutex.Unlock()
+	return i.paused
+}
+
+// waitWhilePaused blocks while paused, handling resync requests
+// (returns false on shutdown.)
+func (i *Indexer) waitWhilePaused(done <-chan struct{}) bool {
+	i.pauseMutex.Lock()
+	paused, resumed := i.paused, i.resumed
+	i.pauseMutex.Unlock()
+	if !paused {
+		return true
+	}
+	for {
+		select {
+		case <-resumed:
+			return true
+		case req := <-i.resyncs:
+			req.result <- i.resync(req.height, req.hash)
+		case <-done:
+			return false
+		}
+	}
+}
+
+// GetQueueStats reports the occupancy of the block queue (called on any goroutine.)
+func (i *Indexer) GetQueueStats() QueueStats {
+	return i.observeQueue(time.Now())
+}
+
+// observeQueue samples the block queue at `now`, tracking how long it has been full.
+func (i *Indexer) observeQueue(now time.Time) QueueStats {
+	i.queueMutex.Lock()
+	defer i.queueMutex.Unlock()
+	stats := QueueStats{Length: len(i.blocks), Capacity: cap(i.blocks)}
+	if stats.Capacity == 0 || stats.Length < stats.Capacity {
+		i.fullSince = time.Time{}
+		return stats
+	}
+	if i.fullSince.IsZero() {
+		i.fullSince = now
+	}
+	stats.FullFor = now.Sub(i.fullSince)
+	return stats
+}
+
+// warnIfQueueFull logs (at most once per queueFullWarning) while the block
+// queue has been full for queueFullWarning or longer. Returns true if it logged.
+func (i *Indexer) warnIfQueueFull(now time.Time) bool {
+	stats := i.observeQueue(now)
+	if stats.FullFor < queueFullWarning || now.Sub(i.lastWarning) < queueFullWarning {
+		return false
+	}
+	i.lastWarning = now
+	log.Printf("[Indexer] WARNING: block queue full (%d blocks) for %v: indexing can't keep up, DogeWalker is waiting", stats.Length, stats.FullFor.Round(time.Second))
+	return true
+}
+
+// valueSpentAt sums the UTXOs a block at `height` spends: those still unspent,
+// or already spent at `height` (the block is being indexed again.)
+func valueSpentAt(utxos []spec.UTXOState, height int64) (sum koinu.Koinu) {
+	for _, u := range utxos {
+		if u.Spent == 0 || u.Spent == height {
+			sum += koinu.Koinu(u.Value)
+		}
+	}
+	return sum
+}
+
+// blockActivity lists the UTXOs a block at `height` created, then those it
+// spent (as valueSpentAt), with the transactions that created or spent them.
+func blockActivity(block *doge.Block, created []spec.UTXO, spent []spec.UTXOState, height int64) []Activity {
+	activity := make([]Activity, 0, len(created)+len(spent))
+	for _, u := range created {
+		activity = append(activity, Activity{UTXO: u, Tx: u.TxID})
+	}
+	if len(spent) == 0 {
+		return activity
+	}
+	type outpoint struct {
+		tx   string
+		vout uint32
+	}
+	spenders := map[outpoint][]byte{}
+	for _, tx := range block.Tx {
+		for _, in := range tx.VIn {
+			spenders[outpoint{string(in.TxID), in.VOut}] = tx.TxID
+		}
+	}
+	for _, u := range spent {
+		if u.Spent == 0 || u.Spent == height {
+			activity = append(activity, Activity{UTXO: u.UTXO, Tx: spenders[outpoint{string(u.TxID), u.VOut}], Spent: true})
+		}
+	}
+	return activity
 }
 
 // GetBlockHistory returns a copy of the recent block history for monitoring
@@ -197,18 +604,47 @@ func (i *Indexer) GetBlockHistory() []BlockHistory {
 	return history
 }
 
+// GetReorgHistory returns a copy of the recent reorgs (most recent first) for monitoring
+func (i *Indexer) GetReorgHistory() []Reorg {
+	i.historyMutex.RLock()
+	defer i.historyMutex.RUnlock()
+
+	history := make([]Reorg, len(i.reorgHistory))
+	copy(history, i.reorgHistory)
+	return history
+}
+
+// recordReorg adds an undo to the sliding window of reorgs
+func (i *Indexer) recordReorg(height int64, depth int64) {
+	i.historyMutex.Lock()
+	defer i.historyMutex.Unlock()
+
+	i.reorgHistory = append([]Reorg{{Height: height, Depth: depth, UndoAt: time.Now()}}, i.reorgHistory...)
+	if len(i.reorgHistory) > maxReorgHistory {
+		i.reorgHistory = i.reorgHistory[:maxReorgHistory]
+	}
+}
+
 // recordBlockHistory adds a block to the sliding window history
-func (i *Indexer) recordBlockHistory(height int64, hash string, txCount, utxoCreated, utxoSpent int, processingTime time.Duration) {
+func (i *Indexer) recordBlockHistory(height int64, chainBlock *walker.ChainBlock, created []spec.UTXO, utxoSpent int, spentValue koinu.Koinu, processingTime time.Duration) {
+	var createdValue koinu.Koinu
+	for _, u := range created {
+		createdValue += koinu.Koinu(u.Value)
+	}
+
 	i.historyMutex.Lock()
 	defer i.historyMutex.Unlock()
 
 	block := BlockHistory{
 		Height:         height,
-		Hash:           hash,
-		Timestamp:      time.Now(),
-		TxCount:        txCount,
-		UTXOCreated:    utxoCreated,
+		Hash:           chainBlock.Hash,
+		Timestamp:      time.Unix(int64(chainBlock.Block.Header.Timestamp), 0).UTC(),
+		ProcessedAt:    time.Now(),
+		TxCount:        len(chainBlock.Block.Tx),
+		UTXOCreated:    len(created),
 		UTXOSpent:      utxoSpent,
+		CreatedValue:   createdValue,
+		SpentValue:     spentValue,
 		ProcessingTime: processingTime,
 	}
 
@@ -220,3 +656,52 @@ func (i *Indexer) recordBlockHistory(height int64, hash string, txCount, utxoCre
 		i.blockHistory = i.blockHistory[:maxBlockHistory]
 	}
 }
+
+// savedHistory is the monitoring state kept in the store across restarts.
+type savedHistory struct {
+	Blocks []BlockHistory `json:"blocks"`
+	Reorgs []Reorg        `json:"reorgs"`
+}
+
+// saveHistory saves the block and reorg history to the store (on shutdown,
+// so it doesn't use the service context, which is already cancelled.)
+func (i *Indexer) saveHistory() {
+	state, err := json.Marshal(savedHistory{Blocks: i.GetBlockHistory(), Reorgs: i.GetReorgHistory()})
+	if err != nil {
+		log.Printf("[Indexer] encode history: %v", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), historySaveTimeout)
+	defer cancel()
+	if err := i._db.WithCtx(ctx).SetMonitorState(state); err != nil {
+		log.Printf("[Indexer] save history: %v", err)
+	}
+}
+
+// loadHistory restores the block and reorg history saved by saveHistory, so
+// dashboards don't show a gap after a restart.
+func (i *Indexer) loadHistory() {
+	state, err := i.db.GetMonitorState()
+	if err != nil {
+		log.Printf("[Indexer] load history: %v", err)
+		return
+	}
+	if state == nil {
+		return // never saved
+	}
+	var saved savedHistory
+	if err := json.Unmarshal(state, &saved); err != nil {
+		log.Printf("[Indexer] decode saved history: %v", err)
+		return
+	}
+	if len(saved.Blocks) > maxBlockHistory {
+		saved.Blocks = saved.Blocks[:maxBlockHistory]
+	}
+	if len(saved.Reorgs) > maxReorgHistory {
+		saved.Reorgs = saved.Reorgs[:maxReorgHistory]
+	}
+	i.historyMutex.Lock()
+	defer i.historyMutex.Unlock()
+	i.blockHistory = saved.Blocks
+	i.reorgHistory = saved.Reorgs
+}
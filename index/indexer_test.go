@@ -0,0 +1,770 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/dogewalker/walker"
+	"github.com/dogeorg/indexer/spec"
+	"github.com/dogeorg/indexer/store"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func p2pkhOutput(value int64) doge.BlockTxOut {
+	script, _ := doge.P2PKHScript(make([]byte, 20))
+	return doge.BlockTxOut{Value: value, Script: script}
+}
+
+func TestBlockChangesSkipDust(t *testing.T) {
+	block := &doge.Block{Tx: []doge.BlockTx{{
+		TxID: make([]byte, 32),
+		VIn:  []doge.BlockTxIn{{TxID: Zeroes[:], VOut: 0xFFFFFFFF}}, // coinbase
+		VOut: []doge.BlockTxOut{
+			p2pkhOutput(DUST_LIMIT - 1),
+			p2pkhOutput(DUST_LIMIT),
+			p2pkhOutput(ONE_DOGE),
+		},
+	}}}
+
+	tests := []struct {
+		name     string
+		skipDust bool
+		want     []uint32 // vouts created
+	}{
+		{"index everything", false, []uint32{0, 1, 2}},
+		{"skip dust", true, []uint32{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexer := NewIndexer(nil, nil, IndexerOptions{SkipDust: tt.skipDust})
+			removes, creates := indexer.blockChanges(block)
+			if len(removes) != 0 {
+				t.Fatalf("removes = %d, want 0 (coinbase input)", len(removes))
+			}
+			if len(creates) != len(tt.want) {
+				t.Fatalf("creates = %+v, want vouts %v", creates, tt.want)
+			}
+			for n, vout := range tt.want {
+				if creates[n].VOut != vout {
+					t.Fatalf("creates[%d].VOut = %d, want %d", n, creates[n].VOut, vout)
+				}
+			}
+		})
+	}
+}
+
+func TestBlockChangesZeroValue(t *testing.T) {
+	block := &doge.Block{Tx: []doge.BlockTx{{
+		TxID: make([]byte, 32),
+		VIn:  []doge.BlockTxIn{{TxID: Zeroes[:], VOut: 0xFFFFFFFF}}, // coinbase
+		VOut: []doge.BlockTxOut{
+			p2pkhOutput(ONE_DOGE),
+			p2pkhOutput(0), // zero-value marker
+			{Value: 0, Script: []byte{doge.OP_RETURN, 1, 0xab}},     // OP_RETURN is never indexed
+			{Value: 0, Script: []byte{doge.OP_1, doge.OP_CHECKSIG}}, // nor is a non-standard script
+		},
+	}}}
+
+	tests := []struct {
+		name    string
+		options IndexerOptions
+		want    []uint32 // vouts created
+	}{
+		{"skip zero-value outputs", IndexerOptions{}, []uint32{0}},
+		{"index zero-value outputs", IndexerOptions{IndexZeroValue: true}, []uint32{0, 1}},
+		{"skip dust wins", IndexerOptions{IndexZeroValue: true, SkipDust: true}, []uint32{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, creates := NewIndexer(nil, nil, tt.options).blockChanges(block)
+			if len(creates) != len(tt.want) {
+				t.Fatalf("creates = %+v, want vouts %v", creates, tt.want)
+			}
+			for n, vout := range tt.want {
+				if creates[n].VOut != vout || creates[n].Type != doge.ScriptTypeP2PKH {
+					t.Fatalf("creates[%d] = %+v, want a P2PKH UTXO at vout %d", n, creates[n], vout)
+				}
+			}
+		})
+	}
+}
+
+func TestBlockChangesMarksCoinbase(t *testing.T) {
+	block := &doge.Block{Tx: []doge.BlockTx{
+		{
+			TxID: bytes.Repeat([]byte{1}, 32),
+			VIn:  []doge.BlockTxIn{{TxID: Zeroes[:], VOut: 0xFFFFFFFF}}, // coinbase
+			VOut: []doge.BlockTxOut{p2pkhOutput(ONE_DOGE)},
+		},
+		{
+			TxID: bytes.Repeat([]byte{2}, 32),
+			VIn:  []doge.BlockTxIn{{TxID: bytes.Repeat([]byte{9}, 32), VOut: 0}},
+			VOut: []doge.BlockTxOut{p2pkhOutput(ONE_DOGE)},
+		},
+	}}
+	indexer := NewIndexer(nil, nil, IndexerOptions{})
+	_, creates := indexer.blockChanges(block)
+	if len(creates) != 2 || !creates[0].Coinbase || creates[1].Coinbase {
+		t.Fatalf("creates = %+v, want only the first (coinbase) output marked Coinbase", creates)
+	}
+}
+
+func TestBlockInputs(t *testing.T) {
+	spender := bytes.Repeat([]byte{2}, 32)
+	block := &doge.Block{Tx: []doge.BlockTx{
+		{
+			TxID: bytes.Repeat([]byte{1}, 32),
+			VIn:  []doge.BlockTxIn{{TxID: Zeroes[:], VOut: 0xFFFFFFFF}}, // coinbase
+			VOut: []doge.BlockTxOut{p2pkhOutput(ONE_DOGE)},
+		},
+		{
+			TxID: spender,
+			VIn:  []doge.BlockTxIn{{TxID: bytes.Repeat([]byte{9}, 32), VOut: 3}, {TxID: bytes.Repeat([]byte{8}, 32), VOut: 0}},
+			VOut: []doge.BlockTxOut{p2pkhOutput(ONE_DOGE)},
+		},
+	}}
+	inputs := blockInputs(block)
+	if len(inputs) != 2 {
+		t.Fatalf("inputs = %+v, want the two non-coinbase inputs", inputs)
+	}
+	for vin, prev := range []spec.OutPointKey{spec.OutPoint(bytes.Repeat([]byte{9}, 32), 3), spec.OutPoint(bytes.Repeat([]byte{8}, 32), 0)} {
+		in := inputs[vin]
+		if !bytes.Equal(in.TxID, spender) || in.VIn != uint32(vin) || !bytes.Equal(in.Prev.Tx, prev.Tx) || in.Prev.VOut != prev.VOut {
+			t.Errorf("inputs[%d] = %+v, want vin %d spending %x:%d", vin, in, vin, prev.Tx, prev.VOut)
+		}
+	}
+}
+
+func TestRecordBlockHistoryUsesHeaderTimestamp(t *testing.T) {
+	indexer := NewIndexer(nil, nil, IndexerOptions{})
+	headerTime := time.Date(2021, time.May, 8, 10, 0, 0, 0, time.UTC)
+	block := &walker.ChainBlock{
+		Hash:  "abcd",
+		Block: doge.Block{Header: doge.BlockHeader{Timestamp: uint32(headerTime.Unix())}, Tx: make([]doge.BlockTx, 3)},
+	}
+
+	before := time.Now()
+	indexer.recordBlockHistory(3700000, block, make([]spec.UTXO, 5), 2, 0, time.Millisecond)
+
+	history := indexer.GetBlockHistory()
+	if len(history) != 1 {
+		t.Fatalf("history length = %d, want 1", len(history))
+	}
+	got := history[0]
+	if !got.Timestamp.Equal(headerTime) {
+		t.Fatalf("Timestamp = %v, want block header time %v", got.Timestamp, headerTime)
+	}
+	if got.ProcessedAt.Before(before) {
+		t.Fatalf("ProcessedAt = %v, want processing time (after %v)", got.ProcessedAt, before)
+	}
+	if got.TxCount != 3 || got.UTXOCreated != 5 || got.UTXOSpent != 2 {
+		t.Fatalf("history = %+v, want 3 tx, 5 created, 2 spent", got)
+	}
+}
+
+func TestUndoRefusesOversizedUndo(t *testing.T) {
+	kind := doge.ScriptTypeP2PKH
+	addr := make([]byte, 20)
+	tests := []struct {
+		name      string
+		undoTo    int64
+		wantUndo  bool
+		wantUTXOs int
+	}{
+		{"within the limit", 950, true, 1},
+		{"exactly the limit", 900, true, 1},
+		{"oversized undo", 899, false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := store.NewIndexStore(":memory:", context.Background(), store.Options{})
+			if err != nil {
+				t.Fatalf("NewIndexStore: %v", err)
+			}
+			defer db.Close()
+			// one UTXO at height 100 and one at height 990, indexed up to 1000
+			err = db.Transact(func(tx spec.StoreTx) error {
+				if err := tx.CreateUTXOs([]spec.UTXO{{TxID: bytes.Repeat([]byte{1}, 32), Value: ONE_DOGE, Type: kind, Script: addr}}, 100); err != nil {
+					return err
+				}
+				if err := tx.CreateUTXOs([]spec.UTXO{{TxID: bytes.Repeat([]byte{2}, 32), Value: ONE_DOGE, Type: kind, Script: addr}}, 990); err != nil {
+					return err
+				}
+				return tx.SetResumePoint([]byte{0xAA}, 1000)
+			})
+			if err != nil {
+				t.Fatalf("setup: %v", err)
+			}
+
+			indexer := NewIndexer(db, nil, IndexerOptions{MaxUndoDepth: 100})
+			indexer.Context = context.Background()
+			indexer.db = db
+
+			if got := indexer.undo(tt.undoTo, []byte{0xBB}); got != tt.wantUndo {
+				t.Fatalf("undo(%d) = %v, want %v", tt.undoTo, got, tt.wantUndo)
+			}
+			height, err := db.GetCurrentHeight()
+			if err != nil {
+				t.Fatalf("GetCurrentHeight: %v", err)
+			}
+			wantHeight := int64(1000) // untouched
+			if tt.wantUndo {
+				wantHeight = tt.undoTo
+			}
+			if height != wantHeight {
+				t.Fatalf("height after undo = %d, want %d", height, wantHeight)
+			}
+			reorgs := indexer.GetReorgHistory()
+			if tt.wantUndo && (len(reorgs) != 1 || reorgs[0].Height != tt.undoTo || reorgs[0].Depth != 1000-tt.undoTo) {
+				t.Fatalf("reorg history = %+v, want an undo of %d blocks to %d", reorgs, 1000-tt.undoTo, tt.undoTo)
+			}
+			if !tt.wantUndo && len(reorgs) != 0 {
+				t.Fatalf("reorg history = %+v, want none (refused)", reorgs)
+			}
+			utxos, err := db.FindUTXOs(kind, addr)
+			if err != nil {
+				t.Fatalf("FindUTXOs: %v", err)
+			}
+			if len(utxos) != tt.wantUTXOs {
+				t.Fatalf("UTXOs after undo = %d, want %d", len(utxos), tt.wantUTXOs)
+			}
+		})
+	}
+}
+
+func TestTrimProgressSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	kind := doge.ScriptTypeP2PKH
+	addr := make([]byte, 20)
+	openIndexer := func() (*Indexer, spec.Store) {
+		db, err := store.NewIndexStore(path, context.Background(), store.Options{})
+		if err != nil {
+			t.Fatalf("NewIndexStore: %v", err)
+		}
+		indexer := NewIndexer(db, nil, IndexerOptions{TrimSpentAfter: 100})
+		indexer.Context = context.Background()
+		indexer.db = db
+		return indexer, db
+	}
+
+	indexer, db := openIndexer()
+	// UTXOs spent at 500 and 1500
+	err := db.Transact(func(tx spec.StoreTx) error {
+		utxos := []spec.UTXO{
+			{TxID: bytes.Repeat([]byte{1}, 32), Value: ONE_DOGE, Type: kind, Script: addr},
+			{TxID: bytes.Repeat([]byte{2}, 32), Value: ONE_DOGE, Type: kind, Script: addr},
+		}
+		if err := tx.CreateUTXOs(utxos, 10); err != nil {
+			return err
+		}
+		if err := tx.RemoveUTXOs([]spec.OutPointKey{spec.OutPoint(utxos[0].TxID, 0)}, 500); err != nil {
+			return err
+		}
+		return tx.RemoveUTXOs([]spec.OutPointKey{spec.OutPoint(utxos[1].TxID, 0)}, 1500)
+	})
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	indexer.maybeTrim(1200) // trim below 1100
+	if trimmed, err := db.GetTrimmedBelow(); err != nil || trimmed != 1100 {
+		t.Fatalf("GetTrimmedBelow = %d, %v; want 1100", trimmed, err)
+	}
+	db.Close()
+
+	// after a restart, trimming waits for trimIntervalBlocks past the stored height
+	indexer, db = openIndexer()
+	defer db.Close()
+	if trimmed, err := db.GetTrimmedBelow(); err != nil || trimmed != 1100 {
+		t.Fatalf("GetTrimmedBelow after restart = %d, %v; want 1100", trimmed, err)
+	}
+	indexer.maybeTrim(1100 + 100 + trimIntervalBlocks - 1)
+	if trimmed, _ := db.GetTrimmedBelow(); trimmed != 1100 {
+		t.Fatalf("trimmed too early: trimmed below %d", trimmed)
+	}
+	indexer.maybeTrim(1100 + 100 + trimIntervalBlocks)
+	if trimmed, _ := db.GetTrimmedBelow(); trimmed != 1100+trimIntervalBlocks {
+		t.Fatalf("GetTrimmedBelow = %d, want %d", trimmed, 1100+trimIntervalBlocks)
+	}
+	utxos, err := db.FindUTXOs(kind, addr)
+	if err != nil {
+		t.Fatalf("FindUTXOs: %v", err)
+	}
+	if len(utxos) != 0 {
+		t.Fatalf("expected both spent UTXOs trimmed, found %d", len(utxos))
+	}
+}
+
+func TestNoTrimKeepsSpentUTXOs(t *testing.T) {
+	db, err := store.NewIndexStore(filepath.Join(t.TempDir(), "index.db"), context.Background(), store.Options{})
+	if err != nil {
+		t.Fatalf("NewIndexStore: %v", err)
+	}
+	defer db.Close()
+	indexer := NewIndexer(db, nil, IndexerOptions{TrimSpentAfter: 0})
+	indexer.Context = context.Background()
+	indexer.db = db
+
+	kind := doge.ScriptTypeP2PKH
+	addr := make([]byte, 20)
+	txID := bytes.Repeat([]byte{1}, 32)
+	err = db.Transact(func(tx spec.StoreTx) error {
+		if err := tx.CreateUTXOs([]spec.UTXO{{TxID: txID, Value: ONE_DOGE, Type: kind, Script: addr}}, 10); err != nil {
+			return err
+		}
+		return tx.RemoveUTXOs([]spec.OutPointKey{spec.OutPoint(txID, 0)}, 500)
+	})
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	for height := int64(1000); height <= 1000000; height *= 10 {
+		indexer.maybeTrim(height)
+	}
+	if trimmed, err := db.GetTrimmedBelow(); err != nil || trimmed != 0 {
+		t.Fatalf("GetTrimmedBelow = %d, %v; want 0", trimmed, err)
+	}
+	diff, err := db.GetUTXODiff(500, 500)
+	if err != nil {
+		t.Fatalf("GetUTXODiff: %v", err)
+	}
+	if len(diff.Spent) != 1 {
+		t.Fatalf("expected the spent UTXO to be kept, found %d spends", len(diff.Spent))
+	}
+
+	// undoing the spend still re-activates it
+	if err := db.Transact(func(tx spec.StoreTx) error { return tx.UndoAbove(499) }); err != nil {
+		t.Fatalf("UndoAbove: %v", err)
+	}
+	utxos, err := db.FindUTXOs(kind, addr)
+	if err != nil {
+		t.Fatalf("FindUTXOs: %v", err)
+	}
+	if len(utxos) != 1 {
+		t.Fatalf("UTXOs after undo = %d, want 1", len(utxos))
+	}
+}
+
+func TestEmptyBlockAdvancesResumePoint(t *testing.T) {
+	db, err := store.NewIndexStore(filepath.Join(t.TempDir(), "index.db"), context.Background(), store.Options{})
+	if err != nil {
+		t.Fatalf("NewIndexStore: %v", err)
+	}
+	defer db.Close()
+	blocks := make(chan walker.BlockOrUndo, 1)
+	indexer := NewIndexer(db, blocks, IndexerOptions{TrimSpentAfter: 1440})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	indexer.Context = ctx
+	stopped := make(chan struct{})
+	go func() {
+		indexer.Run()
+		close(stopped)
+	}()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	// no UTXOs to create or spend: the coinbase pays nothing
+	hash := bytes.Repeat([]byte{7}, 32)
+	block := doge.Block{Tx: []doge.BlockTx{{
+		TxID: hash,
+		VIn:  []doge.BlockTxIn{{TxID: Zeroes[:], VOut: 0xFFFFFFFF}}, // coinbase
+		VOut: []doge.BlockTxOut{p2pkhOutput(0)},
+	}}}
+	if removes, creates := indexer.blockChanges(&block); removes != nil || creates != nil {
+		t.Fatalf("expected an empty block, got %d removes and %d creates", len(removes), len(creates))
+	}
+	blocks <- walker.BlockOrUndo{
+		LastProcessedBlock: doge.HexEncode(hash),
+		Height:             7,
+		Block:              &walker.ChainBlock{Hash: doge.HexEncode(hash), Height: 7, Block: block},
+	}
+
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if height, _ := db.GetCurrentHeight(); height == 7 {
+			break
+		}
+	}
+	if height, err := db.GetCurrentHeight(); err != nil || height != 7 {
+		t.Fatalf("GetCurrentHeight = %d, %v; want 7", height, err)
+	}
+	if resume, err := db.GetResumePoint(); err != nil || !bytes.Equal(resume, hash) {
+		t.Fatalf("GetResumePoint = %x, %v; want %x", resume, err, hash)
+	}
+}
+
+func TestSpendWithinBlock(t *testing.T) {
+	db := newFileStore(t, "index.db")
+	send := runIndexer(t, db, IndexerOptions{}, db)
+
+	coinbase := []doge.BlockTxIn{{TxID: Zeroes[:], VOut: 0xFFFFFFFF}}
+	reward := bytes.Repeat([]byte{0xC0}, 32)
+	send(10, &doge.Block{Tx: []doge.BlockTx{
+		{TxID: reward, VIn: coinbase, VOut: []doge.BlockTxOut{p2pkhOutput(10 * ONE_DOGE)}},
+	}})
+	// the child spends the parent's output in the same block
+	parent, child := bytes.Repeat([]byte{0xA1}, 32), bytes.Repeat([]byte{0xA2}, 32)
+	send(11, &doge.Block{Tx: []doge.BlockTx{
+		{TxID: bytes.Repeat([]byte{0xC1}, 32), VIn: coinbase, VOut: []doge.BlockTxOut{p2pkhOutput(10 * ONE_DOGE)}},
+		{TxID: parent, VIn: []doge.BlockTxIn{{TxID: reward, VOut: 0}}, VOut: []doge.BlockTxOut{p2pkhOutput(9 * ONE_DOGE)}},
+		{TxID: child, VIn: []doge.BlockTxIn{{TxID: parent, VOut: 0}}, VOut: []doge.BlockTxOut{p2pkhOutput(8 * ONE_DOGE)}},
+	}})
+
+	if orphans, err := db.GetOrphanSpends(); err != nil || orphans != 0 {
+		t.Fatalf("GetOrphanSpends = %d, %v; want 0", orphans, err)
+	}
+	utxos, err := db.GetUTXOs([]spec.OutPointKey{spec.OutPoint(parent, 0)})
+	if err != nil || len(utxos) != 1 {
+		t.Fatalf("GetUTXOs(parent) = %v, %v; want the parent's output", utxos, err)
+	}
+	if utxos[0].Spent != 11 {
+		t.Fatalf("parent output spent at %d, want 11", utxos[0].Spent)
+	}
+	if unspent, err := db.GetUnspentCount(); err != nil || unspent != 2 {
+		t.Fatalf("GetUnspentCount = %d, %v; want 2 (block 11's coinbase and the child)", unspent, err)
+	}
+	// the fee is known: the parent's input value comes from the same block
+	stats, err := db.GetBlockStats(11, 11)
+	if err != nil || len(stats) != 1 || !stats[0].FeesKnown || stats[0].Fees != 2*ONE_DOGE {
+		t.Fatalf("GetBlockStats(11) = %+v, %v; want fees of 2 DOGE", stats, err)
+	}
+}
+
+func TestBlockHistorySurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	// run starts an Indexer on the store at `path`, lets `during` use it, then stops it
+	run := func(during func(indexer *Indexer, blocks chan walker.BlockOrUndo)) {
+		t.Helper()
+		db, err := store.NewIndexStore(path, context.Background(), store.Options{})
+		if err != nil {
+			t.Fatalf("NewIndexStore: %v", err)
+		}
+		defer db.Close()
+		blocks := make(chan walker.BlockOrUndo, 1)
+		indexer := NewIndexer(db, blocks, IndexerOptions{})
+		ctx, cancel := context.WithCancel(context.Background())
+		indexer.Context = ctx
+		stopped := make(chan struct{})
+		go func() {
+			indexer.Run()
+			close(stopped)
+		}()
+		during(indexer, blocks)
+		cancel()
+		<-stopped
+	}
+
+	var before []BlockHistory
+	run(func(indexer *Indexer, blocks chan walker.BlockOrUndo) {
+		for height := int64(1); height <= 3; height++ {
+			hash := doge.HexEncode(bytes.Repeat([]byte{byte(height)}, 32))
+			blocks <- walker.BlockOrUndo{
+				LastProcessedBlock: hash,
+				Height:             height,
+				Block: &walker.ChainBlock{Hash: hash, Height: height, Block: doge.Block{Tx: []doge.BlockTx{{
+					TxID: bytes.Repeat([]byte{0xC0 + byte(height)}, 32),
+					VIn:  []doge.BlockTxIn{{TxID: Zeroes[:], VOut: 0xFFFFFFFF}},
+					VOut: []doge.BlockTxOut{p2pkhOutput(ONE_DOGE)},
+				}}}},
+			}
+		}
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			if history := indexer.GetBlockHistory(); len(history) == 3 {
+				break
+			}
+		}
+		indexer.recordReorg(2, 1)
+		before = indexer.GetBlockHistory()
+	})
+	if len(before) != 3 {
+		t.Fatalf("expected 3 blocks in the history, got %d", len(before))
+	}
+
+	// after a restart, the history is back before any new block
+	run(func(indexer *Indexer, blocks chan walker.BlockOrUndo) {
+		var after []BlockHistory
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			if after = indexer.GetBlockHistory(); len(after) > 0 {
+				break
+			}
+		}
+		if len(after) != len(before) {
+			t.Fatalf("expected %d blocks after restart, got %d", len(before), len(after))
+		}
+		for n := range before {
+			b, a := before[n], after[n]
+			if a.Height != b.Height || a.Hash != b.Hash || a.CreatedValue != b.CreatedValue ||
+				!a.ProcessedAt.Equal(b.ProcessedAt) || a.ProcessingTime != b.ProcessingTime {
+				t.Errorf("block %d: expected %+v after restart, got %+v", n, b, a)
+			}
+		}
+		if reorgs := indexer.GetReorgHistory(); len(reorgs) != 1 || reorgs[0].Height != 2 || reorgs[0].Depth != 1 {
+			t.Errorf("expected the reorg at 2 after restart, got %+v", reorgs)
+		}
+	})
+}
+
+func TestPauseStopsIndexing(t *testing.T) {
+	db, err := store.NewIndexStore(filepath.Join(t.TempDir(), "index.db"), context.Background(), store.Options{})
+	if err != nil {
+		t.Fatalf("NewIndexStore: %v", err)
+	}
+	defer db.Close()
+	blocks := make(chan walker.BlockOrUndo, 1)
+	indexer := NewIndexer(db, blocks, IndexerOptions{TrimSpentAfter: 1440})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	indexer.Context = ctx
+	stopped := make(chan struct{})
+	go func() {
+		indexer.Run()
+		close(stopped)
+	}()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	sendBlock := func(height int64) {
+		hash := bytes.Repeat([]byte{byte(height)}, 32)
+		blocks <- walker.BlockOrUndo{
+			LastProcessedBlock: doge.HexEncode(hash),
+			Height:             height,
+			Block: &walker.ChainBlock{Hash: doge.HexEncode(hash), Height: height, Block: doge.Block{Tx: []doge.BlockTx{{
+				TxID: hash,
+				VIn:  []doge.BlockTxIn{{TxID: Zeroes[:], VOut: 0xFFFFFFFF}}, // coinbase
+				VOut: []doge.BlockTxOut{p2pkhOutput(ONE_DOGE)},
+			}}}},
+		}
+	}
+	waitForHeight := func(want int64) bool {
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			if height, _ := db.GetCurrentHeight(); height == want {
+				return true
+			}
+		}
+		return false
+	}
+
+	sendBlock(1)
+	if !waitForHeight(1) {
+		t.Fatalf("block 1 was not indexed")
+	}
+
+	indexer.Pause()
+	if !indexer.Paused() {
+		t.Fatalf("expected Paused() after Pause")
+	}
+	sendBlock(2)
+	time.Sleep(50 * time.Millisecond)
+	if height, _ := db.GetCurrentHeight(); height != 1 {
+		t.Fatalf("height advanced to %d while paused", height)
+	}
+
+	indexer.Resume()
+	if !waitForHeight(2) {
+		t.Fatalf("block 2 was not indexed after Resume")
+	}
+}
+
+func TestQueueStats(t *testing.T) {
+	blocks := make(chan walker.BlockOrUndo, 2)
+	indexer := NewIndexer(nil, blocks, IndexerOptions{})
+	start := time.Date(2026, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	if stats := indexer.observeQueue(start); stats != (QueueStats{Length: 0, Capacity: 2}) {
+		t.Fatalf("empty queue: %+v", stats)
+	}
+	blocks <- walker.BlockOrUndo{}
+	if stats := indexer.observeQueue(start); stats != (QueueStats{Length: 1, Capacity: 2}) {
+		t.Fatalf("one block: %+v", stats)
+	}
+
+	// full: DogeWalker is waiting on the Indexer
+	blocks <- walker.BlockOrUndo{}
+	if indexer.warnIfQueueFull(start) {
+		t.Errorf("warned as soon as the queue was full")
+	}
+	later := start.Add(queueFullWarning)
+	if stats := indexer.observeQueue(later); stats != (QueueStats{Length: 2, Capacity: 2, FullFor: queueFullWarning}) {
+		t.Fatalf("full queue: %+v", stats)
+	}
+	if !indexer.warnIfQueueFull(later) {
+		t.Errorf("no warning after the queue was full for %v", queueFullWarning)
+	}
+	if indexer.warnIfQueueFull(later.Add(time.Second)) {
+		t.Errorf("warned again within %v", queueFullWarning)
+	}
+
+	// taking a block resets the full time
+	<-blocks
+	indexer.observeQueue(later)
+	blocks <- walker.BlockOrUndo{}
+	if stats := indexer.observeQueue(later.Add(time.Second)); stats.FullFor != 0 {
+		t.Errorf("expected the full time to restart, got %v", stats.FullFor)
+	}
+}
+
+func TestClassifyScript(t *testing.T) {
+	key33 := append([]byte{0x02}, bytes.Repeat([]byte{0x11}, 32)...)
+	key65 := append([]byte{0x04}, bytes.Repeat([]byte{0x22}, 64)...)
+	hash := bytes.Repeat([]byte{0x33}, 20)
+	p2pkh, _ := doge.P2PKHScript(hash)
+	p2sh := append(append([]byte{doge.OP_HASH160, 20}, hash...), doge.OP_EQUAL)
+	push := func(key []byte) []byte { return append([]byte{byte(len(key))}, key...) }
+	multisig := func(m, n byte, keys ...[]byte) []byte {
+		script := []byte{doge.OP_1 - 1 + m}
+		for _, key := range keys {
+			script = append(script, push(key)...)
+		}
+		return append(script, doge.OP_1-1+n, doge.OP_CHECKMULTISIG)
+	}
+	oneOfTwo := multisig(1, 2, key33, key65)
+	threeOfTwo := multisig(3, 2, key33, key65) // M > N: looks like MultiSig, but isn't standard
+
+	tests := []struct {
+		name    string
+		script  []byte
+		typ     doge.ScriptType
+		compact []byte
+		indexed bool
+	}{
+		{"P2PKH", p2pkh, doge.ScriptTypeP2PKH, hash, true},
+		{"P2SH", p2sh, doge.ScriptTypeP2SH, hash, true},
+		{"P2PK compressed", append(push(key33), doge.OP_CHECKSIG), doge.ScriptTypeP2PK, key33, true},
+		{"P2PK uncompressed", append(push(key65), doge.OP_CHECKSIG), doge.ScriptTypeP2PK, key65, true},
+		{"MultiSig", oneOfTwo, doge.ScriptTypeMultiSig, oneOfTwo[:len(oneOfTwo)-1], true},
+		{"NullData", []byte{doge.OP_RETURN, 2, 0xab, 0xcd}, doge.ScriptTypeNullData, []byte{2, 0xab, 0xcd}, false},
+		{"MultiSig-looking NonStandard", threeOfTwo, doge.ScriptTypeNonStandard, threeOfTwo, false},
+		{"Empty", []byte{}, doge.ScriptTypeNonStandard, []byte{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ, compact, indexed := ClassifyScript(tt.script)
+			if typ != tt.typ || !bytes.Equal(compact, tt.compact) || indexed != tt.indexed {
+				t.Errorf("got (%v, %x, %v), expected (%v, %x, %v)", typ, compact, indexed, tt.typ, tt.compact, tt.indexed)
+			}
+			// ClassifyOutput indexes the same scripts
+			if _, _, ok := ClassifyOutput(doge.BlockTxOut{Value: ONE_DOGE, Script: tt.script}); ok != tt.indexed {
+				t.Errorf("ClassifyOutput: got %v, expected %v", ok, tt.indexed)
+			}
+		})
+	}
+}
+
+func TestBlockHistoryValues(t *testing.T) {
+	db, err := store.NewIndexStore(filepath.Join(t.TempDir(), "index.db"), context.Background(), store.Options{})
+	if err != nil {
+		t.Fatalf("NewIndexStore: %v", err)
+	}
+	defer db.Close()
+	blocks := make(chan walker.BlockOrUndo, 1)
+	indexer := NewIndexer(db, blocks, IndexerOptions{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	indexer.Context = ctx
+	stopped := make(chan struct{})
+	go func() {
+		indexer.Run()
+		close(stopped)
+	}()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	send := func(height int64, block doge.Block) BlockHistory {
+		t.Helper()
+		hash := doge.HexEncode(bytes.Repeat([]byte{byte(height)}, 32))
+		blocks <- walker.BlockOrUndo{
+			LastProcessedBlock: hash,
+			Height:             height,
+			Block:              &walker.ChainBlock{Hash: hash, Height: height, Block: block},
+		}
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			if history := indexer.GetBlockHistory(); len(history) > 0 && history[0].Height == height {
+				return history[0]
+			}
+		}
+		t.Fatalf("block %d was not indexed", height)
+		return BlockHistory{}
+	}
+	coinbase := []doge.BlockTxIn{{TxID: Zeroes[:], VOut: 0xFFFFFFFF}}
+	fundingTx := bytes.Repeat([]byte{0xF1}, 32)
+	unknownTx := bytes.Repeat([]byte{0xEE}, 32)
+
+	got := send(10, doge.Block{Tx: []doge.BlockTx{
+		{TxID: bytes.Repeat([]byte{0xC0}, 32), VIn: coinbase, VOut: []doge.BlockTxOut{p2pkhOutput(10 * ONE_DOGE)}},
+		{TxID: fundingTx, VIn: []doge.BlockTxIn{{TxID: unknownTx, VOut: 0}}, VOut: []doge.BlockTxOut{
+			p2pkhOutput(3 * ONE_DOGE),
+			p2pkhOutput(2 * ONE_DOGE),
+			{Value: 7 * ONE_DOGE, Script: []byte{doge.OP_RETURN, 1, 0xab}}, // not indexed
+		}},
+	}})
+	if got.CreatedValue != 15*ONE_DOGE || got.SpentValue != 0 {
+		t.Errorf("block 10: created %v, spent %v; want 15, 0 (orphan spends have no value)", got.CreatedValue, got.SpentValue)
+	}
+
+	got = send(11, doge.Block{Tx: []doge.BlockTx{
+		{TxID: bytes.Repeat([]byte{0xC1}, 32), VIn: coinbase, VOut: []doge.BlockTxOut{p2pkhOutput(10 * ONE_DOGE)}},
+		{TxID: bytes.Repeat([]byte{0xF2}, 32), VIn: []doge.BlockTxIn{{TxID: fundingTx, VOut: 0}, {TxID: fundingTx, VOut: 1}}, VOut: []doge.BlockTxOut{
+			p2pkhOutput(4 * ONE_DOGE),
+		}},
+	}})
+	if got.CreatedValue != 14*ONE_DOGE || got.SpentValue != 5*ONE_DOGE {
+		t.Errorf("block 11: created %v, spent %v; want 14, 5", got.CreatedValue, got.SpentValue)
+	}
+	if got.UTXOCreated != 2 || got.UTXOSpent != 2 {
+		t.Errorf("block 11: %d created, %d spent; want 2, 2", got.UTXOCreated, got.UTXOSpent)
+	}
+
+	// block 10 spends an output the index doesn't have; block 11 pays 5 - 4 = 1 DOGE fees
+	stats, err := db.GetBlockStats(10, 11)
+	if err != nil {
+		t.Fatalf("GetBlockStats: %v", err)
+	}
+	// (block 10's orphan spend counts as spent, as in the block history)
+	want := []spec.BlockStats{
+		{Height: 10, TxCount: 2, UTXOCreated: 3, UTXOSpent: 1, Detailed: true},
+		{Height: 11, TxCount: 2, Fees: ONE_DOGE, FeesKnown: true, UTXOCreated: 2, UTXOSpent: 2, Detailed: true},
+	}
+	for n := range stats {
+		stats[n].ProcessingTime = 0 // varies
+	}
+	if len(stats) != 2 || stats[0] != want[0] || stats[1] != want[1] {
+		t.Errorf("GetBlockStats = %+v, want %+v", stats, want)
+	}
+}
+
+func TestBlockActivity(t *testing.T) {
+	kind := doge.ScriptTypeP2PKH
+	addr := bytes.Repeat([]byte{0x55}, 20)
+	funding, spender := bytes.Repeat([]byte{1}, 32), bytes.Repeat([]byte{2}, 32)
+	block := &doge.Block{Tx: []doge.BlockTx{{
+		TxID: spender,
+		VIn:  []doge.BlockTxIn{{TxID: funding, VOut: 0}, {TxID: funding, VOut: 1}},
+		VOut: []doge.BlockTxOut{p2pkhOutput(ONE_DOGE)},
+	}}}
+	created := []spec.UTXO{{TxID: spender, VOut: 0, Value: ONE_DOGE, Type: kind, Script: addr}}
+	spent := []spec.UTXOState{
+		{UTXO: spec.UTXO{TxID: funding, VOut: 0, Value: 2 * ONE_DOGE, Type: kind, Script: addr}, Height: 90},
+		{UTXO: spec.UTXO{TxID: funding, VOut: 1, Value: 3 * ONE_DOGE, Type: kind, Script: addr}, Height: 90, Spent: 95}, // spent by another block
+	}
+
+	activity := blockActivity(block, created, spent, 100)
+	if len(activity) != 2 {
+		t.Fatalf("activity = %+v, want the created UTXO and one spent UTXO", activity)
+	}
+	if activity[0].Spent || !bytes.Equal(activity[0].Tx, spender) || activity[0].Value != ONE_DOGE {
+		t.Errorf("activity[0] = %+v, want the UTXO created by the spender", activity[0])
+	}
+	if !activity[1].Spent || !bytes.Equal(activity[1].Tx, spender) || activity[1].Value != 2*ONE_DOGE {
+		t.Errorf("activity[1] = %+v, want funding:0 spent by the spender", activity[1])
+	}
+}
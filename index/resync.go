@@ -0,0 +1,81 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/dogeorg/indexer/spec"
+)
+
+// Resync rolls the index back to an earlier height on request (see
+// /admin/resync), e.g. when a range of blocks is suspected to be indexed
+// wrongly. The rollback runs on the Indexer goroutine between blocks, like a
+// chain reorg. DogeWalker cannot be rewound while it runs, so indexing then
+// stays paused until the service restarts and walks from the new resume point.
+
+// ErrUnsafeResync is returned by Resync for a height it cannot roll back to.
+var ErrUnsafeResync = errors.New("cannot resync")
+
+type resyncRequest struct {
+	height int64
+	hash   []byte     // block hash at `height` (the new resume point)
+	result chan error // buffered: Resync may have given up waiting
+}
+
+// Resync rolls the index back to `height`, whose block is `hash`, and pauses
+// indexing until the service restarts (called on any goroutine.)
+func (i *Indexer) Resync(ctx context.Context, height int64, hash []byte) error {
+	req := resyncRequest{height: height, hash: hash, result: make(chan error, 1)}
+	select {
+	case i.resyncs <- req:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-req.result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err() // the rollback still finishes
+	}
+}
+
+// resync rolls the index back for a resyncRequest (on the Indexer goroutine.)
+func (i *Indexer) resync(height int64, hash []byte) error {
+	current, err := i.db.GetCurrentHeight()
+	if err != nil {
+		return err
+	}
+	if height >= current {
+		return fmt.Errorf("%w: height %d is not below the indexed height %d", ErrUnsafeResync, height, current)
+	}
+	if i.maxUndoDepth > 0 && current-height > i.maxUndoDepth {
+		return fmt.Errorf("%w: %d blocks is more than the maximum undo depth of %d", ErrUnsafeResync, current-height, i.maxUndoDepth)
+	}
+	// UndoAbove re-activates the UTXOs spent above `height`: they must not be trimmed
+	trimmed, err := i.db.GetTrimmedBelow()
+	if err != nil {
+		return err
+	}
+	if height < trimmed {
+		return fmt.Errorf("%w: spent UTXOs below height %d have been trimmed", ErrUnsafeResync, trimmed)
+	}
+	err = i.db.Transact(func(tx spec.StoreTx) error {
+		err := tx.UndoAbove(height)
+		if err != nil {
+			return err
+		}
+		return tx.SetResumePoint(hash, height)
+	})
+	if err != nil {
+		return err
+	}
+	i.shadowUndo(height, hash)
+	i.pauseMutex.Lock()
+	i.resynced = true
+	i.pauseMutex.Unlock()
+	i.Pause() // DogeWalker's next blocks are above the old height
+	log.Printf("[Indexer] !!! RESYNC: rolled back %v blocks to %v. Indexing is paused: restart the indexer to index them again !!!", current-height, height)
+	return nil
+}
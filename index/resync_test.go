@@ -0,0 +1,168 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/dogewalker/walker"
+	"github.com/dogeorg/indexer/spec"
+	"github.com/dogeorg/indexer/store"
+)
+
+func TestResyncRollsBackAndPauses(t *testing.T) {
+	db, err := store.NewIndexStore(filepath.Join(t.TempDir(), "index.db"), context.Background(), store.Options{})
+	if err != nil {
+		t.Fatalf("NewIndexStore: %v", err)
+	}
+	defer db.Close()
+	blocks := make(chan walker.BlockOrUndo, 1)
+	indexer := NewIndexer(db, blocks, IndexerOptions{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	indexer.Context = ctx
+	stopped := make(chan struct{})
+	go func() {
+		indexer.Run()
+		close(stopped)
+	}()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	blockHash := func(height int64) []byte { return bytes.Repeat([]byte{byte(height)}, 32) }
+	txID := func(height int64) []byte { return bytes.Repeat([]byte{0xC0 + byte(height)}, 32) }
+	// each block has a coinbase output; block 2 also spends block 1's output
+	sendBlock := func(height int64) {
+		txs := []doge.BlockTx{{
+			TxID: txID(height),
+			VIn:  []doge.BlockTxIn{{TxID: Zeroes[:], VOut: 0xFFFFFFFF}},
+			VOut: []doge.BlockTxOut{p2pkhOutput(ONE_DOGE)},
+		}}
+		if height == 2 {
+			txs = append(txs, doge.BlockTx{
+				TxID: bytes.Repeat([]byte{0xEE}, 32),
+				VIn:  []doge.BlockTxIn{{TxID: txID(1), VOut: 0}},
+			})
+		}
+		hash := doge.HexEncode(blockHash(height))
+		blocks <- walker.BlockOrUndo{
+			LastProcessedBlock: hash,
+			Height:             height,
+			Block:              &walker.ChainBlock{Hash: hash, Height: height, Block: doge.Block{Tx: txs}},
+		}
+	}
+	waitForHeight := func(want int64) bool {
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			if height, _ := db.GetCurrentHeight(); height == want {
+				return true
+			}
+		}
+		return false
+	}
+	for height := int64(1); height <= 3; height++ {
+		sendBlock(height)
+	}
+	if !waitForHeight(3) {
+		t.Fatalf("blocks 1-3 were not indexed")
+	}
+
+	// not below the indexed height
+	if err := indexer.Resync(ctx, 3, blockHash(3)); !errors.Is(err, ErrUnsafeResync) {
+		t.Fatalf("expected ErrUnsafeResync for the current height, got %v", err)
+	}
+
+	if err := indexer.Resync(ctx, 1, blockHash(1)); err != nil {
+		t.Fatalf("Resync: %v", err)
+	}
+	height, err := db.GetCurrentHeight()
+	if err != nil || height != 1 {
+		t.Fatalf("expected height 1 after resync, got %d (%v)", height, err)
+	}
+	resume, err := db.GetResumePoint()
+	if err != nil || !bytes.Equal(resume, blockHash(1)) {
+		t.Fatalf("expected the resume point to move to block 1, got %x (%v)", resume, err)
+	}
+	// block 1's output is unspent again; blocks 2 and 3's outputs are gone
+	utxos, err := db.FindUTXOs(doge.ScriptTypeP2PKH, make([]byte, 20))
+	if err != nil {
+		t.Fatalf("FindUTXOs: %v", err)
+	}
+	if len(utxos) != 1 || !bytes.Equal(utxos[0].TxID, txID(1)) {
+		t.Fatalf("expected only block 1's UTXO after resync, got %+v", utxos)
+	}
+
+	// paused until restart: DogeWalker's next block is not indexed, even after Resume
+	if !indexer.Paused() {
+		t.Fatalf("expected Paused() after Resync")
+	}
+	indexer.Resume()
+	if !indexer.Paused() {
+		t.Fatalf("expected Resume to be refused after Resync")
+	}
+	sendBlock(4)
+	time.Sleep(50 * time.Millisecond)
+	if height, _ := db.GetCurrentHeight(); height != 1 {
+		t.Fatalf("height advanced to %d after resync", height)
+	}
+}
+
+func TestResyncRefusesUnsafeHeights(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     int64
+		trimmed  int64 // TrimSpentUTXOs height (0 for none)
+		maxUndo  int64
+		wantSafe bool
+	}{
+		{"Above the trim floor", 500, 400, 0, true},
+		{"At the trim floor", 400, 400, 0, true},
+		{"Below the trim floor", 399, 400, 0, false},
+		{"Within the undo depth", 900, 0, 100, true},
+		{"Beyond the undo depth", 899, 0, 100, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := store.NewIndexStore(":memory:", context.Background(), store.Options{})
+			if err != nil {
+				t.Fatalf("NewIndexStore: %v", err)
+			}
+			defer db.Close()
+			err = db.Transact(func(tx spec.StoreTx) error {
+				if tt.trimmed > 0 {
+					if err := tx.TrimSpentUTXOs(tt.trimmed); err != nil {
+						return err
+					}
+				}
+				return tx.SetResumePoint([]byte{0xAA}, 1000)
+			})
+			if err != nil {
+				t.Fatalf("setup: %v", err)
+			}
+
+			indexer := NewIndexer(db, nil, IndexerOptions{MaxUndoDepth: tt.maxUndo})
+			indexer.Context = context.Background()
+			indexer.db = db
+
+			err = indexer.resync(tt.from, []byte{0xBB})
+			if tt.wantSafe && err != nil {
+				t.Fatalf("resync(%d): %v", tt.from, err)
+			}
+			if !tt.wantSafe && !errors.Is(err, ErrUnsafeResync) {
+				t.Fatalf("resync(%d): expected ErrUnsafeResync, got %v", tt.from, err)
+			}
+			want := int64(1000) // untouched
+			if tt.wantSafe {
+				want = tt.from
+			}
+			if height, _ := db.GetCurrentHeight(); height != want {
+				t.Fatalf("height after resync = %d, want %d", height, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package index
+
+import (
+	"log"
+	"time"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+// The shadow store (-shadowdb) gets the same writes as the store, after each
+// commit, so a new database can be checked against the current one before
+// switching (e.g. SQLite to Postgres.) It is best-effort: failures are logged
+// and never retried or allowed to hold up indexing. Once a block or undo
+// fails the shadow is no longer written (see GetShadowStatus) and must be
+// rebuilt. It should start from a copy of the store (or both empty),
+// otherwise every block is reported as a mismatch.
+
+// shadowBlock writes a block to the shadow store (see writeBlock), with the
+// processing time the store recorded, so their block stats are the same.
+func (i *Indexer) shadowBlock(height int64, block *doge.Block, resumeHash []byte, removeUTXOs []spec.OutPointKey, createUTXOs []spec.UTXO, processingTime time.Duration) {
+	if !i.shadowActive() {
+		return
+	}
+	elapsed := func() time.Duration { return processingTime }
+	err := i.shadow.Transact(func(tx spec.StoreTx) error {
+		_, err := i.writeBlock(tx, height, block, resumeHash, removeUTXOs, createUTXOs, elapsed)
+		return err
+	})
+	if err != nil {
+		log.Printf("[Indexer] shadow store: block %v failed: %v", height, err)
+		i.shadowFailed(height)
+		return
+	}
+	i.compareShadow(height)
+}
+
+// shadowUndo rolls the shadow store back to `height` (see undo.)
+func (i *Indexer) shadowUndo(height int64, resumeHash []byte) {
+	if !i.shadowActive() {
+		return
+	}
+	err := i.shadow.Transact(func(tx spec.StoreTx) error {
+		err := tx.UndoAbove(height)
+		if err != nil {
+			return err
+		}
+		return tx.SetResumePoint(resumeHash, height)
+	})
+	if err != nil {
+		log.Printf("[Indexer] shadow store: undo to %v failed: %v", height, err)
+		i.shadowFailed(height)
+		return
+	}
+	i.compareShadow(height)
+}
+
+// shadowTrim trims the shadow store like the store (see maybeTrim.)
+func (i *Indexer) shadowTrim(height int64) {
+	if !i.shadowActive() {
+		return
+	}
+	err := i.shadow.Transact(func(tx spec.StoreTx) error {
+		return tx.TrimSpentUTXOs(height)
+	})
+	if err != nil {
+		log.Printf("[Indexer] shadow store: trim failed: %v", err)
+	}
+}
+
+// shadowActive reports whether there is a shadow store still being written.
+func (i *Indexer) shadowActive() bool {
+	return i.shadow != nil && !i.GetShadowStatus().Failed
+}
+
+// shadowFailed stops writing to the shadow store after a failed block or undo
+// at `height`: the next blocks would be applied on top of the gap.
+func (i *Indexer) shadowFailed(height int64) {
+	i.shadowMutex.Lock()
+	defer i.shadowMutex.Unlock()
+	i.shadowStatus = ShadowStatus{Failed: true, FailedAt: height}
+	log.Printf("[Indexer] shadow store: no longer written after the failure at %v (rebuild it)", height)
+}
+
+// GetShadowStatus reports whether writing to the shadow store has failed
+// (called on any goroutine.)
+func (i *Indexer) GetShadowStatus() ShadowStatus {
+	i.shadowMutex.Lock()
+	defer i.shadowMutex.Unlock()
+	return i.shadowStatus
+}
+
+// compareShadow checks the shadow store has the same height and unspent
+// count as the store, logging when they start (or stop) differing.
+func (i *Indexer) compareShadow(height int64) {
+	unspent, err := i.db.GetUnspentCount()
+	if err != nil {
+		log.Printf("[Indexer] shadow store: get unspent count: %v", err)
+		return
+	}
+	shadowHeight, err := i.shadow.GetCurrentHeight()
+	if err != nil {
+		log.Printf("[Indexer] shadow store: get current height: %v", err)
+		return
+	}
+	shadowUnspent, err := i.shadow.GetUnspentCount()
+	if err != nil {
+		log.Printf("[Indexer] shadow store: get unspent count: %v", err)
+		return
+	}
+	mismatch := shadowHeight != height || shadowUnspent != unspent
+	if mismatch && !i.shadowMismatch {
+		log.Printf("[Indexer] shadow store MISMATCH at %v: height %v, %v unspent UTXOs (store: %v unspent)", height, shadowHeight, shadowUnspent, unspent)
+	} else if !mismatch && i.shadowMismatch {
+		log.Printf("[Indexer] shadow store matches again at %v", height)
+	}
+	i.shadowMismatch = mismatch
+}
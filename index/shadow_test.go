@@ -0,0 +1,209 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/dogewalker/walker"
+	"github.com/dogeorg/indexer/spec"
+	"github.com/dogeorg/indexer/store"
+)
+
+// runIndexer runs an Indexer until the test ends; send indexes a block (or
+// undoes to `height` if block is nil) and waits for the `wait` stores to reach it.
+func runIndexer(t *testing.T, db spec.Store, options IndexerOptions, wait ...spec.Store) (send func(height int64, block *doge.Block)) {
+	t.Helper()
+	blocks := make(chan walker.BlockOrUndo, 1)
+	return runIndexerWith(t, NewIndexer(db, blocks, options), blocks, wait...)
+}
+
+// runIndexerWith is runIndexer for an Indexer reading `blocks`.
+func runIndexerWith(t *testing.T, indexer *Indexer, blocks chan walker.BlockOrUndo, wait ...spec.Store) (send func(height int64, block *doge.Block)) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	indexer.Context = ctx
+	stopped := make(chan struct{})
+	go func() {
+		indexer.Run()
+		close(stopped)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-stopped
+	})
+	return func(height int64, block *doge.Block) {
+		t.Helper()
+		hash := doge.HexEncode(bytes.Repeat([]byte{byte(height)}, 32))
+		cmd := walker.BlockOrUndo{LastProcessedBlock: hash, Height: height}
+		if block != nil {
+			cmd.Block = &walker.ChainBlock{Hash: hash, Height: height, Block: *block}
+		} else {
+			cmd.Undo = &walker.UndoForkBlocks{LastValidHeight: height, LastValidHash: hash}
+		}
+		blocks <- cmd
+		for _, store := range wait {
+			for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+				if resume, err := store.GetResumePoint(); err == nil && doge.HexEncode(resume) == hash {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("block %d was not indexed", height)
+				}
+			}
+		}
+	}
+}
+
+func newFileStore(t *testing.T, name string) spec.Store {
+	t.Helper()
+	db, err := store.NewIndexStore(filepath.Join(t.TempDir(), name), context.Background(), store.Options{})
+	if err != nil {
+		t.Fatalf("NewIndexStore: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// storeSummary describes everything the indexer wrote, for comparing stores.
+func storeSummary(t *testing.T, db spec.Store) string {
+	t.Helper()
+	height, err := db.GetCurrentHeight()
+	if err != nil {
+		t.Fatalf("GetCurrentHeight: %v", err)
+	}
+	unspent, err := db.GetUnspentCount()
+	if err != nil {
+		t.Fatalf("GetUnspentCount: %v", err)
+	}
+	diff, err := db.GetUTXODiff(0, height)
+	if err != nil {
+		t.Fatalf("GetUTXODiff: %v", err)
+	}
+	stats, err := db.GetBlockStats(0, height)
+	if err != nil {
+		t.Fatalf("GetBlockStats: %v", err)
+	}
+	return fmt.Sprintf("height %d, %d unspent, created %+v, spent %+v, stats %+v", height, unspent, diff.Created, diff.Spent, stats)
+}
+
+func TestShadowStoreGetsTheSameWrites(t *testing.T) {
+	db, shadow := newFileStore(t, "index.db"), newFileStore(t, "shadow.db")
+	send := runIndexer(t, db, IndexerOptions{Shadow: shadow}, db, shadow)
+
+	coinbase := []doge.BlockTxIn{{TxID: Zeroes[:], VOut: 0xFFFFFFFF}}
+	fundingTx := bytes.Repeat([]byte{0xF1}, 32)
+	send(10, &doge.Block{Tx: []doge.BlockTx{
+		{TxID: bytes.Repeat([]byte{0xC0}, 32), VIn: coinbase, VOut: []doge.BlockTxOut{p2pkhOutput(10 * ONE_DOGE)}},
+		{TxID: fundingTx, VIn: []doge.BlockTxIn{{TxID: bytes.Repeat([]byte{0xEE}, 32), VOut: 0}}, VOut: []doge.BlockTxOut{
+			p2pkhOutput(3 * ONE_DOGE),
+			p2pkhOutput(2 * ONE_DOGE),
+		}},
+	}})
+	send(11, &doge.Block{Tx: []doge.BlockTx{
+		{TxID: bytes.Repeat([]byte{0xC1}, 32), VIn: coinbase, VOut: []doge.BlockTxOut{p2pkhOutput(10 * ONE_DOGE)}},
+		{TxID: bytes.Repeat([]byte{0xF2}, 32), VIn: []doge.BlockTxIn{{TxID: fundingTx, VOut: 0}}, VOut: []doge.BlockTxOut{p2pkhOutput(2 * ONE_DOGE)}},
+	}})
+	send(12, &doge.Block{Tx: []doge.BlockTx{
+		{TxID: bytes.Repeat([]byte{0xC2}, 32), VIn: coinbase, VOut: []doge.BlockTxOut{p2pkhOutput(10 * ONE_DOGE)}},
+	}})
+
+	primary := storeSummary(t, db)
+	if got := storeSummary(t, shadow); got != primary {
+		t.Fatalf("shadow store differs after indexing:\nstore:  %s\nshadow: %s", primary, got)
+	}
+
+	send(11, nil) // undo block 12
+	primary = storeSummary(t, db)
+	if got := storeSummary(t, shadow); got != primary {
+		t.Fatalf("shadow store differs after undo:\nstore:  %s\nshadow: %s", primary, got)
+	}
+	if resume, err := shadow.GetResumePoint(); err != nil || !bytes.Equal(resume, bytes.Repeat([]byte{11}, 32)) {
+		t.Fatalf("shadow resume point = %x, %v; want block 11", resume, err)
+	}
+}
+
+func TestShadowStoreFailureDoesNotStopIndexing(t *testing.T) {
+	db, shadow := newFileStore(t, "index.db"), newFileStore(t, "shadow.db")
+	shadow.Close() // every shadow write fails
+	send := runIndexer(t, db, IndexerOptions{Shadow: shadow}, db)
+
+	send(10, &doge.Block{Tx: []doge.BlockTx{
+		{TxID: bytes.Repeat([]byte{0xC0}, 32), VIn: []doge.BlockTxIn{{TxID: Zeroes[:], VOut: 0xFFFFFFFF}}, VOut: []doge.BlockTxOut{p2pkhOutput(ONE_DOGE)}},
+	}})
+	if unspent, err := db.GetUnspentCount(); err != nil || unspent != 1 {
+		t.Fatalf("GetUnspentCount = %d, %v; want 1", unspent, err)
+	}
+}
+
+// failingTrimStore fails every transaction, e.g. the trim.
+type failingTrimStore struct {
+	spec.Store
+}
+
+func (s failingTrimStore) Transact(fn func(tx spec.StoreTx) error) error {
+	return fmt.Errorf("trim failed")
+}
+
+func TestShadowNotTrimmedWhenStoreTrimFails(t *testing.T) {
+	db, shadow := newFileStore(t, "index.db"), newFileStore(t, "shadow.db")
+	indexer := NewIndexer(failingTrimStore{db}, nil, IndexerOptions{TrimSpentAfter: 100, Shadow: shadow})
+	indexer.Context = context.Background()
+	indexer.db, indexer.shadow = failingTrimStore{db}, shadow
+
+	indexer.maybeTrim(1200)
+	if trimmed, err := shadow.GetTrimmedBelow(); err != nil || trimmed != 0 {
+		t.Fatalf("shadow GetTrimmedBelow = %d, %v; want 0 (the store's trim failed)", trimmed, err)
+	}
+
+	indexer.db = db // the store's trim succeeds
+	indexer.maybeTrim(1200)
+	if trimmed, err := shadow.GetTrimmedBelow(); err != nil || trimmed != 1100 {
+		t.Fatalf("shadow GetTrimmedBelow = %d, %v; want 1100", trimmed, err)
+	}
+}
+
+// failOnceStore fails its first transaction, then works.
+type failOnceStore struct {
+	spec.Store
+	failed bool
+}
+
+func (s *failOnceStore) Transact(fn func(tx spec.StoreTx) error) error {
+	if !s.failed {
+		s.failed = true
+		return fmt.Errorf("shadow write failed")
+	}
+	return s.Store.Transact(fn)
+}
+
+func (s *failOnceStore) WithCtx(ctx context.Context) spec.Store {
+	return s
+}
+
+func TestShadowStopsAfterFailure(t *testing.T) {
+	db, shadow := newFileStore(t, "index.db"), newFileStore(t, "shadow.db")
+	blocks := make(chan walker.BlockOrUndo, 1)
+	indexer := NewIndexer(db, blocks, IndexerOptions{Shadow: &failOnceStore{Store: shadow}})
+	send := runIndexerWith(t, indexer, blocks, db)
+
+	coinbase := []doge.BlockTxIn{{TxID: Zeroes[:], VOut: 0xFFFFFFFF}}
+	send(10, &doge.Block{Tx: []doge.BlockTx{
+		{TxID: bytes.Repeat([]byte{0xC0}, 32), VIn: coinbase, VOut: []doge.BlockTxOut{p2pkhOutput(ONE_DOGE)}},
+	}})
+	send(11, &doge.Block{Tx: []doge.BlockTx{
+		{TxID: bytes.Repeat([]byte{0xC1}, 32), VIn: coinbase, VOut: []doge.BlockTxOut{p2pkhOutput(ONE_DOGE)}},
+	}})
+
+	if status := indexer.GetShadowStatus(); !status.Failed || status.FailedAt != 10 {
+		t.Fatalf("GetShadowStatus = %+v; want failed at 10", status)
+	}
+	// block 11 was not written on top of the missing block 10
+	if resume, err := shadow.GetResumePoint(); err != nil || len(resume) != 0 {
+		t.Fatalf("shadow resume point = %x, %v; want none", resume, err)
+	}
+}
@@ -0,0 +1,82 @@
+package index
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// StartOptions are the operator's starting-point settings.
+type StartOptions struct {
+	StartingHeight    int64  // start from the block at this height (on a fresh store)
+	StartingHeightSet bool   // whether StartingHeight was set explicitly
+	StartingHash      string // start from this block hash instead (hex, preferred over StartingHeight)
+	ForceResync       bool   // clear the store and start again
+	ResumeFromMax     bool   // without a resume point, resume from the highest indexed height
+}
+
+// StartPoint is where indexing starts when the service starts.
+type StartPoint struct {
+	ResumeHash []byte   // resume after this block (stored resume point or StartingHash), or
+	Height     int64    // start from the block at this height (if ResumeHash is empty)
+	ClearIndex bool     // clear the store before starting (-force-resync)
+	Warnings   []string // configuration conflicts to report
+}
+
+/*
+ * ChooseStartPoint decides where to start indexing.
+ *
+ * The stored resume point always wins over the starting height or hash
+ * unless `ForceResync` is set, in which case the store must be cleared
+ * and indexing restarts from the starting hash or height.
+ *
+ * `maxIndexedHeight` is the highest height in the store (0 if empty).
+ * Data without a resume point (e.g. imported externally) is resumed from
+ * that height if `ResumeFromMax` is set.
+ */
+func ChooseStartPoint(resumeHash []byte, resumeHeight int64, maxIndexedHeight int64, options StartOptions) (StartPoint, error) {
+	var start StartPoint
+	var startingHash []byte
+	startingFrom := fmt.Sprintf("height %d", options.StartingHeight)
+	if options.StartingHash != "" {
+		var err error
+		startingHash, err = hex.DecodeString(options.StartingHash)
+		if err != nil || len(startingHash) != 32 {
+			return start, fmt.Errorf("starting hash must be 32 bytes of hex: %q", options.StartingHash)
+		}
+		startingFrom = "block " + options.StartingHash
+		if options.StartingHeightSet {
+			start.Warnings = append(start.Warnings, fmt.Sprintf("both a starting height and hash are set: ignoring starting height %d", options.StartingHeight))
+		}
+	}
+	if len(resumeHash) > 0 && !options.ForceResync {
+		start.ResumeHash = resumeHash
+		conflict := startingHash != nil || (options.StartingHeightSet && options.StartingHeight != resumeHeight)
+		if conflict {
+			start.Warnings = append(start.Warnings, fmt.Sprintf("ignoring starting %s: resuming from stored height %d (use -force-resync to restart from %s)", startingFrom, resumeHeight, startingFrom))
+		}
+		return start, nil
+	}
+	if len(resumeHash) == 0 && maxIndexedHeight > 0 && !options.ForceResync {
+		if options.ResumeFromMax {
+			start.Height = maxIndexedHeight
+			start.Warnings = append(start.Warnings, fmt.Sprintf("no stored resume point: resuming from the highest indexed height %d (-resume-from-max)", maxIndexedHeight))
+			return start, nil
+		}
+		start.Warnings = append(start.Warnings, fmt.Sprintf("no stored resume point, but the store has blocks up to height %d: starting from %s (use -resume-from-max to resume from height %d)", maxIndexedHeight, startingFrom, maxIndexedHeight))
+	}
+	if options.ForceResync {
+		start.ClearIndex = true
+		if len(resumeHash) > 0 {
+			start.Warnings = append(start.Warnings, fmt.Sprintf("force re-sync: clearing the index at height %d and restarting from %s", resumeHeight, startingFrom))
+		}
+	}
+	if startingHash != nil {
+		start.ResumeHash = startingHash
+		return start, nil
+	}
+	start.Height = options.StartingHeight
+	if options.StartingHeight <= 0 {
+		start.Warnings = append(start.Warnings, "starting from the genesis block: indexing the whole chain will take a long time")
+	}
+	return start, nil
+}
@@ -0,0 +1,78 @@
+package index
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestChooseStartPoint(t *testing.T) {
+	stored := []byte{0xAB, 0xCD}
+	checkpoint := "1a91e3dace36e2be3bf030a65679fe821aa1d6ef92e7c9902eb318182c355691"
+	checkpointBytes, _ := hex.DecodeString(checkpoint)
+
+	tests := []struct {
+		name         string
+		resumeHash   []byte
+		resumeHeight int64
+		maxIndexed   int64
+		options      StartOptions
+		wantResume   []byte
+		wantHeight   int64
+		wantClear    bool
+		wantWarnings []string // substrings, in order
+		wantErr      string
+	}{
+		{name: "fresh store", options: StartOptions{StartingHeight: 5830000}, wantHeight: 5830000},
+		{name: "fresh store from genesis", options: StartOptions{StartingHeight: 0, StartingHeightSet: true}, wantHeight: 0, wantWarnings: []string{"genesis"}},
+		{name: "resume with default starting height", resumeHash: stored, resumeHeight: 5900000, options: StartOptions{StartingHeight: 5830000}, wantResume: stored},
+		{name: "resume matches starting height", resumeHash: stored, resumeHeight: 5900000, options: StartOptions{StartingHeight: 5900000, StartingHeightSet: true}, wantResume: stored},
+		{name: "starting height above resume", resumeHash: stored, resumeHeight: 5900000, options: StartOptions{StartingHeight: 6000000, StartingHeightSet: true}, wantResume: stored, wantWarnings: []string{"ignoring starting height 6000000: resuming from stored height 5900000"}},
+		{name: "starting height below resume", resumeHash: stored, resumeHeight: 5900000, options: StartOptions{StartingHeight: 100, StartingHeightSet: true}, wantResume: stored, wantWarnings: []string{"use -force-resync"}},
+		{name: "force resync", resumeHash: stored, resumeHeight: 5900000, options: StartOptions{StartingHeight: 100, StartingHeightSet: true, ForceResync: true}, wantHeight: 100, wantClear: true, wantWarnings: []string{"clearing the index at height 5900000"}},
+		{name: "force resync on fresh store", options: StartOptions{StartingHeight: 100, ForceResync: true}, wantHeight: 100, wantClear: true},
+		{name: "starting hash on fresh store", options: StartOptions{StartingHeight: 5830000, StartingHash: checkpoint}, wantResume: checkpointBytes},
+		{name: "starting hash preferred over height", options: StartOptions{StartingHeight: 100, StartingHeightSet: true, StartingHash: checkpoint}, wantResume: checkpointBytes, wantWarnings: []string{"ignoring starting height 100"}},
+		{name: "starting hash with stored resume point", resumeHash: stored, resumeHeight: 5900000, options: StartOptions{StartingHash: checkpoint}, wantResume: stored, wantWarnings: []string{"ignoring starting block " + checkpoint}},
+		{name: "force resync to starting hash", resumeHash: stored, resumeHeight: 5900000, options: StartOptions{StartingHash: checkpoint, ForceResync: true}, wantResume: checkpointBytes, wantClear: true, wantWarnings: []string{"restarting from block " + checkpoint}},
+		{name: "data without resume point", maxIndexed: 5900123, options: StartOptions{StartingHeight: 5830000}, wantHeight: 5830000, wantWarnings: []string{"use -resume-from-max to resume from height 5900123"}},
+		{name: "resume from max", maxIndexed: 5900123, options: StartOptions{StartingHeight: 5830000, ResumeFromMax: true}, wantHeight: 5900123, wantWarnings: []string{"resuming from the highest indexed height 5900123"}},
+		{name: "resume from max on fresh store", options: StartOptions{StartingHeight: 5830000, ResumeFromMax: true}, wantHeight: 5830000},
+		{name: "resume from max with resume point", resumeHash: stored, resumeHeight: 5900000, maxIndexed: 5900000, options: StartOptions{StartingHeight: 5830000, ResumeFromMax: true}, wantResume: stored},
+		{name: "force resync overrides resume from max", maxIndexed: 5900123, options: StartOptions{StartingHeight: 100, ResumeFromMax: true, ForceResync: true}, wantHeight: 100, wantClear: true},
+		{name: "starting hash not hex", options: StartOptions{StartingHash: "xyz"}, wantErr: "32 bytes of hex"},
+		{name: "starting hash too short", options: StartOptions{StartingHash: "abcd"}, wantErr: "32 bytes of hex"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp, err := ChooseStartPoint(tt.resumeHash, tt.resumeHeight, tt.maxIndexed, tt.options)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ChooseStartPoint: %v", err)
+			}
+			if !bytes.Equal(sp.ResumeHash, tt.wantResume) {
+				t.Fatalf("ResumeHash = %x, want %x", sp.ResumeHash, tt.wantResume)
+			}
+			if len(tt.wantResume) == 0 && sp.Height != tt.wantHeight {
+				t.Fatalf("Height = %d, want %d", sp.Height, tt.wantHeight)
+			}
+			if sp.ClearIndex != tt.wantClear {
+				t.Fatalf("ClearIndex = %v, want %v", sp.ClearIndex, tt.wantClear)
+			}
+			if len(sp.Warnings) != len(tt.wantWarnings) {
+				t.Fatalf("Warnings = %q, want %q", sp.Warnings, tt.wantWarnings)
+			}
+			for n, want := range tt.wantWarnings {
+				if !strings.Contains(sp.Warnings[n], want) {
+					t.Fatalf("Warnings[%d] = %q, want it to contain %q", n, sp.Warnings[n], want)
+				}
+			}
+		})
+	}
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
+	"encoding/hex"
 	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/dogeorg/doge"
@@ -13,8 +17,19 @@ import (
 	"github.com/dogeorg/dogewalker/walker"
 	"github.com/dogeorg/governor"
 	"github.com/dogeorg/indexer/index"
+	"github.com/dogeorg/indexer/mempool"
+	"github.com/dogeorg/indexer/mempool/rawtx"
 	"github.com/dogeorg/indexer/store"
+	"github.com/dogeorg/indexer/tippoll"
 	"github.com/dogeorg/indexer/web"
+	"github.com/dogeorg/indexer/webhook"
+)
+
+// Build info, set with e.g. -ldflags "-X main.version=v1.2.0 -X main.commit=$(git rev-parse HEAD) -X main.date=$(date -u +%F)"
+var (
+	version = "dev"
+	commit  = "unknown"
+	date    = "unknown"
 )
 
 const RETRY_DELAY = 5 * time.Second
@@ -29,31 +44,153 @@ type Config struct {
 	zmqHost        string
 	zmqPort        int
 	bindAPI        string
+	bindGRPC       string
 	corsOrigin     string
+	corsMaxAge     time.Duration
 	chainName      string
+	anyChain       bool
 	startingHeight int64
+	startingHash   string
 	cacheBalances  bool
+	trackMempool   bool
+	mempoolTTL     time.Duration
+	checkOnly      bool
+	skipDust       bool
+	tlsCert        string
+	tlsKey         string
+	adminToken     string
+	forceResync    bool
+	resumeFromMax  bool
+	maxUndoDepth   int64
+	confirmations  int64
+	maturity       int64
+	balanceCache   int
+	maxUTXOs       int
+	maxExpensive   int
+	maxBatchItems  int
+	maxBatchRows   int
+	syncedLag      int64
+	balanceTTL     time.Duration
+	queryTimeout   time.Duration
+	shutdownGrace  time.Duration
+	recordChanges  bool
+	noTrim         bool
+	devTools       bool
+	cacheTTL       string
+	showVersion    bool
+	classify       string
+	zeroValue      bool
+	explorer       bool
+	webhooks       string
+	webhookSecret  string
+	shadowDB       string
+	pollInterval   time.Duration
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "query" {
+		os.Exit(queryCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
 	log.Printf("\n\n[Indexer] starting")
 
 	var config Config
 	flag.StringVar(&config.connStr, "dburl", "index.db", "Database connection string")
+	flag.StringVar(&config.shadowDB, "shadowdb", "", "Shadow database connection string: also write every block here (best-effort) and serve /admin/compare, e.g. to trial a migration")
 	flag.StringVar(&config.rpcHost, "rpchost", "127.0.0.1", "RPC host")
 	flag.IntVar(&config.rpcPort, "rpcport", 22555, "RPC port")
 	flag.StringVar(&config.rpcUser, "rpcuser", "dogecoin", "RPC username")
 	flag.StringVar(&config.rpcPass, "rpcpass", "dogecoin", "RPC password")
 	flag.StringVar(&config.zmqHost, "zmqhost", "127.0.0.1", "ZMQ host")
 	flag.IntVar(&config.zmqPort, "zmqport", 28332, "ZMQ port")
-	flag.StringVar(&config.bindAPI, "bindapi", "localhost:8000", "API bind address")
+	flag.DurationVar(&config.pollInterval, "poll-interval", 0, "Poll Core RPC for the tip at this interval while ZMQ is silent for longer (0 to disable)")
+	flag.StringVar(&config.bindAPI, "bindapi", "localhost:8000", "API bind address (host:port, or unix:/path/to.sock)")
+	flag.StringVar(&config.bindGRPC, "grpc", "", "gRPC bind address, e.g. localhost:8001 (host:port, or unix:/path/to.sock; disabled if empty)")
+	flag.StringVar(&config.tlsCert, "tlscert", "", "TLS certificate file (serve the API over HTTPS; requires -tlskey)")
+	flag.StringVar(&config.tlsKey, "tlskey", "", "TLS private key file (requires -tlscert)")
+	flag.StringVar(&config.adminToken, "admintoken", "", "Bearer token for the /admin API endpoints (disabled if empty)")
 	flag.StringVar(&config.corsOrigin, "cors-origin", "http://localhost:5173", "CORS allowed origin")
+	flag.DurationVar(&config.corsMaxAge, "cors-max-age", web.DefaultCORSMaxAge, "How long browsers may cache a CORS preflight (Access-Control-Max-Age)")
 	flag.StringVar(&config.chainName, "chain", "mainnet", "Chain Params (mainnet, testnet, regtest)")
+	flag.BoolVar(&config.anyChain, "any-chain-addresses", false, "Accept addresses of any Dogecoin or Bitcoin network in the API, not just -chain (for testing)")
 	flag.Int64Var(&config.startingHeight, "startingheight", 5830000, "Starting Height")
+	flag.StringVar(&config.startingHash, "startinghash", "", "Starting block hash (checkpoint), preferred over -startingheight")
 	flag.BoolVar(&config.cacheBalances, "cache-balances", false, "Cache balances for faster balance lookups")
+	flag.IntVar(&config.maxUTXOs, "max-utxos", web.DefaultMaxUTXOs, "Most UTXOs in one /utxo response (longer lists are truncated)")
+	flag.IntVar(&config.maxExpensive, "max-expensive", web.DefaultMaxExpensive, "Most concurrent expensive API requests, e.g. /diff and /txtotal (more get 503)")
+	flag.IntVar(&config.maxBatchItems, "max-batch-items", web.DefaultMaxBatchItems, "Most calls in one /rpc request or outpoints in one /check-utxos request (/rpc also allows at most 100)")
+	flag.IntVar(&config.maxBatchRows, "max-batch-rows", web.DefaultMaxBatchRows, "Most rows (UTXOs, outpoints, balances) one batch request can return in total (more get 413)")
+	flag.Int64Var(&config.syncedLag, "synced-lag", web.DefaultSyncedLag, "Most blocks behind Core that /metrics reports as synced (indexer_synced 1)")
+	flag.IntVar(&config.balanceCache, "balancecache", 0, "Number of hot address balances to cache in the API (0 to disable)")
+	flag.DurationVar(&config.balanceTTL, "balancecache-ttl", 10*time.Second, "How long the API caches an address balance (also dropped when a block is indexed)")
+	flag.BoolVar(&config.noTrim, "notrim", false, "Keep spent UTXOs forever instead of deleting them after 1440 blocks (full history)")
+	flag.BoolVar(&config.devTools, "devtools", false, "Serve POST /dev/generate to mine blocks with Core (regtest only)")
+	flag.BoolVar(&config.recordChanges, "changes", false, "Record UTXO create/spend events for /changes (incremental export; the table grows with the chain)")
+	flag.StringVar(&config.cacheTTL, "cache-ttl", "", "Let proxies cache endpoints publicly, e.g. /height=5s,/blocks=5s (only "+strings.Join(web.CacheableEndpoints, ", ")+")")
+	flag.DurationVar(&config.queryTimeout, "query-timeout", web.DefaultQueryTimeout, "Cancel an API request's database queries after this long")
+	flag.DurationVar(&config.shutdownGrace, "shutdown-grace", web.DefaultShutdownGrace, "On shutdown, let in-flight API requests finish for this long")
+	flag.BoolVar(&config.trackMempool, "mempool", false, "Track pending mempool outputs via ZMQ rawtx (requires -zmqpubrawtx in Core)")
+	flag.DurationVar(&config.mempoolTTL, "mempool-ttl", time.Hour, "Drop pending mempool transactions after this long")
+	flag.BoolVar(&config.skipDust, "skip-dust", false, "Do not index outputs below 0.01 DOGE (independent of the /utxo min_value filter)")
+	flag.BoolVar(&config.zeroValue, "index-zero-value", false, "Index zero-value outputs with a standard script, e.g. protocol markers (-skip-dust still skips them)")
+	flag.StringVar(&config.webhooks, "webhooks", "", "JSON file of [{\"address\":...,\"url\":...}] webhooks to POST address activity to (disabled if empty)")
+	flag.StringVar(&config.webhookSecret, "webhook-secret", "", "Secret for the webhook HMAC-SHA256 signature header (unsigned if empty)")
+	flag.BoolVar(&config.explorer, "explorer", false, "Record every transaction's inputs for /tx (explorer mode; the table grows with the chain)")
+	flag.Int64Var(&config.confirmations, "confirmations", web.DefaultConfirmations, "Default confirmations before funds count as available in /balance")
+	flag.Int64Var(&config.maturity, "coinbase-maturity", store.DefaultCoinbaseMaturity, "Confirmations before coinbase outputs count as available (0 to treat them like other outputs)")
+	flag.Int64Var(&config.maxUndoDepth, "maxundo", MaxRollbackDepth, "Stop indexing instead of undoing more than this many blocks (0 for no limit)")
+	flag.BoolVar(&config.forceResync, "force-resync", false, "Clear the index and restart from -startingheight")
+	flag.BoolVar(&config.resumeFromMax, "resume-from-max", false, "If there is no resume point but the database has indexed blocks (e.g. imported), resume from the highest indexed height")
+	flag.BoolVar(&config.checkOnly, "check", false, "Check the database for consistency and exit (non-zero exit status if problems are found)")
+	flag.BoolVar(&config.showVersion, "version", false, "Print the build version and exit")
+	flag.StringVar(&config.classify, "classify", "", "Print how the indexer classifies a hex scriptPubKey and exit")
 
 	flag.Parse()
 
+	build := web.BuildInfo{Version: version, Commit: commit, Date: date}
+	if config.showVersion {
+		fmt.Println(build)
+		return
+	}
+	if config.classify != "" {
+		if err := classifyScript(config.classify); err != nil {
+			fmt.Fprintf(os.Stderr, "-classify: %v\n", err)
+			os.Exit(2)
+		}
+		return
+	}
+	if config.checkOnly {
+		// read-only, so checking a suspect database never migrates or backfills it
+		db, err := store.NewIndexStore(config.connStr, context.Background(), store.Options{
+			CoinbaseMaturity: config.maturity,
+			ReadOnly:         true,
+		})
+		if err != nil {
+			log.Fatalf("[Check] database init: %v", err)
+		}
+		status := checkStore(db)
+		db.Close()
+		os.Exit(status)
+	}
+	log.Printf("[Indexer] %s", build)
+
+	startingHeightSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "startingheight" {
+			startingHeightSet = true
+		}
+	})
+
+	if config.confirmations < 1 {
+		log.Fatalf("[Indexer] -confirmations must be at least 1")
+	}
+	if (config.tlsCert == "") != (config.tlsKey == "") {
+		log.Fatalf("[Indexer] -tlscert and -tlskey must be used together")
+	}
+	cacheTTL, err := web.ParseCacheTTL(config.cacheTTL)
+	if err != nil {
+		log.Fatalf("[Indexer] -cache-ttl: %v", err)
+	}
+
 	var chain *doge.ChainParams
 	switch config.chainName {
 	case "mainnet":
@@ -65,42 +202,110 @@ func main() {
 	default:
 		panic(errors.New("Unexpected chain: " + config.chainName))
 	}
+	if config.devTools && chain != &doge.DogeRegTestChain {
+		log.Fatalf("[Indexer] -devtools is only allowed with -chain=regtest")
+	}
 
 	gov := governor.New().CatchSignals().Restart(1 * time.Second)
 
 	// create database store
-	db, err := store.NewIndexStore(config.connStr, gov.GlobalContext(), config.cacheBalances)
+	db, err := store.NewIndexStore(config.connStr, gov.GlobalContext(), store.Options{
+		CacheBalances:    config.cacheBalances,
+		CoinbaseMaturity: config.maturity,
+		RecordChanges:    config.recordChanges,
+	})
 	if err != nil {
 		log.Fatalf("[Indexer] database init: %v", err)
 	}
+	var shadow store.Store
+	if config.shadowDB != "" {
+		shadow, err = store.NewIndexStore(config.shadowDB, gov.GlobalContext(), store.Options{
+			CacheBalances:    config.cacheBalances,
+			CoinbaseMaturity: config.maturity,
+			RecordChanges:    config.recordChanges,
+		})
+		if err != nil {
+			log.Fatalf("[Indexer] shadow database init: %v", err)
+		}
+	}
 
 	// Core Node blockchain access.
 	blockchain := core.NewCoreRPCClient(config.rpcHost, config.rpcPort, config.rpcUser, config.rpcPass)
+	if err := index.VerifyChain(blockchain, chain, gov.GlobalContext()); err != nil {
+		if gov.Stopping() {
+			return
+		}
+		log.Fatalf("[Indexer] wrong chain: %v", err)
+	}
 
 	// TipChaser
 	zmqAddr := fmt.Sprintf("tcp://%v:%v", config.zmqHost, config.zmqPort)
 	chainEvents := make(chan walkerspec.BlockchainEvent, 1)
-	zmqSvc := core.NewTipChaser(zmqAddr, chainEvents, false)
-	gov.Add("ZMQ", zmqSvc)
+	if config.pollInterval > 0 {
+		// fall back to polling Core while ZMQ is silent
+		zmqEvents := make(chan walkerspec.BlockchainEvent, 1)
+		gov.Add("ZMQ", core.NewTipChaser(zmqAddr, zmqEvents, false))
+		gov.Add("TipPoll", tippoll.NewPoller(blockchain, zmqEvents, chainEvents, config.pollInterval))
+	} else {
+		zmqSvc := core.NewTipChaser(zmqAddr, chainEvents, false)
+		gov.Add("ZMQ", zmqSvc)
+	}
 
 	// Get the resume-point.
 	var fromBlock []byte
-	var fromHash string
+	var fromHeight, maxIndexedHeight int64
 	for !gov.Stopping() {
 		fromBlock, err = db.GetResumePoint()
+		if err == nil {
+			fromHeight, err = db.GetCurrentHeight()
+		}
+		if err == nil && len(fromBlock) == 0 {
+			maxIndexedHeight, err = db.GetMaxIndexedHeight()
+		}
 		if err == nil {
 			break
 		}
 		log.Printf("[Indexer] get chainstate (will retry): %v", err)
 		gov.Sleep(RETRY_DELAY)
 	}
-	if len(fromBlock) > 0 {
-		fromHash = doge.HexEncode(fromBlock)
+	start, err := index.ChooseStartPoint(fromBlock, fromHeight, maxIndexedHeight, index.StartOptions{
+		StartingHeight:    config.startingHeight,
+		StartingHeightSet: startingHeightSet,
+		StartingHash:      config.startingHash,
+		ForceResync:       config.forceResync,
+		ResumeFromMax:     config.resumeFromMax,
+	})
+	if err != nil {
+		log.Fatalf("[Indexer] %v", err)
+	}
+	for _, warning := range start.Warnings {
+		log.Printf("[Indexer] WARNING: %s", warning)
+	}
+	if start.ClearIndex {
+		err = db.Transact(func(tx store.StoreTx) error {
+			return tx.ClearIndex()
+		})
+		if err != nil {
+			log.Fatalf("[Indexer] clear index: %v", err)
+		}
+		if shadow != nil {
+			// otherwise the blocks indexed again conflict with the shadow's old contents
+			err = shadow.Transact(func(tx store.StoreTx) error {
+				return tx.ClearIndex()
+			})
+			if err != nil {
+				log.Fatalf("[Indexer] clear shadow index: %v", err)
+			}
+		}
+	}
+	var fromHash string
+	if len(start.ResumeHash) > 0 {
+		fromHash = doge.HexEncode(start.ResumeHash)
 	} else {
-		// Start from the Genesis Block.
-		fromHash, err = blockchain.GetBlockHash(config.startingHeight, gov.GlobalContext())
+		// Start from the configured starting height.
+		fromHash, err = blockchain.GetBlockHash(start.Height, gov.GlobalContext())
 		if err != nil {
-			log.Printf("[Indexer] get genesis block hash: %v", err)
+			log.Printf("[Indexer] get starting block hash: %v", err)
 			return
 		}
 	}
@@ -115,13 +320,130 @@ func main() {
 	gov.Add("Walk", walkSvc)
 
 	// Index the chain.
-	indexer := index.NewIndexer(db, blocks, MaxRollbackDepth)
+	trimSpentAfter := int64(MaxRollbackDepth)
+	if config.noTrim {
+		trimSpentAfter = 0
+	}
+	indexer := index.NewIndexer(db, blocks, index.IndexerOptions{
+		TrimSpentAfter: trimSpentAfter,
+		SkipDust:       config.skipDust,
+		MaxUndoDepth:   config.maxUndoDepth,
+		IndexZeroValue: config.zeroValue,
+		RecordInputs:   config.explorer,
+		Shadow:         shadow,
+	})
 	gov.Add("Index", indexer)
 
-	// REST API.
-	gov.Add("API", web.New(config.bindAPI, db, indexer, blockchain, config.corsOrigin))
+	// Address activity webhooks.
+	if config.webhooks != "" {
+		subs, err := webhook.LoadSubscriptions(config.webhooks)
+		if err != nil {
+			log.Fatalf("[Indexer] webhooks: %v", err)
+		}
+		notifier, err := webhook.NewNotifier(subs, chain, config.webhookSecret)
+		if err != nil {
+			log.Fatalf("[Indexer] webhooks: %v", err)
+		}
+		indexer.AddListener(notifier)
+		gov.Add("Webhooks", notifier)
+	}
+
+	// Track pending transactions.
+	var pending mempool.Monitor
+	if config.trackMempool {
+		rawTxs := make(chan []byte, 100)
+		gov.Add("MempoolZMQ", rawtx.NewListener(zmqAddr, rawTxs))
+		tracker := mempool.NewTracker(rawTxs, config.mempoolTTL)
+		indexer.AddListener(tracker)
+		gov.Add("Mempool", tracker)
+		pending = tracker
+	}
+
+	// Block events for /events.
+	events := web.NewEventHub(indexer)
+	indexer.AddListener(events)
+
+	// Dev endpoints (regtest only, checked above).
+	var devTools web.CoreRequester
+	if config.devTools {
+		devTools = blockchain.(*core.CoreRPCClient)
+	}
+
+	// REST API (and gRPC if -grpc is set.)
+	gov.Add("API", web.New(web.Options{
+		Bind:       config.bindAPI,
+		Store:      db,
+		Indexer:    indexer,
+		Blockchain: blockchain,
+		Mempool:    pending,
+		CORSOrigin: config.corsOrigin,
+		ChainName:  config.chainName,
+		Chain:      chain,
+		AnyChain:   config.anyChain,
+		TLSCert:    config.tlsCert,
+		TLSKey:     config.tlsKey,
+		Events:     events,
+		Control:    indexer,
+		AdminToken: config.adminToken,
+		Shadow:     shadow,
+		Changes:    config.recordChanges,
+		DevTools:   devTools,
+		GRPCBind:   config.bindGRPC,
+
+		Confirmations:    config.confirmations,
+		BalanceCacheSize: config.balanceCache,
+		BalanceCacheTTL:  config.balanceTTL,
+		QueryTimeout:     config.queryTimeout,
+		CORSMaxAge:       config.corsMaxAge,
+		ShutdownGrace:    config.shutdownGrace,
+		MaxUTXOs:         config.maxUTXOs,
+		MaxExpensive:     config.maxExpensive,
+		MaxBatchItems:    config.maxBatchItems,
+		MaxBatchRows:     config.maxBatchRows,
+		SyncedLag:        config.syncedLag,
+		CacheTTL:         cacheTTL,
+
+		Build:         build,
+		SchemaVersion: store.SchemaVersion(),
+	}))
 
 	// run services until interrupted.
 	gov.Start().WaitForShutdown()
 	fmt.Println("[Indexer] stopped")
 }
+
+// checkStore reports store consistency problems and returns the exit status.
+func checkStore(db store.Store) int {
+	problems, err := db.CheckIntegrity()
+	if err != nil {
+		log.Printf("[Check] cannot check database: %v", err)
+		return 2
+	}
+	for _, problem := range problems {
+		log.Printf("[Check] %s", problem)
+	}
+	if len(problems) > 0 {
+		log.Printf("[Check] %d problem(s) found", len(problems))
+		return 1
+	}
+	log.Printf("[Check] database is consistent")
+	return 0
+}
+
+// classifyScript prints how the indexer classifies and stores a scriptPubKey,
+// to diagnose outputs that are missing from the index.
+func classifyScript(scriptHex string) error {
+	script, err := hex.DecodeString(scriptHex)
+	if err != nil {
+		return fmt.Errorf("script must be hex: %v", err)
+	}
+	typ, compact, indexed := index.ClassifyScript(script)
+	fmt.Printf("type:    %s\n", web.KindName(typ))
+	fmt.Printf("compact: %s\n", hex.EncodeToString(compact))
+	if indexed {
+		fmt.Printf("indexed: yes (outputs below 0.01 DOGE are skipped with -skip-dust)\n")
+	} else {
+		fmt.Printf("indexed: no (%s scripts are not indexed)\n", web.KindName(typ))
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package rawtx
+
+import (
+	"log"
+	"syscall"
+	"time"
+
+	"github.com/dogeorg/governor"
+	"github.com/pebbe/zmq4"
+)
+
+const RETRY_DELAY = 5 * time.Second // for connect errors.
+const ERROR_DELAY = 1 * time.Second // for ZMQ errors.
+
+/*
+ * NewListener subscribes to the Core Node ZMQ `rawtx` topic and
+ * forwards each raw (serialized) transaction to `rawTxs`.
+ *
+ * `coreAddrZMQ` is the TCP address of Core ZMQ, e.g. "tcp://127.0.0.1:28332"
+ * (Core must be started with -zmqpubrawtx on that address.)
+ */
+func NewListener(coreAddrZMQ string, rawTxs chan<- []byte) governor.Service {
+	return &listener{coreAddrZMQ: coreAddrZMQ, rawTxs: rawTxs}
+}
+
+type listener struct {
+	governor.ServiceCtx
+	coreAddrZMQ string
+	rawTxs      chan<- []byte
+}
+
+func (l *listener) Run() {
+	sock := l.connectZMQ()
+	if sock == nil {
+		return // stopping
+	}
+	defer sock.Close()
+	for !l.Stopping() {
+		msg, err := sock.RecvMessageBytes(0)
+		if err != nil {
+			if err == zmq4.Errno(syscall.EAGAIN) {
+				continue // receive timeout: check for shutdown
+			}
+			log.Printf("[Mempool] ZMQ err: %s", err)
+			if l.Sleep(ERROR_DELAY) {
+				return // stopping
+			}
+			continue
+		}
+		if len(msg) >= 2 && string(msg[0]) == "rawtx" {
+			select {
+			case l.rawTxs <- msg[1]:
+			case <-l.Context.Done():
+				return // stopping
+			}
+		}
+	}
+}
+
+func (l *listener) connectZMQ() *zmq4.Socket {
+	for {
+		if l.Stopping() {
+			return nil // stopping
+		}
+		sock, err := zmq4.NewSocket(zmq4.SUB)
+		if err != nil {
+			log.Printf("[Mempool] cannot create ZMQ socket: %v", err)
+			if l.Sleep(RETRY_DELAY) {
+				return nil // stopping
+			}
+			continue
+		}
+		sock.SetRcvtimeo(2 * time.Second) // for shutdown
+		err = sock.Connect(l.coreAddrZMQ)
+		if err == nil {
+			err = sock.SetSubscribe("rawtx")
+		}
+		if err != nil {
+			log.Printf("[Mempool] cannot subscribe to 'rawtx' on Core (%v): %v", l.coreAddrZMQ, err)
+			sock.Close()
+			if l.Sleep(RETRY_DELAY) {
+				return nil // stopping
+			}
+			continue
+		}
+		return sock // success
+	}
+}
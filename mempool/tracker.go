@@ -0,0 +1,221 @@
+package mempool
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/doge/koinu"
+	"github.com/dogeorg/dogewalker/walker"
+	"github.com/dogeorg/governor"
+	"github.com/dogeorg/indexer/index"
+)
+
+const expireInterval = 1 * time.Minute // how often to look for expired entries
+
+// Balance is the pending (unconfirmed) part of an address balance.
+type Balance struct {
+	Incoming koinu.Koinu `json:"incoming"` // sum of pending outputs paying the address
+	TxCount  int         `json:"tx_count"` // number of pending transactions paying the address
+}
+
+// Monitor interface for reading pending balances
+type Monitor interface {
+	PendingBalance(kind doge.ScriptType, script []byte) Balance
+}
+
+type pendingOutput struct {
+	vout  uint32
+	kind  doge.ScriptType
+	key   string // scriptKey(kind, compact script)
+	value int64
+}
+
+type pendingTx struct {
+	seenAt  time.Time
+	outputs []pendingOutput
+	spends  []string // outPointKey of each input
+}
+
+/*
+ * Tracker maintains an in-memory set of pending (mempool) outputs
+ * paying standard scripts, fed with raw transactions from Core ZMQ.
+ *
+ * Entries are dropped when the Indexer commits a block containing
+ * the transaction, or after `ttl` (e.g. evicted or double-spent.)
+ * Spends of confirmed UTXOs are not tracked: the confirmed Balance
+ * reports those once the spending transaction is in a block.
+ */
+type Tracker struct {
+	governor.ServiceCtx
+	rawTxs <-chan []byte
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu       sync.RWMutex
+	pending  map[string]*pendingTx     // txid -> pending tx
+	byScript map[string]map[string]int // scriptKey -> txid -> number of outputs
+	spentBy  map[string]string         // outPointKey -> spending txid
+}
+
+// Ensure Tracker implements governor.Service
+var _ governor.Service = (*Tracker)(nil)
+
+// Ensure Tracker implements Monitor and index.BlockListener
+var _ Monitor = (*Tracker)(nil)
+var _ index.BlockListener = (*Tracker)(nil)
+
+// NewTracker creates a mempool Tracker that reads raw transactions from `rawTxs`.
+func NewTracker(rawTxs <-chan []byte, ttl time.Duration) *Tracker {
+	return &Tracker{
+		rawTxs:   rawTxs,
+		ttl:      ttl,
+		now:      time.Now,
+		pending:  map[string]*pendingTx{},
+		byScript: map[string]map[string]int{},
+		spentBy:  map[string]string{},
+	}
+}
+
+// Run is the entry point for the Tracker service (called by Governor)
+func (t *Tracker) Run() {
+	ticker := time.NewTicker(expireInterval)
+	defer ticker.Stop()
+	done := t.Context.Done()
+	for {
+		select {
+		case raw := <-t.rawTxs:
+			tx, err := doge.DecodeTxErr(raw, true)
+			if err != nil {
+				log.Printf("[Mempool] cannot decode raw tx: %v", err)
+				continue
+			}
+			t.Add(tx)
+		case <-ticker.C:
+			t.Expire()
+		case <-done:
+			return // shutdown
+		}
+	}
+}
+
+// Add records the indexable outputs of a mempool transaction.
+func (t *Tracker) Add(tx doge.BlockTx) {
+	txKey := string(tx.TxID)
+	entry := &pendingTx{seenAt: t.now()}
+	for vout, out := range tx.VOut {
+		if kind, compact, ok := index.ClassifyOutput(out); ok {
+			entry.outputs = append(entry.outputs, pendingOutput{
+				vout:  uint32(vout),
+				kind:  kind,
+				key:   scriptKey(kind, compact),
+				value: out.Value,
+			})
+		}
+	}
+	for _, in := range tx.VIn {
+		entry.spends = append(entry.spends, outPointKey(in.TxID, in.VOut))
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, found := t.pending[txKey]; found {
+		return // already tracked
+	}
+	t.pending[txKey] = entry
+	for _, out := range entry.outputs {
+		txs := t.byScript[out.key]
+		if txs == nil {
+			txs = map[string]int{}
+			t.byScript[out.key] = txs
+		}
+		txs[txKey]++
+	}
+	for _, spend := range entry.spends {
+		t.spentBy[spend] = txKey
+	}
+}
+
+// BlockIndexed drops pending transactions that were confirmed in `block`.
+func (t *Tracker) BlockIndexed(block *walker.ChainBlock) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, tx := range block.Block.Tx {
+		t.removeLocked(string(tx.TxID))
+	}
+}
+
+// Expire drops pending transactions older than the TTL.
+func (t *Tracker) Expire() {
+	cutoff := t.now().Add(-t.ttl)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for txKey, entry := range t.pending {
+		if entry.seenAt.Before(cutoff) {
+			t.removeLocked(txKey)
+		}
+	}
+}
+
+// PendingBalance sums the pending outputs paying a script,
+// excluding outputs already spent by another pending transaction.
+func (t *Tracker) PendingBalance(kind doge.ScriptType, script []byte) Balance {
+	key := scriptKey(kind, script)
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var bal Balance
+	for txKey := range t.byScript[key] {
+		counted := false
+		for _, out := range t.pending[txKey].outputs {
+			if out.key != key {
+				continue
+			}
+			if _, spent := t.spentBy[outPointKey([]byte(txKey), out.vout)]; spent {
+				continue
+			}
+			bal.Incoming += koinu.Koinu(out.value)
+			counted = true
+		}
+		if counted {
+			bal.TxCount++
+		}
+	}
+	return bal
+}
+
+// Size returns the number of pending transactions being tracked.
+func (t *Tracker) Size() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.pending)
+}
+
+func (t *Tracker) removeLocked(txKey string) {
+	entry, found := t.pending[txKey]
+	if !found {
+		return
+	}
+	delete(t.pending, txKey)
+	for _, out := range entry.outputs {
+		if txs := t.byScript[out.key]; txs != nil {
+			delete(txs, txKey)
+			if len(txs) == 0 {
+				delete(t.byScript, out.key)
+			}
+		}
+	}
+	for _, spend := range entry.spends {
+		if t.spentBy[spend] == txKey {
+			delete(t.spentBy, spend)
+		}
+	}
+}
+
+func scriptKey(kind doge.ScriptType, script []byte) string {
+	return string(append([]byte{byte(kind)}, script...))
+}
+
+func outPointKey(txID []byte, vout uint32) string {
+	return string(append(append([]byte{}, txID...), byte(vout>>24), byte(vout>>16), byte(vout>>8), byte(vout)))
+}
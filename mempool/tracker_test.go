@@ -0,0 +1,142 @@
+package mempool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/dogewalker/walker"
+)
+
+func p2pkhScript(b byte) []byte {
+	hash := make([]byte, 20)
+	for i := range hash {
+		hash[i] = b
+	}
+	script, _ := doge.P2PKHScript(hash)
+	return script
+}
+
+func pendingTxPaying(txid byte, script []byte, values ...int64) doge.BlockTx {
+	tx := doge.BlockTx{TxID: make([]byte, 32)}
+	tx.TxID[0] = txid
+	tx.VIn = []doge.BlockTxIn{{TxID: make([]byte, 32), VOut: 0}}
+	tx.VIn[0].TxID[0] = 0xEE // funded by some confirmed tx
+	for _, value := range values {
+		tx.VOut = append(tx.VOut, doge.BlockTxOut{Value: value, Script: script})
+	}
+	return tx
+}
+
+func newTestTracker(now *time.Time) *Tracker {
+	tracker := NewTracker(nil, 10*time.Minute)
+	tracker.now = func() time.Time { return *now }
+	return tracker
+}
+
+func TestTrackerAddsPendingOutputs(t *testing.T) {
+	now := time.Date(2026, time.June, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(&now)
+	script := p2pkhScript(0xAA)
+	_, compact := doge.ClassifyScript(script)
+
+	tracker.Add(pendingTxPaying(1, script, 1000, 2000))
+	tracker.Add(pendingTxPaying(2, script, 500))
+	tracker.Add(pendingTxPaying(2, script, 500)) // duplicate announcement
+	tracker.Add(pendingTxPaying(3, p2pkhScript(0xBB), 7000))
+
+	bal := tracker.PendingBalance(doge.ScriptTypeP2PKH, compact)
+	if bal.Incoming != 3500 || bal.TxCount != 2 {
+		t.Fatalf("PendingBalance = %+v, want {Incoming:3500 TxCount:2}", bal)
+	}
+	if tracker.Size() != 3 {
+		t.Fatalf("Size = %d, want 3", tracker.Size())
+	}
+}
+
+func TestTrackerIgnoresUnindexedOutputs(t *testing.T) {
+	now := time.Date(2026, time.June, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(&now)
+	script := p2pkhScript(0xAA)
+	_, compact := doge.ClassifyScript(script)
+
+	tx := pendingTxPaying(1, script, 0) // zero-value output
+	tx.VOut = append(tx.VOut, doge.BlockTxOut{Value: 1000, Script: []byte{doge.OP_RETURN, 1, 2}})
+	tracker.Add(tx)
+
+	bal := tracker.PendingBalance(doge.ScriptTypeP2PKH, compact)
+	if bal.Incoming != 0 || bal.TxCount != 0 {
+		t.Fatalf("PendingBalance = %+v, want zero", bal)
+	}
+}
+
+func TestTrackerDropsConfirmedTransactions(t *testing.T) {
+	now := time.Date(2026, time.June, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(&now)
+	script := p2pkhScript(0xAA)
+	_, compact := doge.ClassifyScript(script)
+
+	first := pendingTxPaying(1, script, 1000)
+	second := pendingTxPaying(2, script, 2000)
+	tracker.Add(first)
+	tracker.Add(second)
+
+	tracker.BlockIndexed(&walker.ChainBlock{Block: doge.Block{Tx: []doge.BlockTx{first}}})
+
+	bal := tracker.PendingBalance(doge.ScriptTypeP2PKH, compact)
+	if bal.Incoming != 2000 || bal.TxCount != 1 {
+		t.Fatalf("PendingBalance after confirm = %+v, want {Incoming:2000 TxCount:1}", bal)
+	}
+	if tracker.Size() != 1 {
+		t.Fatalf("Size = %d, want 1", tracker.Size())
+	}
+}
+
+func TestTrackerExpiresOldTransactions(t *testing.T) {
+	now := time.Date(2026, time.June, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(&now)
+	script := p2pkhScript(0xAA)
+	_, compact := doge.ClassifyScript(script)
+
+	tracker.Add(pendingTxPaying(1, script, 1000))
+	now = now.Add(6 * time.Minute)
+	tracker.Add(pendingTxPaying(2, script, 2000))
+
+	now = now.Add(5 * time.Minute) // first tx is now 11 minutes old
+	tracker.Expire()
+
+	bal := tracker.PendingBalance(doge.ScriptTypeP2PKH, compact)
+	if bal.Incoming != 2000 || bal.TxCount != 1 {
+		t.Fatalf("PendingBalance after expire = %+v, want {Incoming:2000 TxCount:1}", bal)
+	}
+
+	now = now.Add(10 * time.Minute)
+	tracker.Expire()
+	if tracker.Size() != 0 {
+		t.Fatalf("Size = %d, want 0", tracker.Size())
+	}
+}
+
+func TestTrackerExcludesOutputsSpentInMempool(t *testing.T) {
+	now := time.Date(2026, time.June, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(&now)
+	script := p2pkhScript(0xAA)
+	_, compact := doge.ClassifyScript(script)
+
+	parent := pendingTxPaying(1, script, 1000, 2000)
+	tracker.Add(parent)
+	child := pendingTxPaying(2, p2pkhScript(0xBB), 900)
+	child.VIn = []doge.BlockTxIn{{TxID: parent.TxID, VOut: 0}}
+	tracker.Add(child)
+
+	bal := tracker.PendingBalance(doge.ScriptTypeP2PKH, compact)
+	if bal.Incoming != 2000 {
+		t.Fatalf("PendingBalance = %+v, want Incoming 2000 (vout 0 spent by child)", bal)
+	}
+
+	tracker.BlockIndexed(&walker.ChainBlock{Block: doge.Block{Tx: []doge.BlockTx{parent, child}}})
+	bal = tracker.PendingBalance(doge.ScriptTypeP2PKH, compact)
+	if bal.Incoming != 0 || tracker.Size() != 0 {
+		t.Fatalf("PendingBalance after confirm = %+v (size %d), want empty", bal, tracker.Size())
+	}
+}
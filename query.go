@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+
+	"github.com/dogeorg/indexer/store"
+	"github.com/dogeorg/indexer/web"
+)
+
+// `indexer query balance|utxo <address>` prints what /balance or /utxo would
+// return, straight from the database (opened read-only), for scripts and
+// debugging without a running indexer.
+
+const queryUsage = "usage: indexer query [-dburl=index.db] [-confirmations=N] balance|utxo <address>"
+
+// queryCommand runs `indexer query` and returns the exit status.
+func queryCommand(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("query", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	connStr := flags.String("dburl", "index.db", "Database connection string")
+	confirmations := flags.Int64("confirmations", web.DefaultConfirmations, "Confirmations before funds count as available in the balance")
+	maturity := flags.Int64("coinbase-maturity", store.DefaultCoinbaseMaturity, "Confirmations before coinbase outputs count as available")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, queryUsage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	// flags may also follow the query, e.g. `query balance D... -dburl=...`
+	positional := []string{}
+	for rest := flags.Args(); len(rest) > 0; rest = flags.Args() {
+		positional = append(positional, rest[0])
+		if err := flags.Parse(rest[1:]); err != nil {
+			return 2
+		}
+	}
+	if len(positional) != 2 || !slices.Contains(web.QueryEndpoints, positional[0]) {
+		fmt.Fprintln(stderr, queryUsage)
+		return 2
+	}
+	if *confirmations < 1 {
+		fmt.Fprintln(stderr, "query: -confirmations must be at least 1")
+		return 2
+	}
+
+	db, err := store.NewIndexStore(*connStr, context.Background(), store.Options{
+		CoinbaseMaturity: *maturity,
+		ReadOnly:         true,
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "query: database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+	return runQuery(db, *confirmations, positional[0], positional[1], stdout, stderr)
+}
+
+// runQuery prints the result of a query as JSON and returns the exit status.
+func runQuery(db store.Store, confirmations int64, endpoint string, address string, stdout io.Writer, stderr io.Writer) int {
+	// the database doesn't record its chain, so accept any network's addresses
+	options := web.Options{Store: db, Confirmations: confirmations, AnyChain: true}
+	payload, err := web.Query(options, endpoint, url.Values{"address": {address}})
+	if err != nil {
+		fmt.Fprintf(stderr, "query %s: %v\n", endpoint, err)
+		return 1
+	}
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(payload); err != nil {
+		fmt.Fprintf(stderr, "query %s: %v\n", endpoint, err)
+		return 1
+	}
+	return 0
+}
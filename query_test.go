@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+	"github.com/dogeorg/indexer/store"
+)
+
+var queryHash = bytes.Repeat([]byte{0x42}, 20)
+
+// seedStore indexes two UTXOs for queryHash: one confirmed, one at the tip (height 100.)
+func seedStore(t *testing.T, connStr string) store.Store {
+	t.Helper()
+	db, err := store.NewIndexStore(connStr, context.Background(), store.Options{})
+	if err != nil {
+		t.Fatalf("NewIndexStore: %v", err)
+	}
+	err = db.Transact(func(tx spec.StoreTx) error {
+		if err := tx.CreateUTXOs([]spec.UTXO{{TxID: bytes.Repeat([]byte{0xA1}, 32), VOut: 0, Value: 150000000, Type: doge.ScriptTypeP2PKH, Script: queryHash}}, 10); err != nil {
+			return err
+		}
+		if err := tx.CreateUTXOs([]spec.UTXO{{TxID: bytes.Repeat([]byte{0xA2}, 32), VOut: 1, Value: 50000000, Type: doge.ScriptTypeP2PKH, Script: queryHash}}, 100); err != nil {
+			return err
+		}
+		return tx.SetResumePoint(bytes.Repeat([]byte{0xB0}, 32), 100)
+	})
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	return db
+}
+
+func TestRunQuery(t *testing.T) {
+	db := seedStore(t, ":memory:")
+	defer db.Close()
+	address := string(doge.Hash160toAddress(queryHash, doge.DogeMainNetChain.P2PKH_Address_Prefix))
+
+	tests := []struct {
+		name           string
+		endpoint       string
+		address        string
+		expectedStatus int
+		expectedOut    string
+		expectedErr    string
+	}{
+		{"Balance", "balance", address, 0, `{
+  "incoming": "0.5",
+  "available": "1.5",
+  "outgoing": "0",
+  "current": "2",
+  "utxo_count": 2
+}
+`, ""},
+		{"UTXOs", "utxo", address, 0, `{
+  "utxo": [
+    {
+      "tx": "a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1",
+      "vout": 0,
+      "value": "1.5",
+      "type": "P2PKH",
+      "script": "76a914424242424242424242424242424242424242424288ac"
+    },
+    {
+      "tx": "a2a2a2a2a2a2a2a2a2a2a2a2a2a2a2a2a2a2a2a2a2a2a2a2a2a2a2a2a2a2a2a2",
+      "vout": 1,
+      "value": "0.5",
+      "type": "P2PKH",
+      "script": "76a914424242424242424242424242424242424242424288ac"
+    }
+  ],
+  "count": 2,
+  "total": "2"
+}
+`, ""},
+		{"Bad address", "balance", "nope", 1, "", "query balance: "},
+		{"Unknown endpoint", "height", address, 1, "", "query height: unknown query 'height'\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			status := runQuery(db, 6, tt.endpoint, tt.address, &stdout, &stderr)
+			if status != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d (%s)", tt.expectedStatus, status, stderr.String())
+			}
+			if stdout.String() != tt.expectedOut {
+				t.Errorf("expected output %s, got %s", tt.expectedOut, stdout.String())
+			}
+			if !strings.HasPrefix(stderr.String(), tt.expectedErr) || (tt.expectedErr == "") != (stderr.Len() == 0) {
+				t.Errorf("expected error %q, got %q", tt.expectedErr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestQueryCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	seedStore(t, path).Close()
+	address := string(doge.Hash160toAddress(queryHash, doge.DogeMainNetChain.P2PKH_Address_Prefix))
+	missing := filepath.Join(t.TempDir(), "missing.db")
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectedStatus int
+		expectedOut    string
+	}{
+		{"Flags first", []string{"-dburl=" + path, "balance", address}, 0, `"utxo_count": 2`},
+		{"Flags last", []string{"utxo", address, "-dburl", path}, 0, `"vout": 1`},
+		{"Confirmations", []string{"-dburl=" + path, "-confirmations=100", "balance", address}, 0, `"available": "0"`},
+		{"No query", []string{"-dburl=" + path}, 2, ""},
+		{"No address", []string{"-dburl=" + path, "balance"}, 2, ""},
+		{"Unknown query", []string{"-dburl=" + path, "height", address}, 2, ""},
+		{"Unknown flag", []string{"-port=1", "balance", address}, 2, ""},
+		{"Zero confirmations", []string{"-dburl=" + path, "-confirmations=0", "balance", address}, 2, ""},
+		{"Missing database", []string{"-dburl=" + missing, "balance", address}, 1, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			status := queryCommand(tt.args, &stdout, &stderr)
+			if status != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d (%s)", tt.expectedStatus, status, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.expectedOut) || (tt.expectedOut == "") != (stdout.Len() == 0) {
+				t.Errorf("expected output containing %q, got %q", tt.expectedOut, stdout.String())
+			}
+		})
+	}
+}
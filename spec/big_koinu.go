@@ -28,6 +28,13 @@ func (a BigKoinu) Add(other BigKoinu) BigKoinu {
 	return res
 }
 
+// AddKoinu adds a single (e.g. UTXO) amount.
+func (a BigKoinu) AddKoinu(value koinu.Koinu) BigKoinu {
+	var res BigKoinu
+	res.value.Add(&a.value, big.NewInt(int64(value)))
+	return res
+}
+
 func (a BigKoinu) Equal(other BigKoinu) bool {
 	return a.value.Cmp(&other.value) == 0
 }
@@ -0,0 +1,32 @@
+package spec
+
+// UTXOEventOp is what happened to a UTXO in a UTXOEvent.
+type UTXOEventOp int16
+
+const (
+	EventCreate     UTXOEventOp = 1 // created in a block
+	EventSpend      UTXOEventOp = 2 // spent in a block
+	EventUndoCreate UTXOEventOp = 3 // the block that created it was undone (removed again)
+	EventUndoSpend  UTXOEventOp = 4 // the block that spent it was undone (unspent again)
+)
+
+func (op UTXOEventOp) String() string {
+	switch op {
+	case EventCreate:
+		return "create"
+	case EventSpend:
+		return "spend"
+	case EventUndoCreate:
+		return "undo-create"
+	case EventUndoSpend:
+		return "undo-spend"
+	}
+	return "unknown"
+}
+
+// UTXOEvent is a change to the UTXO set, numbered in the order it was indexed.
+type UTXOEvent struct {
+	UTXOChange             // the UTXO, and the height of the block that created or spent it
+	Seq        int64       // increases with each event (never reused)
+	Op         UTXOEventOp // what happened
+}
@@ -0,0 +1,16 @@
+package spec
+
+import (
+	"crypto/sha256"
+
+	"github.com/dogeorg/doge"
+)
+
+// ScriptHash is the Electrum-protocol script hash of a stored script:
+// sha256 of the full scriptPubKey (expanded from the compact form by
+// doge.ExpandScript), in hash byte order. Electrum clients display and
+// send it hex-encoded in reverse byte order.
+func ScriptHash(kind doge.ScriptType, compact []byte) []byte {
+	hash := sha256.Sum256(doge.ExpandScript(kind, compact))
+	return hash[:]
+}
@@ -0,0 +1,20 @@
+package spec
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/dogeorg/doge"
+)
+
+func TestScriptHash(t *testing.T) {
+	// Electrum protocol documentation example: P2PKH 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa
+	// scriptPubKey 76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac
+	pubKeyHash, _ := hex.DecodeString("62e907b15cbf27d5425399ebf6f0fb50ebb88f18")
+	want := "8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161"
+
+	got := doge.HexEncodeReversed(ScriptHash(doge.ScriptTypeP2PKH, pubKeyHash))
+	if got != want {
+		t.Fatalf("ScriptHash = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,31 @@
+package spec
+
+import (
+	"database/sql"
+	"time"
+)
+
+// BlockStats are per-block statistics recorded as each block is indexed.
+type BlockStats struct {
+	Height  int64
+	TxCount int64 // transactions in the block (including coinbase)
+
+	// Fees is the total fees paid by the block's transactions, if FeesKnown:
+	// the fees are only known if every input spends an indexed UTXO.
+	Fees      int64
+	FeesKnown bool
+
+	// UTXOCreated, UTXOSpent and ProcessingTime are only recorded (Detailed)
+	// for blocks indexed since they were added. UTXOSpent counts the block's
+	// spends, including orphan spends (as BlockHistory does.)
+	UTXOCreated    int64
+	UTXOSpent      int64
+	ProcessingTime time.Duration // from taking the block to recording its stats (before the commit)
+	Detailed       bool
+}
+
+// DBStats are database diagnostics reported by Store.Stats.
+type DBStats struct {
+	PingLatency time.Duration // round trip of a database ping
+	Pool        sql.DBStats   // connection pool statistics
+}
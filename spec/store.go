@@ -16,29 +16,178 @@ type StoreTx interface {
 	// GetCurrentHeight gets the current block height from the resume point.
 	GetCurrentHeight() (height int64, err error)
 
-	// RemoveUTXOs marks UTXOs as spent at `height`
+	// GetTip gets the current block height and hash from the resume point in
+	// one read, so they are always from the same block (nil hash if none.)
+	GetTip() (height int64, hash []byte, err error)
+
+	// GetMaxIndexedHeight gets the highest block height of any indexed transaction
+	// (0 if none), regardless of the resume point.
+	GetMaxIndexedHeight() (height int64, err error)
+
+	// GetTxHeight gets the block height of an indexed transaction, or ErrNotFound.
+	// Only transactions with indexed outputs are stored, and they are deleted
+	// once all their outputs are spent and trimmed.
+	GetTxHeight(hash []byte) (height int64, err error)
+
+	// GetConfirmations gets the block height and confirmations (1 in the tip
+	// block) of an indexed transaction in one query, or ErrNotFound (see GetTxHeight.)
+	GetConfirmations(hash []byte) (height int64, confirmations int64, err error)
+
+	// CreateTxInputs records the inputs of transactions in the block at `height`
+	// (explorer mode; replaying a block keeps the existing rows.) They are
+	// removed by UndoAbove, but not trimmed.
+	CreateTxInputs(inputs []TxInput, height int64) error
+
+	// GetTxInputs lists the recorded inputs of a transaction, in input order
+	// (none if its block was indexed without recording inputs.)
+	GetTxInputs(hash []byte) (res []TxInput, err error)
+
+	// GetTxOutputs lists the stored outputs of a transaction, spent or not, in
+	// vout order, with the spending transaction if its inputs were recorded.
+	// Only indexed outputs are stored, and spent ones are deleted by
+	// TrimSpentUTXOs.
+	GetTxOutputs(hash []byte) (res []TxOutput, err error)
+
+	// GetTxTotal counts the indexed transactions (see GetTxHeight.)
+	GetTxTotal() (count int64, err error)
+
+	// GetUnspentCount gets the number of unspent UTXOs, which the store keeps
+	// up to date as UTXOs are created, spent and undone (no COUNT(*) needed.)
+	GetUnspentCount() (count int64, err error)
+
+	// GetOrphanSpends gets the number of orphan spends RemoveUTXOs has ignored.
+	GetOrphanSpends() (count int64, err error)
+
+	// GetUTXOConflicts gets the number of conflicting UTXOs CreateUTXOs has seen.
+	GetUTXOConflicts() (count int64, err error)
+
+	// RemoveUTXOs marks UTXOs as spent at `height`.
+	// Spends of outputs whose transaction isn't in the index (it predates the
+	// starting height, or none of its outputs were indexed) are orphan spends:
+	// they change nothing, and are counted (see GetOrphanSpends.) Indexing a
+	// block again (after a reorg or restart) counts them again.
 	RemoveUTXOs(removeUTXOs []OutPointKey, height int64) error
 
-	// CreateUTXOs inserts new UTXOs at `height`
+	// CreateUTXOs inserts new UTXOs at `height`.
+	// UTXOs already stored are skipped (a replayed block); if the stored UTXO
+	// differs it is kept, and the conflict is logged and counted (see GetUTXOConflicts.)
 	CreateUTXOs(createUTXOs []UTXO, height int64) error
 
-	// FindUTXOs finds all unspent UTXOs for an address.
+	// FindUTXOs finds all unspent UTXOs for an address, in (height, tx, vout) order.
 	FindUTXOs(kind doge.ScriptType, address []byte) (res []UTXO, err error)
 
+	// FindUTXOsFiltered finds unspent UTXOs for an address matching `filter`,
+	// in the same order unless filter.Order says otherwise.
+	FindUTXOsFiltered(kind doge.ScriptType, address []byte, filter UTXOFilter) (res []UTXO, err error)
+
+	// FindUTXOHistory is FindUTXOsFiltered including spent UTXOs, with their
+	// creation and spent heights. Spent UTXOs are deleted by TrimSpentUTXOs,
+	// so only those spent above the trim floor are found.
+	FindUTXOHistory(kind doge.ScriptType, address []byte, filter UTXOFilter) (res []UTXOState, err error)
+
+	// SumUTXOsFiltered counts the UTXOs FindUTXOsFiltered (or FindUTXOHistory
+	// if `includeSpent`) finds for `filter`, ignoring its Limit, and sums the
+	// values of the unspent ones: the totals of a list cut short by the Limit.
+	SumUTXOsFiltered(kind doge.ScriptType, address []byte, filter UTXOFilter, includeSpent bool) (count int64, unspent BigKoinu, err error)
+
+	// GetUTXOs looks up the stored UTXOs for `outpoints`, spent or not, in the
+	// same order; outpoints that aren't stored are left out. Spent UTXOs are
+	// deleted by TrimSpentUTXOs, so those spent long ago are missing.
+	GetUTXOs(outpoints []OutPointKey) (res []UTXOState, err error)
+
+	// FindOutgoing finds the spent UTXOs for an address that GetBalance counts
+	// as Outgoing (spent within `confirmations` blocks), with their spent heights.
+	FindOutgoing(kind doge.ScriptType, address []byte, confirmations int64) (res []UTXOChange, err error)
+
+	// SetBlockStats records per-block statistics for `height` (replacing any existing.)
+	SetBlockStats(stats BlockStats) error
+
+	// GetBlockStats lists the recorded block statistics between two heights (inclusive), in height order.
+	GetBlockStats(fromHeight int64, toHeight int64) (res []BlockStats, err error)
+
+	// GetUTXODiff lists UTXOs created or spent from `fromHeight` to `toHeight` inclusive.
+	// Spent UTXOs are deleted by TrimSpentUTXOs, so ranges below the trim
+	// horizon are incomplete.
+	GetUTXODiff(fromHeight int64, toHeight int64) (res UTXODiff, err error)
+
+	// GetUTXOEvents lists up to `limit` UTXO events with sequence numbers above `since`,
+	// in order. Events are only recorded if the store records changes.
+	GetUTXOEvents(since int64, limit int) (res []UTXOEvent, err error)
+
+	// GetAddressFirstSeen returns the lowest height of the address's stored UTXOs,
+	// or ErrNotFound. Spent UTXOs are trimmed after a while, so an address may
+	// have first received funds earlier than this.
+	GetAddressFirstSeen(kind doge.ScriptType, address []byte) (height int64, err error)
+
+	// HasAddressUTXOs reports whether any UTXOs paying the address are stored,
+	// spent or unspent. Spent UTXOs are trimmed after a while, so an address
+	// whose funds were all spent long ago has none.
+	HasAddressUTXOs(kind doge.ScriptType, address []byte) (found bool, err error)
+
+	// FindScriptByHash finds the stored script whose ScriptHash is `scriptHash`.
+	// Returns ErrNotFound if no UTXO pays that script.
+	FindScriptByHash(scriptHash []byte) (kind doge.ScriptType, script []byte, err error)
+
 	// GetBalance sums all unspent UTXOs for an address.
 	// 'confirmations' is the number of confirmations before a balance is available (typically 6)
 	GetBalance(kind doge.ScriptType, address []byte, confirmations int64) (res Balance, err error)
 
+	// GetUTXOAgeHistogram counts and sums an address's unspent UTXOs by
+	// confirmations (from the resume height), with one bucket per UTXOAgeBounds
+	// entry in order, including empty ones.
+	GetUTXOAgeHistogram(kind doge.ScriptType, address []byte) (res []UTXOAgeBucket, err error)
+
+	// GetBalanceAtHeight sums the address's UTXOs that were unspent after block `height`
+	// (created at or below `height`, and not spent until a later block.)
+	// Spent UTXOs are trimmed after a while, so this is only correct for
+	// heights at or above GetTrimmedBelow.
+	GetBalanceAtHeight(kind doge.ScriptType, address []byte, height int64) (res BigKoinu, err error)
+
+	// SampleAddresses picks up to `limit` random addresses (kind and compact
+	// script) that have unspent UTXOs, e.g. for spot-checking balances.
+	SampleAddresses(limit int) (res []AddressKey, err error)
+
+	// GetKindBalances sums the unspent UTXOs paying `script` (a compact script,
+	// i.e. a 20 or 32-byte hash) for each address kind (P2PKH, P2SH, P2PKHW, P2SHW)
+	// that has any, in kind order.
+	GetKindBalances(script []byte) (res []KindBalance, err error)
+
 	// UndoAbove removes created UTXOs and re-activates Removed UTXOs above `height`.
 	UndoAbove(height int64) error
 
 	// TrimSpentUTXOs permanently deletes all spent UTXOs below `height`
+	// and records `height` as the trimmed-below height.
 	TrimSpentUTXOs(height int64) error
+
+	// GetTrimmedBelow gets the height below which spent UTXOs have been deleted (0 if never trimmed.)
+	GetTrimmedBelow() (height int64, err error)
+
+	// GetMonitorState gets the state last saved by SetMonitorState (nil if none.)
+	GetMonitorState() (state []byte, err error)
+
+	// SetMonitorState saves opaque monitoring state (the Indexer's recent
+	// block and reorg history) so it survives a restart, replacing any saved.
+	SetMonitorState(state []byte) error
+
+	// ClearIndex deletes all indexed UTXOs and the resume point (for a full re-sync.)
+	ClearIndex() error
+
+	// CheckIntegrity runs consistency checks on the stored index.
+	// Returns a description of each violation found (empty if consistent.)
+	CheckIntegrity() (problems []string, err error)
+
+	// RepairOrphans deletes utxo rows without a matching tx row (which the
+	// tx join hides from every query) and tx rows without any utxo rows,
+	// both reported by CheckIntegrity. Returns the number of each deleted.
+	RepairOrphans() (repair OrphanRepair, err error)
 }
 
 type Store interface {
 	storelib.StoreAPI[Store, StoreTx] // include the Base Store API
 	StoreTx                           // include all the StoreTx methods
+
+	// Stats pings the database and reports connection pool statistics.
+	Stats() (stats DBStats, err error)
 }
 
 // Balance
@@ -47,4 +196,37 @@ type Balance struct {
 	Available BigKoinu `json:"available"` // confirmed balance you can spend
 	Outgoing  BigKoinu `json:"outgoing"`  // takes N confirmations to become fully Spent
 	Current   BigKoinu `json:"current"`   // current balance: Incoming + Available
+
+	UTXOCount int64 `json:"utxo_count"` // number of unspent UTXOs (Incoming and Available)
+}
+
+// UTXOAgeBounds are the lowest confirmations of each GetUTXOAgeHistogram
+// bucket: 0, 1-6, 7-99 and 100 or more.
+var UTXOAgeBounds = []int64{0, 1, 7, 100}
+
+// UTXOAgeBucket is the unspent total for UTXOs with at least MinConf
+// confirmations (and fewer than the next bucket's MinConf.)
+type UTXOAgeBucket struct {
+	MinConf int64
+	Count   int64    // number of unspent UTXOs
+	Value   BigKoinu // sum of their values
+}
+
+// KindBalance is the unspent total for one kind of script with the same payload.
+type KindBalance struct {
+	Kind    doge.ScriptType
+	Count   int64    // number of unspent UTXOs
+	Balance BigKoinu // sum of their values
+}
+
+// OrphanRepair counts the rows deleted by RepairOrphans.
+type OrphanRepair struct {
+	UTXOs int64 `json:"utxos"` // utxo rows without a matching tx row
+	Txs   int64 `json:"txs"`   // tx rows without any utxo rows
+}
+
+// AddressKey identifies an address by kind and compact script (see ClassifyScript.)
+type AddressKey struct {
+	Kind   doge.ScriptType
+	Script []byte
 }
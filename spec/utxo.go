@@ -5,9 +5,66 @@ import (
 )
 
 type UTXO struct {
-	TxID   []byte          // 32-byte tx hash
-	VOut   uint32          // tx output index
-	Value  int64           // Koinu value
-	Type   doge.ScriptType // script type
-	Script []byte          // content depends on 'Type' (compressed by ClassifyScript)
+	TxID     []byte          // 32-byte tx hash
+	VOut     uint32          // tx output index
+	Value    int64           // Koinu value
+	Type     doge.ScriptType // script type
+	Script   []byte          // content depends on 'Type' (compressed by ClassifyScript)
+	Coinbase bool            // created by a coinbase tx (not spendable until mature)
 }
+
+// UTXOChange is a UTXO created or spent at Height.
+type UTXOChange struct {
+	UTXO
+	Height int64 // height of the block that created (or spent) the UTXO
+}
+
+// UTXOState is a stored UTXO, spent or not (see StoreTx.GetUTXOs.)
+type UTXOState struct {
+	UTXO
+	Height int64 // height of the block that created the UTXO
+	Spent  int64 // height of the block that spent it (0 if unspent)
+}
+
+// TxInput is the output a transaction input spends (only recorded in explorer
+// mode, see StoreTx.CreateTxInputs.)
+type TxInput struct {
+	TxID   []byte      // 32-byte hash of the spending tx
+	VIn    uint32      // input number in the spending tx
+	Prev   OutPointKey // the output it spends
+	Height int64       // height of the block with the spending tx (set by GetTxInputs)
+}
+
+// TxOutput is a stored output of a transaction, spent or not (see StoreTx.GetTxOutputs.)
+type TxOutput struct {
+	UTXOState
+	SpentBy  []byte // hash of the tx that spent it (nil if unspent, or its inputs weren't recorded)
+	SpentVIn uint32 // input number in SpentBy
+}
+
+// UTXODiff lists the UTXOs created and spent in a range of blocks.
+type UTXODiff struct {
+	Created []UTXOChange // created in the range (including those since spent)
+	Spent   []UTXOChange // spent in the range
+}
+
+// UTXOFilter narrows the UTXOs returned by FindUTXOsFiltered.
+// Zero-valued fields do not filter.
+type UTXOFilter struct {
+	MinHeight int64     // only UTXOs created at or above this height
+	MaxHeight int64     // only UTXOs created at or below this height
+	MinValue  int64     // only UTXOs worth at least this many koinu
+	Limit     int       // at most this many UTXOs (0 for no limit)
+	Order     UTXOOrder // sort order, applied before Limit
+}
+
+// UTXOOrder is the order of the UTXOs returned by FindUTXOsFiltered.
+// Ties are always broken by (height, tx, vout), so every order is stable.
+type UTXOOrder int
+
+const (
+	UTXOOrderHeight     UTXOOrder = iota // oldest first (the default)
+	UTXOOrderValueDesc                   // largest first
+	UTXOOrderValueAsc                    // smallest first
+	UTXOOrderHeightDesc                  // newest first
+)
@@ -1,10 +1,15 @@
 package store
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log"
+	"math/rand"
 	"strings"
+	"time"
 
 	"github.com/dogeorg/doge"
 	"github.com/dogeorg/indexer/spec"
@@ -21,25 +26,136 @@ const defaultBalanceConfirmations = 6
 
 type IndexStore struct {
 	StoreBase
-	cacheBalances bool
+	cacheBalances    bool
+	coinbaseMaturity int64
+	recordChanges    bool
+	stopBackfill     context.CancelFunc // nil unless this store started the backfill
+	backfillDone     chan struct{}      // closed when the backfill goroutine exits
 }
 
 var _ Store = &IndexStore{} // interface assertion
 
+// DefaultCoinbaseMaturity is Dogecoin's coinbase maturity since block 145000.
+const DefaultCoinbaseMaturity = 240
+
+// Options configures NewIndexStore.
+type Options struct {
+	CacheBalances    bool  // keep a balance table up to date (requires Postgres)
+	CoinbaseMaturity int64 // confirmations before coinbase outputs count as Available (0 to treat them like other outputs)
+	RecordChanges    bool  // record UTXO events for GetUTXOEvents (the table grows with the chain)
+	ReadOnly         bool  // open for queries only: no migrations or backfills (fails if the schema is out of date)
+}
+
 // NewIndexStore returns a spec.Store implementation that uses Postgres or SQLite
-func NewIndexStore(fileName string, ctx context.Context, cacheBalances bool) (Store, error) {
-	store := &IndexStore{cacheBalances: cacheBalances}
+func NewIndexStore(fileName string, ctx context.Context, options Options) (Store, error) {
+	store := &IndexStore{cacheBalances: options.CacheBalances, coinbaseMaturity: options.CoinbaseMaturity, recordChanges: options.RecordChanges}
 	if store.cacheBalances && !isPostgresConnectionString(fileName) {
 		return store, fmt.Errorf("cache balances requires a Postgres database")
 	}
-	err := storelib.InitStore(store, &store.StoreBase, fileName, MIGRATIONS, ctx)
+	connString := fileName
+	if options.ReadOnly {
+		connString = readOnlyConnString(fileName)
+	}
+	err := storelib.InitStore(store, &store.StoreBase, connString, migrationsFor(fileName), ctx)
 	if err != nil {
 		return store, err
 	}
+	if options.ReadOnly {
+		return store, nil
+	}
 	if store.cacheBalances {
-		err = store.withDBTxn(store.ensureBalancesReady)
+		if err = store.withDBTxn(store.ensureBalancesReady); err != nil {
+			return store, err
+		}
+	}
+	// in the background, so a large backfill doesn't hold up startup
+	// (FindScriptByHash doesn't find the rows it hasn't reached yet)
+	backfillCtx, stop := context.WithCancel(ctx)
+	store.stopBackfill, store.backfillDone = stop, make(chan struct{})
+	backfill := store.WithCtx(backfillCtx).(*IndexStore)
+	go func() {
+		defer close(store.backfillDone)
+		if err := backfill.backfillScriptHashes(); err != nil && backfillCtx.Err() == nil {
+			log.Printf("[Store] scripthash backfill stopped, it continues on the next start: %v", err)
+		}
+	}()
+	return store, nil
+}
+
+const scriptHashBatch = 10000                       // rows per backfill transaction
+const scriptHashRetryDelay = 250 * time.Millisecond // before retrying a batch that conflicted with the indexer
+
+// backfillScriptHashes computes the scripthash of UTXOs indexed before the
+// column was added (only the first start after upgrading has any to do.)
+// Each batch commits, so an interrupted backfill continues on the next start.
+// It runs alongside the indexer, so batches that conflict with it are retried.
+func (s *IndexStore) backfillScriptHashes() error {
+	total := 0
+	for {
+		done := 0
+		err := s.Transact(func(tx spec.StoreTx) error {
+			var err error
+			done, err = tx.(*IndexStore).backfillScriptHashBatch()
+			return err
+		})
+		if errors.Is(err, storelib.ErrConflict) {
+			select {
+			case <-s.Ctx.Done():
+				return s.Ctx.Err()
+			case <-time.After(scriptHashRetryDelay):
+				continue
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if done == 0 {
+			return nil
+		}
+		total += done
+		log.Printf("[Store] backfilled scripthash for %d UTXOs", total)
+	}
+}
+
+func (s *IndexStore) backfillScriptHashBatch() (int, error) {
+	type row struct {
+		txid   int64
+		vout   int64
+		kind   doge.ScriptType
+		script []byte
+	}
+	rows, err := s.query(`SELECT txid,vout,kind,script FROM utxo WHERE scripthash IS NULL LIMIT $1`, scriptHashBatch)
+	if err != nil {
+		return 0, s.DBErr(err, "backfillScriptHashes: query")
+	}
+	var batch []row
+	for rows.Next() {
+		var r row
+		if err = rows.Scan(&r.txid, &r.vout, &r.kind, &r.script); err != nil {
+			rows.Close()
+			return 0, s.DBErr(err, "backfillScriptHashes: scan")
+		}
+		batch = append(batch, r)
+	}
+	if err = rows.Close(); err != nil {
+		return 0, s.DBErr(err, "backfillScriptHashes: scan")
 	}
-	return store, err
+	for _, r := range batch {
+		_, err = s.exec(`UPDATE utxo SET scripthash=$1 WHERE txid=$2 AND vout=$3`, spec.ScriptHash(r.kind, r.script), r.txid, r.vout)
+		if err != nil {
+			return 0, s.DBErr(err, "backfillScriptHashes: update")
+		}
+	}
+	return len(batch), nil
+}
+
+// Close stops the scripthash backfill (if running) and closes the database.
+func (s *IndexStore) Close() {
+	if s.stopBackfill != nil {
+		s.stopBackfill()
+		<-s.backfillDone
+	}
+	s.StoreBase.Close()
 }
 
 func (s *IndexStore) withDBTxn(fn func() error) error {
@@ -56,19 +172,59 @@ func (s *IndexStore) withDBTxn(fn func() error) error {
 	if err := fn(); err != nil {
 		return err
 	}
-	return s.DBErr(tx.Commit(), "withDBTxn: commit")
+	if err := tx.Commit(); err != nil {
+		return s.DBErr(err, "withDBTxn: commit")
+	}
+	return nil
 }
 
 // Clone makes a copy of the store implementation (because storelib can't do this part)
 func (s *IndexStore) Clone() (StoreImpl, *StoreBase, Store, StoreTx) {
-	newstore := &IndexStore{cacheBalances: s.cacheBalances}
+	newstore := &IndexStore{cacheBalances: s.cacheBalances, coinbaseMaturity: s.coinbaseMaturity, recordChanges: s.recordChanges}
 	return newstore, &newstore.StoreBase, newstore, newstore
 }
 
+// contextQueryable is the context-aware part of sql.DB and sql.Tx
+// (storelib's Queryable doesn't include it.)
+type contextQueryable interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// exec, query and queryRow run statements under the store's context (see WithCtx)
+// so they are cancelled with it, e.g. when an API client disconnects.
+func (s *IndexStore) exec(query string, args ...any) (sql.Result, error) {
+	return s.Txn.(contextQueryable).ExecContext(s.Ctx, query, args...)
+}
+
+func (s *IndexStore) query(query string, args ...any) (*sql.Rows, error) {
+	return s.Txn.(contextQueryable).QueryContext(s.Ctx, query, args...)
+}
+
+func (s *IndexStore) queryRow(query string, args ...any) *sql.Row {
+	return s.Txn.(contextQueryable).QueryRowContext(s.Ctx, query, args...)
+}
+
 func isPostgresConnectionString(fileName string) bool {
 	return strings.HasPrefix(fileName, "postgres://")
 }
 
+// readOnlyConnString makes the database reject writes: a read-only
+// Postgres session, or a SQLite file opened in read-only mode (which must exist.)
+func readOnlyConnString(fileName string) string {
+	param := "mode=ro"
+	if isPostgresConnectionString(fileName) {
+		param = "default_transaction_read_only=on"
+	} else if !strings.HasPrefix(fileName, "file:") {
+		fileName = "file:" + fileName
+	}
+	if strings.Contains(fileName, "?") {
+		return fileName + "&" + param
+	}
+	return fileName + "?" + param
+}
+
 // DATABASE SCHEMA
 
 // SMALLINT is int16, INTEGER is int32, BIGINT is int64
@@ -114,15 +270,187 @@ CREATE TABLE balance_meta (
 );
 `
 
+// tx.hash becomes UNIQUE so replayed blocks can use ON CONFLICT
+// (a HASH index cannot be unique, so it is replaced.) Before this, each
+// replayed block inserted its transactions again under new txids: keep the
+// first (MIN txid) row of each hash, and drop the copies and their UTXOs.
+const SCHEMA_v2 = `
+DELETE FROM utxo WHERE txid IN (
+	SELECT t.txid FROM tx t WHERE t.txid > (SELECT MIN(d.txid) FROM tx d WHERE d.hash = t.hash)
+);
+DELETE FROM tx WHERE txid > (SELECT MIN(d.txid) FROM tx d WHERE d.hash = tx.hash);
+CREATE UNIQUE INDEX tx_hash_unique ON tx (hash);
+DROP INDEX tx_hash;
+`
+
+// trimmed: single row, spent UTXOs below this height have been deleted
+const SCHEMA_v3 = `
+CREATE TABLE trimmed (
+	height BIGINT NOT NULL
+);
+`
+
+// coinbase outputs only count as available once mature (see GetBalance)
+const SCHEMA_v4 = `
+ALTER TABLE utxo ADD COLUMN coinbase BOOLEAN NOT NULL DEFAULT FALSE;
+`
+
+// block_stats: per-block statistics (kept after trimming)
+const SCHEMA_v5 = `
+CREATE TABLE block_stats (
+	height BIGINT PRIMARY KEY,
+	tx_count BIGINT NOT NULL
+);
+`
+
+// utxo_event: UTXO changes in indexing order, for incremental export (see GetUTXOEvents)
+// op is a spec.UTXOEventOp; the UTXO columns are copied because spent UTXOs are trimmed
+const SCHEMA_v6 = `
+CREATE TABLE utxo_event (
+	seq BIGSERIAL PRIMARY KEY,
+	op SMALLINT NOT NULL,
+	height BIGINT NOT NULL,
+	hash BYTEA NOT NULL,
+	vout INTEGER NOT NULL,
+	value BIGINT NOT NULL,
+	kind SMALLINT NOT NULL,
+	script BYTEA NOT NULL,
+	coinbase BOOLEAN NOT NULL
+);
+`
+
+// vout is a uint32, which doesn't fit Postgres INTEGER (int32) above 2^31-1.
+// (this rewrites the utxo table, which takes a while on a large index)
+const SCHEMA_v7 = `
+ALTER TABLE utxo ALTER COLUMN vout TYPE BIGINT;
+ALTER TABLE utxo_event ALTER COLUMN vout TYPE BIGINT;
+`
+
+// scripthash: sha256 of the full scriptPubKey (spec.ScriptHash) for Electrum-style lookups.
+// NULL in rows indexed before this, until backfillScriptHashes fills them in.
+const SCHEMA_v8 = `
+ALTER TABLE utxo ADD COLUMN scripthash BYTEA NULL;
+CREATE INDEX utxo_scripthash ON utxo USING HASH (scripthash);
+`
+
+// utxo_stats: single row (id=1), the number of unspent UTXOs, kept up to date
+// by CreateUTXOs, RemoveUTXOs and UndoAbove so it doesn't need a COUNT(*).
+const SCHEMA_v9 = `
+CREATE TABLE utxo_stats (
+	id SMALLINT PRIMARY KEY,
+	unspent BIGINT NOT NULL
+);
+INSERT INTO utxo_stats (id,unspent) SELECT 1,COUNT(*) FROM utxo WHERE spent IS NULL;
+`
+
+// orphan_spends: inputs spending an output of a transaction that isn't in the
+// index (see RemoveUTXOs), counted for observability.
+const SCHEMA_v10 = `
+ALTER TABLE utxo_stats ADD COLUMN orphan_spends BIGINT NOT NULL DEFAULT 0;
+`
+
+// utxo.height: copy of tx.height, so address queries don't need the tx join
+// (height range scans still use the tx_height index via the join.)
+// (this rewrites the utxo table, which takes a while on a large index)
+const SCHEMA_v11 = `
+ALTER TABLE utxo ADD COLUMN height BIGINT NULL;
+UPDATE utxo SET height=(SELECT t.height FROM tx t WHERE t.txid = utxo.txid);
+`
+
+// block_stats.fees: total fees paid by the block's transactions
+// (NULL if unknown: the block spends outputs that aren't indexed, or was indexed before this.)
+const SCHEMA_v12 = `
+ALTER TABLE block_stats ADD COLUMN fees BIGINT NULL;
+`
+
+// tx_input: the output each transaction input spends, recorded in explorer mode
+// (see CreateTxInputs.) Keyed by hash, since most transactions have no tx row.
+// Not trimmed, so the table grows with the chain.
+const SCHEMA_v13 = `
+CREATE TABLE tx_input (
+	hash BYTEA NOT NULL,
+	vin BIGINT NOT NULL,
+	prev_hash BYTEA NOT NULL,
+	prev_vout BIGINT NOT NULL,
+	height BIGINT NOT NULL,
+	PRIMARY KEY (hash,vin)
+);
+CREATE INDEX tx_input_prevout ON tx_input (prev_hash,prev_vout);
+CREATE INDEX tx_input_height ON tx_input (height);
+`
+
+// utxo_conflicts: outputs CreateUTXOs found already stored with different
+// content (see CreateUTXOs), counted so operators can be alerted.
+const SCHEMA_v14 = `
+ALTER TABLE utxo_stats ADD COLUMN utxo_conflicts BIGINT NOT NULL DEFAULT 0;
+`
+
+// monitor_state: single row (id=1), the Indexer's recent block and reorg
+// history (see SetMonitorState), saved on shutdown so monitoring survives restarts.
+const SCHEMA_v15 = `
+CREATE TABLE monitor_state (
+	id SMALLINT PRIMARY KEY,
+	state BYTEA NOT NULL
+);
+`
+
+// block_stats activity: UTXOs created and spent, and processing time in
+// milliseconds (NULL for blocks indexed before this, see BlockStats.Detailed.)
+const SCHEMA_v16 = `
+ALTER TABLE block_stats ADD COLUMN utxo_created BIGINT NULL;
+ALTER TABLE block_stats ADD COLUMN utxo_spent BIGINT NULL;
+ALTER TABLE block_stats ADD COLUMN processing_ms BIGINT NULL;
+`
+
 var MIGRATIONS = []storelib.Migration{
 	{Version: 1, SQL: SCHEMA_v0},
 	{Version: 2, SQL: SCHEMA_v1},
+	{Version: 3, SQL: SCHEMA_v2},
+	{Version: 4, SQL: SCHEMA_v3},
+	{Version: 5, SQL: SCHEMA_v4},
+	{Version: 6, SQL: SCHEMA_v5},
+	{Version: 7, SQL: SCHEMA_v6},
+	{Version: 8, SQL: SCHEMA_v7},
+	{Version: 9, SQL: SCHEMA_v8},
+	{Version: 10, SQL: SCHEMA_v9},
+	{Version: 11, SQL: SCHEMA_v10},
+	{Version: 12, SQL: SCHEMA_v11},
+	{Version: 13, SQL: SCHEMA_v12},
+	{Version: 14, SQL: SCHEMA_v13},
+	{Version: 15, SQL: SCHEMA_v14},
+	{Version: 16, SQL: SCHEMA_v15},
+	{Version: 17, SQL: SCHEMA_v16},
+}
+
+// SchemaVersion is the schema version of an up-to-date database (the last
+// of MIGRATIONS, which NewIndexStore applies.)
+func SchemaVersion() int {
+	return MIGRATIONS[len(MIGRATIONS)-1].Version
+}
+
+// SQLITE_SCHEMA replaces Postgres-only migrations on SQLite.
+var SQLITE_SCHEMA = map[int]string{
+	8: `SELECT 1`, // SQLite INTEGER is already 64-bit (and SQLite can't ALTER a column type)
+}
+
+// migrationsFor returns the migrations for the database in `fileName`.
+func migrationsFor(fileName string) []storelib.Migration {
+	if isPostgresConnectionString(fileName) {
+		return MIGRATIONS
+	}
+	res := append([]storelib.Migration{}, MIGRATIONS...)
+	for n, m := range res {
+		if sql, found := SQLITE_SCHEMA[m.Version]; found {
+			res[n].SQL = sql
+		}
+	}
+	return res
 }
 
 // STORE INTERFACE
 
 func (s *IndexStore) GetResumePoint() ([]byte, error) {
-	row := s.Txn.QueryRow(`SELECT hash FROM resume LIMIT 1`)
+	row := s.queryRow(`SELECT hash FROM resume LIMIT 1`)
 	var hash []byte
 	err := row.Scan(&hash)
 	if err != nil {
@@ -135,7 +463,7 @@ func (s *IndexStore) GetResumePoint() ([]byte, error) {
 }
 
 func (s *IndexStore) SetResumePoint(hash []byte, height int64) error {
-	res, err := s.Txn.Exec(`UPDATE resume SET hash=$1, height=$2`, hash, height)
+	res, err := s.exec(`UPDATE resume SET hash=$1, height=$2`, hash, height)
 	if err != nil {
 		return s.DBErr(err, "SetResumePoint")
 	}
@@ -145,7 +473,7 @@ func (s *IndexStore) SetResumePoint(hash []byte, height int64) error {
 	}
 	if rows < 1 {
 		// First time: insert the single row.
-		_, err = s.Txn.Exec(`INSERT INTO resume (hash,height) VALUES ($1,$2)`, hash, height)
+		_, err = s.exec(`INSERT INTO resume (hash,height) VALUES ($1,$2)`, hash, height)
 		if err != nil {
 			return s.DBErr(err, "SetResumePoint Insert")
 		}
@@ -160,7 +488,7 @@ func (s *IndexStore) SetResumePoint(hash []byte, height int64) error {
 
 // GetCurrentHeight gets the current block height from the resume point.
 func (s *IndexStore) GetCurrentHeight() (int64, error) {
-	row := s.Txn.QueryRow(`SELECT height FROM resume LIMIT 1`)
+	row := s.queryRow(`SELECT height FROM resume LIMIT 1`)
 	var height int64
 	err := row.Scan(&height)
 	if err != nil {
@@ -172,6 +500,39 @@ func (s *IndexStore) GetCurrentHeight() (int64, error) {
 	return height, nil
 }
 
+func (s *IndexStore) GetTip() (int64, []byte, error) {
+	row := s.queryRow(`SELECT height,hash FROM resume LIMIT 1`)
+	var height int64
+	var hash []byte
+	err := row.Scan(&height, &hash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil, nil // no blocks indexed yet
+		}
+		return 0, nil, s.DBErr(err, "GetTip")
+	}
+	return height, hash, nil
+}
+
+// GetMaxIndexedHeight gets the highest block height of any indexed transaction.
+func (s *IndexStore) GetMaxIndexedHeight() (int64, error) {
+	row := s.queryRow(`SELECT MAX(height) FROM tx`)
+	var height sql.NullInt64
+	if err := row.Scan(&height); err != nil {
+		return 0, s.DBErr(err, "GetMaxIndexedHeight")
+	}
+	return height.Int64, nil // 0 if no transactions
+}
+
+// Stats pings the database and reports connection pool statistics.
+func (s *IndexStore) Stats() (spec.DBStats, error) {
+	start := time.Now()
+	if err := s.RawDB.PingContext(s.Ctx); err != nil {
+		return spec.DBStats{}, s.DBErr(err, "Stats Ping")
+	}
+	return spec.DBStats{PingLatency: time.Since(start), Pool: s.RawDB.Stats()}, nil
+}
+
 func (s *IndexStore) ensureBalancesReady() error {
 	height, err := s.GetCurrentHeight()
 	if err != nil {
@@ -189,7 +550,7 @@ func (s *IndexStore) ensureBalancesReady() error {
 }
 
 func (s *IndexStore) getBalanceMeta() (height int64, confirmations int64, found bool, err error) {
-	row := s.Txn.QueryRow(`SELECT height,confirmations FROM balance_meta WHERE id=1`)
+	row := s.queryRow(`SELECT height,confirmations FROM balance_meta WHERE id=1`)
 	err = row.Scan(&height, &confirmations)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -201,7 +562,7 @@ func (s *IndexStore) getBalanceMeta() (height int64, confirmations int64, found
 }
 
 func (s *IndexStore) setBalanceMeta(height int64) error {
-	_, err := s.Txn.Exec(`INSERT INTO balance_meta (id,height,confirmations) VALUES (1,$1,$2)
+	_, err := s.exec(`INSERT INTO balance_meta (id,height,confirmations) VALUES (1,$1,$2)
 		ON CONFLICT (id) DO UPDATE SET
 			height=excluded.height,
 			confirmations=excluded.confirmations`, height, defaultBalanceConfirmations)
@@ -214,24 +575,23 @@ func (s *IndexStore) setBalanceMeta(height int64) error {
 func (s *IndexStore) rebuildBalances(height int64) error {
 	threshold := height - defaultBalanceConfirmations
 
-	_, err := s.Txn.Exec(`DELETE FROM balance`)
+	_, err := s.exec(`DELETE FROM balance`)
 	if err != nil {
 		return s.DBErr(err, "rebuildBalances: clear balance")
 	}
-	_, err = s.Txn.Exec(`DELETE FROM balance_meta`)
+	_, err = s.exec(`DELETE FROM balance_meta`)
 	if err != nil {
 		return s.DBErr(err, "rebuildBalances: clear balance_meta")
 	}
 
-	_, err = s.Txn.Exec(`INSERT INTO balance (kind,script,available,incoming,outgoing)
+	_, err = s.exec(`INSERT INTO balance (kind,script,available,incoming,outgoing)
 		SELECT
 			u.kind,
 			u.script,
-			COALESCE(SUM(CASE WHEN u.spent IS NULL AND t.height < $1 THEN CAST(u.value AS NUMERIC) ELSE 0 END),0),
-			COALESCE(SUM(CASE WHEN u.spent IS NULL AND t.height >= $1 THEN CAST(u.value AS NUMERIC) ELSE 0 END),0),
+			COALESCE(SUM(CASE WHEN u.spent IS NULL AND u.height < $1 THEN CAST(u.value AS NUMERIC) ELSE 0 END),0),
+			COALESCE(SUM(CASE WHEN u.spent IS NULL AND u.height >= $1 THEN CAST(u.value AS NUMERIC) ELSE 0 END),0),
 			COALESCE(SUM(CASE WHEN u.spent >= $1 THEN CAST(u.value AS NUMERIC) ELSE 0 END),0)
 		FROM utxo u
-		INNER JOIN tx t ON u.txid = t.txid
 		WHERE u.kind IN (2,3,5,6) AND (u.spent IS NULL OR u.spent >= $1)
 		GROUP BY u.kind,u.script
 		HAVING
@@ -250,7 +610,7 @@ func (s *IndexStore) applyBalanceDelta(kind doge.ScriptType, script []byte, avai
 		return nil
 	}
 
-	_, err := s.Txn.Exec(`INSERT INTO balance (kind,script,available,incoming,outgoing)
+	_, err := s.exec(`INSERT INTO balance (kind,script,available,incoming,outgoing)
 		VALUES ($1,$2,$3,$4,$5)
 		ON CONFLICT (kind,script) DO UPDATE SET
 			available=available+excluded.available,
@@ -261,7 +621,7 @@ func (s *IndexStore) applyBalanceDelta(kind doge.ScriptType, script []byte, avai
 		return s.DBErr(err, "applyBalanceDelta: upsert")
 	}
 
-	_, err = s.Txn.Exec(`DELETE FROM balance WHERE kind=$1 AND script=$2 AND available=0 AND incoming=0 AND outgoing=0`, kind, script)
+	_, err = s.exec(`DELETE FROM balance WHERE kind=$1 AND script=$2 AND available=0 AND incoming=0 AND outgoing=0`, kind, script)
 	if err != nil {
 		return s.DBErr(err, "applyBalanceDelta: prune")
 	}
@@ -323,11 +683,10 @@ func (s *IndexStore) advanceBalances(height int64) error {
 	oldThreshold := oldHeight - defaultBalanceConfirmations
 	newThreshold := height - defaultBalanceConfirmations
 
-	_, err = s.Txn.Exec(`INSERT INTO balance (kind,script,available,incoming,outgoing)
+	_, err = s.exec(`INSERT INTO balance (kind,script,available,incoming,outgoing)
 		SELECT u.kind,u.script,COALESCE(SUM(CAST(u.value AS NUMERIC)),0),-COALESCE(SUM(CAST(u.value AS NUMERIC)),0),0
 		FROM utxo u
-		INNER JOIN tx t ON u.txid = t.txid
-		WHERE u.kind IN (2,3,5,6) AND u.spent IS NULL AND t.height >= $1 AND t.height < $2
+		WHERE u.kind IN (2,3,5,6) AND u.spent IS NULL AND u.height >= $1 AND u.height < $2
 		GROUP BY u.kind,u.script
 		ON CONFLICT (kind,script) DO UPDATE SET
 			available=balance.available+excluded.available,
@@ -337,7 +696,7 @@ func (s *IndexStore) advanceBalances(height int64) error {
 		return s.DBErr(err, "advanceBalances: mature")
 	}
 
-	_, err = s.Txn.Exec(`INSERT INTO balance (kind,script,available,incoming,outgoing)
+	_, err = s.exec(`INSERT INTO balance (kind,script,available,incoming,outgoing)
 		SELECT u.kind,u.script,0,0,-COALESCE(SUM(CAST(u.value AS NUMERIC)),0)
 		FROM utxo u
 		WHERE u.kind IN (2,3,5,6) AND u.spent >= $1 AND u.spent < $2
@@ -353,9 +712,160 @@ func (s *IndexStore) advanceBalances(height int64) error {
 	return s.setBalanceMeta(height)
 }
 
-// RemoveUTXOs marks UTXOs as spent at `height`
+func (s *IndexStore) GetTxHeight(hash []byte) (height int64, err error) {
+	row := s.queryRow(`SELECT height FROM tx WHERE hash=$1`, hash)
+	if err = row.Scan(&height); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, spec.ErrNotFound
+		}
+		return 0, s.DBErr(err, "GetTxHeight")
+	}
+	return height, nil
+}
+
+// GetConfirmations counts from the resume height, like GetCurrentHeight
+// (0 if there is no resume point.)
+func (s *IndexStore) GetConfirmations(hash []byte) (height int64, confirmations int64, err error) {
+	row := s.queryRow(`SELECT height, COALESCE((SELECT height FROM resume LIMIT 1),0) - height + 1 FROM tx WHERE hash=$1`, hash)
+	if err = row.Scan(&height, &confirmations); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, 0, spec.ErrNotFound
+		}
+		return 0, 0, s.DBErr(err, "GetConfirmations")
+	}
+	return height, confirmations, nil
+}
+
+// CreateTxInputs records transaction inputs at `height` (explorer mode.)
+func (s *IndexStore) CreateTxInputs(inputs []spec.TxInput, height int64) error {
+	if len(inputs) == 0 {
+		return nil
+	}
+	// the same block can be replayed (see CreateUTXOs): keep the existing rows
+	stmt, err := s.Txn.Prepare(`INSERT INTO tx_input (hash,vin,prev_hash,prev_vout,height) VALUES ($1,$2,$3,$4,$5) ON CONFLICT (hash,vin) DO NOTHING`)
+	if err != nil {
+		return s.DBErr(err, "CreateTxInputs: prepare")
+	}
+	defer stmt.Close()
+	for _, in := range inputs {
+		_, err = stmt.Exec(in.TxID, in.VIn, in.Prev.Tx, in.Prev.VOut, height)
+		if err != nil {
+			return s.DBErr(err, "CreateTxInputs: insert")
+		}
+	}
+	return nil
+}
+
+func (s *IndexStore) GetTxInputs(hash []byte) (res []spec.TxInput, err error) {
+	rows, err := s.query(`SELECT vin,prev_hash,prev_vout,height FROM tx_input WHERE hash=$1 ORDER BY vin`, hash)
+	if err != nil {
+		return nil, s.DBErr(err, "GetTxInputs: query")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		in := spec.TxInput{TxID: hash}
+		if err = rows.Scan(&in.VIn, &in.Prev.Tx, &in.Prev.VOut, &in.Height); err != nil {
+			return nil, s.DBErr(err, "GetTxInputs: scan")
+		}
+		res = append(res, in)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, s.DBErr(err, "GetTxInputs: scan")
+	}
+	return res, nil
+}
+
+func (s *IndexStore) GetTxOutputs(hash []byte) (res []spec.TxOutput, err error) {
+	// the spending input is only joined for spent outputs (a recorded input
+	// spending an unspent output would be a bug, but must not show as spent)
+	rows, err := s.query(`SELECT u.vout,u.value,u.kind,u.script,u.coinbase,u.height,u.spent,i.hash,i.vin
+		FROM tx t
+		INNER JOIN utxo u ON u.txid = t.txid
+		LEFT OUTER JOIN tx_input i ON i.prev_hash = t.hash AND i.prev_vout = u.vout AND u.spent IS NOT NULL
+		WHERE t.hash=$1
+		ORDER BY u.vout`, hash)
+	if err != nil {
+		return nil, s.DBErr(err, "GetTxOutputs: query")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		out := spec.TxOutput{}
+		out.TxID = hash
+		var spent, spentVIn sql.NullInt64
+		if err = rows.Scan(&out.VOut, &out.Value, &out.Type, &out.Script, &out.Coinbase, &out.Height, &spent, &out.SpentBy, &spentVIn); err != nil {
+			return nil, s.DBErr(err, "GetTxOutputs: scan")
+		}
+		out.Spent = spent.Int64
+		out.SpentVIn = uint32(spentVIn.Int64)
+		res = append(res, out)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, s.DBErr(err, "GetTxOutputs: scan")
+	}
+	return res, nil
+}
+
+func (s *IndexStore) GetTxTotal() (count int64, err error) {
+	row := s.queryRow(`SELECT COUNT(*) FROM tx`)
+	if err = row.Scan(&count); err != nil {
+		return 0, s.DBErr(err, "GetTxTotal")
+	}
+	return count, nil
+}
+
+// GetUnspentCount gets the number of unspent UTXOs (maintained, not counted.)
+func (s *IndexStore) GetUnspentCount() (count int64, err error) {
+	row := s.queryRow(`SELECT unspent FROM utxo_stats WHERE id=1`)
+	if err = row.Scan(&count); err != nil {
+		return 0, s.DBErr(err, "GetUnspentCount")
+	}
+	return count, nil
+}
+
+// addUnspent adjusts the unspent UTXO count, in the same transaction as the
+// change to the utxo table.
+func (s *IndexStore) addUnspent(delta int64) error {
+	if delta == 0 {
+		return nil
+	}
+	_, err := s.exec(`UPDATE utxo_stats SET unspent=unspent+$1 WHERE id=1`, delta)
+	if err != nil {
+		return s.DBErr(err, "addUnspent")
+	}
+	return nil
+}
+
+// GetOrphanSpends gets the number of orphan spends seen (see RemoveUTXOs.)
+func (s *IndexStore) GetOrphanSpends() (count int64, err error) {
+	row := s.queryRow(`SELECT orphan_spends FROM utxo_stats WHERE id=1`)
+	if err = row.Scan(&count); err != nil {
+		return 0, s.DBErr(err, "GetOrphanSpends")
+	}
+	return count, nil
+}
+
+func (s *IndexStore) addOrphanSpends(count int64) error {
+	if count == 0 {
+		return nil
+	}
+	_, err := s.exec(`UPDATE utxo_stats SET orphan_spends=orphan_spends+$1 WHERE id=1`, count)
+	if err != nil {
+		return s.DBErr(err, "addOrphanSpends")
+	}
+	return nil
+}
+
+// removeUTXOsBatch is the number of outpoints per UPDATE. Batching saves a
+// Postgres round trip per input; SQLite looks up $N parameters by name, so
+// preparing a statement gets quadratically slower with more of them.
+const removeUTXOsBatch = 50
+
+// RemoveUTXOs marks UTXOs as spent at `height`.
+// Inputs spending an output of a transaction that isn't in the index are
+// orphan spends: the funding transaction predates the starting height, or
+// none of its outputs were indexed. They're ignored, and counted.
 func (s *IndexStore) RemoveUTXOs(removeUTXOs []spec.OutPointKey, height int64) error {
-	query, err := s.Txn.Prepare(`UPDATE utxo SET spent=$1 WHERE vout=$2 AND txid=(SELECT txid FROM tx WHERE hash=$3)`)
+	txids, err := s.resolveTxids(removeUTXOs)
 	if err != nil {
 		return err
 	}
@@ -366,46 +876,212 @@ func (s *IndexStore) RemoveUTXOs(removeUTXOs []spec.OutPointKey, height int64) e
 			return err
 		}
 	}
+	spent := int64(0)
+	orphans := int64(0)
+	for start := 0; start < len(removeUTXOs); start += removeUTXOsBatch {
+		batch := removeUTXOs[start:min(start+removeUTXOsBatch, len(removeUTXOs))]
+		n, err := s.removeUTXOBatch(batch, txids, height, currentHeight)
+		if err != nil {
+			return err
+		}
+		spent += n
+	}
 	for _, out := range removeUTXOs {
-		var kind doge.ScriptType
-		var script []byte
-		var value int64
-		var txHeight int64
-		found := false
-		if s.cacheBalances {
-			row := s.Txn.QueryRow(`SELECT u.kind,u.script,u.value,t.height
-				FROM utxo u
-				INNER JOIN tx t ON u.txid = t.txid
-				WHERE u.kind IN (2,3,5,6) AND u.vout=$1 AND t.hash=$2 AND u.spent IS NULL`, out.VOut, out.Tx)
-			err = row.Scan(&kind, &script, &value, &txHeight)
-			if err != nil && err != sql.ErrNoRows {
-				return s.DBErr(err, "RemoveUTXOs: lookup")
+		if _, found := txids[string(out.Tx)]; !found {
+			orphans++
+		}
+	}
+	if err := s.addOrphanSpends(orphans); err != nil {
+		return err
+	}
+	return s.addUnspent(-spent)
+}
+
+// resolveTxids looks up the txid of each distinct transaction the outpoints
+// refer to (outputs of transactions that aren't indexed are left out.)
+func (s *IndexStore) resolveTxids(outpoints []spec.OutPointKey) (map[string]int64, error) {
+	txids := map[string]int64{} // hash -> txid
+	var hashes []any
+	seen := map[string]bool{}
+	for _, out := range outpoints {
+		if !seen[string(out.Tx)] {
+			seen[string(out.Tx)] = true
+			hashes = append(hashes, out.Tx)
+		}
+	}
+	for start := 0; start < len(hashes); start += removeUTXOsBatch {
+		batch := hashes[start:min(start+removeUTXOsBatch, len(hashes))]
+		rows, err := s.query(`SELECT txid,hash FROM tx WHERE hash IN (`+placeholders(1, len(batch), 1)+`)`, batch...)
+		if err != nil {
+			return nil, s.DBErr(err, "resolveTxids: query")
+		}
+		for rows.Next() {
+			var txid int64
+			var hash []byte
+			if err = rows.Scan(&txid, &hash); err != nil {
+				rows.Close()
+				return nil, s.DBErr(err, "resolveTxids: scan")
 			}
-			found = err == nil
+			txids[string(hash)] = txid
+		}
+		if err = rows.Close(); err != nil {
+			return nil, s.DBErr(err, "resolveTxids: scan")
 		}
+	}
+	return txids, nil
+}
 
-		_, err := query.Exec(height, out.VOut, out.Tx)
+// removeUTXOBatch marks up to removeUTXOsBatch UTXOs spent with one UPDATE,
+// returning the number that were unspent.
+func (s *IndexStore) removeUTXOBatch(batch []spec.OutPointKey, txids map[string]int64, height int64, currentHeight int64) (int64, error) {
+	keys := utxoKeys(batch, txids)
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	// details of the UTXOs being spent, only if still unspent (not when a block is replayed)
+	type spentUTXO struct {
+		hash     []byte
+		value    int64
+		kind     doge.ScriptType
+		script   []byte
+		coinbase bool
+		txHeight int64
+	}
+	unspent := map[utxoKey]spentUTXO{}
+	if s.cacheBalances || s.recordChanges {
+		clause, args := utxoKeysWhere("u.", 1, keys)
+		rows, err := s.query(`SELECT u.txid,u.vout,t.hash,u.value,u.kind,u.script,u.coinbase,t.height
+			FROM utxo u
+			INNER JOIN tx t ON u.txid = t.txid
+			WHERE `+clause+` AND u.spent IS NULL`, args...)
 		if err != nil {
-			return s.DBErr(err, "RemoveUTXOs")
+			return 0, s.DBErr(err, "RemoveUTXOs: lookup")
+		}
+		for rows.Next() {
+			var key utxoKey
+			var u spentUTXO
+			if err = rows.Scan(&key.txid, &key.vout, &u.hash, &u.value, &u.kind, &u.script, &u.coinbase, &u.txHeight); err != nil {
+				rows.Close()
+				return 0, s.DBErr(err, "RemoveUTXOs: lookup scan")
+			}
+			unspent[key] = u
+		}
+		if err = rows.Close(); err != nil {
+			return 0, s.DBErr(err, "RemoveUTXOs: lookup scan")
 		}
-		if s.cacheBalances && found {
+	}
+
+	if s.recordChanges {
+		for _, key := range keys { // in input order
+			u, found := unspent[key]
+			if !found {
+				continue
+			}
+			_, err := s.exec(`INSERT INTO utxo_event (op,height,hash,vout,value,kind,script,coinbase) VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`,
+				spec.EventSpend, height, u.hash, key.vout, u.value, u.kind, u.script, u.coinbase)
+			if err != nil {
+				return 0, s.DBErr(err, "RemoveUTXOs: record event")
+			}
+		}
+	}
+
+	clause, args := utxoKeysWhere("", 2, keys)
+	res, err := s.exec(`UPDATE utxo SET spent=$1 WHERE `+clause+` AND spent IS NULL`, append([]any{height}, args...)...)
+	if err != nil {
+		return 0, s.DBErr(err, "RemoveUTXOs")
+	}
+	spent, err := res.RowsAffected()
+	if err != nil {
+		return 0, s.DBErr(err, "RemoveUTXOs RowsAffected")
+	}
+
+	if s.cacheBalances {
+		for _, key := range keys {
+			u, found := unspent[key]
+			if !found || !cacheableBalanceKind(u.kind) {
+				continue
+			}
 			availableDelta := int64(0)
 			incomingDelta := int64(0)
 			outgoingDelta := int64(0)
-			if balanceIsAvailable(txHeight, currentHeight, defaultBalanceConfirmations) {
-				availableDelta = -value
+			if balanceIsAvailable(u.txHeight, currentHeight, defaultBalanceConfirmations) {
+				availableDelta = -u.value
 			} else {
-				incomingDelta = -value
+				incomingDelta = -u.value
 			}
 			if spendIsOutgoing(height, currentHeight, defaultBalanceConfirmations) {
-				outgoingDelta = value
+				outgoingDelta = u.value
 			}
-			if err := s.applyBalanceDelta(kind, script, availableDelta, incomingDelta, outgoingDelta); err != nil {
-				return err
+			if err := s.applyBalanceDelta(u.kind, u.script, availableDelta, incomingDelta, outgoingDelta); err != nil {
+				return 0, err
 			}
 		}
 	}
-	return nil
+	return spent, nil
+}
+
+// utxoKey is the primary key of a utxo row.
+type utxoKey struct {
+	txid int64
+	vout uint32
+}
+
+// utxoKeys maps outpoints to utxo keys, in order, leaving out those whose
+// transaction isn't in `txids` (see resolveTxids.)
+func utxoKeys(outpoints []spec.OutPointKey, txids map[string]int64) []utxoKey {
+	var keys []utxoKey
+	for _, out := range outpoints {
+		if txid, found := txids[string(out.Tx)]; found {
+			keys = append(keys, utxoKey{txid, out.VOut})
+		}
+	}
+	return keys
+}
+
+// utxoKeysWhere matches the utxo rows for `keys` (columns prefixed with
+// `prefix`), with parameters from $first.
+// (txid,vout) IN (...) alone makes SQLite scan the table: txid IN (...)
+// lets it (and Postgres) use the primary key.
+func utxoKeysWhere(prefix string, first int, keys []utxoKey) (string, []any) {
+	var distinct, pairs []any
+	seen := map[int64]bool{}
+	for _, key := range keys {
+		pairs = append(pairs, key.txid, key.vout)
+		if !seen[key.txid] {
+			seen[key.txid] = true
+			distinct = append(distinct, key.txid)
+		}
+	}
+	clause := fmt.Sprintf("%stxid IN (%s) AND (%stxid,%svout) IN (%s)",
+		prefix, placeholders(first, len(distinct), 1), prefix, prefix, placeholders(first+len(distinct), len(keys), 2))
+	return clause, append(distinct, pairs...)
+}
+
+// placeholders returns `count` comma-separated parameters from $first, or
+// `count` row values of `width` parameters each, e.g. ($1,$2),($3,$4).
+func placeholders(first int, count int, width int) string {
+	var b strings.Builder
+	n := first
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if width > 1 {
+			b.WriteByte('(')
+		}
+		for j := 0; j < width; j++ {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(&b, "$%d", n)
+			n++
+		}
+		if width > 1 {
+			b.WriteByte(')')
+		}
+	}
+	return b.String()
 }
 
 // CreateUTXOs inserts new UTXOs at `height` (can replace Removed UTXOs)
@@ -420,9 +1096,10 @@ func (s *IndexStore) CreateUTXOs(createUTXOs []spec.UTXO, height int64) error {
 	}
 
 	// insert all required `tx` rows and cache the mapping to txid
-	// no conflict expected: we delete tx on rollback, and hash is unique in Core
+	// hash is unique in Core, but the same block can be replayed if we stopped
+	// after committing it and before the resume point advanced: keep the existing row.
 	txidMap := map[string]int64{} // hash -> txid
-	txStmt, err := s.Txn.Prepare(`INSERT INTO tx (height,hash) VALUES ($1,$2) RETURNING txid`)
+	txStmt, err := s.Txn.Prepare(`INSERT INTO tx (height,hash) VALUES ($1,$2) ON CONFLICT (hash) DO NOTHING RETURNING txid`)
 	if err != nil {
 		return s.DBErr(err, "CreateUTXOs: prepare tx")
 	}
@@ -432,6 +1109,11 @@ func (s *IndexStore) CreateUTXOs(createUTXOs []spec.UTXO, height int64) error {
 			row := txStmt.QueryRow(height, utxo.TxID)
 			var txid int64
 			err = row.Scan(&txid)
+			if err == sql.ErrNoRows {
+				// replayed: the tx row already exists
+				row = s.queryRow(`SELECT txid FROM tx WHERE hash=$1`, utxo.TxID)
+				err = row.Scan(&txid)
+			}
 			if err != nil {
 				return s.DBErr(err, "CreateUTXOs: insert tx")
 			}
@@ -439,20 +1121,41 @@ func (s *IndexStore) CreateUTXOs(createUTXOs []spec.UTXO, height int64) error {
 		}
 	}
 	// insert all utxos
-	utxoStmt, err := s.Txn.Prepare(`INSERT INTO utxo (txid,vout,value,kind,script) VALUES ($1,$2,$3,$4,$5)`)
+	utxoStmt, err := s.Txn.Prepare(`INSERT INTO utxo (txid,vout,value,kind,script,coinbase,scripthash,height) VALUES ($1,$2,$3,$4,$5,$6,$7,$8) ON CONFLICT (txid,vout) DO NOTHING`)
 	if err != nil {
 		return err
 	}
+	created := int64(0)
 	for _, utxo := range createUTXOs {
 		txid, found := txidMap[string(utxo.TxID)]
 		if !found {
 			return fmt.Errorf("CreateUTXOs: txid not found in map (BUG: was inserted above)")
 		}
-		// no conflict expected: we delete utxo on rollback, and (hash,vout) is unique in Core
-		_, err := utxoStmt.Exec(txid, utxo.VOut, utxo.Value, utxo.Type, utxo.Script)
+		// (hash,vout) is unique in Core: a conflict means this block is being
+		// replayed, unless the stored output differs (see checkUTXOConflict)
+		res, err := utxoStmt.Exec(txid, utxo.VOut, utxo.Value, utxo.Type, utxo.Script, utxo.Coinbase, spec.ScriptHash(utxo.Type, utxo.Script), height)
 		if err != nil {
 			return s.DBErr(err, "CreateUTXOs: insert utxo")
 		}
+		inserted, err := res.RowsAffected()
+		if err != nil {
+			return s.DBErr(err, "CreateUTXOs: insert utxo RowsAffected")
+		}
+		if inserted == 0 {
+			// already indexed (don't count it twice in cached balances)
+			if err := s.checkUTXOConflict(txid, utxo, height); err != nil {
+				return err
+			}
+			continue
+		}
+		created++
+		if s.recordChanges {
+			_, err := s.exec(`INSERT INTO utxo_event (op,height,hash,vout,value,kind,script,coinbase) VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`,
+				spec.EventCreate, height, utxo.TxID, utxo.VOut, utxo.Value, utxo.Type, utxo.Script, utxo.Coinbase)
+			if err != nil {
+				return s.DBErr(err, "CreateUTXOs: record event")
+			}
+		}
 		if s.cacheBalances && cacheableBalanceKind(utxo.Type) {
 			availableDelta := int64(0)
 			incomingDelta := utxo.Value
@@ -465,11 +1168,49 @@ func (s *IndexStore) CreateUTXOs(createUTXOs []spec.UTXO, height int64) error {
 			}
 		}
 	}
+	return s.addUnspent(created)
+}
+
+// checkUTXOConflict compares a UTXO that CreateUTXOs didn't insert with the
+// stored one. A replayed block stores the same output; anything else (e.g. a
+// BIP30-style duplicate txid) is a conflict: the stored output is kept, and the
+// conflict is logged and counted (see GetUTXOConflicts) rather than retried.
+func (s *IndexStore) checkUTXOConflict(txid int64, utxo spec.UTXO, height int64) error {
+	var stored spec.UTXO
+	var storedHeight sql.NullInt64
+	row := s.queryRow(`SELECT value,kind,script,coinbase,height FROM utxo WHERE txid=$1 AND vout=$2`, txid, utxo.VOut)
+	if err := row.Scan(&stored.Value, &stored.Type, &stored.Script, &stored.Coinbase, &storedHeight); err != nil {
+		return s.DBErr(err, "CreateUTXOs: check conflict")
+	}
+	if stored.Value == utxo.Value && stored.Type == utxo.Type && bytes.Equal(stored.Script, utxo.Script) &&
+		stored.Coinbase == utxo.Coinbase && storedHeight.Int64 == height {
+		return nil
+	}
+	log.Printf("[Store] UTXO conflict: %s:%d at height %d (value %d, kind %d) is already stored at height %d (value %d, kind %d); keeping the stored output",
+		doge.HexEncodeReversed(utxo.TxID), utxo.VOut, height, utxo.Value, utxo.Type, storedHeight.Int64, stored.Value, stored.Type)
+	_, err := s.exec(`UPDATE utxo_stats SET utxo_conflicts=utxo_conflicts+1 WHERE id=1`)
+	if err != nil {
+		return s.DBErr(err, "CreateUTXOs: count conflict")
+	}
 	return nil
 }
 
+// GetUTXOConflicts gets the number of conflicting UTXO inserts seen (see checkUTXOConflict.)
+func (s *IndexStore) GetUTXOConflicts() (count int64, err error) {
+	row := s.queryRow(`SELECT utxo_conflicts FROM utxo_stats WHERE id=1`)
+	if err = row.Scan(&count); err != nil {
+		return 0, s.DBErr(err, "GetUTXOConflicts")
+	}
+	return count, nil
+}
+
 func (s *IndexStore) FindUTXOs(kind doge.ScriptType, address []byte) (res []spec.UTXO, err error) {
-	rows, err := s.Txn.Query(`SELECT t.hash,u.vout,u.value,u.script FROM utxo u INNER JOIN tx t ON u.txid = t.txid WHERE u.script=$1 AND u.kind=$2 AND u.spent IS NULL`, address, kind)
+	return s.FindUTXOsFiltered(kind, address, spec.UTXOFilter{})
+}
+
+func (s *IndexStore) FindUTXOsFiltered(kind doge.ScriptType, address []byte, filter spec.UTXOFilter) (res []spec.UTXO, err error) {
+	query, args := utxoFilterQuery(`SELECT t.hash,u.vout,u.value,u.script,u.coinbase FROM utxo u INNER JOIN tx t ON u.txid = t.txid WHERE u.script=$1 AND u.kind=$2 AND u.spent IS NULL`, address, kind, filter)
+	rows, err := s.query(query, args...)
 	if err != nil {
 		return []spec.UTXO{}, s.DBErr(err, "FindUTXOs: query")
 	}
@@ -478,11 +1219,12 @@ func (s *IndexStore) FindUTXOs(kind doge.ScriptType, address []byte) (res []spec
 		var vout uint32
 		var value int64
 		var script []byte
-		err = rows.Scan(&hash, &vout, &value, &script)
+		var coinbase bool
+		err = rows.Scan(&hash, &vout, &value, &script, &coinbase)
 		if err != nil {
 			return []spec.UTXO{}, s.DBErr(err, "FindUTXOs: scan")
 		}
-		res = append(res, spec.UTXO{TxID: hash, VOut: vout, Value: value, Type: kind, Script: script})
+		res = append(res, spec.UTXO{TxID: hash, VOut: vout, Value: value, Type: kind, Script: script, Coinbase: coinbase})
 	}
 	if err = rows.Close(); err != nil {
 		return []spec.UTXO{}, s.DBErr(err, "FindUTXOs: scan")
@@ -490,9 +1232,267 @@ func (s *IndexStore) FindUTXOs(kind doge.ScriptType, address []byte) (res []spec
 	return res, nil
 }
 
+// FindUTXOHistory finds UTXOs for an address matching `filter`, spent or not.
+func (s *IndexStore) FindUTXOHistory(kind doge.ScriptType, address []byte, filter spec.UTXOFilter) (res []spec.UTXOState, err error) {
+	query, args := utxoFilterQuery(`SELECT t.hash,u.vout,u.value,u.script,u.coinbase,u.height,u.spent FROM utxo u INNER JOIN tx t ON u.txid = t.txid WHERE u.script=$1 AND u.kind=$2`, address, kind, filter)
+	rows, err := s.query(query, args...)
+	if err != nil {
+		return []spec.UTXOState{}, s.DBErr(err, "FindUTXOHistory: query")
+	}
+	for rows.Next() {
+		u := spec.UTXOState{UTXO: spec.UTXO{Type: kind}}
+		var spent sql.NullInt64
+		err = rows.Scan(&u.TxID, &u.VOut, &u.Value, &u.Script, &u.Coinbase, &u.Height, &spent)
+		if err != nil {
+			return []spec.UTXOState{}, s.DBErr(err, "FindUTXOHistory: scan")
+		}
+		u.Spent = spent.Int64
+		res = append(res, u)
+	}
+	if err = rows.Close(); err != nil {
+		return []spec.UTXOState{}, s.DBErr(err, "FindUTXOHistory: scan")
+	}
+	return res, nil
+}
+
+// SumUTXOsFiltered counts and sums the UTXOs for an address matching `filter`
+// (without its limit.)
+func (s *IndexStore) SumUTXOsFiltered(kind doge.ScriptType, address []byte, filter spec.UTXOFilter, includeSpent bool) (count int64, unspent spec.BigKoinu, err error) {
+	query := `SELECT COUNT(*),COALESCE(SUM(CASE WHEN u.spent IS NULL THEN CAST(u.value AS NUMERIC) ELSE 0 END),0) FROM utxo u WHERE u.script=$1 AND u.kind=$2`
+	if !includeSpent {
+		query += ` AND u.spent IS NULL`
+	}
+	query, args := utxoFilterWhere(query, address, kind, filter)
+	err = s.queryRow(query, args...).Scan(&count, &unspent)
+	if err != nil {
+		return 0, spec.BigKoinu{}, s.DBErr(err, "SumUTXOsFiltered")
+	}
+	return count, unspent, nil
+}
+
+// utxoFilterQuery adds the conditions, order and limit of `filter` to a UTXO
+// query whose first two parameters are the address and kind.
+func utxoFilterQuery(query string, address []byte, kind doge.ScriptType, filter spec.UTXOFilter) (string, []any) {
+	query, args := utxoFilterWhere(query, address, kind, filter)
+	switch filter.Order {
+	case spec.UTXOOrderValueDesc:
+		query += " ORDER BY u.value DESC,u.height,u.txid,u.vout"
+	case spec.UTXOOrderValueAsc:
+		query += " ORDER BY u.value,u.height,u.txid,u.vout"
+	case spec.UTXOOrderHeightDesc:
+		query += " ORDER BY u.height DESC,u.txid,u.vout"
+	default:
+		query += " ORDER BY u.height,u.txid,u.vout" // stable order for diffing and paging
+	}
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	return query, args
+}
+
+// utxoFilterWhere adds the conditions of `filter` (not its order or limit)
+// to a UTXO query whose first two parameters are the address and kind.
+func utxoFilterWhere(query string, address []byte, kind doge.ScriptType, filter spec.UTXOFilter) (string, []any) {
+	args := []any{address, kind}
+	if filter.MinHeight > 0 {
+		args = append(args, filter.MinHeight)
+		query += fmt.Sprintf(" AND u.height >= $%d", len(args))
+	}
+	if filter.MaxHeight > 0 {
+		args = append(args, filter.MaxHeight)
+		query += fmt.Sprintf(" AND u.height <= $%d", len(args))
+	}
+	if filter.MinValue > 0 {
+		args = append(args, filter.MinValue)
+		query += fmt.Sprintf(" AND u.value >= $%d", len(args))
+	}
+	return query, args
+}
+
+// GetUTXOs looks up the stored UTXOs (spent or not) for `outpoints`, in the
+// same order, leaving out those that aren't stored. Queries are batched like
+// RemoveUTXOs.
+func (s *IndexStore) GetUTXOs(outpoints []spec.OutPointKey) (res []spec.UTXOState, err error) {
+	txids, err := s.resolveTxids(outpoints)
+	if err != nil {
+		return nil, err
+	}
+	keys := utxoKeys(outpoints, txids)
+	found := map[utxoKey]spec.UTXOState{}
+	for start := 0; start < len(keys); start += removeUTXOsBatch {
+		batch := keys[start:min(start+removeUTXOsBatch, len(keys))]
+		clause, args := utxoKeysWhere("u.", 1, batch)
+		rows, err := s.query(`SELECT u.txid,t.hash,u.vout,u.value,u.kind,u.script,u.coinbase,u.height,u.spent
+			FROM utxo u
+			INNER JOIN tx t ON u.txid = t.txid
+			WHERE `+clause, args...)
+		if err != nil {
+			return nil, s.DBErr(err, "GetUTXOs: query")
+		}
+		for rows.Next() {
+			var key utxoKey
+			var u spec.UTXOState
+			var spent sql.NullInt64
+			if err = rows.Scan(&key.txid, &u.TxID, &u.VOut, &u.Value, &u.Type, &u.Script, &u.Coinbase, &u.Height, &spent); err != nil {
+				rows.Close()
+				return nil, s.DBErr(err, "GetUTXOs: scan")
+			}
+			key.vout = u.VOut
+			u.Spent = spent.Int64
+			found[key] = u
+		}
+		if err = rows.Close(); err != nil {
+			return nil, s.DBErr(err, "GetUTXOs: scan")
+		}
+	}
+	for _, key := range keys {
+		if u, ok := found[key]; ok {
+			res = append(res, u)
+		}
+	}
+	return res, nil
+}
+
+func (s *IndexStore) FindOutgoing(kind doge.ScriptType, address []byte, confirmations int64) (res []spec.UTXOChange, err error) {
+	// same predicate as the Outgoing sum in getBalanceUncached
+	res, err = s.utxoChanges(`SELECT t.hash,u.vout,u.value,u.kind,u.script,u.spent FROM utxo u INNER JOIN tx t ON u.txid = t.txid
+		WHERE u.script=$1 AND u.kind=$2 AND u.spent >= COALESCE((SELECT height FROM resume LIMIT 1),0)-$3 ORDER BY u.spent,t.txid,u.vout`, address, kind, confirmations)
+	if err != nil {
+		return nil, s.DBErr(err, "FindOutgoing")
+	}
+	return res, nil
+}
+
+func (s *IndexStore) SetBlockStats(stats spec.BlockStats) error {
+	fees := sql.NullInt64{Int64: stats.Fees, Valid: stats.FeesKnown}
+	created := sql.NullInt64{Int64: stats.UTXOCreated, Valid: stats.Detailed}
+	spent := sql.NullInt64{Int64: stats.UTXOSpent, Valid: stats.Detailed}
+	processing := sql.NullInt64{Int64: stats.ProcessingTime.Milliseconds(), Valid: stats.Detailed}
+	_, err := s.exec(`INSERT INTO block_stats (height,tx_count,fees,utxo_created,utxo_spent,processing_ms) VALUES ($1,$2,$3,$4,$5,$6)
+		ON CONFLICT (height) DO UPDATE SET tx_count=excluded.tx_count,fees=excluded.fees,
+			utxo_created=excluded.utxo_created,utxo_spent=excluded.utxo_spent,processing_ms=excluded.processing_ms`,
+		stats.Height, stats.TxCount, fees, created, spent, processing)
+	if err != nil {
+		return s.DBErr(err, "SetBlockStats")
+	}
+	return nil
+}
+
+func (s *IndexStore) GetBlockStats(fromHeight int64, toHeight int64) (res []spec.BlockStats, err error) {
+	rows, err := s.query(`SELECT height,tx_count,fees,utxo_created,utxo_spent,processing_ms FROM block_stats WHERE height >= $1 AND height <= $2 ORDER BY height`, fromHeight, toHeight)
+	if err != nil {
+		return nil, s.DBErr(err, "GetBlockStats: query")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var stats spec.BlockStats
+		var fees, created, spent, processing sql.NullInt64
+		if err = rows.Scan(&stats.Height, &stats.TxCount, &fees, &created, &spent, &processing); err != nil {
+			return nil, s.DBErr(err, "GetBlockStats: scan")
+		}
+		stats.Fees, stats.FeesKnown = fees.Int64, fees.Valid
+		if created.Valid && spent.Valid && processing.Valid {
+			stats.UTXOCreated, stats.UTXOSpent = created.Int64, spent.Int64
+			stats.ProcessingTime = time.Duration(processing.Int64) * time.Millisecond
+			stats.Detailed = true
+		}
+		res = append(res, stats)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, s.DBErr(err, "GetBlockStats: scan")
+	}
+	return res, nil
+}
+
+func (s *IndexStore) GetUTXODiff(fromHeight int64, toHeight int64) (res spec.UTXODiff, err error) {
+	res.Created, err = s.utxoChanges(`SELECT t.hash,u.vout,u.value,u.kind,u.script,t.height FROM utxo u INNER JOIN tx t ON u.txid = t.txid
+		WHERE t.height >= $1 AND t.height <= $2 ORDER BY t.height,t.txid,u.vout`, fromHeight, toHeight)
+	if err != nil {
+		return res, s.DBErr(err, "GetUTXODiff: created")
+	}
+	res.Spent, err = s.utxoChanges(`SELECT t.hash,u.vout,u.value,u.kind,u.script,u.spent FROM utxo u INNER JOIN tx t ON u.txid = t.txid
+		WHERE u.spent >= $1 AND u.spent <= $2 ORDER BY u.spent,t.txid,u.vout`, fromHeight, toHeight)
+	if err != nil {
+		return res, s.DBErr(err, "GetUTXODiff: spent")
+	}
+	return res, nil
+}
+
+func (s *IndexStore) utxoChanges(query string, args ...any) (res []spec.UTXOChange, err error) {
+	rows, err := s.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c spec.UTXOChange
+		if err = rows.Scan(&c.TxID, &c.VOut, &c.Value, &c.Type, &c.Script, &c.Height); err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return res, rows.Err()
+}
+
+func (s *IndexStore) GetUTXOEvents(since int64, limit int) (res []spec.UTXOEvent, err error) {
+	rows, err := s.query(`SELECT seq,op,height,hash,vout,value,kind,script,coinbase FROM utxo_event WHERE seq > $1 ORDER BY seq LIMIT $2`, since, limit)
+	if err != nil {
+		return nil, s.DBErr(err, "GetUTXOEvents")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e spec.UTXOEvent
+		if err = rows.Scan(&e.Seq, &e.Op, &e.Height, &e.TxID, &e.VOut, &e.Value, &e.Type, &e.Script, &e.Coinbase); err != nil {
+			return nil, s.DBErr(err, "GetUTXOEvents: scan")
+		}
+		res = append(res, e)
+	}
+	return res, rows.Err()
+}
+
+func (s *IndexStore) GetAddressFirstSeen(kind doge.ScriptType, address []byte) (int64, error) {
+	row := s.queryRow(`SELECT MIN(u.height) FROM utxo u WHERE u.script=$1 AND u.kind=$2`, address, kind)
+	var height sql.NullInt64
+	if err := row.Scan(&height); err != nil {
+		return 0, s.DBErr(err, "GetAddressFirstSeen")
+	}
+	if !height.Valid {
+		return 0, spec.ErrNotFound // no UTXOs stored for the address
+	}
+	return height.Int64, nil
+}
+
+func (s *IndexStore) HasAddressUTXOs(kind doge.ScriptType, address []byte) (found bool, err error) {
+	row := s.queryRow(`SELECT EXISTS(SELECT 1 FROM utxo u WHERE u.script=$1 AND u.kind=$2)`, address, kind)
+	if err = row.Scan(&found); err != nil {
+		return false, s.DBErr(err, "HasAddressUTXOs")
+	}
+	return found, nil
+}
+
+func (s *IndexStore) FindScriptByHash(scriptHash []byte) (kind doge.ScriptType, script []byte, err error) {
+	row := s.queryRow(`SELECT kind,script FROM utxo WHERE scripthash=$1 LIMIT 1`, scriptHash)
+	if err = row.Scan(&kind, &script); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil, spec.ErrNotFound
+		}
+		return 0, nil, s.DBErr(err, "FindScriptByHash")
+	}
+	return kind, script, nil
+}
+
 func (s *IndexStore) GetBalance(kind doge.ScriptType, address []byte, confirmations int64) (res spec.Balance, err error) {
 	if s.cacheBalances && confirmations == defaultBalanceConfirmations && cacheableBalanceKind(kind) {
-		row := s.Txn.QueryRow(`SELECT available,incoming,outgoing FROM balance WHERE script=$1 AND kind=$2`, address, kind)
+		// the balance table doesn't know about coinbase maturity
+		immature, err := s.hasImmatureCoinbase(kind, address)
+		if err != nil {
+			return spec.Balance{}, err
+		}
+		if immature {
+			return s.getBalanceUncached(kind, address, confirmations)
+		}
+		row := s.queryRow(`SELECT available,incoming,outgoing FROM balance WHERE script=$1 AND kind=$2`, address, kind)
 		err = row.Scan(&res.Available, &res.Incoming, &res.Outgoing)
 		if err != nil {
 			if err == sql.ErrNoRows {
@@ -500,6 +1500,11 @@ func (s *IndexStore) GetBalance(kind doge.ScriptType, address []byte, confirmati
 			}
 			return spec.Balance{}, s.DBErr(err, "GetBalance: balance scan")
 		}
+		// nor does it count UTXOs (the address index finds them)
+		row = s.queryRow(`SELECT COUNT(*) FROM utxo WHERE script=$1 AND kind=$2 AND spent IS NULL`, address, kind)
+		if err = row.Scan(&res.UTXOCount); err != nil {
+			return spec.Balance{}, s.DBErr(err, "GetBalance: count scan")
+		}
 		return res, nil
 	}
 
@@ -507,35 +1512,226 @@ func (s *IndexStore) GetBalance(kind doge.ScriptType, address []byte, confirmati
 }
 
 func (s *IndexStore) getBalanceUncached(kind doge.ScriptType, address []byte, confirmations int64) (res spec.Balance, err error) {
-	row := s.Txn.QueryRow(`SELECT
-		(SELECT COALESCE(SUM(CAST(u.value AS NUMERIC)),0) FROM utxo u INNER JOIN tx t ON u.txid = t.txid WHERE u.script=$1 AND u.kind=$2 AND t.height < (SELECT height FROM resume LIMIT 1)-$3 AND u.spent IS NULL),
-		(SELECT COALESCE(SUM(CAST(u.value AS NUMERIC)),0) FROM utxo u INNER JOIN tx t ON u.txid = t.txid WHERE u.script=$1 AND u.kind=$2 AND t.height >= (SELECT height FROM resume LIMIT 1)-$3 AND u.spent IS NULL),
-		(SELECT COALESCE(SUM(CAST(u.value AS NUMERIC)),0) FROM utxo u INNER JOIN tx t ON u.txid = t.txid WHERE u.script=$1 AND u.kind=$2 AND u.spent >= (SELECT height FROM resume LIMIT 1)-$3)`,
-		address, kind, confirmations)
-	err = row.Scan(&res.Available, &res.Incoming, &res.Outgoing)
+	// immature coinbase (fewer than coinbaseMaturity confirmations) is Incoming, not Available.
+	// Without a resume point (e.g. after an import) the head is height 0, like
+	// GetCurrentHeight: nothing is confirmed, rather than every comparison being NULL.
+	row := s.queryRow(`WITH head AS (SELECT COALESCE((SELECT height FROM resume LIMIT 1),0) AS height) SELECT
+		(SELECT COALESCE(SUM(CAST(u.value AS NUMERIC)),0) FROM utxo u WHERE u.script=$1 AND u.kind=$2 AND u.height < (SELECT height FROM head)-$3 AND u.spent IS NULL AND NOT (u.coinbase AND u.height > (SELECT height FROM head)-$4)),
+		(SELECT COALESCE(SUM(CAST(u.value AS NUMERIC)),0) FROM utxo u WHERE u.script=$1 AND u.kind=$2 AND (u.height >= (SELECT height FROM head)-$3 OR (u.coinbase AND u.height > (SELECT height FROM head)-$4)) AND u.spent IS NULL),
+		(SELECT COALESCE(SUM(CAST(u.value AS NUMERIC)),0) FROM utxo u WHERE u.script=$1 AND u.kind=$2 AND u.spent >= (SELECT height FROM head)-$3),
+		(SELECT COUNT(*) FROM utxo u WHERE u.script=$1 AND u.kind=$2 AND u.spent IS NULL)`,
+		address, kind, confirmations, s.coinbaseMaturity-1)
+	err = row.Scan(&res.Available, &res.Incoming, &res.Outgoing, &res.UTXOCount)
 	if err != nil {
 		return spec.Balance{}, s.DBErr(err, "GetBalance: scan")
 	}
 	return res, nil
 }
 
+func (s *IndexStore) GetBalanceAtHeight(kind doge.ScriptType, address []byte, height int64) (res spec.BigKoinu, err error) {
+	row := s.queryRow(`SELECT COALESCE(SUM(CAST(u.value AS NUMERIC)),0) FROM utxo u WHERE u.script=$1 AND u.kind=$2 AND u.height <= $3 AND (u.spent IS NULL OR u.spent > $3)`,
+		address, kind, height)
+	if err = row.Scan(&res); err != nil {
+		return spec.BigKoinu{}, s.DBErr(err, "GetBalanceAtHeight")
+	}
+	return res, nil
+}
+
+// GetKindBalances only looks at address kinds, which the 'address' index covers.
+func (s *IndexStore) GetKindBalances(script []byte) (res []spec.KindBalance, err error) {
+	rows, err := s.query(`SELECT kind,COUNT(*),COALESCE(SUM(CAST(value AS NUMERIC)),0) FROM utxo WHERE script=$1 AND kind IN (2,3,5,6) AND spent IS NULL GROUP BY kind ORDER BY kind`, script)
+	if err != nil {
+		return nil, s.DBErr(err, "GetKindBalances: query")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var kb spec.KindBalance
+		if err = rows.Scan(&kb.Kind, &kb.Count, &kb.Balance); err != nil {
+			return nil, s.DBErr(err, "GetKindBalances: scan")
+		}
+		res = append(res, kb)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, s.DBErr(err, "GetKindBalances: scan")
+	}
+	return res, nil
+}
+
+// GetUTXOAgeHistogram buckets the address's UTXOs in SQL, like GetBalance.
+func (s *IndexStore) GetUTXOAgeHistogram(kind doge.ScriptType, address []byte) (res []spec.UTXOAgeBucket, err error) {
+	// CASE WHEN conf >= 100 THEN 3 WHEN conf >= 7 THEN 2 ... ELSE 0 (highest bound first)
+	bucket := "CASE"
+	for n := len(spec.UTXOAgeBounds) - 1; n > 0; n-- {
+		bucket += fmt.Sprintf(" WHEN conf >= %d THEN %d", spec.UTXOAgeBounds[n], n)
+	}
+	bucket += " ELSE 0 END"
+	rows, err := s.query(`SELECT `+bucket+` AS bucket,COUNT(*),COALESCE(SUM(CAST(value AS NUMERIC)),0)
+		FROM (SELECT value,COALESCE((SELECT height FROM resume LIMIT 1),0)-height+1 AS conf FROM utxo WHERE script=$1 AND kind=$2 AND spent IS NULL) u
+		GROUP BY bucket`, address, kind)
+	if err != nil {
+		return nil, s.DBErr(err, "GetUTXOAgeHistogram: query")
+	}
+	defer rows.Close()
+	res = make([]spec.UTXOAgeBucket, len(spec.UTXOAgeBounds))
+	for n, bound := range spec.UTXOAgeBounds {
+		res[n].MinConf = bound
+	}
+	for rows.Next() {
+		var n int
+		var count int64
+		var value spec.BigKoinu
+		if err = rows.Scan(&n, &count, &value); err != nil {
+			return nil, s.DBErr(err, "GetUTXOAgeHistogram: scan")
+		}
+		res[n].Count, res[n].Value = count, value
+	}
+	if err = rows.Err(); err != nil {
+		return nil, s.DBErr(err, "GetUTXOAgeHistogram: scan")
+	}
+	return res, nil
+}
+
+const (
+	sampleRangeRows = 10 // unspent UTXOs read from each random txid
+	sampleTries     = 4  // random txids tried per address asked for
+)
+
+// SampleAddresses reads short runs of unspent UTXOs from random txids (index
+// seeks, so it doesn't read the whole utxo table) and returns their distinct
+// addresses. Addresses with many UTXOs, or after a run of spent ones, are more
+// likely to be picked: it's a sample for admin checks, not a uniform one.
+func (s *IndexStore) SampleAddresses(limit int) (res []spec.AddressKey, err error) {
+	var first, last sql.NullInt64
+	if err = s.queryRow(`SELECT MIN(txid),MAX(txid) FROM utxo`).Scan(&first, &last); err != nil {
+		return nil, s.DBErr(err, "SampleAddresses: txid range")
+	}
+	if !first.Valid {
+		return nil, nil // no UTXOs
+	}
+	type addressKey struct {
+		kind   doge.ScriptType
+		script string
+	}
+	seen := map[addressKey]bool{}
+	for try := 0; try < limit*sampleTries && len(res) < limit; try++ {
+		start := first.Int64 + rand.Int63n(last.Int64-first.Int64+1)
+		keys, err := s.sampleRange(start)
+		if err == nil && len(keys) == 0 {
+			keys, err = s.sampleRange(first.Int64) // past the last unspent UTXO: wrap around
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(keys) == 0 {
+			break // all spent
+		}
+		for _, key := range keys {
+			k := addressKey{key.Kind, string(key.Script)}
+			if !seen[k] && len(res) < limit {
+				seen[k] = true
+				res = append(res, key)
+			}
+		}
+	}
+	return res, nil
+}
+
+// sampleRange reads the addresses of the unspent UTXOs from txid `start`.
+func (s *IndexStore) sampleRange(start int64) (res []spec.AddressKey, err error) {
+	rows, err := s.query(`SELECT kind,script FROM utxo WHERE txid >= $1 AND spent IS NULL ORDER BY txid,vout LIMIT $2`, start, sampleRangeRows)
+	if err != nil {
+		return nil, s.DBErr(err, "SampleAddresses: query")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var kind doge.ScriptType
+		var script []byte
+		if err = rows.Scan(&kind, &script); err != nil {
+			return nil, s.DBErr(err, "SampleAddresses: scan")
+		}
+		res = append(res, spec.AddressKey{Kind: kind, Script: script})
+	}
+	if err = rows.Err(); err != nil {
+		return nil, s.DBErr(err, "SampleAddresses: scan")
+	}
+	return res, nil
+}
+
+// hasImmatureCoinbase reports whether the address has unspent coinbase outputs
+// with fewer than coinbaseMaturity confirmations.
+func (s *IndexStore) hasImmatureCoinbase(kind doge.ScriptType, address []byte) (bool, error) {
+	row := s.queryRow(`SELECT EXISTS (SELECT 1 FROM utxo u WHERE u.script=$1 AND u.kind=$2 AND u.coinbase AND u.spent IS NULL AND u.height > COALESCE((SELECT height FROM resume LIMIT 1),0)-$3)`,
+		address, kind, s.coinbaseMaturity-1)
+	var immature bool
+	if err := row.Scan(&immature); err != nil {
+		return false, s.DBErr(err, "GetBalance: coinbase maturity")
+	}
+	return immature, nil
+}
+
 // UndoAbove removes created UTXOs and re-activates Removed UTXOs above `height`.
 func (s *IndexStore) UndoAbove(height int64) error {
-	// undo inserting utxos.
-	_, err := s.Txn.Exec(`DELETE FROM utxo WHERE txid IN (SELECT txid FROM tx WHERE height > $1)`, height)
+	if s.recordChanges {
+		// compensating events, latest first: unspend, then remove created utxos
+		_, err := s.exec(`INSERT INTO utxo_event (op,height,hash,vout,value,kind,script,coinbase)
+			SELECT $1,u.spent,t.hash,u.vout,u.value,u.kind,u.script,u.coinbase
+			FROM utxo u
+			INNER JOIN tx t ON u.txid = t.txid
+			WHERE u.spent > $2
+			ORDER BY u.spent DESC,t.txid,u.vout`, spec.EventUndoSpend, height)
+		if err != nil {
+			return s.DBErr(err, "UndoAbove: record undo-spend events")
+		}
+		_, err = s.exec(`INSERT INTO utxo_event (op,height,hash,vout,value,kind,script,coinbase)
+			SELECT $1,t.height,t.hash,u.vout,u.value,u.kind,u.script,u.coinbase
+			FROM utxo u
+			INNER JOIN tx t ON u.txid = t.txid
+			WHERE t.height > $2
+			ORDER BY t.height DESC,t.txid,u.vout`, spec.EventUndoCreate, height)
+		if err != nil {
+			return s.DBErr(err, "UndoAbove: record undo-create events")
+		}
+	}
+	// undo inserting utxos (unspent first, to count them.)
+	res, err := s.exec(`DELETE FROM utxo WHERE txid IN (SELECT txid FROM tx WHERE height > $1) AND spent IS NULL`, height)
+	if err != nil {
+		return s.DBErr(err, "UndoAbove: delete unspent utxo")
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return s.DBErr(err, "UndoAbove: delete unspent utxo RowsAffected")
+	}
+	_, err = s.exec(`DELETE FROM utxo WHERE txid IN (SELECT txid FROM tx WHERE height > $1)`, height)
 	if err != nil {
 		return s.DBErr(err, "UndoAbove: delete utxo")
 	}
 	// undo inserting txes.
-	_, err = s.Txn.Exec(`DELETE FROM tx WHERE height > $1`, height)
+	_, err = s.exec(`DELETE FROM tx WHERE height > $1`, height)
 	if err != nil {
 		return s.DBErr(err, "UndoAbove: delete tx")
 	}
+	// undo recording block stats.
+	_, err = s.exec(`DELETE FROM block_stats WHERE height > $1`, height)
+	if err != nil {
+		return s.DBErr(err, "UndoAbove: delete block_stats")
+	}
+	// undo recording tx inputs.
+	_, err = s.exec(`DELETE FROM tx_input WHERE height > $1`, height)
+	if err != nil {
+		return s.DBErr(err, "UndoAbove: delete tx_input")
+	}
 	// undo marking utxos spent.
-	_, err = s.Txn.Exec(`UPDATE utxo SET spent=NULL WHERE spent > $1`, height)
+	res, err = s.exec(`UPDATE utxo SET spent=NULL WHERE spent > $1`, height)
 	if err != nil {
 		return s.DBErr(err, "UndoAbove: unmark spent")
 	}
+	unspent, err := res.RowsAffected()
+	if err != nil {
+		return s.DBErr(err, "UndoAbove: unmark spent RowsAffected")
+	}
+	if err := s.addUnspent(unspent - deleted); err != nil {
+		return err
+	}
 	if s.cacheBalances {
 		return s.rebuildBalances(height)
 	}
@@ -543,17 +1739,168 @@ func (s *IndexStore) UndoAbove(height int64) error {
 }
 
 // TrimSpentUTXOs permanently deletes all 'Removed' UTXOs below `height`
+// (only spent UTXOs, so the unspent count doesn't change.)
 func (s *IndexStore) TrimSpentUTXOs(height int64) error {
 	// only considers utxos with 'spent' non-null
-	_, err := s.Txn.Exec(`DELETE FROM utxo WHERE spent < $1`, height)
+	_, err := s.exec(`DELETE FROM utxo WHERE spent < $1`, height)
 	if err != nil {
 		return s.DBErr(err, "TrimRemoved")
 	}
 	// prune tx entries that no longer have any utxos
 	// (delete all TX without a matching UTXO: after joining, UTXO-fields are NULL)
-	_, err = s.Txn.Exec(`DELETE FROM tx WHERE txid IN (SELECT t.txid FROM tx t LEFT OUTER JOIN utxo u ON t.txid = u.txid WHERE u.txid IS NULL)`)
+	_, err = s.exec(`DELETE FROM tx WHERE txid IN (SELECT t.txid FROM tx t LEFT OUTER JOIN utxo u ON t.txid = u.txid WHERE u.txid IS NULL)`)
 	if err != nil {
 		return s.DBErr(err, "TrimRemoved")
 	}
+	// record trim progress (never moves down)
+	res, err := s.exec(`UPDATE trimmed SET height=$1 WHERE height < $1`, height)
+	if err != nil {
+		return s.DBErr(err, "TrimRemoved: trimmed")
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return s.DBErr(err, "TrimRemoved: trimmed RowsAffected")
+	}
+	if rows < 1 {
+		// First time: insert the single row.
+		_, err = s.exec(`INSERT INTO trimmed (height) SELECT $1 WHERE NOT EXISTS (SELECT 1 FROM trimmed)`, height)
+		if err != nil {
+			return s.DBErr(err, "TrimRemoved: trimmed insert")
+		}
+	}
+	return nil
+}
+
+func (s *IndexStore) GetTrimmedBelow() (int64, error) {
+	row := s.queryRow(`SELECT height FROM trimmed LIMIT 1`)
+	var height int64
+	err := row.Scan(&height)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil // never trimmed
+		}
+		return 0, s.DBErr(err, "GetTrimmedBelow")
+	}
+	return height, nil
+}
+
+func (s *IndexStore) GetMonitorState() ([]byte, error) {
+	row := s.queryRow(`SELECT state FROM monitor_state WHERE id=1`)
+	var state []byte
+	err := row.Scan(&state)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // never saved
+		}
+		return nil, s.DBErr(err, "GetMonitorState")
+	}
+	return state, nil
+}
+
+func (s *IndexStore) SetMonitorState(state []byte) error {
+	_, err := s.exec(`INSERT INTO monitor_state (id,state) VALUES (1,$1)
+		ON CONFLICT (id) DO UPDATE SET state=excluded.state`, state)
+	if err != nil {
+		return s.DBErr(err, "SetMonitorState")
+	}
+	return nil
+}
+
+func (s *IndexStore) ClearIndex() error {
+	// balance_meta is rebuilt on demand (see balanceCacheHeight);
+	// monitor_state holds recent blocks from the old history
+	_, err := s.exec(`DELETE FROM balance_meta; DELETE FROM balance; DELETE FROM utxo; DELETE FROM tx; DELETE FROM resume; DELETE FROM trimmed; DELETE FROM block_stats; DELETE FROM utxo_event; DELETE FROM tx_input; DELETE FROM monitor_state; UPDATE utxo_stats SET unspent=0,orphan_spends=0,utxo_conflicts=0`)
+	if err != nil {
+		return s.DBErr(err, "ClearIndex")
+	}
 	return nil
 }
+
+func (s *IndexStore) CheckIntegrity() (problems []string, err error) {
+	// resume: exactly one row once anything has been indexed
+	var resumeRows, utxoRows int64
+	if err = s.queryRow(`SELECT COUNT(*) FROM resume`).Scan(&resumeRows); err != nil {
+		return nil, s.DBErr(err, "CheckIntegrity: resume rows")
+	}
+	if err = s.queryRow(`SELECT COUNT(*) FROM utxo`).Scan(&utxoRows); err != nil {
+		return nil, s.DBErr(err, "CheckIntegrity: utxo rows")
+	}
+	if resumeRows > 1 || (resumeRows == 0 && utxoRows > 0) {
+		problems = append(problems, fmt.Sprintf("expected exactly one resume row, found %d", resumeRows))
+	}
+	checks := []struct {
+		query   string
+		problem string
+	}{
+		{`SELECT COUNT(*) FROM utxo u WHERE NOT EXISTS (SELECT 1 FROM tx t WHERE t.txid = u.txid)`, "utxo rows without a matching tx row"},
+		{`SELECT COUNT(*) FROM tx t WHERE NOT EXISTS (SELECT 1 FROM utxo u WHERE u.txid = t.txid)`, "tx rows without any utxo rows"},
+		{`SELECT COUNT(*) FROM utxo WHERE value < 0`, "utxo rows with a negative value"},
+		{`SELECT COUNT(*) FROM utxo u INNER JOIN tx t ON u.txid = t.txid WHERE u.spent < t.height`, "utxo rows spent below their creation height"},
+		{`SELECT COUNT(*) FROM utxo u INNER JOIN tx t ON u.txid = t.txid WHERE u.height IS NULL OR u.height <> t.height`, "utxo rows with a different height than their tx row"},
+	}
+	for _, check := range checks {
+		var count int64
+		if err = s.queryRow(check.query).Scan(&count); err != nil {
+			return nil, s.DBErr(err, "CheckIntegrity: "+check.problem)
+		}
+		if count > 0 {
+			problems = append(problems, fmt.Sprintf("%d %s", count, check.problem))
+		}
+	}
+	// the maintained unspent count matches the utxo table
+	var unspentRows, unspentCount int64
+	err = s.queryRow(`SELECT (SELECT COUNT(*) FROM utxo WHERE spent IS NULL), (SELECT unspent FROM utxo_stats WHERE id=1)`).Scan(&unspentRows, &unspentCount)
+	if err != nil {
+		return nil, s.DBErr(err, "CheckIntegrity: unspent count")
+	}
+	if unspentCount != unspentRows {
+		problems = append(problems, fmt.Sprintf("unspent count is %d but %d utxo rows are unspent", unspentCount, unspentRows))
+	}
+	// nothing can be created above the resume point
+	if resumeRows > 0 {
+		var resumeHeight, maxHeight int64
+		err = s.queryRow(`SELECT (SELECT height FROM resume LIMIT 1), (SELECT COALESCE(MAX(height),0) FROM tx)`).Scan(&resumeHeight, &maxHeight)
+		if err != nil {
+			return nil, s.DBErr(err, "CheckIntegrity: max height")
+		}
+		if maxHeight > resumeHeight {
+			problems = append(problems, fmt.Sprintf("resume height %d is below the highest tx height %d", resumeHeight, maxHeight))
+		}
+	}
+	return problems, nil
+}
+
+func (s *IndexStore) RepairOrphans() (repair spec.OrphanRepair, err error) {
+	// unspent orphans are included in the maintained unspent count
+	var unspent int64
+	err = s.queryRow(`SELECT COUNT(*) FROM utxo u WHERE u.spent IS NULL AND NOT EXISTS (SELECT 1 FROM tx t WHERE t.txid = u.txid)`).Scan(&unspent)
+	if err != nil {
+		return repair, s.DBErr(err, "RepairOrphans: count unspent")
+	}
+	res, err := s.exec(`DELETE FROM utxo WHERE NOT EXISTS (SELECT 1 FROM tx t WHERE t.txid = utxo.txid)`)
+	if err != nil {
+		return repair, s.DBErr(err, "RepairOrphans: delete utxo")
+	}
+	if repair.UTXOs, err = res.RowsAffected(); err != nil {
+		return repair, s.DBErr(err, "RepairOrphans: delete utxo RowsAffected")
+	}
+	res, err = s.exec(`DELETE FROM tx WHERE NOT EXISTS (SELECT 1 FROM utxo u WHERE u.txid = tx.txid)`)
+	if err != nil {
+		return repair, s.DBErr(err, "RepairOrphans: delete tx")
+	}
+	if repair.Txs, err = res.RowsAffected(); err != nil {
+		return repair, s.DBErr(err, "RepairOrphans: delete tx RowsAffected")
+	}
+	if err := s.addUnspent(-unspent); err != nil {
+		return repair, err
+	}
+	if repair.UTXOs > 0 && s.cacheBalances {
+		// cached balances include the deleted utxos
+		height, err := s.GetCurrentHeight()
+		if err != nil {
+			return repair, err
+		}
+		return repair, s.rebuildBalances(height)
+	}
+	return repair, nil
+}
@@ -1,16 +1,26 @@
 package store_test
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 
 	"github.com/dogeorg/doge"
 	"github.com/dogeorg/indexer/spec"
 	idxstore "github.com/dogeorg/indexer/store"
+	"github.com/dogeorg/storelib"
 )
 
 func newTestStore(t *testing.T) (spec.Store, func()) {
@@ -19,7 +29,7 @@ func newTestStore(t *testing.T) (spec.Store, func()) {
 
 	// Use a unique in-memory database for each test to ensure isolation
 	// Using ":memory:" creates a temporary database that's isolated per connection
-	db, err := idxstore.NewIndexStore(":memory:", ctx, false)
+	db, err := idxstore.NewIndexStore(":memory:", ctx, idxstore.Options{})
 	if err != nil {
 		t.Fatalf("NewIndexStore: %v", err)
 	}
@@ -33,7 +43,7 @@ func newCachedBalanceTestStore(t *testing.T) (spec.Store, func()) {
 	dsn := postgresTestDSN(t)
 	resetPostgresTestDatabase(t, dsn)
 
-	db, err := idxstore.NewIndexStore(dsn, ctx, true)
+	db, err := idxstore.NewIndexStore(dsn, ctx, idxstore.Options{CacheBalances: true})
 	if err != nil {
 		t.Fatalf("NewIndexStore: %v", err)
 	}
@@ -52,7 +62,7 @@ func postgresTestDSN(t *testing.T) string {
 
 func resetPostgresTestDatabase(t *testing.T, dsn string) {
 	t.Helper()
-	db, err := idxstore.NewIndexStore(dsn, context.Background(), false)
+	db, err := idxstore.NewIndexStore(dsn, context.Background(), idxstore.Options{})
 	if err != nil {
 		t.Fatalf("reset NewIndexStore: %v", err)
 	}
@@ -62,14 +72,14 @@ func resetPostgresTestDatabase(t *testing.T, dsn string) {
 	if !ok {
 		t.Fatalf("reset unexpected store type %T", db)
 	}
-	_, err = indexStore.RawDB.Exec(`DELETE FROM balance_meta; DELETE FROM balance; DELETE FROM utxo; DELETE FROM tx; DELETE FROM resume`)
+	_, err = indexStore.RawDB.Exec(`DELETE FROM balance_meta; DELETE FROM balance; DELETE FROM utxo; DELETE FROM tx; DELETE FROM resume; DELETE FROM trimmed; DELETE FROM block_stats; DELETE FROM utxo_event; UPDATE utxo_stats SET unspent=0,orphan_spends=0,utxo_conflicts=0`)
 	if err != nil {
 		t.Fatalf("reset test database: %v", err)
 	}
 }
 
 func TestPGStore_CacheBalancesRequiresPostgres(t *testing.T) {
-	db, err := idxstore.NewIndexStore(":memory:", context.Background(), true)
+	db, err := idxstore.NewIndexStore(":memory:", context.Background(), idxstore.Options{CacheBalances: true})
 	if err == nil {
 		db.Close()
 		t.Fatal("NewIndexStore succeeded with SQLite cache, want error")
@@ -79,6 +89,19 @@ func TestPGStore_CacheBalancesRequiresPostgres(t *testing.T) {
 	}
 }
 
+func TestPGStore_SchemaVersion(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	var version int
+	if err := db.(*idxstore.IndexStore).RawDB.QueryRow(`SELECT version FROM migration`).Scan(&version); err != nil {
+		t.Fatalf("migration version: %v", err)
+	}
+	if version != idxstore.SchemaVersion() {
+		t.Errorf("database is at version %d, SchemaVersion() = %d", version, idxstore.SchemaVersion())
+	}
+}
+
 func TestPGStore_ResumePoint(t *testing.T) {
 	db, stop := newTestStore(t)
 	defer stop()
@@ -273,49 +296,2281 @@ func TestPGStore_UndoAbove(t *testing.T) {
 	}
 }
 
-func TestPGStore_GetCurrentHeight(t *testing.T) {
+func TestPGStore_CreateUTXOs_ReplayIsIdempotent(t *testing.T) {
 	db, stop := newTestStore(t)
 	defer stop()
 
-	// Get initial height (should be 0 for a fresh database)
-	initialHeight, err := db.GetCurrentHeight()
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x3C, 20)
+	txA := bytesOf(0xA7, 32)
+	block := []spec.UTXO{
+		{TxID: txA, VOut: 0, Value: 1000, Type: kind, Script: addr},
+		{TxID: txA, VOut: 1, Value: 2000, Type: kind, Script: addr},
+	}
+
+	// Process the same block twice (e.g. killed before the resume point advanced)
+	for i := 0; i < 2; i++ {
+		if err := db.Transact(func(tx spec.StoreTx) error {
+			if err := tx.CreateUTXOs(block, 100); err != nil {
+				return err
+			}
+			return tx.SetResumePoint(bytesOf(0xD7, 32), 100)
+		}); err != nil {
+			t.Fatalf("CreateUTXOs (pass %d): %v", i+1, err)
+		}
+	}
+
+	found, err := db.FindUTXOs(kind, addr)
 	if err != nil {
-		t.Fatalf("GetCurrentHeight (initial): %v", err)
+		t.Fatalf("FindUTXOs: %v", err)
 	}
-	if initialHeight != 0 {
-		t.Fatalf("expected initial height 0, got: %d", initialHeight)
+	if len(found) != 2 {
+		t.Fatalf("FindUTXOs count = %d, want 2", len(found))
+	}
+	bal, err := db.GetBalance(kind, addr, 0)
+	if err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	if !bal.Incoming.Equal(amount(3000)) {
+		t.Fatalf("Incoming = %s, want 3000 (not double-counted)", bal.Incoming)
 	}
+	if conflicts, err := db.GetUTXOConflicts(); err != nil || conflicts != 0 {
+		t.Fatalf("GetUTXOConflicts = %d, %v; want 0 (a replay is not a conflict)", conflicts, err)
+	}
+}
 
-	// Set height
-	hash1 := bytesOf(0xAA, 32)
+func TestPGStore_CreateUTXOs_Conflict(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x3D, 20)
+	coinbase := bytesOf(0xA8, 32)
+	first := spec.UTXO{TxID: coinbase, VOut: 0, Value: 1000, Type: kind, Script: addr, Coinbase: true}
+	create := func(utxo spec.UTXO, height int64) error {
+		return db.Transact(func(tx spec.StoreTx) error {
+			return tx.CreateUTXOs([]spec.UTXO{utxo}, height)
+		})
+	}
+	if err := create(first, 100); err != nil {
+		t.Fatalf("CreateUTXOs: %v", err)
+	}
+
+	// the same txid in a later block (BIP30-style duplicate coinbase) paying someone else
+	duplicate := first
+	duplicate.Value = 5000
+	duplicate.Script = bytesOf(0x3E, 20)
+	if err := create(duplicate, 200); err != nil {
+		t.Fatalf("CreateUTXOs(conflict) = %v, want it skipped rather than failing", err)
+	}
+	if err := create(first, 100); err != nil { // a benign replay
+		t.Fatalf("CreateUTXOs(replay): %v", err)
+	}
+
+	if conflicts, err := db.GetUTXOConflicts(); err != nil || conflicts != 1 {
+		t.Fatalf("GetUTXOConflicts = %d, %v; want 1", conflicts, err)
+	}
+	found, err := db.GetUTXOs([]spec.OutPointKey{spec.OutPoint(coinbase, 0)})
+	if err != nil || len(found) != 1 || found[0].Value != 1000 || found[0].Height != 100 || !bytes.Equal(found[0].Script, addr) {
+		t.Fatalf("GetUTXOs = %+v, %v; want the first output kept", found, err)
+	}
+	if unspent, err := db.GetUnspentCount(); err != nil || unspent != 1 {
+		t.Fatalf("GetUnspentCount = %d, %v; want 1", unspent, err)
+	}
+
+	if err := db.ClearIndex(); err != nil {
+		t.Fatalf("ClearIndex: %v", err)
+	}
+	if conflicts, err := db.GetUTXOConflicts(); err != nil || conflicts != 0 {
+		t.Fatalf("GetUTXOConflicts after ClearIndex = %d, %v; want 0", conflicts, err)
+	}
+}
+
+func TestPGStore_FindUTXOsFiltered(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x4D, 20)
+
+	// One UTXO at each of heights 100, 200, 300, 400
+	for i, height := range []int64{100, 200, 300, 400} {
+		utxo := spec.UTXO{TxID: bytesOf(byte(0x10+i), 32), VOut: 0, Value: height, Type: kind, Script: addr}
+		if err := db.Transact(func(tx spec.StoreTx) error {
+			return tx.CreateUTXOs([]spec.UTXO{utxo}, height)
+		}); err != nil {
+			t.Fatalf("CreateUTXOs(%d): %v", height, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter spec.UTXOFilter
+		want   []int64 // values (== heights) expected
+	}{
+		{"no filter", spec.UTXOFilter{}, []int64{100, 200, 300, 400}},
+		{"min height", spec.UTXOFilter{MinHeight: 200}, []int64{200, 300, 400}},
+		{"max height", spec.UTXOFilter{MaxHeight: 300}, []int64{100, 200, 300}},
+		{"range", spec.UTXOFilter{MinHeight: 150, MaxHeight: 350}, []int64{200, 300}},
+		{"empty range", spec.UTXOFilter{MinHeight: 401}, nil},
+		{"min value", spec.UTXOFilter{MinValue: 250}, []int64{300, 400}},
+		{"min value and range", spec.UTXOFilter{MaxHeight: 300, MinValue: 200}, []int64{200, 300}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := db.FindUTXOsFiltered(kind, addr, tt.filter)
+			if err != nil {
+				t.Fatalf("FindUTXOsFiltered: %v", err)
+			}
+			got := map[int64]bool{}
+			for _, u := range found {
+				got[u.Value] = true
+			}
+			if len(found) != len(tt.want) {
+				t.Fatalf("FindUTXOsFiltered count = %d, want %d (%+v)", len(found), len(tt.want), found)
+			}
+			for _, v := range tt.want {
+				if !got[v] {
+					t.Fatalf("FindUTXOsFiltered missing UTXO at height %d", v)
+				}
+			}
+		})
+	}
+}
+
+func TestPGStore_FindUTXOsSorted(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x4E, 20)
+
+	// (height, value): two at height 200 and two worth 300, to check tie-breaks
+	utxos := []struct{ height, value int64 }{{100, 300}, {200, 50}, {200, 700}, {300, 300}, {400, 10}}
+	for i, u := range utxos {
+		utxo := spec.UTXO{TxID: bytesOf(byte(0x20+i), 32), VOut: 0, Value: u.value, Type: kind, Script: addr}
+		if err := db.Transact(func(tx spec.StoreTx) error {
+			return tx.CreateUTXOs([]spec.UTXO{utxo}, u.height)
+		}); err != nil {
+			t.Fatalf("CreateUTXOs(%d): %v", u.height, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter spec.UTXOFilter
+		want   []int64 // values, in order
+	}{
+		{"oldest first", spec.UTXOFilter{}, []int64{300, 50, 700, 300, 10}},
+		{"largest first", spec.UTXOFilter{Order: spec.UTXOOrderValueDesc}, []int64{700, 300, 300, 50, 10}},
+		{"smallest first", spec.UTXOFilter{Order: spec.UTXOOrderValueAsc}, []int64{10, 50, 300, 300, 700}},
+		{"newest first", spec.UTXOFilter{Order: spec.UTXOOrderHeightDesc}, []int64{10, 300, 50, 700, 300}},
+		{"largest first, limited", spec.UTXOFilter{Order: spec.UTXOOrderValueDesc, Limit: 2}, []int64{700, 300}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := db.FindUTXOsFiltered(kind, addr, tt.filter)
+			if err != nil {
+				t.Fatalf("FindUTXOsFiltered: %v", err)
+			}
+			got := []int64{}
+			for _, u := range found {
+				got = append(got, u.Value)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("FindUTXOsFiltered values = %v, want %v", got, tt.want)
+			}
+			// equal values keep (height, tx, vout) order: the older 300 first
+			if tt.filter.Order == spec.UTXOOrderValueDesc && found[1].TxID[0] != 0x20 {
+				t.Errorf("expected the older UTXO first among equal values, got tx %x", found[1].TxID[0])
+			}
+		})
+	}
+}
+
+func TestPGStore_SumUTXOsFiltered(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x4E, 20)
+	// UTXOs worth 100..400 at heights 100..400; the one at 200 is spent
 	if err := db.Transact(func(tx spec.StoreTx) error {
-		return tx.SetResumePoint(hash1, 100)
+		for i, height := range []int64{100, 200, 300, 400} {
+			utxo := spec.UTXO{TxID: bytesOf(byte(0x20+i), 32), VOut: 0, Value: height, Type: kind, Script: addr}
+			if err := tx.CreateUTXOs([]spec.UTXO{utxo}, height); err != nil {
+				return err
+			}
+		}
+		return tx.RemoveUTXOs([]spec.OutPointKey{spec.OutPoint(bytesOf(0x21, 32), 0)}, 450)
 	}); err != nil {
-		t.Fatalf("SetResumePoint: %v", err)
+		t.Fatalf("setup: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		filter       spec.UTXOFilter
+		includeSpent bool
+		count        int64
+		unspent      int64
+	}{
+		{"unspent", spec.UTXOFilter{}, false, 3, 800},
+		{"limit is ignored", spec.UTXOFilter{Limit: 1, Order: spec.UTXOOrderValueDesc}, false, 3, 800},
+		{"min value", spec.UTXOFilter{MinValue: 300}, false, 2, 700},
+		{"height range", spec.UTXOFilter{MinHeight: 150, MaxHeight: 350}, false, 1, 300},
+		{"with spent", spec.UTXOFilter{}, true, 4, 800},
+		{"spent in range", spec.UTXOFilter{MaxHeight: 200}, true, 2, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, unspent, err := db.SumUTXOsFiltered(kind, addr, tt.filter, tt.includeSpent)
+			if err != nil || count != tt.count || !unspent.Equal(amount(tt.unspent)) {
+				t.Fatalf("SumUTXOsFiltered = %d, %s, %v; want %d, %d", count, unspent, err, tt.count, tt.unspent)
+			}
+		})
 	}
+}
 
-	height, err := db.GetCurrentHeight()
+func TestPGStore_FindUTXOHistory(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x4F, 20)
+	utxoA := spec.UTXO{TxID: bytesOf(0x31, 32), VOut: 0, Value: 1000, Type: kind, Script: addr}
+	utxoB := spec.UTXO{TxID: bytesOf(0x32, 32), VOut: 1, Value: 2000, Type: kind, Script: addr}
+	utxoC := spec.UTXO{TxID: bytesOf(0x33, 32), VOut: 0, Value: 3000, Type: kind, Script: addr}
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		if err := tx.CreateUTXOs([]spec.UTXO{utxoA}, 100); err != nil {
+			return err
+		}
+		if err := tx.CreateUTXOs([]spec.UTXO{utxoB}, 150); err != nil {
+			return err
+		}
+		if err := tx.CreateUTXOs([]spec.UTXO{utxoC}, 160); err != nil {
+			return err
+		}
+		if err := tx.RemoveUTXOs([]spec.OutPointKey{spec.OutPoint(utxoA.TxID, utxoA.VOut)}, 120); err != nil {
+			return err
+		}
+		return tx.RemoveUTXOs([]spec.OutPointKey{spec.OutPoint(utxoB.TxID, utxoB.VOut)}, 250)
+	}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	heights := func(found []spec.UTXOState) (got [][2]int64) {
+		for _, u := range found {
+			got = append(got, [2]int64{u.Height, u.Spent})
+		}
+		return got
+	}
+	found, err := db.FindUTXOHistory(kind, addr, spec.UTXOFilter{})
 	if err != nil {
-		t.Fatalf("GetCurrentHeight (after set): %v", err)
+		t.Fatalf("FindUTXOHistory: %v", err)
 	}
-	if height != 100 {
-		t.Fatalf("expected height 100, got: %d", height)
+	if want := [][2]int64{{100, 120}, {150, 250}, {160, 0}}; !reflect.DeepEqual(heights(found), want) {
+		t.Fatalf("FindUTXOHistory (height, spent) = %v, want %v", heights(found), want)
+	}
+	if !bytes.Equal(found[0].TxID, utxoA.TxID) || found[0].Value != 1000 || found[0].Type != kind || !bytes.Equal(found[0].Script, addr) {
+		t.Errorf("FindUTXOHistory[0] = %+v, want UTXO A", found[0])
+	}
+	unspent, err := db.FindUTXOsFiltered(kind, addr, spec.UTXOFilter{})
+	if err != nil || len(unspent) != 1 || !bytes.Equal(unspent[0].TxID, utxoC.TxID) {
+		t.Fatalf("FindUTXOsFiltered = %+v, %v; want only UTXO C", unspent, err)
 	}
 
-	// Update height
-	hash2 := bytesOf(0xBB, 32)
+	// filters apply as in FindUTXOsFiltered
+	found, err = db.FindUTXOHistory(kind, addr, spec.UTXOFilter{MinValue: 1500, Order: spec.UTXOOrderValueDesc})
+	if err != nil {
+		t.Fatalf("FindUTXOHistory: %v", err)
+	}
+	if want := [][2]int64{{160, 0}, {150, 250}}; !reflect.DeepEqual(heights(found), want) {
+		t.Errorf("FindUTXOHistory(MinValue, ValueDesc) = %v, want %v", heights(found), want)
+	}
+
+	// UTXOs spent below the trim floor are gone
 	if err := db.Transact(func(tx spec.StoreTx) error {
-		return tx.SetResumePoint(hash2, 200)
+		return tx.TrimSpentUTXOs(200)
 	}); err != nil {
-		t.Fatalf("SetResumePoint (update): %v", err)
+		t.Fatalf("TrimSpentUTXOs: %v", err)
+	}
+	found, err = db.FindUTXOHistory(kind, addr, spec.UTXOFilter{})
+	if err != nil {
+		t.Fatalf("FindUTXOHistory: %v", err)
+	}
+	if want := [][2]int64{{150, 250}, {160, 0}}; !reflect.DeepEqual(heights(found), want) {
+		t.Errorf("FindUTXOHistory after trim = %v, want %v", heights(found), want)
 	}
+}
 
-	height, err = db.GetCurrentHeight()
+func TestPGStore_MonitorState(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	if state, err := db.GetMonitorState(); err != nil || state != nil {
+		t.Fatalf("GetMonitorState before saving = %q, %v; want nil", state, err)
+	}
+	for _, want := range []string{`{"blocks":[1]}`, `{"blocks":[2]}`} {
+		if err := db.SetMonitorState([]byte(want)); err != nil {
+			t.Fatalf("SetMonitorState: %v", err)
+		}
+		if state, err := db.GetMonitorState(); err != nil || string(state) != want {
+			t.Fatalf("GetMonitorState = %q, %v; want %q", state, err, want)
+		}
+	}
+}
+
+// A tx paying the same address in several outputs (a common donation or
+// consolidation pattern) stores one tx row and a UTXO per output.
+func TestPGStore_SameAddressOutputs(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x5A, 20)
+	other := bytesOf(0x5B, 20)
+	txid := bytesOf(0x41, 32)
+	utxos := []spec.UTXO{
+		{TxID: txid, VOut: 0, Value: 1000, Type: kind, Script: addr},
+		{TxID: txid, VOut: 1, Value: 2500, Type: kind, Script: other},
+		{TxID: txid, VOut: 2, Value: 3000, Type: kind, Script: addr},
+		{TxID: txid, VOut: 3, Value: 1000, Type: kind, Script: addr}, // same value as vout 0
+	}
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		if err := tx.CreateUTXOs(utxos, 100); err != nil {
+			return err
+		}
+		return tx.SetResumePoint(bytesOf(0x64, 32), 110)
+	}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if total, err := db.GetTxTotal(); err != nil || total != 1 {
+		t.Fatalf("GetTxTotal = %d, %v; want 1 tx row", total, err)
+	}
+	found, err := db.FindUTXOs(kind, addr)
 	if err != nil {
-		t.Fatalf("GetCurrentHeight (after update): %v", err)
+		t.Fatalf("FindUTXOs: %v", err)
 	}
-	if height != 200 {
-		t.Fatalf("expected height 200, got: %d", height)
+	if len(found) != 3 || found[0].VOut != 0 || found[1].VOut != 2 || found[2].VOut != 3 {
+		t.Fatalf("FindUTXOs = %+v, want vouts 0, 2 and 3", found)
+	}
+	for _, u := range found {
+		if !bytes.Equal(u.TxID, txid) {
+			t.Errorf("FindUTXOs: vout %d has tx %x, want %x", u.VOut, u.TxID, txid)
+		}
+	}
+	balance, err := db.GetBalance(kind, addr, 6)
+	if err != nil || !balance.Available.Equal(amount(5000)) || balance.UTXOCount != 3 {
+		t.Fatalf("GetBalance = {A:%s N:%d}, %v; want {A:5000 N:3}", balance.Available, balance.UTXOCount, err)
+	}
+
+	// spending one output leaves the others
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.RemoveUTXOs([]spec.OutPointKey{spec.OutPoint(txid, 0)}, 101)
+	}); err != nil {
+		t.Fatalf("RemoveUTXOs: %v", err)
+	}
+	balance, err = db.GetBalance(kind, addr, 6)
+	if err != nil || !balance.Available.Equal(amount(4000)) || balance.UTXOCount != 2 {
+		t.Fatalf("GetBalance after spend = {A:%s N:%d}, %v; want {A:4000 N:2}", balance.Available, balance.UTXOCount, err)
+	}
+	if count, err := db.GetUnspentCount(); err != nil || count != 3 {
+		t.Fatalf("GetUnspentCount = %d, %v; want 3", count, err)
+	}
+}
+
+func TestPGStore_CheckIntegrity(t *testing.T) {
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x5E, 20)
+	txA := bytesOf(0xA8, 32)
+
+	tests := []struct {
+		name    string
+		corrupt string // SQL injecting the corruption ("" for none)
+		want    string // expected problem substring ("" for consistent)
+	}{
+		{"consistent", "", ""},
+		{"missing resume row", `DELETE FROM resume`, "exactly one resume row, found 0"},
+		{"extra resume row", `INSERT INTO resume (hash,height) VALUES (X'00', 1)`, "exactly one resume row, found 2"},
+		{"orphan utxo", `DELETE FROM tx`, "without a matching tx row"},
+		{"orphan tx", `DELETE FROM utxo; UPDATE utxo_stats SET unspent = 0`, "tx rows without any utxo rows"},
+		{"negative value", `UPDATE utxo SET value = -1`, "negative value"},
+		{"spent before creation", `UPDATE utxo SET spent = 50; UPDATE utxo_stats SET unspent = 0`, "spent below their creation height"},
+		{"unspent count drift", `UPDATE utxo_stats SET unspent = 5`, "unspent count is 5 but 1 utxo rows are unspent"},
+		{"height drift", `UPDATE utxo SET height = 99`, "utxo rows with a different height than their tx row"},
+		{"resume behind tx", `UPDATE resume SET height = 10`, "resume height 10 is below the highest tx height 100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, stop := newTestStore(t)
+			defer stop()
+
+			if err := db.Transact(func(tx spec.StoreTx) error {
+				if err := tx.CreateUTXOs([]spec.UTXO{{TxID: txA, VOut: 0, Value: 1000, Type: kind, Script: addr}}, 100); err != nil {
+					return err
+				}
+				return tx.SetResumePoint(bytesOf(0xD8, 32), 100)
+			}); err != nil {
+				t.Fatalf("CreateUTXOs/SetResumePoint: %v", err)
+			}
+			if tt.corrupt != "" {
+				if _, err := db.(*idxstore.IndexStore).RawDB.Exec(tt.corrupt); err != nil {
+					t.Fatalf("inject corruption: %v", err)
+				}
+			}
+
+			problems, err := db.CheckIntegrity()
+			if err != nil {
+				t.Fatalf("CheckIntegrity: %v", err)
+			}
+			if tt.want == "" {
+				if len(problems) != 0 {
+					t.Fatalf("CheckIntegrity problems = %q, want none", problems)
+				}
+				return
+			}
+			if len(problems) != 1 || !strings.Contains(problems[0], tt.want) {
+				t.Fatalf("CheckIntegrity problems = %q, want one containing %q", problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestPGStore_RepairOrphans(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x5F, 20)
+	txA, txB, txC := bytesOf(0xA9, 32), bytesOf(0xB9, 32), bytesOf(0xC9, 32)
+
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		if err := tx.CreateUTXOs([]spec.UTXO{
+			{TxID: txA, VOut: 0, Value: 1000, Type: kind, Script: addr},
+			{TxID: txB, VOut: 0, Value: 2000, Type: kind, Script: addr},
+			{TxID: txB, VOut: 1, Value: 3000, Type: kind, Script: addr},
+		}, 100); err != nil {
+			return err
+		}
+		if err := tx.RemoveUTXOs([]spec.OutPointKey{spec.OutPoint(txB, 1)}, 101); err != nil {
+			return err
+		}
+		return tx.SetResumePoint(bytesOf(0xD9, 32), 101)
+	}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	// orphan txB's utxo rows (one unspent, one spent) and add a tx row without utxos
+	raw := db.(*idxstore.IndexStore).RawDB
+	if _, err := raw.Exec(`DELETE FROM tx WHERE hash = $1`, txB); err != nil {
+		t.Fatalf("inject orphan utxos: %v", err)
+	}
+	if _, err := raw.Exec(`INSERT INTO tx (height,hash) VALUES (101,$1)`, txC); err != nil {
+		t.Fatalf("inject orphan tx: %v", err)
+	}
+	if problems, err := db.CheckIntegrity(); err != nil || len(problems) != 2 {
+		t.Fatalf("CheckIntegrity before repair = %q, %v; want the two orphan problems", problems, err)
+	}
+
+	var repair spec.OrphanRepair
+	if err := db.Transact(func(tx spec.StoreTx) (err error) {
+		repair, err = tx.RepairOrphans()
+		return err
+	}); err != nil {
+		t.Fatalf("RepairOrphans: %v", err)
+	}
+	if repair != (spec.OrphanRepair{UTXOs: 2, Txs: 1}) {
+		t.Errorf("RepairOrphans = %+v, want 2 utxos and 1 tx", repair)
+	}
+	if problems, err := db.CheckIntegrity(); err != nil || len(problems) != 0 {
+		t.Errorf("CheckIntegrity after repair = %q, %v; want none", problems, err)
+	}
+	utxos, err := db.FindUTXOs(kind, addr)
+	if err != nil || len(utxos) != 1 || !bytes.Equal(utxos[0].TxID, txA) {
+		t.Errorf("FindUTXOs after repair = %+v, %v; want only txA's UTXO", utxos, err)
+	}
+	var txRows int64
+	if err := raw.QueryRow(`SELECT COUNT(*) FROM tx`).Scan(&txRows); err != nil || txRows != 1 {
+		t.Errorf("tx rows after repair = %d, %v; want 1 (txA)", txRows, err)
+	}
+
+	// nothing left to repair
+	if err := db.Transact(func(tx spec.StoreTx) (err error) {
+		repair, err = tx.RepairOrphans()
+		return err
+	}); err != nil || repair != (spec.OrphanRepair{}) {
+		t.Errorf("second RepairOrphans = %+v, %v; want nothing deleted", repair, err)
+	}
+}
+
+func TestPGStore_CheckIntegrity_EmptyStore(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	problems, err := db.CheckIntegrity()
+	if err != nil {
+		t.Fatalf("CheckIntegrity: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Fatalf("CheckIntegrity problems = %q, want none for a new store", problems)
+	}
+}
+
+func TestPGStore_ClearIndex(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x6F, 20)
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		if err := tx.CreateUTXOs([]spec.UTXO{{TxID: bytesOf(0xA9, 32), VOut: 0, Value: 1000, Type: kind, Script: addr}}, 100); err != nil {
+			return err
+		}
+		return tx.SetResumePoint(bytesOf(0xD9, 32), 100)
+	}); err != nil {
+		t.Fatalf("CreateUTXOs/SetResumePoint: %v", err)
+	}
+	if err := db.SetMonitorState([]byte(`{"blocks":[100]}`)); err != nil {
+		t.Fatalf("SetMonitorState: %v", err)
+	}
+
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.ClearIndex()
+	}); err != nil {
+		t.Fatalf("ClearIndex: %v", err)
+	}
+
+	resume, err := db.GetResumePoint()
+	if err != nil {
+		t.Fatalf("GetResumePoint: %v", err)
+	}
+	if len(resume) != 0 {
+		t.Fatalf("resume point = %x, want none after ClearIndex", resume)
+	}
+	found, err := db.FindUTXOs(kind, addr)
+	if err != nil {
+		t.Fatalf("FindUTXOs: %v", err)
+	}
+	if len(found) != 0 {
+		t.Fatalf("FindUTXOs count = %d, want 0 after ClearIndex", len(found))
+	}
+	// a resync mustn't reload the old history's recent blocks
+	if state, err := db.GetMonitorState(); err != nil || state != nil {
+		t.Fatalf("GetMonitorState after ClearIndex = %q, %v; want nil", state, err)
+	}
+}
+
+func TestPGStore_FindScriptByHash(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	p2pkh := bytesOf(0x7A, 20)
+	p2sh := bytesOf(0x7B, 20)
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.CreateUTXOs([]spec.UTXO{
+			{TxID: bytesOf(0xAA, 32), VOut: 0, Value: 1000, Type: doge.ScriptTypeP2PKH, Script: p2pkh},
+			{TxID: bytesOf(0xAA, 32), VOut: 1, Value: 2000, Type: doge.ScriptTypeP2SH, Script: p2sh},
+		}, 100)
+	}); err != nil {
+		t.Fatalf("CreateUTXOs: %v", err)
+	}
+
+	kind, script, err := db.FindScriptByHash(spec.ScriptHash(doge.ScriptTypeP2SH, p2sh))
+	if err != nil {
+		t.Fatalf("FindScriptByHash: %v", err)
+	}
+	if kind != doge.ScriptTypeP2SH || string(script) != string(p2sh) {
+		t.Fatalf("FindScriptByHash = (%v, %x), want (P2SH, %x)", kind, script, p2sh)
+	}
+
+	_, _, err = db.FindScriptByHash(bytesOf(0x00, 32))
+	if err != spec.ErrNotFound {
+		t.Fatalf("FindScriptByHash(unknown) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPGStore_ScriptHashColumn(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	hash := bytesOf(0x7C, 20)
+	pubKey := append([]byte{0x02}, bytesOf(0x7D, 32)...)
+	tests := []struct {
+		kind   doge.ScriptType
+		script []byte // compact, as stored
+		full   []byte // scriptPubKey
+	}{
+		{doge.ScriptTypeP2PKH, hash, bytes.Join([][]byte{{0x76, 0xa9, 0x14}, hash, {0x88, 0xac}}, nil)},
+		{doge.ScriptTypeP2SH, hash, bytes.Join([][]byte{{0xa9, 0x14}, hash, {0x87}}, nil)},
+		{doge.ScriptTypeP2PK, pubKey, bytes.Join([][]byte{{0x21}, pubKey, {0xac}}, nil)},
+	}
+	for n, tt := range tests {
+		utxo := spec.UTXO{TxID: bytesOf(0xE0, 32), VOut: uint32(n), Value: int64(1000 * (n + 1)), Type: tt.kind, Script: tt.script}
+		if err := db.Transact(func(tx spec.StoreTx) error { return tx.CreateUTXOs([]spec.UTXO{utxo}, 100) }); err != nil {
+			t.Fatalf("CreateUTXOs: %v", err)
+		}
+	}
+
+	for n, tt := range tests {
+		scriptHash := sha256.Sum256(tt.full)
+		kind, script, err := db.FindScriptByHash(scriptHash[:])
+		if err != nil || kind != tt.kind || !bytes.Equal(script, tt.script) {
+			t.Fatalf("FindScriptByHash(%v) = %v %x %v, want %v %x", tt.kind, kind, script, err, tt.kind, tt.script)
+		}
+		utxos, err := db.FindUTXOs(kind, script)
+		if err != nil || len(utxos) != 1 || utxos[0].VOut != uint32(n) {
+			t.Fatalf("FindUTXOs(%v) = %+v, %v; want vout %d", tt.kind, utxos, err, n)
+		}
+	}
+}
+
+func TestPGStore_ScriptHashBackfill(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	db, err := idxstore.NewIndexStore(path, context.Background(), idxstore.Options{})
+	if err != nil {
+		t.Fatalf("NewIndexStore: %v", err)
+	}
+	hash := bytesOf(0x7E, 20)
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.CreateUTXOs([]spec.UTXO{
+			{TxID: bytesOf(0xE1, 32), VOut: 0, Value: 1000, Type: doge.ScriptTypeP2PKH, Script: hash},
+			{TxID: bytesOf(0xE1, 32), VOut: 1, Value: 2000, Type: doge.ScriptTypeP2SH, Script: hash},
+		}, 100)
+	}); err != nil {
+		t.Fatalf("CreateUTXOs: %v", err)
+	}
+	db.Close()
+
+	// rows indexed before the scripthash column existed
+	raw, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	if _, err := raw.Exec(`UPDATE utxo SET scripthash=NULL`); err != nil {
+		t.Fatalf("clear scripthash: %v", err)
+	}
+	raw.Close()
+
+	db, err = idxstore.NewIndexStore(path, context.Background(), idxstore.Options{})
+	if err != nil {
+		t.Fatalf("NewIndexStore after upgrade: %v", err)
+	}
+	defer db.Close()
+	// the backfill runs in the background after NewIndexStore returns
+	for _, kind := range []doge.ScriptType{doge.ScriptTypeP2PKH, doge.ScriptTypeP2SH} {
+		for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+			found, script, err := db.FindScriptByHash(spec.ScriptHash(kind, hash))
+			if err == nil && found == kind && bytes.Equal(script, hash) {
+				break
+			}
+			if !errors.Is(err, spec.ErrNotFound) || time.Now().After(deadline) {
+				t.Fatalf("FindScriptByHash(%v) after backfill = %v %x %v", kind, found, script, err)
+			}
+		}
+	}
+}
+
+func TestPGStore_UTXOHeight(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x4D, 20)
+
+	// a database from before the height column (schema version 11, which
+	// SCHEMA_v11 upgrades): migrating copies each UTXO's height from tx
+	old := migratedStore(t, path, 11)
+	for n, height := range []int64{100, 105, 110} {
+		if _, err := old.RawDB.Exec(`INSERT INTO tx (txid,height,hash) VALUES ($1,$2,$3)`, n+1, height, bytesOf(byte(0xD0+n), 32)); err != nil {
+			t.Fatalf("insert tx: %v", err)
+		}
+		if _, err := old.RawDB.Exec(`INSERT INTO utxo (txid,vout,value,kind,script,scripthash) VALUES ($1,0,$2,$3,$4,$5)`, n+1, 1000*(n+1), kind, addr, spec.ScriptHash(kind, addr)); err != nil {
+			t.Fatalf("insert utxo: %v", err)
+		}
+	}
+	if _, err := old.RawDB.Exec(`INSERT INTO resume (hash,height) VALUES ($1,112); UPDATE utxo_stats SET unspent=3`, bytesOf(0xEE, 32)); err != nil {
+		t.Fatalf("insert resume: %v", err)
+	}
+	old.Close()
+
+	db, err := idxstore.NewIndexStore(path, context.Background(), idxstore.Options{})
+	if err != nil {
+		t.Fatalf("NewIndexStore after upgrade: %v", err)
+	}
+	defer db.Close()
+	var mismatched int64
+	err = db.(*idxstore.IndexStore).RawDB.QueryRow(`SELECT COUNT(*) FROM utxo u INNER JOIN tx t ON u.txid = t.txid WHERE u.height IS NULL OR u.height <> t.height`).Scan(&mismatched)
+	if err != nil || mismatched != 0 {
+		t.Fatalf("expected utxo.height to match tx.height, %d rows differ (%v)", mismatched, err)
+	}
+	utxos, err := db.FindUTXOsFiltered(kind, addr, spec.UTXOFilter{MinHeight: 101, MaxHeight: 110})
+	if err != nil || len(utxos) != 2 || utxos[0].Value != 2000 || utxos[1].Value != 3000 {
+		t.Fatalf("FindUTXOsFiltered(101..110) = %v, %v; want the UTXOs at 105 and 110", utxos, err)
+	}
+	if first, err := db.GetAddressFirstSeen(kind, addr); err != nil || first != 100 {
+		t.Fatalf("GetAddressFirstSeen = %d, %v; want 100", first, err)
+	}
+	if at, err := db.GetBalanceAtHeight(kind, addr, 105); err != nil || !at.Equal(amount(3000)) {
+		t.Fatalf("GetBalanceAtHeight(105) = %s, %v; want 3000", at, err)
+	}
+	// at 112 with 6 confirmations: 100 and 105 are available, 110 is incoming
+	balance, err := db.GetBalance(kind, addr, 6)
+	if err != nil || !balance.Available.Equal(amount(3000)) || !balance.Incoming.Equal(amount(3000)) {
+		t.Fatalf("GetBalance = {A:%s I:%s}, %v; want {A:3000 I:3000}", balance.Available, balance.Incoming, err)
+	}
+}
+
+func TestPGStore_DuplicateTxHashes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x4E, 20)
+
+	// a database from before tx.hash was unique (schema version 2, which
+	// SCHEMA_v2 upgrades), where a replayed block stored its tx again
+	old := migratedStore(t, path, 2)
+	for n, hash := range [][]byte{bytesOf(0xD1, 32), bytesOf(0xD1, 32), bytesOf(0xD2, 32)} {
+		if _, err := old.RawDB.Exec(`INSERT INTO tx (txid,height,hash) VALUES ($1,100,$2)`, n+1, hash); err != nil {
+			t.Fatalf("insert tx: %v", err)
+		}
+		if _, err := old.RawDB.Exec(`INSERT INTO utxo (txid,vout,value,kind,script) VALUES ($1,0,1000,$2,$3)`, n+1, kind, addr); err != nil {
+			t.Fatalf("insert utxo: %v", err)
+		}
+	}
+	if _, err := old.RawDB.Exec(`INSERT INTO resume (hash,height) VALUES ($1,100)`, bytesOf(0xEE, 32)); err != nil {
+		t.Fatalf("insert resume: %v", err)
+	}
+	old.Close()
+
+	db, err := idxstore.NewIndexStore(path, context.Background(), idxstore.Options{})
+	if err != nil {
+		t.Fatalf("NewIndexStore after upgrade: %v", err)
+	}
+	defer db.Close()
+	var txids []int64
+	rows, err := db.(*idxstore.IndexStore).RawDB.Query(`SELECT txid FROM tx ORDER BY txid`)
+	if err != nil {
+		t.Fatalf("query tx: %v", err)
+	}
+	for rows.Next() {
+		var txid int64
+		if err := rows.Scan(&txid); err != nil {
+			t.Fatalf("scan tx: %v", err)
+		}
+		txids = append(txids, txid)
+	}
+	rows.Close()
+	if len(txids) != 2 || txids[0] != 1 || txids[1] != 3 {
+		t.Fatalf("tx rows after upgrade = %v; want [1 3] (the first of each hash)", txids)
+	}
+	utxos, err := db.FindUTXOs(kind, addr)
+	if err != nil || len(utxos) != 2 {
+		t.Fatalf("FindUTXOs = %v, %v; want 2 (the copy's UTXO dropped)", utxos, err)
+	}
+	if unspent, err := db.GetUnspentCount(); err != nil || unspent != 2 {
+		t.Fatalf("GetUnspentCount = %d, %v; want 2", unspent, err)
+	}
+}
+
+// migratedStore creates a database at `path` with MIGRATIONS up to `version`,
+// as an older indexer left it, to test upgrading from there.
+func migratedStore(t *testing.T, path string, version int) *idxstore.IndexStore {
+	t.Helper()
+	var migrations []storelib.Migration
+	for _, m := range idxstore.MIGRATIONS {
+		if m.Version <= version {
+			if sql, found := idxstore.SQLITE_SCHEMA[m.Version]; found {
+				m.SQL = sql
+			}
+			migrations = append(migrations, m)
+		}
+	}
+	store := &idxstore.IndexStore{}
+	if err := storelib.InitStore(store, &store.StoreBase, path, migrations, context.Background()); err != nil {
+		t.Fatalf("migrating to version %d: %v", version, err)
+	}
+	return store
+}
+
+func TestPGStore_HasAddressUTXOs(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x5A, 20)
+	hasUTXOs := func(want bool, when string) {
+		t.Helper()
+		if found, err := db.HasAddressUTXOs(kind, addr); err != nil || found != want {
+			t.Fatalf("HasAddressUTXOs %s = %v, %v; want %v", when, found, err, want)
+		}
+	}
+	hasUTXOs(false, "before any UTXOs")
+
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.CreateUTXOs([]spec.UTXO{{TxID: bytesOf(0x31, 32), VOut: 0, Value: 1000, Type: kind, Script: addr}}, 100)
+	}); err != nil {
+		t.Fatalf("CreateUTXOs: %v", err)
+	}
+	hasUTXOs(true, "when unspent")
+	// the same hash as another kind is a different address
+	if found, err := db.HasAddressUTXOs(doge.ScriptTypeP2SH, addr); err != nil || found {
+		t.Fatalf("HasAddressUTXOs(P2SH) = %v, %v; want false", found, err)
+	}
+
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.RemoveUTXOs([]spec.OutPointKey{spec.OutPoint(bytesOf(0x31, 32), 0)}, 110)
+	}); err != nil {
+		t.Fatalf("RemoveUTXOs: %v", err)
+	}
+	hasUTXOs(true, "when spent")
+
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.TrimSpentUTXOs(200)
+	}); err != nil {
+		t.Fatalf("TrimSpentUTXOs: %v", err)
+	}
+	hasUTXOs(false, "after trimming")
+}
+
+func TestPGStore_GetUTXODiff(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x8C, 20)
+	utxoA := spec.UTXO{TxID: bytesOf(0xA1, 32), VOut: 0, Value: 1000, Type: kind, Script: addr}
+	utxoB := spec.UTXO{TxID: bytesOf(0xB1, 32), VOut: 1, Value: 2000, Type: kind, Script: addr}
+	utxoC := spec.UTXO{TxID: bytesOf(0xC1, 32), VOut: 0, Value: 3000, Type: kind, Script: addr}
+
+	// A at 100, B at 105, C at 110; A spent at 106, B spent at 111
+	steps := []struct {
+		height int64
+		create []spec.UTXO
+		spend  []spec.UTXO
+	}{
+		{100, []spec.UTXO{utxoA}, nil},
+		{105, []spec.UTXO{utxoB}, nil},
+		{106, nil, []spec.UTXO{utxoA}},
+		{110, []spec.UTXO{utxoC}, nil},
+		{111, nil, []spec.UTXO{utxoB}},
+	}
+	for _, step := range steps {
+		if err := db.Transact(func(tx spec.StoreTx) error {
+			for _, u := range step.spend {
+				if err := tx.RemoveUTXOs([]spec.OutPointKey{spec.OutPoint(u.TxID, u.VOut)}, step.height); err != nil {
+					return err
+				}
+			}
+			if step.create != nil {
+				if err := tx.CreateUTXOs(step.create, step.height); err != nil {
+					return err
+				}
+			}
+			return tx.SetResumePoint(bytesOf(byte(step.height), 32), step.height)
+		}); err != nil {
+			t.Fatalf("height %d: %v", step.height, err)
+		}
+	}
+
+	diff, err := db.GetUTXODiff(105, 110)
+	if err != nil {
+		t.Fatalf("GetUTXODiff: %v", err)
+	}
+	// created in range: B (105) and C (110); spent in range: A (106)
+	if len(diff.Created) != 2 || diff.Created[0].Value != 2000 || diff.Created[0].Height != 105 ||
+		diff.Created[1].Value != 3000 || diff.Created[1].Height != 110 {
+		t.Fatalf("Created = %+v, want B@105 and C@110", diff.Created)
+	}
+	if len(diff.Spent) != 1 || diff.Spent[0].Value != 1000 || diff.Spent[0].Height != 106 ||
+		string(diff.Spent[0].TxID) != string(utxoA.TxID) || diff.Spent[0].VOut != 0 {
+		t.Fatalf("Spent = %+v, want A spent @106", diff.Spent)
+	}
+
+	diff, err = db.GetUTXODiff(200, 300)
+	if err != nil {
+		t.Fatalf("GetUTXODiff (empty): %v", err)
+	}
+	if len(diff.Created) != 0 || len(diff.Spent) != 0 {
+		t.Fatalf("GetUTXODiff (empty) = %+v, want no changes", diff)
+	}
+}
+
+func TestPGStore_FindOutgoing_MatchesBalance(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x9D, 20)
+	utxos := []spec.UTXO{
+		{TxID: bytesOf(0xA2, 32), VOut: 0, Value: 1000, Type: kind, Script: addr},
+		{TxID: bytesOf(0xA2, 32), VOut: 1, Value: 2000, Type: kind, Script: addr},
+		{TxID: bytesOf(0xB3, 32), VOut: 0, Value: 4000, Type: kind, Script: addr},
+		{TxID: bytesOf(0xC4, 32), VOut: 0, Value: 8000, Type: kind, Script: addr},
+	}
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.CreateUTXOs(utxos, 100)
+	}); err != nil {
+		t.Fatalf("CreateUTXOs: %v", err)
+	}
+	// spend at 150 (long ago), 197 and 199 (recent); the last stays unspent
+	for n, spentAt := range []int64{150, 197, 199} {
+		u := utxos[n]
+		if err := db.Transact(func(tx spec.StoreTx) error {
+			return tx.RemoveUTXOs([]spec.OutPointKey{spec.OutPoint(u.TxID, u.VOut)}, spentAt)
+		}); err != nil {
+			t.Fatalf("RemoveUTXOs: %v", err)
+		}
+	}
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.SetResumePoint(bytesOf(0xDD, 32), 200)
+	}); err != nil {
+		t.Fatalf("SetResumePoint: %v", err)
+	}
+
+	for _, confirmations := range []int64{0, 2, 6, 100} {
+		bal, err := db.GetBalance(kind, addr, confirmations)
+		if err != nil {
+			t.Fatalf("GetBalance(%d): %v", confirmations, err)
+		}
+		outgoing, err := db.FindOutgoing(kind, addr, confirmations)
+		if err != nil {
+			t.Fatalf("FindOutgoing(%d): %v", confirmations, err)
+		}
+		sum := int64(0)
+		for _, c := range outgoing {
+			sum += c.Value
+			if c.Height < 200-confirmations {
+				t.Fatalf("FindOutgoing(%d) listed a UTXO spent at %d", confirmations, c.Height)
+			}
+		}
+		if !bal.Outgoing.Equal(amount(sum)) {
+			t.Fatalf("confirmations %d: Outgoing = %s, listed entries sum to %d", confirmations, bal.Outgoing, sum)
+		}
+	}
+
+	outgoing, err := db.FindOutgoing(kind, addr, 6)
+	if err != nil {
+		t.Fatalf("FindOutgoing: %v", err)
+	}
+	if len(outgoing) != 2 || outgoing[0].Height != 197 || outgoing[1].Height != 199 || outgoing[0].Value != 2000 {
+		t.Fatalf("FindOutgoing(6) = %+v, want vout 1 spent at 197 and B spent at 199", outgoing)
+	}
+}
+
+func TestPGStore_GetAddressFirstSeen(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x5E, 20)
+	if _, err := db.GetAddressFirstSeen(kind, addr); !errors.Is(err, spec.ErrNotFound) {
+		t.Fatalf("GetAddressFirstSeen on an unknown address: expected ErrNotFound, got %v", err)
+	}
+
+	for n, height := range []int64{300, 120, 250} {
+		u := spec.UTXO{TxID: bytesOf(byte(0x10+n), 32), VOut: 0, Value: 1000, Type: kind, Script: addr}
+		if err := db.Transact(func(tx spec.StoreTx) error {
+			return tx.CreateUTXOs([]spec.UTXO{u}, height)
+		}); err != nil {
+			t.Fatalf("CreateUTXOs: %v", err)
+		}
+	}
+	// another address paid earlier does not count
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.CreateUTXOs([]spec.UTXO{{TxID: bytesOf(0x20, 32), VOut: 0, Value: 1000, Type: kind, Script: bytesOf(0x6F, 20)}}, 50)
+	}); err != nil {
+		t.Fatalf("CreateUTXOs: %v", err)
+	}
+
+	height, err := db.GetAddressFirstSeen(kind, addr)
+	if err != nil || height != 120 {
+		t.Fatalf("GetAddressFirstSeen = %d, %v; want 120", height, err)
+	}
+
+	// once the first UTXO is spent and trimmed, the address appears to be first seen later
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.RemoveUTXOs([]spec.OutPointKey{spec.OutPoint(bytesOf(0x11, 32), 0)}, 130)
+	}); err != nil {
+		t.Fatalf("RemoveUTXOs: %v", err)
+	}
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.TrimSpentUTXOs(200)
+	}); err != nil {
+		t.Fatalf("TrimSpentUTXOs: %v", err)
+	}
+	height, err = db.GetAddressFirstSeen(kind, addr)
+	if err != nil || height != 250 {
+		t.Fatalf("GetAddressFirstSeen after trim = %d, %v; want 250", height, err)
+	}
+}
+
+func TestPGStore_TrimmedBelow(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	trim := func(height int64) {
+		t.Helper()
+		if err := db.Transact(func(tx spec.StoreTx) error {
+			return tx.TrimSpentUTXOs(height)
+		}); err != nil {
+			t.Fatalf("TrimSpentUTXOs(%d): %v", height, err)
+		}
+	}
+	expect := func(want int64) {
+		t.Helper()
+		if got, err := db.GetTrimmedBelow(); err != nil || got != want {
+			t.Fatalf("GetTrimmedBelow = %d, %v; want %d", got, err, want)
+		}
+	}
+
+	expect(0) // never trimmed
+	trim(1000)
+	expect(1000)
+	trim(3000)
+	expect(3000)
+	trim(2000) // never moves down
+	expect(3000)
+
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.ClearIndex()
+	}); err != nil {
+		t.Fatalf("ClearIndex: %v", err)
+	}
+	expect(0)
+}
+
+func TestPGStore_Balance_CoinbaseMaturity(t *testing.T) {
+	db, err := idxstore.NewIndexStore(":memory:", context.Background(), idxstore.Options{CoinbaseMaturity: 10})
+	if err != nil {
+		t.Fatalf("NewIndexStore: %v", err)
+	}
+
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x7C, 20)
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.CreateUTXOs([]spec.UTXO{
+			{TxID: bytesOf(0xC0, 32), VOut: 0, Value: 5000, Type: kind, Script: addr, Coinbase: true},
+			{TxID: bytesOf(0xC1, 32), VOut: 0, Value: 300, Type: kind, Script: addr},
+		}, 100)
+	}); err != nil {
+		t.Fatalf("CreateUTXOs: %v", err)
+	}
+
+	tests := []struct {
+		height    int64
+		available int64
+		incoming  int64
+	}{
+		{105, 0, 5300},   // 6 confirmations: nothing available yet
+		{107, 300, 5000}, // 8 confirmations: coinbase is still immature
+		{108, 300, 5000}, // 9 confirmations
+		{109, 5300, 0},   // 10 confirmations: coinbase is mature
+	}
+	for _, tt := range tests {
+		if err := db.Transact(func(tx spec.StoreTx) error {
+			return tx.SetResumePoint(bytesOf(byte(tt.height), 32), tt.height)
+		}); err != nil {
+			t.Fatalf("SetResumePoint: %v", err)
+		}
+		bal, err := db.GetBalance(kind, addr, 6)
+		if err != nil {
+			t.Fatalf("GetBalance at %d: %v", tt.height, err)
+		}
+		if !bal.Available.Equal(amount(tt.available)) || !bal.Incoming.Equal(amount(tt.incoming)) {
+			t.Fatalf("at height %d: available %s incoming %s, want %d and %d", tt.height, bal.Available, bal.Incoming, tt.available, tt.incoming)
+		}
+	}
+
+	utxos, err := db.FindUTXOs(kind, addr)
+	if err != nil {
+		t.Fatalf("FindUTXOs: %v", err)
+	}
+	coinbase := 0
+	for _, u := range utxos {
+		if u.Coinbase {
+			coinbase++
+		}
+	}
+	if len(utxos) != 2 || coinbase != 1 {
+		t.Fatalf("FindUTXOs: %d UTXOs, %d coinbase; want 2 and 1", len(utxos), coinbase)
+	}
+}
+
+func TestPGStore_BlockStats(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	for height := int64(10); height <= 15; height++ {
+		if err := db.Transact(func(tx spec.StoreTx) error {
+			return tx.SetBlockStats(spec.BlockStats{Height: height, TxCount: height * 2})
+		}); err != nil {
+			t.Fatalf("SetBlockStats(%d): %v", height, err)
+		}
+	}
+	// a replayed block replaces its stats (fees and activity are NULL unless known)
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.SetBlockStats(spec.BlockStats{Height: 12, TxCount: 5, Fees: 7500, FeesKnown: true})
+	}); err != nil {
+		t.Fatalf("SetBlockStats replay: %v", err)
+	}
+	detailed := spec.BlockStats{Height: 13, TxCount: 26, UTXOCreated: 40, UTXOSpent: 30, ProcessingTime: 1500 * time.Millisecond, Detailed: true}
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.SetBlockStats(detailed)
+	}); err != nil {
+		t.Fatalf("SetBlockStats detailed: %v", err)
+	}
+
+	stats, err := db.GetBlockStats(11, 13)
+	if err != nil {
+		t.Fatalf("GetBlockStats: %v", err)
+	}
+	want := []spec.BlockStats{{Height: 11, TxCount: 22}, {Height: 12, TxCount: 5, Fees: 7500, FeesKnown: true}, detailed}
+	if len(stats) != len(want) {
+		t.Fatalf("GetBlockStats = %+v, want %+v", stats, want)
+	}
+	for n := range want {
+		if stats[n] != want[n] {
+			t.Fatalf("GetBlockStats = %+v, want %+v", stats, want)
+		}
+	}
+
+	// undo drops stats above the undo height
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.UndoAbove(13)
+	}); err != nil {
+		t.Fatalf("UndoAbove: %v", err)
+	}
+	stats, err = db.GetBlockStats(0, 100)
+	if err != nil {
+		t.Fatalf("GetBlockStats: %v", err)
+	}
+	if len(stats) != 4 || stats[3].Height != 13 {
+		t.Fatalf("GetBlockStats after undo = %+v, want heights 10-13", stats)
+	}
+}
+
+func TestPGStore_Stats(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.PingLatency <= 0 {
+		t.Fatalf("PingLatency = %v, want > 0", stats.PingLatency)
+	}
+	if stats.Pool.OpenConnections < 1 {
+		t.Fatalf("OpenConnections = %d, want at least 1 after a ping", stats.Pool.OpenConnections)
+	}
+}
+
+func TestPGStore_MultiSig(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	// 1-of-2 bare multisig, stored in compact form (without OP_CHECKMULTISIG)
+	full := append(append(append(append([]byte{0x51, 0x21}, bytesOf(0x02, 33)...), 0x21), bytesOf(0x03, 33)...), 0x52, 0xae)
+	kind, compact := doge.ClassifyScript(full)
+	if kind != doge.ScriptTypeMultiSig {
+		t.Fatalf("ClassifyScript = %v, want MultiSig", kind)
+	}
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		if err := tx.CreateUTXOs([]spec.UTXO{{TxID: bytesOf(0x11, 32), VOut: 0, Value: 300000000, Type: kind, Script: compact}}, 1); err != nil {
+			return err
+		}
+		return tx.SetResumePoint(bytesOf(0xaa, 32), 10)
+	}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	utxos, err := db.FindUTXOs(doge.ScriptTypeMultiSig, compact)
+	if err != nil {
+		t.Fatalf("FindUTXOs: %v", err)
+	}
+	if len(utxos) != 1 || utxos[0].Type != doge.ScriptTypeMultiSig || !bytes.Equal(utxos[0].Script, compact) {
+		t.Fatalf("FindUTXOs = %+v, want the multisig UTXO", utxos)
+	}
+	bal, err := db.GetBalance(doge.ScriptTypeMultiSig, compact, 6)
+	if err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	if !bal.Available.Equal(amount(300000000)) {
+		t.Fatalf("Available = %s, want 3", bal.Available.String())
+	}
+	kind, script, err := db.FindScriptByHash(spec.ScriptHash(doge.ScriptTypeMultiSig, compact))
+	if err != nil || kind != doge.ScriptTypeMultiSig || !bytes.Equal(script, compact) {
+		t.Fatalf("FindScriptByHash = %v %x %v, want the compact multisig script", kind, script, err)
+	}
+}
+
+func TestPGStore_GetMaxIndexedHeight(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	height, err := db.GetMaxIndexedHeight()
+	if err != nil || height != 0 {
+		t.Fatalf("GetMaxIndexedHeight on empty store = %d, %v; want 0", height, err)
+	}
+
+	// imported data: transactions but no resume point
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		if err := tx.CreateUTXOs([]spec.UTXO{{TxID: bytesOf(0x01, 32), VOut: 0, Value: 100, Type: doge.ScriptTypeP2PKH, Script: bytesOf(0x02, 20)}}, 120); err != nil {
+			return err
+		}
+		return tx.CreateUTXOs([]spec.UTXO{{TxID: bytesOf(0x03, 32), VOut: 0, Value: 100, Type: doge.ScriptTypeP2PKH, Script: bytesOf(0x02, 20)}}, 150)
+	}); err != nil {
+		t.Fatalf("CreateUTXOs: %v", err)
+	}
+	resume, err := db.GetResumePoint()
+	if err != nil || len(resume) != 0 {
+		t.Fatalf("GetResumePoint = %x, %v; want none", resume, err)
+	}
+	height, err = db.GetMaxIndexedHeight()
+	if err != nil || height != 150 {
+		t.Fatalf("GetMaxIndexedHeight = %d, %v; want 150", height, err)
+	}
+}
+
+func TestPGStore_BalanceWithoutResumePoint(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	// UTXOs without a resume point, e.g. imported before indexing starts
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x76, 20)
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		if err := tx.CreateUTXOs([]spec.UTXO{
+			{TxID: bytesOf(0xD1, 32), VOut: 0, Value: 1000, Type: kind, Script: addr},
+			{TxID: bytesOf(0xD1, 32), VOut: 1, Value: 2000, Type: kind, Script: addr},
+		}, 100); err != nil {
+			return err
+		}
+		return tx.RemoveUTXOs([]spec.OutPointKey{spec.OutPoint(bytesOf(0xD1, 32), 1)}, 105)
+	}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if height, err := db.GetCurrentHeight(); err != nil || height != 0 {
+		t.Fatalf("GetCurrentHeight = %d, %v; want 0 (no resume point)", height, err)
+	}
+
+	// the head is height 0, so nothing has any confirmations yet
+	for _, confirmations := range []int64{0, 6} {
+		bal, err := db.GetBalance(kind, addr, confirmations)
+		if err != nil {
+			t.Fatalf("GetBalance: %v", err)
+		}
+		if !bal.Available.Equal(amount(0)) || !bal.Incoming.Equal(amount(1000)) || !bal.Outgoing.Equal(amount(2000)) || bal.UTXOCount != 1 {
+			t.Errorf("confirmations=%d: GetBalance = {A:%s I:%s O:%s N:%d}; want {A:0 I:1000 O:2000 N:1}",
+				confirmations, bal.Available, bal.Incoming, bal.Outgoing, bal.UTXOCount)
+		}
+	}
+	if outgoing, err := db.FindOutgoing(kind, addr, 6); err != nil || len(outgoing) != 1 {
+		t.Errorf("FindOutgoing = %d UTXOs, %v; want 1", len(outgoing), err)
+	}
+
+	// once indexing sets a resume point, the UTXOs confirm as usual
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.SetResumePoint(bytesOf(0x12, 32), 120)
+	}); err != nil {
+		t.Fatalf("SetResumePoint: %v", err)
+	}
+	bal, err := db.GetBalance(kind, addr, 6)
+	if err != nil || !bal.Available.Equal(amount(1000)) || !bal.Incoming.Equal(amount(0)) || !bal.Outgoing.Equal(amount(0)) {
+		t.Errorf("GetBalance = {A:%s I:%s O:%s}, %v; want {A:1000 I:0 O:0}", bal.Available, bal.Incoming, bal.Outgoing, err)
+	}
+}
+
+func TestPGStore_BalanceUTXOCount(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x73, 20)
+	utxos := []spec.UTXO{
+		{TxID: bytesOf(0xC1, 32), VOut: 0, Value: 1000, Type: kind, Script: addr},
+		{TxID: bytesOf(0xC1, 32), VOut: 1, Value: 2000, Type: kind, Script: addr},
+		{TxID: bytesOf(0xC2, 32), VOut: 0, Value: 3000, Type: kind, Script: addr},
+		{TxID: bytesOf(0xC2, 32), VOut: 1, Value: 4000, Type: doge.ScriptTypeP2SH, Script: addr}, // another kind
+		{TxID: bytesOf(0xC3, 32), VOut: 0, Value: 5000, Type: kind, Script: bytesOf(0x74, 20)},   // another address
+	}
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		if err := tx.CreateUTXOs(utxos[:2], 100); err != nil {
+			return err
+		}
+		if err := tx.CreateUTXOs(utxos[2:], 110); err != nil { // incoming at 6 confirmations
+			return err
+		}
+		if err := tx.RemoveUTXOs([]spec.OutPointKey{spec.OutPoint(utxos[1].TxID, 1)}, 111); err != nil {
+			return err
+		}
+		return tx.SetResumePoint(bytesOf(0x11, 32), 112)
+	}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	for _, confirmations := range []int64{0, 6} {
+		bal, err := db.GetBalance(kind, addr, confirmations)
+		if err != nil {
+			t.Fatalf("GetBalance: %v", err)
+		}
+		// the count doesn't depend on confirmations: available and incoming UTXOs
+		if bal.UTXOCount != 2 {
+			t.Errorf("confirmations=%d: expected 2 unspent UTXOs, got %d", confirmations, bal.UTXOCount)
+		}
+	}
+	bal, err := db.GetBalance(kind, bytesOf(0x75, 20), 6)
+	if err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	if bal.UTXOCount != 0 {
+		t.Errorf("never paid: expected 0 UTXOs, got %d", bal.UTXOCount)
+	}
+}
+
+func TestPGStore_GetBalanceAtHeight(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x8E, 20)
+	other := bytesOf(0x8F, 20)
+	// created at 100: 1000 (spent at 120), 2000 (spent at 140)
+	// created at 110: 4000 (unspent), and 500 to another address
+	// created at 130: 8000 (spent at 130, in the same block)
+	create := map[int64][]spec.UTXO{
+		100: {{TxID: bytesOf(0xA1, 32), VOut: 0, Value: 1000, Type: kind, Script: addr}, {TxID: bytesOf(0xA1, 32), VOut: 1, Value: 2000, Type: kind, Script: addr}},
+		110: {{TxID: bytesOf(0xA2, 32), VOut: 0, Value: 4000, Type: kind, Script: addr}, {TxID: bytesOf(0xA2, 32), VOut: 1, Value: 500, Type: kind, Script: other}},
+		130: {{TxID: bytesOf(0xA3, 32), VOut: 0, Value: 8000, Type: kind, Script: addr}},
+	}
+	spend := map[int64][]spec.OutPointKey{
+		120: {spec.OutPoint(bytesOf(0xA1, 32), 0)},
+		130: {spec.OutPoint(bytesOf(0xA3, 32), 0)},
+		140: {spec.OutPoint(bytesOf(0xA1, 32), 1)},
+	}
+	for height := int64(100); height <= 150; height++ {
+		if err := db.Transact(func(tx spec.StoreTx) error {
+			if err := tx.CreateUTXOs(create[height], height); err != nil {
+				return err
+			}
+			if err := tx.RemoveUTXOs(spend[height], height); err != nil {
+				return err
+			}
+			return tx.SetResumePoint(bytesOf(0xDD, 32), height)
+		}); err != nil {
+			t.Fatalf("block %d: %v", height, err)
+		}
+	}
+
+	for _, tc := range []struct {
+		height int64
+		want   int64
+	}{
+		{99, 0},
+		{100, 3000},
+		{110, 7000},
+		{119, 7000},
+		{120, 6000}, // spent in block 120: no longer counted after it
+		{130, 6000}, // created and spent in block 130
+		{139, 6000},
+		{140, 4000},
+		{150, 4000},
+	} {
+		bal, err := db.GetBalanceAtHeight(kind, addr, tc.height)
+		if err != nil {
+			t.Fatalf("GetBalanceAtHeight(%d): %v", tc.height, err)
+		}
+		if !bal.Equal(amount(tc.want)) {
+			t.Fatalf("GetBalanceAtHeight(%d) = %s, want %d koinu", tc.height, bal, tc.want)
+		}
+	}
+}
+
+func TestPGStore_WithCtx_CancelsQueries(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x7A, 20)
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		if err := tx.CreateUTXOs([]spec.UTXO{{TxID: bytesOf(0x7B, 32), VOut: 0, Value: 1000, Type: kind, Script: addr}}, 1); err != nil {
+			return err
+		}
+		return tx.SetResumePoint(bytesOf(0xDD, 32), 10)
+	}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bound := db.WithCtx(ctx)
+	if _, err := bound.GetBalance(kind, addr, 6); err != nil {
+		t.Fatalf("GetBalance before cancel: %v", err)
+	}
+	cancel()
+	if _, err := bound.GetBalance(kind, addr, 6); !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetBalance after cancel = %v, want %v", err, context.Canceled)
+	}
+	if _, err := bound.FindUTXOs(kind, addr); !errors.Is(err, context.Canceled) {
+		t.Fatalf("FindUTXOs after cancel = %v, want %v", err, context.Canceled)
+	}
+	// the store itself is unaffected
+	if _, err := db.GetBalance(kind, addr, 6); err != nil {
+		t.Fatalf("GetBalance on the unbound store: %v", err)
+	}
+}
+
+func TestPGStore_UTXOEvents(t *testing.T) {
+	db, err := idxstore.NewIndexStore(":memory:", context.Background(), idxstore.Options{RecordChanges: true})
+	if err != nil {
+		t.Fatalf("NewIndexStore: %v", err)
+	}
+
+	kind := doge.ScriptTypeP2PKH
+	a := spec.UTXO{TxID: bytesOf(0xE1, 32), VOut: 0, Value: 1000, Type: kind, Script: bytesOf(0x01, 20)}
+	b := spec.UTXO{TxID: bytesOf(0xE2, 32), VOut: 0, Value: 2000, Type: kind, Script: bytesOf(0x02, 20)}
+	block := func(height int64, create []spec.UTXO, spend []spec.UTXO) {
+		t.Helper()
+		if err := db.Transact(func(tx spec.StoreTx) error {
+			var remove []spec.OutPointKey
+			for _, u := range spend {
+				remove = append(remove, spec.OutPoint(u.TxID, u.VOut))
+			}
+			if err := tx.RemoveUTXOs(remove, height); err != nil {
+				return err
+			}
+			return tx.CreateUTXOs(create, height)
+		}); err != nil {
+			t.Fatalf("block %d: %v", height, err)
+		}
+	}
+	type event struct {
+		op     spec.UTXOEventOp
+		height int64
+		txid   []byte
+	}
+	expect := func(since int64, limit int, want []event) []spec.UTXOEvent {
+		t.Helper()
+		events, err := db.GetUTXOEvents(since, limit)
+		if err != nil {
+			t.Fatalf("GetUTXOEvents: %v", err)
+		}
+		if len(events) != len(want) {
+			t.Fatalf("GetUTXOEvents(%d) = %d events, want %d: %+v", since, len(events), len(want), events)
+		}
+		for n, w := range want {
+			e := events[n]
+			if e.Op != w.op || e.Height != w.height || !bytes.Equal(e.TxID, w.txid) {
+				t.Fatalf("event %d = %v at %d tx %x, want %v at %d tx %x", n, e.Op, e.Height, e.TxID, w.op, w.height, w.txid)
+			}
+			if n > 0 && e.Seq <= events[n-1].Seq {
+				t.Fatalf("event %d seq %d does not increase", n, e.Seq)
+			}
+		}
+		return events
+	}
+
+	// forward: create a and b, then spend a
+	block(100, []spec.UTXO{a}, nil)
+	block(101, []spec.UTXO{b}, nil)
+	block(102, nil, []spec.UTXO{a})
+	forward := expect(0, 100, []event{
+		{spec.EventCreate, 100, a.TxID},
+		{spec.EventCreate, 101, b.TxID},
+		{spec.EventSpend, 102, a.TxID},
+	})
+	if forward[2].Value != 1000 || !bytes.Equal(forward[2].Script, a.Script) {
+		t.Fatalf("spend event = %+v, want a's value and script", forward[2])
+	}
+	// a consumer resuming after the first event
+	expect(forward[0].Seq, 1, []event{{spec.EventCreate, 101, b.TxID}})
+
+	// replaying a block doesn't repeat its events
+	block(102, nil, []spec.UTXO{a})
+	expect(forward[2].Seq, 100, nil)
+
+	// reorg: undo 101 and 102, latest first
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.UndoAbove(100)
+	}); err != nil {
+		t.Fatalf("UndoAbove: %v", err)
+	}
+	expect(forward[2].Seq, 100, []event{
+		{spec.EventUndoSpend, 102, a.TxID},
+		{spec.EventUndoCreate, 101, b.TxID},
+	})
+}
+
+func TestPGStore_LargeVOut(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x5C, 20)
+	// above the int32 range of a Postgres INTEGER column
+	vouts := []uint32{1<<31 + 5, math.MaxUint32}
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		var utxos []spec.UTXO
+		for _, vout := range vouts {
+			utxos = append(utxos, spec.UTXO{TxID: bytesOf(0x5D, 32), VOut: vout, Value: 1000, Type: kind, Script: addr})
+		}
+		return tx.CreateUTXOs(utxos, 10)
+	}); err != nil {
+		t.Fatalf("CreateUTXOs: %v", err)
+	}
+
+	found, err := db.FindUTXOs(kind, addr)
+	if err != nil {
+		t.Fatalf("FindUTXOs: %v", err)
+	}
+	got := map[uint32]bool{}
+	for _, u := range found {
+		got[u.VOut] = true
+	}
+	if len(got) != len(vouts) || !got[vouts[0]] || !got[vouts[1]] {
+		t.Fatalf("FindUTXOs vouts = %v, want %v", got, vouts)
+	}
+
+	// spend by outpoint with the large vout
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.RemoveUTXOs([]spec.OutPointKey{spec.OutPoint(bytesOf(0x5D, 32), math.MaxUint32)}, 11)
+	}); err != nil {
+		t.Fatalf("RemoveUTXOs: %v", err)
+	}
+	found, err = db.FindUTXOs(kind, addr)
+	if err != nil {
+		t.Fatalf("FindUTXOs: %v", err)
+	}
+	if len(found) != 1 || found[0].VOut != vouts[0] {
+		t.Fatalf("FindUTXOs after spend = %+v, want only vout %d", found, vouts[0])
+	}
+}
+
+func TestPGStore_FindUTXOsOrder(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x9A, 20)
+	utxo := func(tx byte, vout uint32, value int64) spec.UTXO {
+		return spec.UTXO{TxID: bytesOf(tx, 32), VOut: vout, Value: value, Type: kind, Script: addr}
+	}
+	// created out of order: heights 30, 10, 20 and outputs 2, 0, 1
+	steps := []struct {
+		height int64
+		create []spec.UTXO
+	}{
+		{30, []spec.UTXO{utxo(0xE1, 2, 6), utxo(0xE1, 0, 4), utxo(0xE1, 1, 5)}},
+		{10, []spec.UTXO{utxo(0xF1, 5, 1)}},
+		{20, []spec.UTXO{utxo(0xA2, 1, 2), utxo(0xB2, 0, 3)}},
+	}
+	for _, step := range steps {
+		if err := db.Transact(func(tx spec.StoreTx) error {
+			return tx.CreateUTXOs(step.create, step.height)
+		}); err != nil {
+			t.Fatalf("height %d: %v", step.height, err)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		utxos, err := db.FindUTXOs(kind, addr)
+		if err != nil {
+			t.Fatalf("FindUTXOs: %v", err)
+		}
+		if len(utxos) != 6 {
+			t.Fatalf("FindUTXOs returned %d UTXOs, want 6", len(utxos))
+		}
+		for n, u := range utxos {
+			if u.Value != int64(n+1) {
+				t.Fatalf("FindUTXOs order: position %d has value %d (vout %d), want %d", n, u.Value, u.VOut, n+1)
+			}
+		}
+	}
+
+	// a limit keeps the first UTXOs in the same order
+	utxos, err := db.FindUTXOsFiltered(kind, addr, spec.UTXOFilter{Limit: 4})
+	if err != nil {
+		t.Fatalf("FindUTXOsFiltered: %v", err)
+	}
+	if len(utxos) != 4 || utxos[0].Value != 1 || utxos[3].Value != 4 {
+		t.Fatalf("FindUTXOsFiltered(Limit 4) = %+v, want the first 4", utxos)
+	}
+}
+
+func TestPGStore_GetKindBalances(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	hash := bytesOf(0x5B, 20)
+	other := bytesOf(0x5C, 20)
+	utxos := []spec.UTXO{
+		{TxID: bytesOf(0xD1, 32), VOut: 0, Value: 1000, Type: doge.ScriptTypeP2PKH, Script: hash},
+		{TxID: bytesOf(0xD1, 32), VOut: 1, Value: 2000, Type: doge.ScriptTypeP2PKH, Script: hash},
+		{TxID: bytesOf(0xD2, 32), VOut: 0, Value: 4000, Type: doge.ScriptTypeP2PKHW, Script: hash},
+		{TxID: bytesOf(0xD2, 32), VOut: 1, Value: 8000, Type: doge.ScriptTypeP2SH, Script: hash},
+		{TxID: bytesOf(0xD3, 32), VOut: 0, Value: 16000, Type: doge.ScriptTypeP2SH, Script: hash},   // spent
+		{TxID: bytesOf(0xD3, 32), VOut: 1, Value: 32000, Type: doge.ScriptTypeP2PKH, Script: other}, // other hash
+	}
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		if err := tx.CreateUTXOs(utxos, 10); err != nil {
+			return err
+		}
+		return tx.RemoveUTXOs([]spec.OutPointKey{spec.OutPoint(utxos[4].TxID, 0)}, 11)
+	}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	kinds, err := db.GetKindBalances(hash)
+	if err != nil {
+		t.Fatalf("GetKindBalances: %v", err)
+	}
+	want := []spec.KindBalance{
+		{Kind: doge.ScriptTypeP2PKH, Count: 2, Balance: amount(3000)},
+		{Kind: doge.ScriptTypeP2SH, Count: 1, Balance: amount(8000)},
+		{Kind: doge.ScriptTypeP2PKHW, Count: 1, Balance: amount(4000)},
+	}
+	if len(kinds) != len(want) {
+		t.Fatalf("GetKindBalances = %+v, want %+v", kinds, want)
+	}
+	for i, kb := range kinds {
+		if kb.Kind != want[i].Kind || kb.Count != want[i].Count || !kb.Balance.Equal(want[i].Balance) {
+			t.Fatalf("GetKindBalances[%d] = %v %d %s, want %v %d %s", i, kb.Kind, kb.Count, kb.Balance, want[i].Kind, want[i].Count, want[i].Balance)
+		}
+	}
+
+	if kinds, err := db.GetKindBalances(bytesOf(0x5D, 20)); err != nil || len(kinds) != 0 {
+		t.Fatalf("GetKindBalances(never paid) = %+v, %v; want none", kinds, err)
+	}
+}
+
+func TestPGStore_ReadOnly(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "index.db")
+	if _, err := idxstore.NewIndexStore(path, ctx, idxstore.Options{ReadOnly: true}); err == nil {
+		t.Fatalf("expected an error opening a missing database read-only")
+	}
+
+	db, err := idxstore.NewIndexStore(path, ctx, idxstore.Options{})
+	if err != nil {
+		t.Fatalf("NewIndexStore: %v", err)
+	}
+	utxo := spec.UTXO{TxID: bytesOf(0xE3, 32), Value: 1000, Type: doge.ScriptTypeP2PKH, Script: bytesOf(0x63, 20)}
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.CreateUTXOs([]spec.UTXO{utxo}, 10)
+	}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	db.Close()
+
+	ro, err := idxstore.NewIndexStore(path, ctx, idxstore.Options{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("NewIndexStore(ReadOnly): %v", err)
+	}
+	defer ro.Close()
+	if unspent, err := ro.GetUnspentCount(); err != nil || unspent != 1 {
+		t.Fatalf("GetUnspentCount = %d, %v; want 1", unspent, err)
+	}
+	if err := ro.Transact(func(tx spec.StoreTx) error {
+		return tx.TrimSpentUTXOs(10)
+	}); err == nil {
+		t.Fatalf("expected a write to a read-only store to fail")
+	}
+}
+
+func TestPGStore_GetUTXOAgeHistogram(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x64, 20)
+	// confirmations at resume height 200: 0, 1, 6, 7, 99, 100, 151
+	heights := map[int64]int64{201: 1, 200: 2, 195: 4, 194: 8, 102: 16, 101: 32, 50: 64}
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		n := byte(0)
+		for height, value := range heights {
+			n++
+			if err := tx.CreateUTXOs([]spec.UTXO{{TxID: bytesOf(n, 32), Value: value, Type: kind, Script: addr}}, height); err != nil {
+				return err
+			}
+		}
+		spent := spec.UTXO{TxID: bytesOf(0xE4, 32), Value: 128, Type: kind, Script: addr}
+		other := spec.UTXO{TxID: bytesOf(0xE5, 32), Value: 256, Type: kind, Script: bytesOf(0x65, 20)}
+		if err := tx.CreateUTXOs([]spec.UTXO{spent, other}, 150); err != nil {
+			return err
+		}
+		if err := tx.RemoveUTXOs([]spec.OutPointKey{spec.OutPoint(spent.TxID, 0)}, 160); err != nil {
+			return err
+		}
+		return tx.SetResumePoint(bytesOf(0x02, 32), 200)
+	}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	buckets, err := db.GetUTXOAgeHistogram(kind, addr)
+	if err != nil {
+		t.Fatalf("GetUTXOAgeHistogram: %v", err)
+	}
+	want := []spec.UTXOAgeBucket{
+		{MinConf: 0, Count: 1, Value: amount(1)},
+		{MinConf: 1, Count: 2, Value: amount(6)},
+		{MinConf: 7, Count: 2, Value: amount(24)},
+		{MinConf: 100, Count: 2, Value: amount(96)},
+	}
+	if len(buckets) != len(want) {
+		t.Fatalf("GetUTXOAgeHistogram = %+v, want %+v", buckets, want)
+	}
+	for n, b := range buckets {
+		if b.MinConf != want[n].MinConf || b.Count != want[n].Count || !b.Value.Equal(want[n].Value) {
+			t.Fatalf("bucket %d = %d %d %s, want %d %d %s", n, b.MinConf, b.Count, b.Value, want[n].MinConf, want[n].Count, want[n].Value)
+		}
+	}
+
+	buckets, err = db.GetUTXOAgeHistogram(kind, bytesOf(0x66, 20))
+	if err != nil || len(buckets) != len(want) || buckets[3].MinConf != 100 || buckets[3].Count != 0 || !buckets[3].Value.Equal(amount(0)) {
+		t.Fatalf("GetUTXOAgeHistogram(never paid) = %+v, %v; want empty buckets", buckets, err)
+	}
+}
+
+func TestPGStore_SampleAddresses(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	if sample, err := db.SampleAddresses(10); err != nil || len(sample) != 0 {
+		t.Fatalf("SampleAddresses on an empty index = %v, %v; want none", sample, err)
+	}
+	utxos := []spec.UTXO{
+		{TxID: bytesOf(0xE1, 32), VOut: 0, Value: 1000, Type: doge.ScriptTypeP2PKH, Script: bytesOf(0x61, 20)},
+		{TxID: bytesOf(0xE1, 32), VOut: 1, Value: 2000, Type: doge.ScriptTypeP2PKH, Script: bytesOf(0x61, 20)}, // same address
+		{TxID: bytesOf(0xE1, 32), VOut: 2, Value: 4000, Type: doge.ScriptTypeP2SH, Script: bytesOf(0x61, 20)},  // other kind
+		{TxID: bytesOf(0xE2, 32), VOut: 0, Value: 8000, Type: doge.ScriptTypeP2PKH, Script: bytesOf(0x62, 20)}, // spent
+	}
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		if err := tx.CreateUTXOs(utxos, 10); err != nil {
+			return err
+		}
+		return tx.RemoveUTXOs([]spec.OutPointKey{spec.OutPoint(utxos[3].TxID, 0)}, 11)
+	}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	sample, err := db.SampleAddresses(10)
+	if err != nil {
+		t.Fatalf("SampleAddresses: %v", err)
+	}
+	found := map[string]bool{}
+	for _, key := range sample {
+		found[fmt.Sprintf("%v %x", key.Kind, key.Script)] = true
+	}
+	want := map[string]bool{
+		fmt.Sprintf("%v %x", doge.ScriptTypeP2PKH, bytesOf(0x61, 20)): true,
+		fmt.Sprintf("%v %x", doge.ScriptTypeP2SH, bytesOf(0x61, 20)):  true,
+	}
+	if len(sample) != len(want) || !reflect.DeepEqual(found, want) {
+		t.Fatalf("SampleAddresses = %v, want %v", found, want)
+	}
+
+	if sample, err := db.SampleAddresses(1); err != nil || len(sample) != 1 {
+		t.Fatalf("SampleAddresses(1) = %d addresses, %v; want 1", len(sample), err)
+	}
+
+	// with more addresses than asked for, the sample stops at the limit
+	var more []spec.UTXO
+	for n := 0; n < 50; n++ {
+		more = append(more, spec.UTXO{TxID: bytesOf(byte(n), 32), VOut: 0, Value: 1000, Type: doge.ScriptTypeP2PKH, Script: bytesOf(byte(0x80+n), 20)})
+	}
+	if err := db.Transact(func(tx spec.StoreTx) error { return tx.CreateUTXOs(more, 12) }); err != nil {
+		t.Fatalf("CreateUTXOs: %v", err)
+	}
+	sample, err = db.SampleAddresses(5)
+	if err != nil || len(sample) != 5 {
+		t.Fatalf("SampleAddresses(5) = %d addresses, %v; want 5", len(sample), err)
+	}
+	distinct := map[string]bool{}
+	for _, key := range sample {
+		distinct[fmt.Sprintf("%v %x", key.Kind, key.Script)] = true
+	}
+	if len(distinct) != 5 {
+		t.Fatalf("SampleAddresses(5) returned duplicates: %v", sample)
+	}
+}
+
+func TestPGStore_GetTxHeight(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	if total, err := db.GetTxTotal(); err != nil || total != 0 {
+		t.Fatalf("GetTxTotal(empty) = %d, %v; want 0", total, err)
+	}
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x6A, 20)
+	for height, tx := range map[int64]byte{100: 0xF1, 105: 0xF2} {
+		if err := db.Transact(func(stx spec.StoreTx) error {
+			return stx.CreateUTXOs([]spec.UTXO{
+				{TxID: bytesOf(tx, 32), VOut: 0, Value: 1000, Type: kind, Script: addr},
+				{TxID: bytesOf(tx, 32), VOut: 1, Value: 2000, Type: kind, Script: addr},
+			}, height)
+		}); err != nil {
+			t.Fatalf("CreateUTXOs: %v", err)
+		}
+	}
+
+	if height, err := db.GetTxHeight(bytesOf(0xF2, 32)); err != nil || height != 105 {
+		t.Fatalf("GetTxHeight = %d, %v; want 105", height, err)
+	}
+	if _, err := db.GetTxHeight(bytesOf(0xF3, 32)); !errors.Is(err, spec.ErrNotFound) {
+		t.Fatalf("GetTxHeight(absent) error = %v, want ErrNotFound", err)
+	}
+	if total, err := db.GetTxTotal(); err != nil || total != 2 {
+		t.Fatalf("GetTxTotal = %d, %v; want 2", total, err)
+	}
+}
+
+func TestPGStore_GetConfirmations(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	tx := bytesOf(0xF4, 32)
+	if err := db.Transact(func(stx spec.StoreTx) error {
+		err := stx.CreateUTXOs([]spec.UTXO{{TxID: tx, VOut: 0, Value: 1000, Type: doge.ScriptTypeP2PKH, Script: bytesOf(0x6B, 20)}}, 100)
+		if err != nil {
+			return err
+		}
+		return stx.SetResumePoint(bytesOf(0x01, 32), 105)
+	}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	if height, confirmations, err := db.GetConfirmations(tx); err != nil || height != 100 || confirmations != 6 {
+		t.Fatalf("GetConfirmations = %d, %d, %v; want 100, 6", height, confirmations, err)
+	}
+	if _, confirmations, err := db.GetConfirmations(bytesOf(0xF5, 32)); !errors.Is(err, spec.ErrNotFound) || confirmations != 0 {
+		t.Fatalf("GetConfirmations(unknown) = %d, %v; want 0, ErrNotFound", confirmations, err)
+	}
+}
+
+func TestPGStore_UnspentCount(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	kind := doge.ScriptTypeP2PKH
+	addr := bytesOf(0x7C, 20)
+	utxoA0 := spec.UTXO{TxID: bytesOf(0xA1, 32), VOut: 0, Value: 1000, Type: kind, Script: addr}
+	utxoA1 := spec.UTXO{TxID: bytesOf(0xA1, 32), VOut: 1, Value: 2000, Type: kind, Script: addr}
+	utxoB := spec.UTXO{TxID: bytesOf(0xB1, 32), VOut: 0, Value: 3000, Type: kind, Script: addr}
+	utxoC := spec.UTXO{TxID: bytesOf(0xC1, 32), VOut: 0, Value: 4000, Type: kind, Script: addr}
+	utxoD := spec.UTXO{TxID: bytesOf(0xD1, 32), VOut: 0, Value: 5000, Type: kind, Script: addr}
+	spend := func(u spec.UTXO) []spec.OutPointKey { return []spec.OutPointKey{spec.OutPoint(u.TxID, u.VOut)} }
+
+	steps := []struct {
+		name     string
+		apply    func(tx spec.StoreTx) error
+		expected int64
+	}{
+		{"create", func(tx spec.StoreTx) error { return tx.CreateUTXOs([]spec.UTXO{utxoA0, utxoA1, utxoB}, 100) }, 3},
+		{"replay create", func(tx spec.StoreTx) error { return tx.CreateUTXOs([]spec.UTXO{utxoA0, utxoA1, utxoB}, 100) }, 3},
+		{"spend", func(tx spec.StoreTx) error { return tx.RemoveUTXOs(spend(utxoA0), 101) }, 2},
+		{"replay spend", func(tx spec.StoreTx) error { return tx.RemoveUTXOs(spend(utxoA0), 101) }, 2},
+		{"spend unknown", func(tx spec.StoreTx) error { return tx.RemoveUTXOs(spend(utxoC), 101) }, 2},
+		{"create and spend", func(tx spec.StoreTx) error {
+			if err := tx.CreateUTXOs([]spec.UTXO{utxoC, utxoD}, 102); err != nil {
+				return err
+			}
+			return tx.RemoveUTXOs(append(spend(utxoB), spend(utxoD)...), 103)
+		}, 2},
+		{"undo", func(tx spec.StoreTx) error { return tx.UndoAbove(101) }, 2}, // B unspent again; C and D gone
+		{"trim", func(tx spec.StoreTx) error { return tx.TrimSpentUTXOs(102) }, 2},
+		{"spend after trim", func(tx spec.StoreTx) error { return tx.RemoveUTXOs(spend(utxoA1), 104) }, 1},
+		{"clear", func(tx spec.StoreTx) error { return tx.ClearIndex() }, 0},
+	}
+	for _, step := range steps {
+		if err := db.Transact(step.apply); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		count, err := db.GetUnspentCount()
+		if err != nil {
+			t.Fatalf("%s: GetUnspentCount: %v", step.name, err)
+		}
+		var recount int64
+		if err := db.(*idxstore.IndexStore).RawDB.QueryRow(`SELECT COUNT(*) FROM utxo WHERE spent IS NULL`).Scan(&recount); err != nil {
+			t.Fatalf("%s: COUNT(*): %v", step.name, err)
+		}
+		if count != recount || count != step.expected {
+			t.Fatalf("%s: GetUnspentCount = %d, COUNT(*) = %d, want %d", step.name, count, recount, step.expected)
+		}
+	}
+}
+
+// manyUTXOs makes `txs` transactions with `outputs` UTXOs each, paying `addr`.
+func manyUTXOs(txs int, outputs int, addr []byte) []spec.UTXO {
+	var res []spec.UTXO
+	for i := 0; i < txs; i++ {
+		txID := bytesOf(0, 32)
+		txID[0], txID[1] = byte(i>>8), byte(i)
+		for vout := 0; vout < outputs; vout++ {
+			res = append(res, spec.UTXO{TxID: txID, VOut: uint32(vout), Value: 1000, Type: doge.ScriptTypeP2PKH, Script: addr})
+		}
+	}
+	return res
+}
+
+func TestPGStore_RemoveUTXOs_ManyInputs(t *testing.T) {
+	db, err := idxstore.NewIndexStore(":memory:", context.Background(), idxstore.Options{RecordChanges: true})
+	if err != nil {
+		t.Fatalf("NewIndexStore: %v", err)
+	}
+	defer db.Close()
+
+	addr := bytesOf(0x6B, 20)
+	utxos := manyUTXOs(300, 4, addr) // 1200 UTXOs, several batches
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		if err := tx.CreateUTXOs(utxos, 100); err != nil {
+			return err
+		}
+		return tx.SetResumePoint(bytesOf(0xEE, 32), 200)
+	}); err != nil {
+		t.Fatalf("CreateUTXOs: %v", err)
+	}
+
+	// spend the first three outputs of every tx (inputs sharing a prior tx),
+	// plus outputs that were never indexed
+	var inputs []spec.OutPointKey
+	for _, u := range utxos {
+		if u.VOut < 3 {
+			inputs = append(inputs, spec.OutPoint(u.TxID, u.VOut))
+		}
+	}
+	inputs = append(inputs, spec.OutPoint(utxos[0].TxID, 9), spec.OutPoint(bytesOf(0xF0, 32), 0))
+	for i := 0; i < 2; i++ { // the second time replays the block
+		if err := db.Transact(func(tx spec.StoreTx) error {
+			return tx.RemoveUTXOs(inputs, 150)
+		}); err != nil {
+			t.Fatalf("RemoveUTXOs: %v", err)
+		}
+	}
+
+	remaining, err := db.FindUTXOs(doge.ScriptTypeP2PKH, addr)
+	if err != nil {
+		t.Fatalf("FindUTXOs: %v", err)
+	}
+	if len(remaining) != 300 {
+		t.Fatalf("expected 300 unspent UTXOs, got %d", len(remaining))
+	}
+	for _, u := range remaining {
+		if u.VOut != 3 {
+			t.Fatalf("expected only vout 3 unspent, got vout %d", u.VOut)
+		}
+	}
+	if count, err := db.GetUnspentCount(); err != nil || count != 300 {
+		t.Fatalf("GetUnspentCount = %d, %v; want 300", count, err)
+	}
+	balance, err := db.GetBalance(doge.ScriptTypeP2PKH, addr, 6)
+	if err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	if !balance.Available.Equal(amount(300 * 1000)) {
+		t.Errorf("expected available %d, got %s", 300*1000, balance.Available)
+	}
+	diff, err := db.GetUTXODiff(150, 150)
+	if err != nil {
+		t.Fatalf("GetUTXODiff: %v", err)
+	}
+	if len(diff.Spent) != 900 {
+		t.Errorf("expected 900 UTXOs spent at 150, got %d", len(diff.Spent))
+	}
+	events, err := db.GetUTXOEvents(0, 5000)
+	if err != nil {
+		t.Fatalf("GetUTXOEvents: %v", err)
+	}
+	if len(events) != 1200+900 {
+		t.Fatalf("expected %d events, got %d", 1200+900, len(events))
+	}
+	for n, e := range events[1200:] { // spend events in input order
+		if e.Op != spec.EventSpend || !bytes.Equal(e.TxID, inputs[n].Tx) || e.VOut != inputs[n].VOut {
+			t.Fatalf("event %d: expected spend of %x:%d, got %v %x:%d", n, inputs[n].Tx, inputs[n].VOut, e.Op, e.TxID, e.VOut)
+		}
+	}
+}
+
+func TestPGStore_OrphanSpends(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	utxoA := spec.UTXO{TxID: bytesOf(0xA9, 32), VOut: 0, Value: 1000, Type: doge.ScriptTypeP2PKH, Script: bytesOf(0x2E, 20)}
+	if err := db.Transact(func(tx spec.StoreTx) error { return tx.CreateUTXOs([]spec.UTXO{utxoA}, 100) }); err != nil {
+		t.Fatalf("CreateUTXOs: %v", err)
+	}
+
+	steps := []struct {
+		name     string
+		inputs   []spec.OutPointKey
+		expected int64
+	}{
+		// funded before the starting height: never indexed
+		{"unindexed tx", []spec.OutPointKey{spec.OutPoint(bytesOf(0xF9, 32), 0)}, 1},
+		// an indexed tx's output that wasn't indexed (e.g. dust) isn't an orphan
+		{"unindexed output", []spec.OutPointKey{spec.OutPoint(utxoA.TxID, 5)}, 1},
+		{"mixed", []spec.OutPointKey{spec.OutPoint(utxoA.TxID, 0), spec.OutPoint(bytesOf(0xF9, 32), 1), spec.OutPoint(bytesOf(0xFA, 32), 0)}, 3},
+	}
+	for _, step := range steps {
+		if err := db.Transact(func(tx spec.StoreTx) error { return tx.RemoveUTXOs(step.inputs, 101) }); err != nil {
+			t.Fatalf("%s: RemoveUTXOs: %v", step.name, err)
+		}
+		orphans, err := db.GetOrphanSpends()
+		if err != nil {
+			t.Fatalf("%s: GetOrphanSpends: %v", step.name, err)
+		}
+		if orphans != step.expected {
+			t.Errorf("%s: expected %d orphan spends, got %d", step.name, step.expected, orphans)
+		}
+	}
+	if count, err := db.GetUnspentCount(); err != nil || count != 0 {
+		t.Errorf("GetUnspentCount = %d, %v; want 0 (A spent)", count, err)
+	}
+}
+
+func TestPGStore_GetUTXOs(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	utxoA := spec.UTXO{TxID: bytesOf(0xB1, 32), VOut: 0, Value: 1000, Type: doge.ScriptTypeP2PKH, Script: bytesOf(0x2F, 20)}
+	utxoB := spec.UTXO{TxID: bytesOf(0xB1, 32), VOut: 1, Value: 2000, Type: doge.ScriptTypeP2SH, Script: bytesOf(0x30, 20)}
+	utxoC := spec.UTXO{TxID: bytesOf(0xB2, 32), VOut: 0, Value: 5000, Type: doge.ScriptTypeP2PKH, Script: bytesOf(0x2F, 20), Coinbase: true}
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		if err := tx.CreateUTXOs([]spec.UTXO{utxoA, utxoB}, 100); err != nil {
+			return err
+		}
+		if err := tx.CreateUTXOs([]spec.UTXO{utxoC}, 101); err != nil {
+			return err
+		}
+		return tx.RemoveUTXOs([]spec.OutPointKey{spec.OutPoint(utxoB.TxID, 1)}, 102)
+	}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	// in request order, leaving out an unindexed tx and an unindexed output
+	res, err := db.GetUTXOs([]spec.OutPointKey{
+		spec.OutPoint(utxoC.TxID, 0),
+		spec.OutPoint(bytesOf(0xB3, 32), 0),
+		spec.OutPoint(utxoA.TxID, 1),
+		spec.OutPoint(utxoA.TxID, 7),
+		spec.OutPoint(utxoA.TxID, 0),
+	})
+	if err != nil {
+		t.Fatalf("GetUTXOs: %v", err)
+	}
+	expected := []spec.UTXOState{
+		{UTXO: utxoC, Height: 101},
+		{UTXO: utxoB, Height: 100, Spent: 102},
+		{UTXO: utxoA, Height: 100},
+	}
+	if len(res) != len(expected) {
+		t.Fatalf("expected %d UTXOs, got %d: %+v", len(expected), len(res), res)
+	}
+	for n, u := range res {
+		e := expected[n]
+		if !bytes.Equal(u.TxID, e.TxID) || u.VOut != e.VOut || u.Value != e.Value || u.Type != e.Type ||
+			!bytes.Equal(u.Script, e.Script) || u.Coinbase != e.Coinbase || u.Height != e.Height || u.Spent != e.Spent {
+			t.Errorf("%d: expected %+v, got %+v", n, e, u)
+		}
+	}
+
+	// more outpoints than one batch
+	utxos := manyUTXOs(60, 2, bytesOf(0x31, 20))
+	if err := db.Transact(func(tx spec.StoreTx) error { return tx.CreateUTXOs(utxos, 103) }); err != nil {
+		t.Fatalf("CreateUTXOs: %v", err)
+	}
+	outpoints := make([]spec.OutPointKey, len(utxos))
+	for n, u := range utxos {
+		outpoints[n] = spec.OutPoint(u.TxID, u.VOut)
+	}
+	res, err = db.GetUTXOs(outpoints)
+	if err != nil {
+		t.Fatalf("GetUTXOs: %v", err)
+	}
+	if len(res) != len(utxos) {
+		t.Fatalf("expected %d UTXOs, got %d", len(utxos), len(res))
+	}
+	for n, u := range res {
+		if !bytes.Equal(u.TxID, utxos[n].TxID) || u.VOut != utxos[n].VOut {
+			t.Errorf("%d: expected %x:%d, got %x:%d", n, utxos[n].TxID, utxos[n].VOut, u.TxID, u.VOut)
+		}
+	}
+}
+
+func TestPGStore_TxInputsAndOutputs(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	kind := doge.ScriptTypeP2PKH
+	txA, txB := bytesOf(0xC1, 32), bytesOf(0xC2, 32)
+	created := []spec.UTXO{
+		{TxID: txA, VOut: 0, Value: 1000, Type: kind, Script: bytesOf(0x41, 20)},
+		{TxID: txA, VOut: 1, Value: 2000, Type: kind, Script: bytesOf(0x42, 20)},
+		{TxID: txA, VOut: 2, Value: 3000, Type: kind, Script: bytesOf(0x43, 20)},
+	}
+	// txB spends an unindexed output and A:1; A:2 is spent in a block indexed without inputs
+	inputs := []spec.TxInput{
+		{TxID: txB, VIn: 0, Prev: spec.OutPoint(bytesOf(0xC0, 32), 5)},
+		{TxID: txB, VIn: 1, Prev: spec.OutPoint(txA, 1)},
+	}
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		if err := tx.CreateUTXOs(created, 100); err != nil {
+			return err
+		}
+		if err := tx.RemoveUTXOs([]spec.OutPointKey{inputs[0].Prev, inputs[1].Prev}, 101); err != nil {
+			return err
+		}
+		if err := tx.CreateTxInputs(inputs, 101); err != nil {
+			return err
+		}
+		if err := tx.CreateTxInputs(inputs, 101); err != nil { // replayed block
+			return err
+		}
+		return tx.RemoveUTXOs([]spec.OutPointKey{spec.OutPoint(txA, 2)}, 102)
+	}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	outputs, err := db.GetTxOutputs(txA)
+	if err != nil {
+		t.Fatalf("GetTxOutputs: %v", err)
+	}
+	expected := []spec.TxOutput{
+		{UTXOState: spec.UTXOState{UTXO: created[0], Height: 100}},
+		{UTXOState: spec.UTXOState{UTXO: created[1], Height: 100, Spent: 101}, SpentBy: txB, SpentVIn: 1},
+		{UTXOState: spec.UTXOState{UTXO: created[2], Height: 100, Spent: 102}},
+	}
+	if len(outputs) != len(expected) {
+		t.Fatalf("expected %d outputs, got %d: %+v", len(expected), len(outputs), outputs)
+	}
+	for n, out := range outputs {
+		e := expected[n]
+		if !bytes.Equal(out.TxID, e.TxID) || out.VOut != e.VOut || out.Value != e.Value || !bytes.Equal(out.Script, e.Script) ||
+			out.Height != e.Height || out.Spent != e.Spent || !bytes.Equal(out.SpentBy, e.SpentBy) || out.SpentVIn != e.SpentVIn {
+			t.Errorf("output %d: expected %+v, got %+v", n, e, out)
+		}
+	}
+
+	res, err := db.GetTxInputs(txB)
+	if err != nil {
+		t.Fatalf("GetTxInputs: %v", err)
+	}
+	if len(res) != len(inputs) {
+		t.Fatalf("expected %d inputs, got %d: %+v", len(inputs), len(res), res)
+	}
+	for n, in := range res {
+		e := inputs[n]
+		if !bytes.Equal(in.TxID, txB) || in.VIn != e.VIn || !bytes.Equal(in.Prev.Tx, e.Prev.Tx) || in.Prev.VOut != e.Prev.VOut || in.Height != 101 {
+			t.Errorf("input %d: expected %+v at 101, got %+v", n, e, in)
+		}
+	}
+
+	if outputs, err := db.GetTxOutputs(txB); err != nil || len(outputs) != 0 {
+		t.Errorf("GetTxOutputs(no indexed outputs) = %+v, %v; want none", outputs, err)
+	}
+
+	// undo removes the inputs and unspends A:1
+	if err := db.Transact(func(tx spec.StoreTx) error { return tx.UndoAbove(100) }); err != nil {
+		t.Fatalf("UndoAbove: %v", err)
+	}
+	if res, err := db.GetTxInputs(txB); err != nil || len(res) != 0 {
+		t.Errorf("GetTxInputs after undo = %+v, %v; want none", res, err)
+	}
+	outputs, err = db.GetTxOutputs(txA)
+	if err != nil {
+		t.Fatalf("GetTxOutputs after undo: %v", err)
+	}
+	if len(outputs) != 3 || outputs[1].Spent != 0 || outputs[1].SpentBy != nil {
+		t.Errorf("GetTxOutputs after undo: expected A:1 unspent, got %+v", outputs)
+	}
+}
+
+func BenchmarkPGStore_RemoveUTXOs(b *testing.B) {
+	db, err := idxstore.NewIndexStore(":memory:", context.Background(), idxstore.Options{})
+	if err != nil {
+		b.Fatalf("NewIndexStore: %v", err)
+	}
+	defer db.Close()
+
+	utxos := manyUTXOs(1000, 2, bytesOf(0x6C, 20))
+	if err := db.Transact(func(tx spec.StoreTx) error { return tx.CreateUTXOs(utxos, 100) }); err != nil {
+		b.Fatalf("CreateUTXOs: %v", err)
+	}
+	inputs := make([]spec.OutPointKey, len(utxos))
+	for n, u := range utxos {
+		inputs[n] = spec.OutPoint(u.TxID, u.VOut)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := db.Transact(func(tx spec.StoreTx) error { return tx.RemoveUTXOs(inputs, 101) }); err != nil {
+			b.Fatalf("RemoveUTXOs: %v", err)
+		}
+		b.StopTimer()
+		if err := db.Transact(func(tx spec.StoreTx) error { return tx.UndoAbove(100) }); err != nil {
+			b.Fatalf("UndoAbove: %v", err)
+		}
+		b.StartTimer()
+	}
+}
+
+func TestPGStore_GetCurrentHeight(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	// Get initial height (should be 0 for a fresh database)
+	initialHeight, err := db.GetCurrentHeight()
+	if err != nil {
+		t.Fatalf("GetCurrentHeight (initial): %v", err)
+	}
+	if initialHeight != 0 {
+		t.Fatalf("expected initial height 0, got: %d", initialHeight)
+	}
+
+	// Set height
+	hash1 := bytesOf(0xAA, 32)
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.SetResumePoint(hash1, 100)
+	}); err != nil {
+		t.Fatalf("SetResumePoint: %v", err)
+	}
+
+	height, err := db.GetCurrentHeight()
+	if err != nil {
+		t.Fatalf("GetCurrentHeight (after set): %v", err)
+	}
+	if height != 100 {
+		t.Fatalf("expected height 100, got: %d", height)
+	}
+
+	// Update height
+	hash2 := bytesOf(0xBB, 32)
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		return tx.SetResumePoint(hash2, 200)
+	}); err != nil {
+		t.Fatalf("SetResumePoint (update): %v", err)
+	}
+
+	height, err = db.GetCurrentHeight()
+	if err != nil {
+		t.Fatalf("GetCurrentHeight (after update): %v", err)
+	}
+	if height != 200 {
+		t.Fatalf("expected height 200, got: %d", height)
+	}
+}
+
+func TestPGStore_GetTip(t *testing.T) {
+	db, stop := newTestStore(t)
+	defer stop()
+
+	if height, hash, err := db.GetTip(); err != nil || height != 0 || hash != nil {
+		t.Fatalf("GetTip (initial) = %d, %x, %v; want 0, nil", height, hash, err)
+	}
+	for n, height := range []int64{100, 200} {
+		want := bytesOf(byte(0xAA+n), 32)
+		if err := db.Transact(func(tx spec.StoreTx) error {
+			return tx.SetResumePoint(want, height)
+		}); err != nil {
+			t.Fatalf("SetResumePoint: %v", err)
+		}
+		if got, hash, err := db.GetTip(); err != nil || got != height || !bytes.Equal(hash, want) {
+			t.Fatalf("GetTip = %d, %x, %v; want %d, %x", got, hash, err, height, want)
+		}
 	}
 }
 
@@ -762,7 +3017,7 @@ func TestPGStore_CachedBalanceBootstrapExistingDB(t *testing.T) {
 	dsn := postgresTestDSN(t)
 	resetPostgresTestDatabase(t, dsn)
 
-	db, err := idxstore.NewIndexStore(dsn, ctx, false)
+	db, err := idxstore.NewIndexStore(dsn, ctx, idxstore.Options{})
 	if err != nil {
 		t.Fatalf("NewIndexStore: %v", err)
 	}
@@ -798,7 +3053,7 @@ func TestPGStore_CachedBalanceBootstrapExistingDB(t *testing.T) {
 	}
 	db.Close()
 
-	db, err = idxstore.NewIndexStore(dsn, ctx, true)
+	db, err = idxstore.NewIndexStore(dsn, ctx, idxstore.Options{CacheBalances: true})
 	if err != nil {
 		t.Fatalf("NewIndexStore: %v", err)
 	}
@@ -817,7 +3072,7 @@ func TestPGStore_BalanceOverflow_UncachedAndCached(t *testing.T) {
 	dsn := postgresTestDSN(t)
 	resetPostgresTestDatabase(t, dsn)
 
-	base, err := idxstore.NewIndexStore(dsn, context.Background(), false)
+	base, err := idxstore.NewIndexStore(dsn, context.Background(), idxstore.Options{})
 	if err != nil {
 		t.Fatalf("NewIndexStore: %v", err)
 	}
@@ -850,7 +3105,7 @@ func TestPGStore_BalanceOverflow_UncachedAndCached(t *testing.T) {
 	}
 	base.Close()
 
-	fast, err := idxstore.NewIndexStore(dsn, context.Background(), true)
+	fast, err := idxstore.NewIndexStore(dsn, context.Background(), idxstore.Options{CacheBalances: true})
 	if err != nil {
 		t.Fatalf("NewIndexStore: %v", err)
 	}
@@ -877,9 +3132,9 @@ func assertBalanceParity(t *testing.T, base spec.Store, fast spec.Store, kind do
 	if err != nil {
 		t.Fatalf("fast GetBalance: %v", err)
 	}
-	if !got.Available.Equal(want.Available) || !got.Incoming.Equal(want.Incoming) || !got.Outgoing.Equal(want.Outgoing) {
-		t.Fatalf("fast GetBalance = {A:%s I:%s O:%s}, want {A:%s I:%s O:%s}",
-			got.Available, got.Incoming, got.Outgoing, want.Available, want.Incoming, want.Outgoing)
+	if !got.Available.Equal(want.Available) || !got.Incoming.Equal(want.Incoming) || !got.Outgoing.Equal(want.Outgoing) || got.UTXOCount != want.UTXOCount {
+		t.Fatalf("fast GetBalance = {A:%s I:%s O:%s N:%d}, want {A:%s I:%s O:%s N:%d}",
+			got.Available, got.Incoming, got.Outgoing, got.UTXOCount, want.Available, want.Incoming, want.Outgoing, want.UTXOCount)
 	}
 }
 
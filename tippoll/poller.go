@@ -0,0 +1,119 @@
+package tippoll
+
+import (
+	"context"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"github.com/dogeorg/dogewalker/spec"
+	"github.com/dogeorg/governor"
+)
+
+// BestBlockSource is the part of the Core RPC client the Poller uses.
+type BestBlockSource interface {
+	GetBestBlockHash(ctx context.Context) (blockHash string, err error)
+}
+
+/*
+ * Poller sits between the ZMQ TipChaser and the Walker: it forwards ZMQ
+ * events unchanged, and while ZMQ has been silent for longer than the poll
+ * interval (e.g. Core was started without -zmqpubhashblock, or the socket
+ * died) it asks Core for the best block hash every interval, sending a
+ * block event whenever the tip changes.
+ *
+ * This wakes the Walker promptly without ZMQ; the Walker's own fallback
+ * only polls every 90 seconds.
+ */
+type Poller struct {
+	governor.ServiceCtx
+	client    BestBlockSource
+	zmq       <-chan spec.BlockchainEvent
+	out       chan<- spec.BlockchainEvent
+	interval  time.Duration
+	lastEvent time.Time // last ZMQ block event (or when the Poller started)
+	lastHash  []byte    // last tip sent to the Walker
+	polling   bool      // ZMQ is silent (for logging once)
+}
+
+func NewPoller(client BestBlockSource, zmq <-chan spec.BlockchainEvent, out chan<- spec.BlockchainEvent, interval time.Duration) *Poller {
+	return &Poller{client: client, zmq: zmq, out: out, interval: interval}
+}
+
+func (p *Poller) Run() {
+	p.lastEvent = time.Now()
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.Context.Done():
+			return
+		case e := <-p.zmq:
+			if e.Event == spec.EventTypeBlock {
+				p.zmqBlock(e.Hash, time.Now())
+			}
+			if !p.send(e) {
+				return // stopping
+			}
+		case now := <-ticker.C:
+			if !p.shouldPoll(now) {
+				continue
+			}
+			hash, changed := p.poll()
+			if changed && !p.send(spec.BlockchainEvent{Event: spec.EventTypeBlock, Hash: hash}) {
+				return // stopping
+			}
+		}
+	}
+}
+
+// zmqBlock records a block event from ZMQ.
+func (p *Poller) zmqBlock(hash []byte, now time.Time) {
+	if p.polling {
+		log.Printf("[TipPoll] ZMQ is back, stopped polling")
+		p.polling = false
+	}
+	p.lastEvent = now
+	p.lastHash = hash
+}
+
+// shouldPoll is true once ZMQ has been silent for longer than the interval.
+func (p *Poller) shouldPoll(now time.Time) bool {
+	return now.Sub(p.lastEvent) >= p.interval
+}
+
+// poll asks Core for the best block hash and reports whether it differs
+// from the last tip sent to the Walker.
+func (p *Poller) poll() (hash []byte, changed bool) {
+	if !p.polling {
+		log.Printf("[TipPoll] no ZMQ block events for %v, polling Core every %v", time.Since(p.lastEvent).Round(time.Second), p.interval)
+		p.polling = true
+	}
+	// the RPC client retries until the context ends: give up by the next tick
+	ctx, cancel := context.WithTimeout(p.Context, p.interval)
+	defer cancel()
+	hexHash, err := p.client.GetBestBlockHash(ctx)
+	if err != nil {
+		log.Printf("[TipPoll] getbestblockhash: %v", err)
+		return nil, false
+	}
+	hash, err = hex.DecodeString(hexHash)
+	if err != nil {
+		log.Printf("[TipPoll] getbestblockhash: bad hash %q: %v", hexHash, err)
+		return nil, false
+	}
+	if string(hash) == string(p.lastHash) {
+		return hash, false
+	}
+	p.lastHash = hash
+	return hash, true
+}
+
+func (p *Poller) send(e spec.BlockchainEvent) bool {
+	select {
+	case p.out <- e:
+		return true
+	case <-p.Context.Done():
+		return false
+	}
+}
@@ -0,0 +1,124 @@
+package tippoll
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dogeorg/dogewalker/spec"
+)
+
+type mockCore struct {
+	mu    sync.Mutex
+	hash  string
+	err   error
+	calls int
+}
+
+func (m *mockCore) GetBestBlockHash(ctx context.Context) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	return m.hash, m.err
+}
+
+func (m *mockCore) set(hash string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hash = hash
+}
+
+func TestShouldPoll(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name     string
+		silent   time.Duration // since the last ZMQ event
+		expected bool
+	}{
+		{"Recent ZMQ event", time.Second, false},
+		{"Just under the interval", 10*time.Second - time.Millisecond, false},
+		{"At the interval", 10 * time.Second, true},
+		{"Long silence", time.Hour, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPoller(&mockCore{}, nil, nil, 10*time.Second)
+			p.zmqBlock([]byte{1}, start)
+			if got := p.shouldPoll(start.Add(tt.silent)); got != tt.expected {
+				t.Errorf("expected shouldPoll %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPoll(t *testing.T) {
+	core := &mockCore{hash: "00ff"}
+	p := NewPoller(core, nil, nil, time.Second)
+	p.Context = context.Background()
+
+	hash, changed := p.poll()
+	if !changed || !bytes.Equal(hash, []byte{0x00, 0xff}) {
+		t.Errorf("expected a new tip 00ff, got %x changed=%v", hash, changed)
+	}
+	if _, changed = p.poll(); changed {
+		t.Errorf("expected no change for the same tip")
+	}
+	p.zmqBlock([]byte{0xab, 0xcd}, time.Now())
+	if _, changed = p.poll(); !changed {
+		t.Errorf("expected a change from the tip ZMQ reported")
+	}
+	core.set("zz")
+	if _, changed = p.poll(); changed {
+		t.Errorf("expected no change for an invalid hash")
+	}
+	core.err = errors.New("rpc down")
+	if _, changed = p.poll(); changed {
+		t.Errorf("expected no change on RPC error")
+	}
+}
+
+func TestPollerRun(t *testing.T) {
+	core := &mockCore{hash: "0a"}
+	zmq := make(chan spec.BlockchainEvent)
+	out := make(chan spec.BlockchainEvent, 10)
+	p := NewPoller(core, zmq, out, 10*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	p.Context = ctx
+	stopped := make(chan struct{})
+	go func() {
+		p.Run()
+		close(stopped)
+	}()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	next := func() spec.BlockchainEvent {
+		t.Helper()
+		select {
+		case e := <-out:
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no event sent")
+			return spec.BlockchainEvent{}
+		}
+	}
+
+	// ZMQ events are forwarded
+	zmq <- spec.BlockchainEvent{Event: spec.EventTypeBlock, Hash: []byte{0x01}}
+	if e := next(); !bytes.Equal(e.Hash, []byte{0x01}) {
+		t.Errorf("expected the ZMQ event, got %x", e.Hash)
+	}
+	// ZMQ falls silent: polling finds Core's tip
+	if e := next(); e.Event != spec.EventTypeBlock || !bytes.Equal(e.Hash, []byte{0x0a}) {
+		t.Errorf("expected a polled block event 0a, got %+v", e)
+	}
+	core.set("0b")
+	if e := next(); !bytes.Equal(e.Hash, []byte{0x0b}) {
+		t.Errorf("expected a polled block event 0b, got %x", e.Hash)
+	}
+}
@@ -0,0 +1,135 @@
+package web
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dogeorg/indexer/index"
+	"github.com/dogeorg/indexer/spec"
+)
+
+// Admin endpoints are only registered when an admin token is configured,
+// and require "Authorization: Bearer <token>".
+
+type PauseResponse struct {
+	Paused bool `json:"paused"`
+}
+
+type ResyncResponse struct {
+	From       int64  `json:"from"`        // the new indexed height
+	ResumeHash string `json:"resume_hash"` // block hash at `from`, where indexing resumes
+	Paused     bool   `json:"paused"`      // until the indexer restarts
+}
+
+func (a *WebAPI) adminPause(w http.ResponseWriter, r *http.Request) {
+	options := "POST, OPTIONS"
+	switch r.Method {
+	case http.MethodPost:
+		if !a.authorizeAdmin(w, r, options) {
+			return
+		}
+		a.control.Pause()
+		sendJson(w, PauseResponse{Paused: a.control.Paused()}, options, a.corsOrigin)
+	default:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) adminResume(w http.ResponseWriter, r *http.Request) {
+	options := "POST, OPTIONS"
+	switch r.Method {
+	case http.MethodPost:
+		if !a.authorizeAdmin(w, r, options) {
+			return
+		}
+		a.control.Resume()
+		sendJson(w, PauseResponse{Paused: a.control.Paused()}, options, a.corsOrigin)
+	default:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+// adminRepairOrphans deletes orphaned utxo and tx rows (see spec.StoreTx.RepairOrphans.)
+func (a *WebAPI) adminRepairOrphans(w http.ResponseWriter, r *http.Request) {
+	options := "POST, OPTIONS"
+	switch r.Method {
+	case http.MethodPost:
+		if !a.authorizeAdmin(w, r, options) {
+			return
+		}
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		var repair spec.OrphanRepair
+		err := store.Transact(func(tx spec.StoreTx) (err error) {
+			repair, err = tx.RepairOrphans()
+			return err
+		})
+		if err == nil && (repair.UTXOs > 0 || repair.Txs > 0) {
+			log.Printf("[API] repaired orphans: deleted %d utxo rows and %d tx rows", repair.UTXOs, repair.Txs)
+		}
+		sendResult(w, repair, err, options, a.corsOrigin)
+	default:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+// adminResync rolls the index back to ?from=<height> (see index.Indexer.Resync.)
+func (a *WebAPI) adminResync(w http.ResponseWriter, r *http.Request) {
+	options := "POST, OPTIONS"
+	switch r.Method {
+	case http.MethodPost:
+		if !a.authorizeAdmin(w, r, options) {
+			return
+		}
+		payload, err := a.resync(r.Context(), r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	default:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) resync(ctx context.Context, query url.Values) (any, error) {
+	from, err := strconv.ParseInt(query.Get("from"), 10, 64)
+	if err != nil || from < 0 {
+		return nil, badRequest("'from' must be a block height")
+	}
+	if a.blockchain == nil {
+		return nil, &apiError{CodeUnavailable, "resync needs Core RPC (for the block hash)"}
+	}
+	hashCtx, cancel := context.WithTimeout(ctx, txProofTimeout)
+	defer cancel()
+	blockHash, err := a.blockchain.GetBlockHash(from, hashCtx)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := hex.DecodeString(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	err = a.control.Resync(ctx, from, hash)
+	if errors.Is(err, index.ErrUnsafeResync) {
+		return nil, badRequest(err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[API] resync: rolled back to %d (%s)", from, blockHash)
+	return ResyncResponse{From: from, ResumeHash: blockHash, Paused: a.control.Paused()}, nil
+}
+
+// authorizeAdmin checks the admin bearer token (sends an error if it doesn't match.)
+func (a *WebAPI) authorizeAdmin(w http.ResponseWriter, r *http.Request, options string) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || a.adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(a.adminToken)) != 1 {
+		sendError(w, CodeUnauthorized, "admin token required", options, a.corsOrigin)
+		return false
+	}
+	return true
+}
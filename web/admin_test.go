@@ -0,0 +1,192 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dogeorg/doge"
+	walkerspec "github.com/dogeorg/dogewalker/spec"
+	"github.com/dogeorg/indexer/index"
+	"github.com/dogeorg/indexer/spec"
+)
+
+type MockControl struct {
+	paused     bool
+	resyncTo   int64 // last height passed to Resync (-1 if not called)
+	resyncHash []byte
+	resyncErr  error
+}
+
+func (m *MockControl) Pause()       { m.paused = true }
+func (m *MockControl) Resume()      { m.paused = false }
+func (m *MockControl) Paused() bool { return m.paused }
+func (m *MockControl) Resync(ctx context.Context, height int64, hash []byte) error {
+	m.resyncTo, m.resyncHash = height, hash
+	if m.resyncErr != nil {
+		return m.resyncErr
+	}
+	m.paused = true
+	return nil
+}
+
+func TestAdminPauseResume(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		auth           string
+		startPaused    bool
+		expectedStatus int
+		expectedBody   string
+		expectedPaused bool
+	}{
+		{"Pause", "POST", "/admin/pause", "Bearer s3cret", false, 200, `{"paused":true}`, true},
+		{"Resume", "POST", "/admin/resume", "Bearer s3cret", true, 200, `{"paused":false}`, false},
+		{"Missing token", "POST", "/admin/pause", "", false, 401, `{"error":"unauthorized","reason":"admin token required"}`, false},
+		{"Wrong token", "POST", "/admin/resume", "Bearer guess", true, 401, `{"error":"unauthorized","reason":"admin token required"}`, true},
+		{"GET not allowed", "GET", "/admin/pause", "Bearer s3cret", false, 405, "method not allowed\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			control := &MockControl{paused: tt.startPaused}
+			server := New(Options{Bind: ":0", Store: &MockStore{}, Indexer: &MockIndexer{}, Control: control, AdminToken: "s3cret"})
+			webAPI := server.(*WebAPI)
+
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+			if control.paused != tt.expectedPaused {
+				t.Errorf("expected paused=%v, got %v", tt.expectedPaused, control.paused)
+			}
+		})
+	}
+}
+
+func TestAdminDisabledWithoutToken(t *testing.T) {
+	server := New(Options{Bind: ":0", Store: &MockStore{}, Indexer: &MockIndexer{}, Control: &MockControl{}})
+	webAPI := server.(*WebAPI)
+
+	w := httptest.NewRecorder()
+	webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("POST", "/admin/pause", nil))
+	if w.Code != 404 {
+		t.Errorf("expected 404 without an admin token, got %d", w.Code)
+	}
+}
+
+func TestAdminRepairOrphans(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		auth           string
+		store          *MockStore
+		expectedStatus int
+		expectedBody   string
+		expectedRepair bool
+	}{
+		{"Repair", "POST", "Bearer s3cret", &MockStore{repair: spec.OrphanRepair{UTXOs: 2, Txs: 1}}, 200, `{"utxos":2,"txs":1}`, true},
+		{"Nothing to repair", "POST", "Bearer s3cret", &MockStore{}, 200, `{"utxos":0,"txs":0}`, true},
+		{"Store error", "POST", "Bearer s3cret", &MockStore{repairErr: errors.New("db down")}, 500, `{"error":"error","reason":"db down"}`, true},
+		{"Missing token", "POST", "", &MockStore{}, 401, `{"error":"unauthorized","reason":"admin token required"}`, false},
+		{"GET not allowed", "GET", "Bearer s3cret", &MockStore{}, 405, "method not allowed\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: tt.store, Indexer: &MockIndexer{}, AdminToken: "s3cret"})
+			webAPI := server.(*WebAPI)
+			webAPI.store = tt.store
+
+			req := httptest.NewRequest(tt.method, "/admin/repair-orphans", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+			if tt.store.repaired != tt.expectedRepair {
+				t.Errorf("expected RepairOrphans called=%v, got %v", tt.expectedRepair, tt.store.repaired)
+			}
+		})
+	}
+}
+
+func TestAdminResync(t *testing.T) {
+	hash := "1a91e3dace36e2be3bf030a65679fe821aa1d6ef92e7c9902eb318182c355691"
+	core := fakeBlockchain{hashes: map[int64]string{100: hash}}
+	unsafe := fmt.Errorf("%w: spent UTXOs below height 200 have been trimmed", index.ErrUnsafeResync)
+	tests := []struct {
+		name           string
+		query          string
+		auth           string
+		control        *MockControl
+		blockchain     walkerspec.Blockchain
+		expectedStatus int
+		expectedBody   string
+		expectedResync int64
+	}{
+		{"Resync", "?from=100", "Bearer s3cret", &MockControl{}, core, 200,
+			`{"from":100,"resume_hash":"` + hash + `","paused":true}`, 100},
+		{"Below the trim floor", "?from=100", "Bearer s3cret", &MockControl{resyncErr: unsafe}, core, 400,
+			`{"error":"bad-request","reason":"cannot resync: spent UTXOs below height 200 have been trimmed"}`, 100},
+		{"Indexer error", "?from=100", "Bearer s3cret", &MockControl{resyncErr: errors.New("db down")}, core, 500,
+			`{"error":"error","reason":"db down"}`, 100},
+		{"Core error", "?from=100", "Bearer s3cret", &MockControl{}, fakeBlockchain{hashErr: errors.New("core down")}, 500,
+			`{"error":"error","reason":"core down"}`, -1},
+		{"Missing from", "", "Bearer s3cret", &MockControl{}, core, 400,
+			`{"error":"bad-request","reason":"'from' must be a block height"}`, -1},
+		{"Negative from", "?from=-1", "Bearer s3cret", &MockControl{}, core, 400,
+			`{"error":"bad-request","reason":"'from' must be a block height"}`, -1},
+		{"No Core RPC", "?from=100", "Bearer s3cret", &MockControl{}, nil, 503,
+			`{"error":"unavailable","reason":"resync needs Core RPC (for the block hash)"}`, -1},
+		{"Missing token", "?from=100", "", &MockControl{}, core, 401,
+			`{"error":"unauthorized","reason":"admin token required"}`, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.control.resyncTo = -1
+			server := New(Options{Bind: ":0", Store: &MockStore{}, Indexer: &MockIndexer{}, Control: tt.control, Blockchain: tt.blockchain, AdminToken: "s3cret"})
+			webAPI := server.(*WebAPI)
+
+			req := httptest.NewRequest("POST", "/admin/resync"+tt.query, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+			if tt.control.resyncTo != tt.expectedResync {
+				t.Errorf("expected Resync(%d), got %d", tt.expectedResync, tt.control.resyncTo)
+			}
+			if tt.expectedResync >= 0 && doge.HexEncode(tt.control.resyncHash) != hash {
+				t.Errorf("expected resume hash %s, got %x", hash, tt.control.resyncHash)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package web
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+type balanceKey struct {
+	kind          doge.ScriptType
+	script        string
+	confirmations int64
+}
+
+type cachedBalance struct {
+	key      balanceKey
+	balance  spec.Balance
+	storedAt time.Time
+}
+
+// balanceCache keeps recently requested balances for a short time.
+// Every entry is dropped as soon as the indexed tip (resume hash) changes,
+// which also catches a reorg that replaces the tip at the same height.
+type balanceCache struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	tip     string                       // resume hash the entries were computed at
+	entries map[balanceKey]*list.Element // of *cachedBalance
+	lru     *list.List                   // most recently used first
+}
+
+func newBalanceCache(size int, ttl time.Duration) *balanceCache {
+	if size <= 0 || ttl <= 0 {
+		return nil
+	}
+	return &balanceCache{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[balanceKey]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+// get returns a cached balance computed at tip `resumeHash`, if fresh.
+func (c *balanceCache) get(kind doge.ScriptType, script []byte, confirmations int64, resumeHash []byte) (spec.Balance, bool) {
+	if c == nil {
+		return spec.Balance{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.syncTipLocked(resumeHash)
+	elem, found := c.entries[balanceKey{kind, string(script), confirmations}]
+	if !found {
+		return spec.Balance{}, false
+	}
+	entry := elem.Value.(*cachedBalance)
+	if c.now().Sub(entry.storedAt) >= c.ttl {
+		c.lru.Remove(elem)
+		delete(c.entries, entry.key)
+		return spec.Balance{}, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.balance, true
+}
+
+// put stores a balance computed at tip `resumeHash`.
+func (c *balanceCache) put(kind doge.ScriptType, script []byte, confirmations int64, resumeHash []byte, balance spec.Balance) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.syncTipLocked(resumeHash)
+	key := balanceKey{kind, string(script), confirmations}
+	if elem, found := c.entries[key]; found {
+		c.lru.Remove(elem)
+	}
+	c.entries[key] = c.lru.PushFront(&cachedBalance{key: key, balance: balance, storedAt: c.now()})
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedBalance).key)
+	}
+}
+
+// syncTipLocked drops all entries when the indexed tip changes.
+func (c *balanceCache) syncTipLocked(resumeHash []byte) {
+	if string(resumeHash) != c.tip {
+		c.entries = map[balanceKey]*list.Element{}
+		c.lru.Init()
+		c.tip = string(resumeHash)
+	}
+}
@@ -0,0 +1,102 @@
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestBalanceCacheHitMissAndExpiry(t *testing.T) {
+	now := time.Date(2026, time.June, 1, 12, 0, 0, 0, time.UTC)
+	cache := newBalanceCache(10, 10*time.Second)
+	cache.now = func() time.Time { return now }
+	script, tipA := []byte{1, 2, 3}, []byte{0xA}
+	bal := spec.Balance{Available: bigKoinu(100)}
+
+	if _, found := cache.get(doge.ScriptTypeP2PKH, script, 6, tipA); found {
+		t.Fatal("expected miss on empty cache")
+	}
+	cache.put(doge.ScriptTypeP2PKH, script, 6, tipA, bal)
+	if got, found := cache.get(doge.ScriptTypeP2PKH, script, 6, tipA); !found || !got.Available.Equal(bal.Available) {
+		t.Fatalf("expected hit, got %v %v", got, found)
+	}
+	// the key includes kind and confirmations
+	if _, found := cache.get(doge.ScriptTypeP2SH, script, 6, tipA); found {
+		t.Fatal("expected miss for a different kind")
+	}
+	if _, found := cache.get(doge.ScriptTypeP2PKH, script, 1, tipA); found {
+		t.Fatal("expected miss for different confirmations")
+	}
+
+	now = now.Add(10 * time.Second)
+	if _, found := cache.get(doge.ScriptTypeP2PKH, script, 6, tipA); found {
+		t.Fatal("expected miss after TTL")
+	}
+}
+
+func TestBalanceCacheInvalidatedOnTipChange(t *testing.T) {
+	cache := newBalanceCache(10, time.Minute)
+	script, tipA, tipB := []byte{1, 2, 3}, []byte{0xA}, []byte{0xB}
+	cache.put(doge.ScriptTypeP2PKH, script, 6, tipA, spec.Balance{Available: bigKoinu(100)})
+
+	// a new block, or a reorg replacing the tip at the same height
+	if _, found := cache.get(doge.ScriptTypeP2PKH, script, 6, tipB); found {
+		t.Fatal("expected miss after the tip changed")
+	}
+	// and the old entries are gone, even if asked at the old tip
+	if _, found := cache.get(doge.ScriptTypeP2PKH, script, 6, tipA); found {
+		t.Fatal("expected entries to be dropped on tip change")
+	}
+}
+
+func TestBalanceCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newBalanceCache(2, time.Minute)
+	a, b, c, tipA := []byte{0xA}, []byte{0xB}, []byte{0xC}, []byte{0xA}
+	cache.put(doge.ScriptTypeP2PKH, a, 6, tipA, spec.Balance{})
+	cache.put(doge.ScriptTypeP2PKH, b, 6, tipA, spec.Balance{})
+	cache.get(doge.ScriptTypeP2PKH, a, 6, tipA) // a is now most recent
+	cache.put(doge.ScriptTypeP2PKH, c, 6, tipA, spec.Balance{})
+
+	if _, found := cache.get(doge.ScriptTypeP2PKH, b, 6, tipA); found {
+		t.Fatal("expected least recently used entry to be evicted")
+	}
+	for _, script := range [][]byte{a, c} {
+		if _, found := cache.get(doge.ScriptTypeP2PKH, script, 6, tipA); !found {
+			t.Fatalf("expected %x to be cached", script)
+		}
+	}
+}
+
+func TestGetBalanceUsesCache(t *testing.T) {
+	validAddress := "D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS"
+	mockStore := &MockStore{currentHeight: 1000, resumePoint: []byte{0xA}, balance: spec.Balance{Available: bigKoinu(100000000), Incoming: bigKoinu(0), Outgoing: bigKoinu(0)}}
+	server := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}, BalanceCacheSize: 10, BalanceCacheTTL: time.Minute})
+	webAPI := server.(*WebAPI)
+	webAPI.store = mockStore
+
+	get := func() string {
+		w := httptest.NewRecorder()
+		webAPI.getBalance(w, httptest.NewRequest("GET", "/balance?address="+validAddress, nil))
+		return w.Body.String()
+	}
+
+	first := get()
+	mockStore.balance = spec.Balance{Available: bigKoinu(200000000), Incoming: bigKoinu(0), Outgoing: bigKoinu(0)}
+	if cached := get(); cached != first {
+		t.Errorf("expected cached body %q, got %q", first, cached)
+	}
+	mockStore.currentHeight, mockStore.resumePoint = 1001, []byte{0xB} // a block was indexed
+	expected := `{"incoming":"0","available":"2","outgoing":"0","current":"2","utxo_count":0}`
+	if fresh := get(); fresh != expected {
+		t.Errorf("expected fresh body %q after new block, got %q", expected, fresh)
+	}
+	mockStore.balance = spec.Balance{Available: bigKoinu(300000000), Incoming: bigKoinu(0), Outgoing: bigKoinu(0)}
+	mockStore.resumePoint = []byte{0xC} // a reorg replaced block 1001
+	expected = `{"incoming":"0","available":"3","outgoing":"0","current":"3","utxo_count":0}`
+	if fresh := get(); fresh != expected {
+		t.Errorf("expected fresh body %q after a same-height reorg, got %q", expected, fresh)
+	}
+}
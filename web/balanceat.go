@@ -0,0 +1,60 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/dogeorg/indexer/spec"
+)
+
+type BalanceAtResponse struct {
+	Height  int64         `json:"height"`
+	Balance spec.BigKoinu `json:"balance"` // unspent balance after the block at Height
+}
+
+func (a *WebAPI) getBalanceAt(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.balanceAt(store, r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) balanceAt(store spec.Store, query url.Values) (any, error) {
+	kind, hash, err := a.addressParam(query)
+	if err != nil {
+		return nil, err
+	}
+	height, err := strconv.ParseInt(query.Get("height"), 10, 64)
+	if err != nil || height < 0 {
+		return nil, badRequest("'height' must be a block height")
+	}
+	current, err := store.GetCurrentHeight()
+	if err != nil {
+		return nil, err
+	}
+	if height > current {
+		return nil, badRequest(fmt.Sprintf("'height' is above the indexed height %d", current))
+	}
+	// UTXOs spent below the trim floor have been deleted,
+	// so older balances would be missing those UTXOs.
+	trimFloor, err := store.GetTrimmedBelow()
+	if err != nil {
+		return nil, err
+	}
+	if height < trimFloor {
+		return nil, badRequest(fmt.Sprintf("'height' is below the trim floor %d", trimFloor))
+	}
+	balance, err := store.GetBalanceAtHeight(kind, hash, height)
+	if err != nil {
+		return nil, err
+	}
+	return BalanceAtResponse{Height: height, Balance: balance}, nil
+}
@@ -0,0 +1,84 @@
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetBalanceAt(t *testing.T) {
+	validAddress := "D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS"
+	tests := []struct {
+		name           string
+		query          string
+		store          *MockStore
+		expectedStatus int
+		expectedBody   string
+		expectedHeight int64 // height passed to GetBalanceAtHeight
+	}{
+		{
+			name:           "Balance at height",
+			query:          "?address=" + validAddress + "&height=5000",
+			store:          &MockStore{currentHeight: 6000, trimmedBelow: 4000, balanceAt: bigKoinu(250000000)},
+			expectedStatus: 200,
+			expectedBody:   `{"height":5000,"balance":"2.5"}`,
+			expectedHeight: 5000,
+		},
+		{
+			name:           "At the trim floor",
+			query:          "?address=" + validAddress + "&height=4000",
+			store:          &MockStore{currentHeight: 6000, trimmedBelow: 4000, balanceAt: bigKoinu(0)},
+			expectedStatus: 200,
+			expectedBody:   `{"height":4000,"balance":"0"}`,
+			expectedHeight: 4000,
+		},
+		{
+			name:           "Below the trim floor",
+			query:          "?address=" + validAddress + "&height=3999",
+			store:          &MockStore{currentHeight: 6000, trimmedBelow: 4000},
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'height' is below the trim floor 4000"}`,
+		},
+		{
+			name:           "Above the indexed height",
+			query:          "?address=" + validAddress + "&height=6001",
+			store:          &MockStore{currentHeight: 6000},
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'height' is above the indexed height 6000"}`,
+		},
+		{
+			name:           "Missing height",
+			query:          "?address=" + validAddress,
+			store:          &MockStore{currentHeight: 6000},
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'height' must be a block height"}`,
+		},
+		{
+			name:           "Missing address",
+			query:          "?height=5",
+			store:          &MockStore{},
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"missing 'address' in the URL"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: tt.store, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = tt.store
+
+			w := httptest.NewRecorder()
+			webAPI.getBalanceAt(w, httptest.NewRequest("GET", "/balance-at"+tt.query, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+			if tt.store.balanceAtHeight != tt.expectedHeight {
+				t.Errorf("expected lookup at height %d, got %d", tt.expectedHeight, tt.store.balanceAtHeight)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/dogeorg/doge/koinu"
+	"github.com/dogeorg/indexer/spec"
+)
+
+type BlockResponse struct {
+	Height  int64        `json:"height"`
+	TxCount int64        `json:"tx_count"` // transactions in the block (including coinbase)
+	Fees    *koinu.Koinu `json:"fees"`     // total fees paid, or null if unknown
+}
+
+// getBlock returns the statistics recorded for one indexed block.
+func (a *WebAPI) getBlock(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.block(store, r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) block(store spec.Store, query url.Values) (any, error) {
+	height, err := strconv.ParseInt(query.Get("height"), 10, 64)
+	if err != nil || height < 0 {
+		return nil, badRequest("'height' must be a block height")
+	}
+	stats, err := store.GetBlockStats(height, height)
+	if err != nil {
+		return nil, err
+	}
+	if len(stats) == 0 {
+		return nil, notFound(fmt.Sprintf("no stats for block %d (not indexed, or indexed before stats were recorded)", height))
+	}
+	res := BlockResponse{Height: stats[0].Height, TxCount: stats[0].TxCount}
+	if stats[0].FeesKnown {
+		fees := koinu.Koinu(stats[0].Fees)
+		res.Fees = &fees
+	}
+	return res, nil
+}
@@ -0,0 +1,99 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/dogeorg/indexer/spec"
+)
+
+// /block-stats aggregates the recorded block statistics over a height range
+// into buckets of `bucket` blocks, for charts that don't need every block.
+
+const maxBlockStatsBlocks = 100000  // blocks per /block-stats request
+const defaultBlockStatsBucket = 100 // blocks per bucket without ?bucket=
+
+type BlockStatsResponse struct {
+	From    int64            `json:"from"`
+	To      int64            `json:"to"`
+	Bucket  int64            `json:"bucket"`  // blocks per bucket (the last may be shorter)
+	Buckets []BlockStatsItem `json:"buckets"` // in height order, including empty ones
+}
+
+type BlockStatsItem struct {
+	From            int64    `json:"from"`                        // first height in the bucket
+	To              int64    `json:"to"`                          // last height in the bucket
+	Blocks          int64    `json:"blocks"`                      // blocks with recorded stats
+	TxCount         int64    `json:"tx_count"`                    // transactions in those blocks
+	DetailedBlocks  int64    `json:"detailed_blocks"`             // blocks with UTXO counts and processing time
+	UTXOCreated     int64    `json:"utxo_created"`                // UTXOs created by the detailed blocks
+	UTXOSpent       int64    `json:"utxo_spent"`                  // UTXOs spent by the detailed blocks
+	AvgProcessingMS *float64 `json:"avg_processing_ms,omitempty"` // mean processing time of the detailed blocks
+}
+
+func (a *WebAPI) getBlockStats(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.blockStats(store, r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) blockStats(store spec.Store, query url.Values) (any, error) {
+	from, to, err := heightRangeParam(query, maxBlockStatsBlocks)
+	if err != nil {
+		return nil, err
+	}
+	bucket := int64(defaultBlockStatsBucket)
+	if param := query.Get("bucket"); param != "" {
+		bucket, err = strconv.ParseInt(param, 10, 64)
+		if err != nil || bucket < 1 || bucket > maxBlockStatsBlocks {
+			return nil, badRequest(fmt.Sprintf("'bucket' must be a number of blocks from 1 to %d", maxBlockStatsBlocks))
+		}
+	}
+	stats, err := store.GetBlockStats(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return BlockStatsResponse{From: from, To: to, Bucket: bucket, Buckets: aggregateBlockStats(stats, from, to, bucket)}, nil
+}
+
+// aggregateBlockStats sums `stats` (in height order, between `from` and `to`)
+// into buckets of `bucket` blocks starting at `from`.
+func aggregateBlockStats(stats []spec.BlockStats, from int64, to int64, bucket int64) []BlockStatsItem {
+	items := []BlockStatsItem{}
+	processing := []int64{} // total processing ms per bucket
+	for start := from; start <= to; start += bucket {
+		items = append(items, BlockStatsItem{From: start, To: min(start+bucket-1, to)})
+		processing = append(processing, 0)
+	}
+	for _, s := range stats {
+		if s.Height < from || s.Height > to {
+			continue
+		}
+		n := (s.Height - from) / bucket
+		item := &items[n]
+		item.Blocks++
+		item.TxCount += s.TxCount
+		if s.Detailed {
+			item.DetailedBlocks++
+			item.UTXOCreated += s.UTXOCreated
+			item.UTXOSpent += s.UTXOSpent
+			processing[n] += s.ProcessingTime.Milliseconds()
+		}
+	}
+	for n := range items {
+		if items[n].DetailedBlocks > 0 {
+			avg := float64(processing[n]) / float64(items[n].DetailedBlocks)
+			items[n].AvgProcessingMS = &avg
+		}
+	}
+	return items
+}
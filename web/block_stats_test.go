@@ -0,0 +1,70 @@
+package web
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestGetBlockStats(t *testing.T) {
+	// heights 100-106: 101 was indexed before detailed stats, 104 has none
+	stats := []spec.BlockStats{
+		{Height: 100, TxCount: 2, UTXOCreated: 3, UTXOSpent: 1, ProcessingTime: 10 * time.Millisecond, Detailed: true},
+		{Height: 101, TxCount: 5},
+		{Height: 102, TxCount: 1, UTXOCreated: 1, ProcessingTime: 20 * time.Millisecond, Detailed: true},
+		{Height: 103, TxCount: 4, UTXOCreated: 6, UTXOSpent: 5, ProcessingTime: 40 * time.Millisecond, Detailed: true},
+		{Height: 105, TxCount: 3, UTXOCreated: 2, UTXOSpent: 2, ProcessingTime: 5 * time.Millisecond, Detailed: true},
+		{Height: 106, TxCount: 7},
+	}
+	tests := []struct {
+		name           string
+		query          string
+		store          *MockStore
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Buckets of 3", "?from=100&to=106&bucket=3", &MockStore{blockStats: stats}, 200,
+			`{"from":100,"to":106,"bucket":3,"buckets":[` +
+				`{"from":100,"to":102,"blocks":3,"tx_count":8,"detailed_blocks":2,"utxo_created":4,"utxo_spent":1,"avg_processing_ms":15},` +
+				`{"from":103,"to":105,"blocks":2,"tx_count":7,"detailed_blocks":2,"utxo_created":8,"utxo_spent":7,"avg_processing_ms":22.5},` +
+				`{"from":106,"to":106,"blocks":1,"tx_count":7,"detailed_blocks":0,"utxo_created":0,"utxo_spent":0}]}`},
+		{"One bucket", "?from=100&to=106&bucket=10", &MockStore{blockStats: stats}, 200,
+			`{"from":100,"to":106,"bucket":10,"buckets":[` +
+				`{"from":100,"to":106,"blocks":6,"tx_count":22,"detailed_blocks":4,"utxo_created":12,"utxo_spent":8,"avg_processing_ms":18.75}]}`},
+		{"Per block", "?from=103&to=104&bucket=1", &MockStore{blockStats: stats}, 200,
+			`{"from":103,"to":104,"bucket":1,"buckets":[` +
+				`{"from":103,"to":103,"blocks":1,"tx_count":4,"detailed_blocks":1,"utxo_created":6,"utxo_spent":5,"avg_processing_ms":40},` +
+				`{"from":104,"to":104,"blocks":0,"tx_count":0,"detailed_blocks":0,"utxo_created":0,"utxo_spent":0}]}`},
+		{"Default bucket", "?from=0&to=199", &MockStore{blockStats: stats}, 200,
+			`{"from":0,"to":199,"bucket":100,"buckets":[` +
+				`{"from":0,"to":99,"blocks":0,"tx_count":0,"detailed_blocks":0,"utxo_created":0,"utxo_spent":0},` +
+				`{"from":100,"to":199,"blocks":6,"tx_count":22,"detailed_blocks":4,"utxo_created":12,"utxo_spent":8,"avg_processing_ms":18.75}]}`},
+		{"Bad bucket", "?from=100&to=106&bucket=0", &MockStore{}, 400,
+			`{"error":"bad-request","reason":"'bucket' must be a number of blocks from 1 to 100000"}`},
+		{"Range too large", "?from=0&to=100000", &MockStore{}, 400,
+			`{"error":"bad-request","reason":"at most 100000 blocks per request"}`},
+		{"Store error", "?from=100&to=106", &MockStore{blockStatsErr: errors.New("db down")}, 500,
+			`{"error":"error","reason":"db down"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: tt.store, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = tt.store
+
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/block-stats"+tt.query, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package web
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestGetBlock(t *testing.T) {
+	stats := []spec.BlockStats{
+		{Height: 100, TxCount: 3, Fees: 150_000_000, FeesKnown: true},
+		{Height: 101, TxCount: 1, FeesKnown: true},
+		{Height: 102, TxCount: 7},
+	}
+	tests := []struct {
+		name           string
+		query          string
+		store          *MockStore
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Fees known",
+			query:          "?height=100",
+			store:          &MockStore{blockStats: stats},
+			expectedStatus: 200,
+			expectedBody:   `{"height":100,"tx_count":3,"fees":"1.5"}`,
+		},
+		{
+			name:           "Coinbase only",
+			query:          "?height=101",
+			store:          &MockStore{blockStats: stats},
+			expectedStatus: 200,
+			expectedBody:   `{"height":101,"tx_count":1,"fees":"0"}`,
+		},
+		{
+			name:           "Fees unknown",
+			query:          "?height=102",
+			store:          &MockStore{blockStats: stats},
+			expectedStatus: 200,
+			expectedBody:   `{"height":102,"tx_count":7,"fees":null}`,
+		},
+		{
+			name:           "No stats",
+			query:          "?height=500",
+			store:          &MockStore{blockStats: stats},
+			expectedStatus: 404,
+			expectedBody:   `{"error":"not-found","reason":"no stats for block 500 (not indexed, or indexed before stats were recorded)"}`,
+		},
+		{
+			name:           "Missing height",
+			query:          "",
+			store:          &MockStore{},
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'height' must be a block height"}`,
+		},
+		{
+			name:           "Store error",
+			query:          "?height=1",
+			store:          &MockStore{blockStatsErr: errors.New("db down")},
+			expectedStatus: 500,
+			expectedBody:   `{"error":"error","reason":"db down"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: tt.store, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = tt.store
+
+			w := httptest.NewRecorder()
+			webAPI.getBlock(w, httptest.NewRequest("GET", "/block"+tt.query, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package web
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/index"
+	"github.com/dogeorg/indexer/spec"
+)
+
+// getUtxoByScript lists UTXOs for a raw (compact) script of any indexed kind,
+// including MultiSig scripts that have no base58 address.
+func (a *WebAPI) getUtxoByScript(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.utxosByScript(store, r.URL.Query())
+		sendNegotiated(w, r, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) utxosByScript(store spec.Store, query url.Values) (any, error) {
+	kind, script, err := scriptParam(query)
+	if err != nil {
+		return nil, err
+	}
+	query.Del("script") // the lookup key here, not a /utxo script form
+	return a.utxosFor(store, kind, script, query)
+}
+
+// scriptParam parses the required 'kind' (doge.ScriptType number) and
+// 'script' (compact script payload, hex) parameters.
+func scriptParam(query url.Values) (kind doge.ScriptType, script []byte, err error) {
+	param := query.Get("kind")
+	if param == "" {
+		return 0, nil, badRequest("missing 'kind' in the URL")
+	}
+	n, err := strconv.Atoi(param)
+	if err != nil || n < int(doge.ScriptTypeP2PK) || n > int(doge.ScriptTypeNonStandard) {
+		return 0, nil, badRequest("'kind' must be an indexed script type from 1 to 6")
+	}
+	if !index.IsIndexedType(doge.ScriptType(n)) {
+		return 0, nil, badRequest("'kind' " + param + " is not indexed (NullData and NonStandard outputs are skipped)")
+	}
+	param = query.Get("script")
+	if param == "" {
+		return 0, nil, badRequest("missing 'script' in the URL")
+	}
+	script, err = hex.DecodeString(param)
+	if err != nil {
+		return 0, nil, badRequest("'script' must be hex")
+	}
+	return doge.ScriptType(n), script, nil
+}
@@ -0,0 +1,96 @@
+package web
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestGetUtxoByScript(t *testing.T) {
+	// 1-of-1 bare multisig: OP_1 <33-byte pubkey> OP_1 OP_CHECKMULTISIG
+	multiSigScript := append(append([]byte{0x51, 0x21}, bytes.Repeat([]byte{0x02}, 33)...), 0x51, 0xae)
+	_, multiSig := doge.ClassifyScript(multiSigScript) // compact form drops OP_CHECKMULTISIG
+	nonStandard := []byte{0x6a, 0x6a, 0x01}            // OP_RETURN OP_RETURN 01 (does not classify as NullData)
+
+	tests := []struct {
+		name           string
+		query          string
+		utxos          []spec.UTXO
+		expectedStatus int
+		expectedKind   doge.ScriptType
+		expectedScript []byte
+		expectedBody   string
+	}{
+		{
+			name:           "MultiSig script",
+			query:          "?kind=4&script=" + hex.EncodeToString(multiSig),
+			utxos:          []spec.UTXO{{TxID: []byte{1, 2}, VOut: 0, Value: 100000000, Type: doge.ScriptTypeMultiSig, Script: multiSig}},
+			expectedStatus: 200,
+			expectedKind:   doge.ScriptTypeMultiSig,
+			expectedScript: multiSig,
+			expectedBody:   `{"utxo":[{"tx":"0201","vout":0,"value":"1","type":"MultiSig","script":"` + hex.EncodeToString(multiSigScript) + `"}],"count":1,"total":"1"}`,
+		},
+		{
+			name:           "NonStandard kind is not indexed",
+			query:          "?kind=8&script=" + hex.EncodeToString(nonStandard),
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'kind' 8 is not indexed (NullData and NonStandard outputs are skipped)"}`,
+		},
+		{
+			name:           "NullData kind is not indexed",
+			query:          "?kind=7&script=00",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'kind' 7 is not indexed (NullData and NonStandard outputs are skipped)"}`,
+		},
+		{
+			name:           "Kind out of range",
+			query:          "?kind=9&script=00",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'kind' must be an indexed script type from 1 to 6"}`,
+		},
+		{
+			name:           "Kind zero",
+			query:          "?kind=0&script=00",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'kind' must be an indexed script type from 1 to 6"}`,
+		},
+		{
+			name:           "Missing script",
+			query:          "?kind=4",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"missing 'script' in the URL"}`,
+		},
+		{
+			name:           "Invalid hex",
+			query:          "?kind=4&script=zz",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'script' must be hex"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{utxos: tt.utxos}
+			server := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = mockStore
+
+			w := httptest.NewRecorder()
+			webAPI.getUtxoByScript(w, httptest.NewRequest("GET", "/utxo-by-script"+tt.query, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+			if tt.expectedStatus == 200 && (mockStore.utxoKind != tt.expectedKind || !bytes.Equal(mockStore.utxoScript, tt.expectedScript)) {
+				t.Errorf("expected lookup of kind %d script %x, got kind %d script %x", tt.expectedKind, tt.expectedScript, mockStore.utxoKind, mockStore.utxoScript)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Responses are "private; max-age=0" unless the endpoint has a cache TTL
+// (Options.CacheTTL), which lets proxies and CDNs share it for that long.
+// Only endpoints that don't depend on who is asking can be made public.
+
+const privateCacheControl = "private; max-age=0"
+
+// CacheableEndpoints can be given a public cache TTL.
+var CacheableEndpoints = []string{"/height", "/blocks", "/feerate", "/trimmed", "/txcount", "/txtotal"}
+
+// ParseCacheTTL parses "<path>=<duration>,..." (e.g. "/height=5s,/blocks=5s")
+// into Options.CacheTTL.
+func ParseCacheTTL(value string) (map[string]time.Duration, error) {
+	res := map[string]time.Duration{}
+	if value == "" {
+		return res, nil
+	}
+	for _, item := range strings.Split(value, ",") {
+		path, ttl, found := strings.Cut(item, "=")
+		if !found {
+			return nil, fmt.Errorf("expecting <path>=<duration>: %q", item)
+		}
+		if !isCacheable(path) {
+			return nil, fmt.Errorf("%s cannot be cached (cacheable: %s)", path, strings.Join(CacheableEndpoints, ", "))
+		}
+		duration, err := time.ParseDuration(ttl)
+		if err != nil || duration < time.Second {
+			return nil, fmt.Errorf("%s: cache TTL must be a duration of at least 1s: %q", path, ttl)
+		}
+		res[path] = duration
+	}
+	return res, nil
+}
+
+func isCacheable(path string) bool {
+	for _, p := range CacheableEndpoints {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheFor makes successful GET responses from `next` publicly cacheable for
+// `ttl` (errors stay private: sendError always sets its own Cache-Control.)
+func cacheFor(ttl time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	if ttl <= 0 {
+		return next
+	}
+	header := fmt.Sprintf("public, max-age=%d", int64(ttl/time.Second))
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Header().Set("Cache-Control", header)
+		}
+		next(w, r)
+	}
+}
+
+// setDefaultCacheControl keeps a Cache-Control header set by cacheFor,
+// otherwise makes the response private.
+func setDefaultCacheControl(w http.ResponseWriter) {
+	if w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", privateCacheControl)
+	}
+}
@@ -0,0 +1,73 @@
+package web
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHeightCacheControl(t *testing.T) {
+	tests := []struct {
+		name          string
+		cacheTTL      map[string]time.Duration
+		path          string
+		storeErr      error
+		expectedCache string
+	}{
+		{"Private by default", nil, "/height", nil, "private; max-age=0"},
+		{"Public when configured", map[string]time.Duration{"/height": 5 * time.Second}, "/height", nil, "public, max-age=5"},
+		{"Other endpoints stay private", map[string]time.Duration{"/height": 5 * time.Second}, "/balance?address=D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS", nil, "private; max-age=0"},
+		{"Errors stay private", map[string]time.Duration{"/height": 5 * time.Second}, "/height", errors.New("boom"), "private; max-age=0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{currentHeight: 100, heightErr: tt.storeErr}
+			webAPI := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}, CacheTTL: tt.cacheTTL}).(*WebAPI)
+			webAPI.store = mockStore
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Cache-Control"); got != tt.expectedCache {
+				t.Errorf("expected Cache-Control %q, got %q (status %d)", tt.expectedCache, got, w.Code)
+			}
+		})
+	}
+}
+
+func TestParseCacheTTL(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expected    map[string]time.Duration
+		expectedErr bool
+	}{
+		{"Empty", "", map[string]time.Duration{}, false},
+		{"One path", "/height=5s", map[string]time.Duration{"/height": 5 * time.Second}, false},
+		{"Two paths", "/height=5s,/blocks=1m", map[string]time.Duration{"/height": 5 * time.Second, "/blocks": time.Minute}, false},
+		{"Not cacheable", "/balance=5s", nil, true},
+		{"Missing duration", "/height", nil, true},
+		{"Bad duration", "/height=soon", nil, true},
+		{"Under a second", "/height=500ms", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := ParseCacheTTL(tt.value)
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if len(res) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, res)
+			}
+			for path, ttl := range tt.expected {
+				if res[path] != ttl {
+					t.Errorf("expected %s=%v, got %v", path, ttl, res[path])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package web
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dogeorg/indexer/index"
+	"github.com/dogeorg/indexer/spec"
+)
+
+// ChainHealthResponse summarises recent blocks and reorgs for risk scoring.
+// The window is the Indexer's in-memory block history (the last 10 blocks.)
+type ChainHealthResponse struct {
+	Height         int64    `json:"height"`               // indexed height
+	TipHeight      *int64   `json:"tip_height,omitempty"` // Core headers height (if Core RPC is configured)
+	Lag            *int64   `json:"lag,omitempty"`        // blocks between Height and TipHeight
+	Blocks         int      `json:"blocks"`               // blocks in the window (not counting undone blocks)
+	Intervals      int      `json:"intervals"`            // intervals between consecutive blocks in the window
+	AvgIntervalSec *float64 `json:"avg_interval_s"`       // mean of the intervals (null if none)
+	MinIntervalSec *float64 `json:"min_interval_s"`       // header timestamps aren't ordered, so this can be negative
+	MaxIntervalSec *float64 `json:"max_interval_s"`
+	Reorgs         int      `json:"reorgs"`          // reorgs since the oldest block in the window was indexed
+	MaxReorgDepth  int64    `json:"max_reorg_depth"` // most blocks undone by one of those reorgs
+}
+
+// getChainHealth reports block intervals, reorgs and lag.
+func (a *WebAPI) getChainHealth(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.chainHealthFor(store)
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) chainHealthFor(store spec.Store) (any, error) {
+	height, err := store.GetCurrentHeight()
+	if err != nil {
+		return nil, err
+	}
+	res := chainHealth(a.indexer.GetBlockHistory(), a.indexer.GetReorgHistory())
+	res.Height = height
+	res.TipHeight, res.Lag = a.coreLag(height)
+	return res, nil
+}
+
+// chainHealth computes the interval and reorg stats from the Indexer's
+// block history and reorg history (both most recent first.)
+func chainHealth(blocks []index.BlockHistory, reorgs []index.Reorg) ChainHealthResponse {
+	// after a reorg the history repeats heights: the earlier blocks were undone
+	var chain []index.BlockHistory
+	seen := map[int64]bool{}
+	for _, block := range blocks {
+		if !seen[block.Height] {
+			seen[block.Height] = true
+			chain = append(chain, block)
+		}
+	}
+	res := ChainHealthResponse{Blocks: len(chain)}
+	var total, least, most time.Duration
+	for n := 0; n+1 < len(chain); n++ {
+		if chain[n].Height != chain[n+1].Height+1 {
+			continue // only compare a block with its parent
+		}
+		interval := chain[n].Timestamp.Sub(chain[n+1].Timestamp)
+		if res.Intervals == 0 || interval < least {
+			least = interval
+		}
+		if res.Intervals == 0 || interval > most {
+			most = interval
+		}
+		total += interval
+		res.Intervals++
+	}
+	if res.Intervals > 0 {
+		avg, lo, hi := total.Seconds()/float64(res.Intervals), least.Seconds(), most.Seconds()
+		res.AvgIntervalSec, res.MinIntervalSec, res.MaxIntervalSec = &avg, &lo, &hi
+	}
+	var since time.Time // all remembered reorgs if there are no blocks yet
+	if len(blocks) > 0 {
+		since = blocks[len(blocks)-1].ProcessedAt
+	}
+	for _, reorg := range reorgs {
+		if reorg.UndoAt.Before(since) {
+			continue
+		}
+		res.Reorgs++
+		res.MaxReorgDepth = max(res.MaxReorgDepth, reorg.Depth)
+	}
+	return res
+}
@@ -0,0 +1,76 @@
+package web
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dogeorg/indexer/index"
+)
+
+func TestGetChainHealth(t *testing.T) {
+	start := time.Date(2026, time.October, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+	// most recent first, like Indexer.GetBlockHistory; 104 replaced an orphaned 104 (undo to 103)
+	blocks := []index.BlockHistory{
+		{Height: 105, Timestamp: at(230), ProcessedAt: at(235)},
+		{Height: 104, Timestamp: at(170), ProcessedAt: at(175)},
+		{Height: 104, Timestamp: at(150), ProcessedAt: at(155)},
+		{Height: 103, Timestamp: at(100), ProcessedAt: at(105)},
+		{Height: 102, Timestamp: at(110), ProcessedAt: at(112)}, // header time earlier than its child's
+		{Height: 101, Timestamp: at(0), ProcessedAt: at(3)},
+	}
+	reorgs := []index.Reorg{
+		{Height: 103, Depth: 1, UndoAt: at(160)},
+		{Height: 90, Depth: 3, UndoAt: at(2)}, // before the window
+	}
+	tests := []struct {
+		name           string
+		store          *MockStore
+		indexer        *MockIndexer
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Intervals and reorgs",
+			store:          &MockStore{currentHeight: 105},
+			indexer:        &MockIndexer{blockHistory: blocks, reorgHistory: reorgs},
+			expectedStatus: 200,
+			// intervals 105-104: 60s, 104-103: 70s, 103-102: -10s, 102-101: 110s (the undone 104 is skipped)
+			expectedBody: `{"height":105,"blocks":5,"intervals":4,"avg_interval_s":57.5,"min_interval_s":-10,"max_interval_s":110,"reorgs":1,"max_reorg_depth":1}`,
+		},
+		{
+			name:           "Reorg before any blocks",
+			store:          &MockStore{currentHeight: 90},
+			indexer:        &MockIndexer{reorgHistory: reorgs[1:]},
+			expectedStatus: 200,
+			expectedBody:   `{"height":90,"blocks":0,"intervals":0,"avg_interval_s":null,"min_interval_s":null,"max_interval_s":null,"reorgs":1,"max_reorg_depth":3}`,
+		},
+		{
+			name:           "Store error",
+			store:          &MockStore{heightErr: errors.New("db down")},
+			indexer:        &MockIndexer{},
+			expectedStatus: 500,
+			expectedBody:   `{"error":"error","reason":"db down"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: tt.store, Indexer: tt.indexer})
+			webAPI := server.(*WebAPI)
+			webAPI.store = tt.store
+
+			w := httptest.NewRecorder()
+			webAPI.getChainHealth(w, httptest.NewRequest("GET", "/chain-health", nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
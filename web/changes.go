@@ -0,0 +1,70 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/dogeorg/indexer/spec"
+)
+
+const defaultChangesLimit = 1000 // events per /changes request
+const maxChangesLimit = 10000
+
+type ChangeItem struct {
+	Seq    int64  `json:"seq"`    // pass the last seq as 'since' to get the next events
+	Op     string `json:"op"`     // create, spend, undo-create or undo-spend
+	Height int64  `json:"height"` // height of the block that created or spent the UTXO
+	UTXOItem
+}
+
+type ChangesResponse struct {
+	Changes []ChangeItem `json:"changes"`
+	Next    int64        `json:"next"` // 'since' for the next request (unchanged if there are no new events)
+}
+
+// getChanges lists UTXO events after a sequence number, so consumers can
+// follow the UTXO set incrementally (including reorg undos) and resume
+// from the last sequence number they processed.
+func (a *WebAPI) getChanges(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.changes(store, r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) changes(store spec.Store, query url.Values) (any, error) {
+	var since int64
+	if param := query.Get("since"); param != "" {
+		var err error
+		since, err = strconv.ParseInt(param, 10, 64)
+		if err != nil || since < 0 {
+			return nil, badRequest("'since' must be a sequence number")
+		}
+	}
+	limit := defaultChangesLimit
+	if param := query.Get("limit"); param != "" {
+		var err error
+		limit, err = strconv.Atoi(param)
+		if err != nil || limit < 1 || limit > maxChangesLimit {
+			return nil, badRequest(fmt.Sprintf("'limit' must be from 1 to %d", maxChangesLimit))
+		}
+	}
+	events, err := store.GetUTXOEvents(since, limit)
+	if err != nil {
+		return nil, err
+	}
+	response := ChangesResponse{Changes: []ChangeItem{}, Next: since}
+	for _, e := range events {
+		response.Changes = append(response.Changes, ChangeItem{Seq: e.Seq, Op: e.Op.String(), Height: e.Height, UTXOItem: utxoItem(e.UTXO)})
+		response.Next = e.Seq
+	}
+	return response, nil
+}
@@ -0,0 +1,103 @@
+package web
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestGetChanges(t *testing.T) {
+	hash := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}
+	utxo := spec.UTXO{TxID: []byte{0xAB}, VOut: 1, Value: 100000000, Type: doge.ScriptTypeP2PKH, Script: hash}
+	events := []spec.UTXOEvent{
+		{Seq: 1, Op: spec.EventCreate, UTXOChange: spec.UTXOChange{UTXO: utxo, Height: 100}},
+		{Seq: 2, Op: spec.EventSpend, UTXOChange: spec.UTXOChange{UTXO: utxo, Height: 101}},
+		{Seq: 3, Op: spec.EventUndoSpend, UTXOChange: spec.UTXOChange{UTXO: utxo, Height: 101}},
+	}
+	item := `"tx":"ab","vout":1,"value":"1","type":"P2PKH","script":"76a9140102030405060708090a0b0c0d0e0f101112131488ac"`
+
+	tests := []struct {
+		name           string
+		query          string
+		store          *MockStore
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "From the start",
+			query:          "",
+			store:          &MockStore{events: events},
+			expectedStatus: 200,
+			expectedBody:   `{"changes":[{"seq":1,"op":"create","height":100,` + item + `},{"seq":2,"op":"spend","height":101,` + item + `},{"seq":3,"op":"undo-spend","height":101,` + item + `}],"next":3}`,
+		},
+		{
+			name:           "Since with limit",
+			query:          "?since=1&limit=1",
+			store:          &MockStore{events: events},
+			expectedStatus: 200,
+			expectedBody:   `{"changes":[{"seq":2,"op":"spend","height":101,` + item + `}],"next":2}`,
+		},
+		{
+			name:           "Caught up",
+			query:          "?since=3",
+			store:          &MockStore{events: events},
+			expectedStatus: 200,
+			expectedBody:   `{"changes":[],"next":3}`,
+		},
+		{
+			name:           "Invalid since",
+			query:          "?since=-1",
+			store:          &MockStore{},
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'since' must be a sequence number"}`,
+		},
+		{
+			name:           "Limit too large",
+			query:          "?limit=10001",
+			store:          &MockStore{},
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'limit' must be from 1 to 10000"}`,
+		},
+		{
+			name:           "Store error",
+			query:          "",
+			store:          &MockStore{eventsErr: errors.New("db down")},
+			expectedStatus: 500,
+			expectedBody:   `{"error":"error","reason":"db down"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: tt.store, Indexer: &MockIndexer{}, Changes: true})
+			webAPI := server.(*WebAPI)
+			webAPI.store = tt.store
+
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/changes"+tt.query, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestChangesDisabled(t *testing.T) {
+	store := &MockStore{}
+	server := New(Options{Bind: ":0", Store: store, Indexer: &MockIndexer{}})
+	webAPI := server.(*WebAPI)
+	webAPI.store = store
+
+	w := httptest.NewRecorder()
+	webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/changes", nil))
+	if w.Code != 404 {
+		t.Errorf("expected status 404 without Options.Changes, got %d", w.Code)
+	}
+}
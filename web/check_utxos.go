@@ -0,0 +1,100 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/doge/koinu"
+	"github.com/dogeorg/indexer/spec"
+)
+
+const maxCheckUTXOs = 1000 // outpoints per /check-utxos request
+
+// CheckOutPoint is one outpoint in a /check-utxos request, e.g. {"txid":"ab12...","vout":0}
+type CheckOutPoint struct {
+	TxID string  `json:"txid"` // hex-encoded transaction ID (byte-reversed)
+	VOut *uint32 `json:"vout"` // transaction output number
+}
+
+// CheckUTXOItem is the state of one outpoint, in request order.
+// Spent UTXOs are deleted after a while, so they become not present.
+type CheckUTXOItem struct {
+	TxID          string       `json:"tx"`              // hex-encoded transaction ID (byte-reversed)
+	VOut          uint32       `json:"vout"`            // transaction output number
+	Present       bool         `json:"present"`         // the UTXO is in the index
+	Unspent       bool         `json:"unspent"`         // present and not spent
+	Value         *koinu.Koinu `json:"value,omitempty"` // only if present
+	Coinbase      bool         `json:"coinbase"`        // not spendable until mature
+	Confirmations int64        `json:"confirmations"`   // 1 in the tip block (0 if not present)
+}
+
+// postCheckUTXOs checks whether a list of outpoints are still unspent.
+func (a *WebAPI) postCheckUTXOs(w http.ResponseWriter, r *http.Request) {
+	options := "POST, OPTIONS"
+	switch r.Method {
+	case http.MethodPost:
+		var outpoints []CheckOutPoint
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBytes)).Decode(&outpoints); err != nil {
+			sendError(w, CodeBadRequest, "expecting a JSON array of {txid, vout}", options, a.corsOrigin)
+			return
+		}
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.checkUTXOs(store, outpoints)
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) checkUTXOs(store spec.Store, outpoints []CheckOutPoint) (any, error) {
+	limit := a.batchLimit(maxCheckUTXOs)
+	if len(outpoints) == 0 || len(outpoints) > limit {
+		return nil, badRequest(fmt.Sprintf("expecting between 1 and %d outpoints", limit))
+	}
+	if len(outpoints) > a.maxBatchRows {
+		return nil, tooLarge(fmt.Sprintf("batch would return more than %d rows", a.maxBatchRows))
+	}
+	keys := make([]spec.OutPointKey, len(outpoints))
+	for n, out := range outpoints {
+		hash, err := doge.HexDecodeReversed(out.TxID)
+		if err != nil || len(hash) != 32 {
+			return nil, badRequest(fmt.Sprintf("outpoint %d: 'txid' must be 32 bytes of hex", n))
+		}
+		if out.VOut == nil {
+			return nil, badRequest(fmt.Sprintf("outpoint %d: missing 'vout'", n))
+		}
+		keys[n] = spec.OutPoint(hash, *out.VOut)
+	}
+	utxos, err := store.GetUTXOs(keys)
+	if err != nil {
+		return nil, err
+	}
+	current, err := store.GetCurrentHeight()
+	if err != nil {
+		return nil, err
+	}
+	type outpoint struct {
+		tx   string
+		vout uint32
+	}
+	found := map[outpoint]spec.UTXOState{}
+	for _, u := range utxos {
+		found[outpoint{string(u.TxID), u.VOut}] = u
+	}
+	items := make([]CheckUTXOItem, len(keys))
+	for n, key := range keys {
+		items[n] = CheckUTXOItem{TxID: doge.HexEncodeReversed(key.Tx), VOut: key.VOut}
+		if u, ok := found[outpoint{string(key.Tx), key.VOut}]; ok {
+			value := koinu.Koinu(u.Value)
+			items[n].Present = true
+			items[n].Unspent = u.Spent == 0
+			items[n].Value = &value
+			items[n].Coinbase = u.Coinbase
+			items[n].Confirmations = current - u.Height + 1
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,92 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestCheckUTXOs(t *testing.T) {
+	tx1, tx2, tx3 := strings.Repeat("11", 32), strings.Repeat("22", 32), strings.Repeat("33", 32)
+	hash1, hash2 := bytes.Repeat([]byte{0x11}, 32), bytes.Repeat([]byte{0x22}, 32)
+	stored := []spec.UTXOState{
+		{UTXO: spec.UTXO{TxID: hash1, VOut: 0, Value: 150000000, Type: doge.ScriptTypeP2PKH}, Height: 95},
+		{UTXO: spec.UTXO{TxID: hash1, VOut: 1, Value: 100000000, Type: doge.ScriptTypeP2PKH}, Height: 95, Spent: 99},
+		{UTXO: spec.UTXO{TxID: hash2, VOut: 0, Value: 1000000000000, Type: doge.ScriptTypeP2PKH, Coinbase: true}, Height: 100},
+	}
+	tests := []struct {
+		name           string
+		body           string
+		store          *MockStore
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Unspent, spent and missing",
+			body:           `[{"txid":"` + tx1 + `","vout":0},{"txid":"` + tx1 + `","vout":1},{"txid":"` + tx3 + `","vout":0},{"txid":"` + tx2 + `","vout":0}]`,
+			store:          &MockStore{currentHeight: 100, utxoStates: stored},
+			expectedStatus: 200,
+			expectedBody: `[{"tx":"` + tx1 + `","vout":0,"present":true,"unspent":true,"value":"1.5","coinbase":false,"confirmations":6},` +
+				`{"tx":"` + tx1 + `","vout":1,"present":true,"unspent":false,"value":"1","coinbase":false,"confirmations":6},` +
+				`{"tx":"` + tx3 + `","vout":0,"present":false,"unspent":false,"coinbase":false,"confirmations":0},` +
+				`{"tx":"` + tx2 + `","vout":0,"present":true,"unspent":true,"value":"10000","coinbase":true,"confirmations":1}]`,
+		},
+		{
+			name:           "Store error",
+			body:           `[{"txid":"` + tx1 + `","vout":0}]`,
+			store:          &MockStore{utxoErr: errors.New("db down")},
+			expectedStatus: 500,
+			expectedBody:   `{"error":"error","reason":"db down"}`,
+		},
+		{
+			name:           "Not JSON",
+			body:           `{"txid":"` + tx1 + `"}`,
+			store:          &MockStore{},
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"expecting a JSON array of {txid, vout}"}`,
+		},
+		{
+			name:           "Empty",
+			body:           `[]`,
+			store:          &MockStore{},
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"expecting between 1 and 1000 outpoints"}`,
+		},
+		{
+			name:           "Bad txid",
+			body:           `[{"txid":"` + tx1 + `","vout":0},{"txid":"0102","vout":0}]`,
+			store:          &MockStore{},
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"outpoint 1: 'txid' must be 32 bytes of hex"}`,
+		},
+		{
+			name:           "Missing vout",
+			body:           `[{"txid":"` + tx1 + `"}]`,
+			store:          &MockStore{},
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"outpoint 0: missing 'vout'"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webAPI := New(Options{Bind: ":0", Store: tt.store, Indexer: &MockIndexer{}}).(*WebAPI)
+			webAPI.store = tt.store
+
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("POST", "/check-utxos", strings.NewReader(tt.body)))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
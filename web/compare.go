@@ -0,0 +1,126 @@
+package web
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+// /admin/compare spot-checks the shadow store (-shadowdb) against the store:
+// the heights, unspent counts, and the balances of a random sample of
+// addresses. Only registered with an admin token and a shadow store. Once a
+// block fails to write to the shadow it is no longer written, and
+// shadow_failed_at gives the height (see index.ShadowStatus.)
+
+const defaultCompareSample = 20
+const maxCompareSample = 1000
+
+type CompareResponse struct {
+	Height        int64             `json:"height"`
+	ShadowHeight  int64             `json:"shadow_height"`
+	Unspent       int64             `json:"unspent"`
+	ShadowUnspent int64             `json:"shadow_unspent"`
+	ShadowFailed  *int64            `json:"shadow_failed_at,omitempty"` // height of the failed block or undo
+	Sampled       int               `json:"sampled"`                    // addresses compared
+	Mismatches    []BalanceMismatch `json:"mismatches"`                 // sampled addresses whose balances differ
+	Match         bool              `json:"match"`                      // not failed, and heights, counts and sampled balances all match
+}
+
+type BalanceMismatch struct {
+	Type    string       `json:"type"`
+	Script  string       `json:"script"`            // compact script (hex)
+	Address string       `json:"address,omitempty"` // for P2PKH and P2SH
+	Balance spec.Balance `json:"balance"`
+	Shadow  spec.Balance `json:"shadow"`
+}
+
+func (a *WebAPI) adminCompare(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		if !a.authorizeAdmin(w, r, options) {
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), a.queryTimeout)
+		defer cancel()
+		payload, err := a.compare(a.store.WithCtx(ctx), a.shadow.WithCtx(ctx), r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) compare(store spec.Store, shadow spec.Store, query url.Values) (any, error) {
+	sample := defaultCompareSample
+	if param := query.Get("sample"); param != "" {
+		var err error
+		sample, err = strconv.Atoi(param)
+		if err != nil || sample < 0 || sample > maxCompareSample {
+			return nil, badRequest(fmt.Sprintf("'sample' must be from 0 to %d", maxCompareSample))
+		}
+	}
+	var res CompareResponse
+	var err error
+	if res.Height, err = store.GetCurrentHeight(); err != nil {
+		return nil, err
+	}
+	if res.ShadowHeight, err = shadow.GetCurrentHeight(); err != nil {
+		return nil, err
+	}
+	if res.Unspent, err = store.GetUnspentCount(); err != nil {
+		return nil, err
+	}
+	if res.ShadowUnspent, err = shadow.GetUnspentCount(); err != nil {
+		return nil, err
+	}
+	addresses, err := store.SampleAddresses(sample)
+	if err != nil {
+		return nil, err
+	}
+	res.Sampled = len(addresses)
+	res.Mismatches = []BalanceMismatch{}
+	for _, key := range addresses {
+		balance, err := store.GetBalance(key.Kind, key.Script, a.confirmations)
+		if err != nil {
+			return nil, err
+		}
+		shadowBalance, err := shadow.GetBalance(key.Kind, key.Script, a.confirmations)
+		if err != nil {
+			return nil, err
+		}
+		if sameBalance(balance, shadowBalance) {
+			continue
+		}
+		mismatch := BalanceMismatch{
+			Type:    utxoKindStr(key.Kind),
+			Script:  hex.EncodeToString(key.Script),
+			Balance: withCurrent(balance),
+			Shadow:  withCurrent(shadowBalance),
+		}
+		if key.Kind == doge.ScriptTypeP2PKH || key.Kind == doge.ScriptTypeP2SH {
+			mismatch.Address, _ = encodeAddress(a.chain, key.Kind, key.Script)
+		}
+		res.Mismatches = append(res.Mismatches, mismatch)
+	}
+	if status := a.indexer.GetShadowStatus(); status.Failed {
+		res.ShadowFailed = &status.FailedAt
+	}
+	res.Match = res.ShadowFailed == nil && res.Height == res.ShadowHeight && res.Unspent == res.ShadowUnspent && len(res.Mismatches) == 0
+	return res, nil
+}
+
+func sameBalance(a spec.Balance, b spec.Balance) bool {
+	return a.Available.Equal(b.Available) && a.Incoming.Equal(b.Incoming) &&
+		a.Outgoing.Equal(b.Outgoing) && a.UTXOCount == b.UTXOCount
+}
+
+func withCurrent(balance spec.Balance) spec.Balance {
+	balance.Current = balance.Available.Add(balance.Incoming)
+	return balance
+}
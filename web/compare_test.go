@@ -0,0 +1,106 @@
+package web
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/index"
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestAdminCompare(t *testing.T) {
+	hash := bytes.Repeat([]byte{0x42}, 20)
+	address := string(doge.Hash160toAddress(hash, doge.DogeMainNetChain.P2PKH_Address_Prefix))
+	sample := []spec.AddressKey{{Kind: doge.ScriptTypeP2PKH, Script: hash}}
+	balance := spec.Balance{Available: bigKoinu(100000000), Incoming: bigKoinu(0), Outgoing: bigKoinu(0), UTXOCount: 1}
+	other := spec.Balance{Available: bigKoinu(50000000), Incoming: bigKoinu(0), Outgoing: bigKoinu(0), UTXOCount: 1}
+
+	tests := []struct {
+		name           string
+		target         string
+		auth           string
+		shadow         *MockStore
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Match", "/admin/compare", "Bearer s3cret",
+			&MockStore{currentHeight: 100, unspentCount: 5, balance: balance}, 200,
+			`{"height":100,"shadow_height":100,"unspent":5,"shadow_unspent":5,"sampled":1,"mismatches":[],"match":true}`},
+		{"Behind", "/admin/compare", "Bearer s3cret",
+			&MockStore{currentHeight: 99, unspentCount: 4, balance: balance}, 200,
+			`{"height":100,"shadow_height":99,"unspent":5,"shadow_unspent":4,"sampled":1,"mismatches":[],"match":false}`},
+		{"Balance differs", "/admin/compare", "Bearer s3cret",
+			&MockStore{currentHeight: 100, unspentCount: 5, balance: other}, 200,
+			`{"height":100,"shadow_height":100,"unspent":5,"shadow_unspent":5,"sampled":1,"mismatches":[{"type":"P2PKH","script":"4242424242424242424242424242424242424242","address":"` + address + `","balance":{"incoming":"0","available":"1","outgoing":"0","current":"1","utxo_count":1},"shadow":{"incoming":"0","available":"0.5","outgoing":"0","current":"0.5","utxo_count":1}}],"match":false}`},
+		{"No sample", "/admin/compare?sample=0", "Bearer s3cret",
+			&MockStore{currentHeight: 100, unspentCount: 5, balance: other}, 200,
+			`{"height":100,"shadow_height":100,"unspent":5,"shadow_unspent":5,"sampled":0,"mismatches":[],"match":true}`},
+		{"Bad sample", "/admin/compare?sample=x", "Bearer s3cret",
+			&MockStore{}, 400,
+			`{"error":"bad-request","reason":"'sample' must be from 0 to 1000"}`},
+		{"Missing token", "/admin/compare", "",
+			&MockStore{}, 401,
+			`{"error":"unauthorized","reason":"admin token required"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &MockStore{currentHeight: 100, unspentCount: 5, balance: balance, sample: sample}
+			server := New(Options{Bind: ":0", Store: s, Indexer: &MockIndexer{}, AdminToken: "s3cret", Shadow: tt.shadow})
+			webAPI := server.(*WebAPI)
+			webAPI.store = s
+			webAPI.shadow = tt.shadow
+
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %s, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAdminCompareDisabledWithoutShadow(t *testing.T) {
+	server := New(Options{Bind: ":0", Store: &MockStore{}, Indexer: &MockIndexer{}, AdminToken: "s3cret"})
+	webAPI := server.(*WebAPI)
+
+	req := httptest.NewRequest("GET", "/admin/compare", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	w := httptest.NewRecorder()
+	webAPI.srv.Handler.ServeHTTP(w, req)
+
+	if w.Code != 404 {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+func TestAdminCompareShadowFailed(t *testing.T) {
+	s := &MockStore{currentHeight: 100, unspentCount: 5}
+	shadow := &MockStore{currentHeight: 100, unspentCount: 5}
+	indexer := &MockIndexer{shadow: index.ShadowStatus{Failed: true, FailedAt: 90}}
+	server := New(Options{Bind: ":0", Store: s, Indexer: indexer, AdminToken: "s3cret", Shadow: shadow})
+	webAPI := server.(*WebAPI)
+	webAPI.store = s
+	webAPI.shadow = shadow
+
+	req := httptest.NewRequest("GET", "/admin/compare?sample=0", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	w := httptest.NewRecorder()
+	webAPI.srv.Handler.ServeHTTP(w, req)
+
+	// the counts match, but the shadow stopped being written at 90
+	expected := `{"height":100,"shadow_height":100,"unspent":5,"shadow_unspent":5,"shadow_failed_at":90,"sampled":0,"mismatches":[],"match":false}`
+	if w.Body.String() != expected {
+		t.Errorf("expected body %s, got %s", expected, w.Body.String())
+	}
+}
@@ -0,0 +1,118 @@
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/mempool"
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestCSVResponses(t *testing.T) {
+	validAddress := "D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS"
+	utxos := []spec.UTXO{
+		{TxID: []byte{1, 2, 3, 4}, VOut: 0, Value: 100000000, Type: doge.ScriptTypeP2PKH, Script: []byte{0x76, 0xA9, 0x14, 0x88, 0xAC}},
+		{TxID: []byte{0xAA, 0xBB}, VOut: 7, Value: 12345, Type: doge.ScriptTypeP2SH, Script: make([]byte, 20)},
+	}
+	balance := spec.Balance{Available: bigKoinu(100000000), Incoming: bigKoinu(50000000), Outgoing: bigKoinu(0), UTXOCount: 2}
+
+	tests := []struct {
+		name         string
+		path         string
+		accept       string
+		pending      mempool.Monitor
+		expectedType string
+		expectedBody string
+	}{
+		{
+			name:         "UTXO via format param",
+			path:         "/utxo?format=csv&address=" + validAddress,
+			expectedType: "text/csv; charset=utf-8",
+			expectedBody: "tx,vout,value,type,script\n" +
+				"04030201,0,1,P2PKH,76a91476a91488ac00000000000000000000000000000088ac\n" +
+				"bbaa,7,0.00012345,P2SH,a914000000000000000000000000000000000000000087\n",
+		},
+		{
+			name:         "UTXO via Accept header",
+			path:         "/utxo?address=" + validAddress,
+			accept:       "text/csv",
+			expectedType: "text/csv; charset=utf-8",
+			expectedBody: "tx,vout,value,type,script\n" +
+				"04030201,0,1,P2PKH,76a91476a91488ac00000000000000000000000000000088ac\n" +
+				"bbaa,7,0.00012345,P2SH,a914000000000000000000000000000000000000000087\n",
+		},
+		{
+			name:         "Balance",
+			path:         "/balance?format=csv&address=" + validAddress,
+			expectedType: "text/csv; charset=utf-8",
+			expectedBody: "incoming,available,outgoing,current,utxo_count\n0.5,1,0,1.5,2\n",
+		},
+		{
+			name:         "Balance with mempool",
+			path:         "/balance?format=csv&address=" + validAddress,
+			pending:      &MockMempool{pending: mempool.Balance{Incoming: 25000000, TxCount: 2}},
+			expectedType: "text/csv; charset=utf-8",
+			expectedBody: "incoming,available,outgoing,current,utxo_count,mempool_incoming,mempool_tx_count\n0.5,1,0,1.5,2,0.25,2\n",
+		},
+		{
+			name:         "JSON is the default",
+			path:         "/balance?address=" + validAddress,
+			expectedType: "application/json",
+			expectedBody: `{"incoming":"0.5","available":"1","outgoing":"0","current":"1.5","utxo_count":2}`,
+		},
+		{
+			name:         "format=json overrides Accept",
+			path:         "/balance?format=json&address=" + validAddress,
+			accept:       "text/csv",
+			expectedType: "application/json",
+			expectedBody: `{"incoming":"0.5","available":"1","outgoing":"0","current":"1.5","utxo_count":2}`,
+		},
+		{
+			name:         "Errors stay JSON",
+			path:         "/utxo?format=csv",
+			expectedType: "application/json",
+			expectedBody: `{"error":"bad-request","reason":"missing 'address' in the URL"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{utxos: utxos, balance: balance}
+			server := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}, Mempool: tt.pending})
+			webAPI := server.(*WebAPI)
+			webAPI.store = mockStore
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			webAPI.srv.Handler.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Type"); got != tt.expectedType {
+				t.Errorf("expected Content-Type %q, got %q", tt.expectedType, got)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSendCsvEscaping(t *testing.T) {
+	w := httptest.NewRecorder()
+	sendCsv(w, csvRowsFunc(func() ([]string, [][]string) {
+		return []string{"a", "b"}, [][]string{{"plain,comma", `say "hi"`}, {"multi\nline", "ab12"}}
+	}), "GET, OPTIONS", "")
+
+	expected := "a,b\n\"plain,comma\",\"say \"\"hi\"\"\"\n\"multi\nline\",ab12\n"
+	if w.Body.String() != expected {
+		t.Errorf("expected body %q, got %q", expected, w.Body.String())
+	}
+}
+
+type csvRowsFunc func() ([]string, [][]string)
+
+func (f csvRowsFunc) csvRows() ([]string, [][]string) { return f() }
@@ -0,0 +1,89 @@
+package web
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Amounts (koinu.Koinu and spec.BigKoinu) are sent as decimal strings in DOGE,
+// always formatted the same way, whatever the server's locale: an optional
+// '-', the whole DOGE digits with no thousands separators, then '.' and up to
+// 8 decimals without trailing zeros ("1.5", "0.00000001", "12"). With
+// ?decimals=fixed, /balance and /utxo (and the other address and script
+// endpoints) send exactly 8 decimals instead ("1.50000000", "12.00000000").
+
+const amountDecimals = 8 // koinu per DOGE is 10^8
+
+// amountFields are the JSON keys and CSV columns that hold amounts.
+var amountFields = []string{"incoming", "available", "outgoing", "current", "value", "total", "total_value"}
+
+// fixedAmountJSON matches an amount field in compact JSON (json.Marshal output.)
+var fixedAmountJSON = regexp.MustCompile(`"(` + strings.Join(amountFields, "|") + `)":"(-?[0-9]+(?:\.[0-9]{1,8})?)"`)
+
+// decimalsParam parses ?decimals: true for fixed, false for the default (trimmed.)
+func decimalsParam(query url.Values) (fixed bool, err error) {
+	switch query.Get("decimals") {
+	case "", "trim":
+		return false, nil
+	case "fixed":
+		return true, nil
+	}
+	return false, badRequest("'decimals' must be one of: trim, fixed")
+}
+
+// fixedDecimals pads an amount to exactly 8 decimals: "1.5" becomes "1.50000000".
+func fixedDecimals(amount string) string {
+	whole, decimals, _ := strings.Cut(amount, ".")
+	return whole + "." + decimals + strings.Repeat("0", amountDecimals-len(decimals))
+}
+
+// withDecimals returns the response for ?decimals (`payload` itself unless fixed.)
+func withDecimals(payload csvTable, fixed bool) any {
+	if !fixed {
+		return payload
+	}
+	return fixedDecimalsResponse{payload}
+}
+
+// fixedDecimalsResponse sends a response's amounts with exactly 8 decimals,
+// as JSON or CSV.
+type fixedDecimalsResponse struct {
+	payload csvTable
+}
+
+func (f fixedDecimalsResponse) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(f.payload)
+	if err != nil {
+		return nil, err
+	}
+	return fixedAmountJSON.ReplaceAllFunc(data, func(field []byte) []byte {
+		match := fixedAmountJSON.FindSubmatch(field)
+		return []byte(`"` + string(match[1]) + `":"` + fixedDecimals(string(match[2])) + `"`)
+	}), nil
+}
+
+func (f fixedDecimalsResponse) csvRows() (header []string, rows [][]string) {
+	header, rows = f.payload.csvRows()
+	for col, name := range header {
+		if !isAmountColumn(name) {
+			continue
+		}
+		for _, row := range rows {
+			row[col] = fixedDecimals(row[col])
+		}
+	}
+	return header, rows
+}
+
+// isAmountColumn recognises amount columns, including prefixed ones
+// such as "unconfirmed_incoming".
+func isAmountColumn(name string) bool {
+	for _, field := range amountFields {
+		if name == field || strings.HasSuffix(name, "_"+field) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestDecimals(t *testing.T) {
+	validAddress := "D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS"
+	hash := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}
+	script := "76a9140102030405060708090a0b0c0d0e0f101112131488ac" // expanded P2PKH script
+	store := &MockStore{
+		balance: spec.Balance{Available: bigKoinu(150000000), Incoming: bigKoinu(0), Outgoing: bigKoinu(1), UTXOCount: 2},
+		utxos: []spec.UTXO{
+			{TxID: []byte{1}, Value: 200000000, Type: doge.ScriptTypeP2PKH, Script: hash},
+			{TxID: []byte{2}, Value: 1, Type: doge.ScriptTypeP2PKH, Script: hash},
+			{TxID: []byte{3}, Value: 9223372036854775807, Type: doge.ScriptTypeP2PKH, Script: hash},
+		},
+	}
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Balance, default", "/balance?address=" + validAddress, 200, `{"incoming":"0","available":"1.5","outgoing":"0.00000001","current":"1.5","utxo_count":2}`},
+		{"Balance, trim", "/balance?decimals=trim&address=" + validAddress, 200, `{"incoming":"0","available":"1.5","outgoing":"0.00000001","current":"1.5","utxo_count":2}`},
+		{"Balance, fixed", "/balance?decimals=fixed&address=" + validAddress, 200, `{"incoming":"0.00000000","available":"1.50000000","outgoing":"0.00000001","current":"1.50000000","utxo_count":2}`},
+		{"Balance, fixed CSV", "/balance?decimals=fixed&format=csv&address=" + validAddress, 200, "incoming,available,outgoing,current,utxo_count\n0.00000000,1.50000000,0.00000001,1.50000000,2\n"},
+		{"UTXOs, default", "/utxo?address=" + validAddress, 200, `{"utxo":[{"tx":"01","vout":0,"value":"2","type":"P2PKH","script":"` + script + `"},{"tx":"02","vout":0,"value":"0.00000001","type":"P2PKH","script":"` + script + `"},{"tx":"03","vout":0,"value":"92233720368.54775807","type":"P2PKH","script":"` + script + `"}],"count":3,"total":"92233720370.54775808"}`},
+		{"UTXOs, fixed", "/utxo?decimals=fixed&address=" + validAddress, 200, `{"utxo":[{"tx":"01","vout":0,"value":"2.00000000","type":"P2PKH","script":"` + script + `"},{"tx":"02","vout":0,"value":"0.00000001","type":"P2PKH","script":"` + script + `"},{"tx":"03","vout":0,"value":"92233720368.54775807","type":"P2PKH","script":"` + script + `"}],"count":3,"total":"92233720370.54775808"}`},
+		{"UTXOs, fixed CSV", "/utxo?decimals=fixed&format=csv&address=" + validAddress, 200, "tx,vout,value,type,script\n01,0,2.00000000,P2PKH," + script + "\n02,0,0.00000001,P2PKH," + script + "\n03,0,92233720368.54775807,P2PKH," + script + "\n"},
+		{"Invalid decimals", "/utxo?decimals=8&address=" + validAddress, 400, `{"error":"bad-request","reason":"'decimals' must be one of: trim, fixed"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: store, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = store
+
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestFixedDecimals(t *testing.T) {
+	for amount, expected := range map[string]string{
+		"0":                    "0.00000000",
+		"12":                   "12.00000000",
+		"1.5":                  "1.50000000",
+		"0.00000001":           "0.00000001",
+		"-0.1":                 "-0.10000000",
+		"92233720368.54775807": "92233720368.54775807",
+	} {
+		if got := fixedDecimals(amount); got != expected {
+			t.Errorf("fixedDecimals(%q): expected %q, got %q", amount, expected, got)
+		}
+	}
+}
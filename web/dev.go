@@ -0,0 +1,67 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/dogeorg/doge"
+)
+
+// Dev endpoints are only registered on regtest, when Options.DevTools is set.
+
+const maxGenerateBlocks = 1000 // blocks per /dev/generate request
+
+// CoreRequester makes raw Core RPC requests (core.CoreRPCClient implements it.)
+type CoreRequester interface {
+	Request(ctx context.Context, method string, params []any, result any) (int, error)
+}
+
+type GenerateResponse struct {
+	Address string   `json:"address"` // address the block rewards were paid to
+	Blocks  []string `json:"blocks"`  // hashes of the generated blocks, hex
+}
+
+// isRegTest reports whether dev endpoints may be served on `chain`.
+func isRegTest(chain *doge.ChainParams) bool {
+	return chain != nil && chain.ChainName == doge.DogeRegTestChain.ChainName
+}
+
+// devGenerate mines blocks on the regtest node with Core's generatetoaddress.
+func (a *WebAPI) devGenerate(w http.ResponseWriter, r *http.Request) {
+	options := "POST, OPTIONS"
+	switch r.Method {
+	case http.MethodPost:
+		payload, err := a.generate(r.Context(), r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	default:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) generate(ctx context.Context, query url.Values) (any, error) {
+	n := int64(1)
+	if param := query.Get("n"); param != "" {
+		var err error
+		n, err = strconv.ParseInt(param, 10, 64)
+		if err != nil || n < 1 || n > maxGenerateBlocks {
+			return nil, badRequest(fmt.Sprintf("'n' must be a number of blocks from 1 to %d", maxGenerateBlocks))
+		}
+	}
+	address := addressString(query)
+	if address == "" {
+		// pay to the node's wallet
+		if _, err := a.devCore.Request(ctx, "getnewaddress", []any{}, &address); err != nil {
+			return nil, fmt.Errorf("getnewaddress: %w", err)
+		}
+	} else if !doge.ValidateP2PKH(doge.Address(address), a.chain) && !doge.ValidateP2SH(doge.Address(address), a.chain) {
+		return nil, badRequest("invalid Dogecoin address")
+	}
+	blocks := []string{}
+	if _, err := a.devCore.Request(ctx, "generatetoaddress", []any{n, address}, &blocks); err != nil {
+		return nil, fmt.Errorf("generatetoaddress: %w", err)
+	}
+	return GenerateResponse{Address: address, Blocks: blocks}, nil
+}
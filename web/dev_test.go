@@ -0,0 +1,114 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dogeorg/doge"
+)
+
+type MockCore struct {
+	calls []string
+	err   error
+}
+
+func (m *MockCore) Request(ctx context.Context, method string, params []any, result any) (int, error) {
+	m.calls = append(m.calls, method)
+	if m.err != nil {
+		return 0, m.err
+	}
+	switch method {
+	case "getnewaddress":
+		*result.(*string) = "mwallet"
+	case "generatetoaddress":
+		blocks := result.(*[]string)
+		for i := int64(0); i < params[0].(int64); i++ {
+			*blocks = append(*blocks, "00ab")
+		}
+	case "gettxoutproof":
+		*result.(*string) = "0100cafe"
+	}
+	return 0, nil
+}
+
+func TestDevGenerateOnlyOnRegTest(t *testing.T) {
+	tests := []struct {
+		name           string
+		chain          *doge.ChainParams
+		devTools       bool
+		expectedStatus int
+	}{
+		{"RegTest", &doge.DogeRegTestChain, true, 200},
+		{"RegTest without devtools", &doge.DogeRegTestChain, false, 404},
+		{"MainNet", &doge.DogeMainNetChain, true, 404},
+		{"TestNet", &doge.DogeTestNetChain, true, 404},
+		{"Default chain", nil, true, 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core := &MockCore{}
+			options := Options{Bind: ":0", Store: &MockStore{}, Indexer: &MockIndexer{}, Chain: tt.chain}
+			if tt.devTools {
+				options.DevTools = core
+			}
+			webAPI := New(options).(*WebAPI)
+
+			req := httptest.NewRequest("POST", "/dev/generate?n=1", nil)
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus != 200 && len(core.calls) != 0 {
+				t.Errorf("expected no Core calls, got %v", core.calls)
+			}
+		})
+	}
+}
+
+func TestDevGenerate(t *testing.T) {
+	address := string(doge.Hash160toAddress(make([]byte, 20), doge.DogeRegTestChain.P2PKH_Address_Prefix))
+
+	tests := []struct {
+		name           string
+		method         string
+		query          string
+		coreErr        error
+		expectedStatus int
+		expectedBody   string
+		expectedCalls  int
+	}{
+		{"Default one block", "POST", "", nil, 200, `{"address":"mwallet","blocks":["00ab"]}`, 2},
+		{"To address", "POST", "?n=3&address=" + address, nil, 200, `{"address":"` + address + `","blocks":["00ab","00ab","00ab"]}`, 1},
+		{"Too many blocks", "POST", "?n=1001", nil, 400, `{"error":"bad-request","reason":"'n' must be a number of blocks from 1 to 1000"}`, 0},
+		{"Zero blocks", "POST", "?n=0", nil, 400, `{"error":"bad-request","reason":"'n' must be a number of blocks from 1 to 1000"}`, 0},
+		{"Mainnet address", "POST", "?address=D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS", nil, 400, `{"error":"bad-request","reason":"invalid Dogecoin address"}`, 0},
+		{"Core error", "POST", "?address=" + address, errors.New("boom"), 500, `{"error":"error","reason":"generatetoaddress: boom"}`, 1},
+		{"GET not allowed", "GET", "", nil, 405, "method not allowed\n", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core := &MockCore{err: tt.coreErr}
+			webAPI := New(Options{Bind: ":0", Store: &MockStore{}, Indexer: &MockIndexer{}, Chain: &doge.DogeRegTestChain, DevTools: core}).(*WebAPI)
+
+			req := httptest.NewRequest(tt.method, "/dev/generate"+tt.query, nil)
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+			if len(core.calls) != tt.expectedCalls {
+				t.Errorf("expected %d Core calls, got %v", tt.expectedCalls, core.calls)
+			}
+		})
+	}
+}
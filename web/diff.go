@@ -0,0 +1,58 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/dogeorg/indexer/spec"
+)
+
+const maxDiffBlocks = 100 // blocks per /diff request
+
+type DiffItem struct {
+	UTXOItem
+	Height int64 `json:"height"` // height of the block that created (or spent) the UTXO
+}
+
+type DiffResponse struct {
+	From    int64      `json:"from"`
+	To      int64      `json:"to"`
+	Created []DiffItem `json:"created"`
+	Spent   []DiffItem `json:"spent"`
+}
+
+// getDiff lists UTXOs created and spent between two heights (inclusive.)
+// Spent UTXOs are trimmed after MaxRollbackDepth blocks, so a range far
+// below the current height will be missing spends and spent outputs.
+func (a *WebAPI) getDiff(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.diff(store, r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) diff(store spec.Store, query url.Values) (any, error) {
+	from, to, err := heightRangeParam(query, maxDiffBlocks)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := store.GetUTXODiff(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return DiffResponse{From: from, To: to, Created: diffItems(diff.Created), Spent: diffItems(diff.Spent)}, nil
+}
+
+func diffItems(changes []spec.UTXOChange) []DiffItem {
+	items := []DiffItem{}
+	for _, c := range changes {
+		items = append(items, DiffItem{UTXOItem: utxoItem(c.UTXO), Height: c.Height})
+	}
+	return items
+}
@@ -0,0 +1,82 @@
+package web
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestGetDiff(t *testing.T) {
+	script := []byte{0x76, 0xA9, 0x14, 0x88, 0xAC}
+	diff := spec.UTXODiff{
+		Created: []spec.UTXOChange{{UTXO: spec.UTXO{TxID: []byte{1, 2}, VOut: 0, Value: 100000000, Type: doge.ScriptTypeP2PKH, Script: script}, Height: 101}},
+		Spent:   []spec.UTXOChange{{UTXO: spec.UTXO{TxID: []byte{3, 4}, VOut: 2, Value: 50000000, Type: doge.ScriptTypeP2PKH, Script: script}, Height: 102}},
+	}
+	expandedScript := "76a91476a91488ac00000000000000000000000000000088ac"
+
+	tests := []struct {
+		name           string
+		query          string
+		diffErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Valid range",
+			query:          "?from=100&to=102",
+			expectedStatus: 200,
+			expectedBody: `{"from":100,"to":102,` +
+				`"created":[{"tx":"0201","vout":0,"value":"1","type":"P2PKH","script":"` + expandedScript + `","height":101}],` +
+				`"spent":[{"tx":"0403","vout":2,"value":"0.5","type":"P2PKH","script":"` + expandedScript + `","height":102}]}`,
+		},
+		{
+			name:           "Missing from",
+			query:          "?to=102",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'from' must be a block height"}`,
+		},
+		{
+			name:           "Inverted range",
+			query:          "?from=102&to=100",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'to' must be a block height not less than 'from'"}`,
+		},
+		{
+			name:           "Range too large",
+			query:          "?from=100&to=200",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"at most 100 blocks per request"}`,
+		},
+		{
+			name:           "Database error",
+			query:          "?from=100&to=102",
+			diffErr:        fmt.Errorf("database error"),
+			expectedStatus: 500,
+			expectedBody:   `{"error":"error","reason":"database error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{diff: diff, diffErr: tt.diffErr}
+			server := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = mockStore
+
+			req := httptest.NewRequest("GET", "/diff"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			webAPI.getDiff(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
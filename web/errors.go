@@ -0,0 +1,42 @@
+package web
+
+import "net/http"
+
+// ErrorCode is the machine-readable `error` in a WebError response.
+// Each code is always sent with the same HTTP status (see Status.)
+type ErrorCode string
+
+const (
+	CodeBadRequest    ErrorCode = "bad-request"    // invalid query parameters or body
+	CodeUnauthorized  ErrorCode = "unauthorized"   // missing or wrong admin token
+	CodeNotFound      ErrorCode = "not-found"      // the requested item does not exist
+	CodeAlreadyExists ErrorCode = "already-exists" // the item already exists
+	CodeUnknownMethod ErrorCode = "unknown-method" // /rpc method does not exist
+	CodeTooLarge      ErrorCode = "too-large"      // a batch request exceeds the server's row budget
+	CodeUnavailable   ErrorCode = "unavailable"    // feature not configured, or shutting down
+	CodeInternal      ErrorCode = "internal"       // a handler panicked
+	CodeError         ErrorCode = "error"          // any other failure (e.g. database)
+)
+
+// ErrorCodes lists every ErrorCode the API sends.
+var ErrorCodes = []ErrorCode{CodeBadRequest, CodeUnauthorized, CodeNotFound, CodeAlreadyExists, CodeUnknownMethod, CodeTooLarge, CodeUnavailable, CodeInternal, CodeError}
+
+// Status returns the canonical HTTP status for the code.
+func (c ErrorCode) Status() int {
+	switch c {
+	case CodeBadRequest:
+		return http.StatusBadRequest
+	case CodeUnauthorized:
+		return http.StatusUnauthorized
+	case CodeNotFound, CodeUnknownMethod:
+		return http.StatusNotFound
+	case CodeAlreadyExists:
+		return http.StatusConflict
+	case CodeTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case CodeUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
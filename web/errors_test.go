@@ -0,0 +1,68 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestErrorCodesAreCanonical(t *testing.T) {
+	validAddress := "D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS"
+	tests := []struct {
+		method       string
+		target       string
+		body         string
+		store        *MockStore
+		expectedCode ErrorCode
+	}{
+		{"GET", "/health", "", &MockStore{resumeErr: errors.New("db down")}, CodeError},
+		{"GET", "/balance", "", &MockStore{}, CodeBadRequest},
+		{"GET", "/balance?address=" + validAddress, "", &MockStore{balanceErr: fmt.Errorf("GetBalance: %w", spec.ErrNotFound)}, CodeNotFound},
+		{"GET", "/balance?address=" + validAddress, "", &MockStore{balanceErr: spec.ErrAlreadyExists}, CodeAlreadyExists},
+		{"GET", "/utxo?address=bogus", "", &MockStore{}, CodeBadRequest},
+		{"GET", "/utxo?address=" + validAddress, "", &MockStore{utxoErr: errors.New("db down")}, CodeError},
+		{"GET", "/height", "", &MockStore{heightErr: errors.New("db down")}, CodeError},
+		{"GET", "/feerate", "", &MockStore{}, CodeUnavailable},
+		{"GET", "/feerate?blocks=0", "", &MockStore{}, CodeBadRequest},
+		{"POST", "/rpc", "{}", &MockStore{}, CodeBadRequest},
+		{"GET", "/diff", "", &MockStore{}, CodeBadRequest},
+		{"GET", "/outgoing?address=" + validAddress + "&confirmations=-1", "", &MockStore{}, CodeBadRequest},
+		{"GET", "/utxo-by-script?kind=99&script=00", "", &MockStore{}, CodeBadRequest},
+		{"GET", "/scripthash/balance?scripthash=zz", "", &MockStore{}, CodeBadRequest},
+		{"GET", "/scripthash/utxo", "", &MockStore{}, CodeBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: tt.store, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = tt.store
+
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+
+			var res WebError
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatalf("expected a WebError, got %q", w.Body.String())
+			}
+			if res.Error != tt.expectedCode {
+				t.Errorf("expected code %q, got %q (%s)", tt.expectedCode, res.Error, res.Reason)
+			}
+			known := false
+			for _, code := range ErrorCodes {
+				known = known || res.Error == code
+			}
+			if !known {
+				t.Errorf("code %q is not in ErrorCodes", res.Error)
+			}
+			if w.Code != res.Error.Status() {
+				t.Errorf("expected status %d for %q, got %d", res.Error.Status(), res.Error, w.Code)
+			}
+		})
+	}
+}
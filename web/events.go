@@ -0,0 +1,130 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/dogeorg/dogewalker/walker"
+	"github.com/dogeorg/indexer/index"
+)
+
+const eventBacklog = 16 // events buffered per client before we drop them
+
+// EventHub publishes each newly indexed block (its index.BlockHistory)
+// to the clients connected to /events.
+type EventHub struct {
+	history index.IndexerMonitor
+	mu      sync.Mutex
+	clients map[chan index.BlockHistory]struct{}
+	closed  bool
+}
+
+// Ensure EventHub implements index.BlockListener
+var _ index.BlockListener = (*EventHub)(nil)
+
+// NewEventHub creates an EventHub; register it with Indexer.AddListener.
+func NewEventHub(history index.IndexerMonitor) *EventHub {
+	return &EventHub{history: history, clients: make(map[chan index.BlockHistory]struct{})}
+}
+
+// BlockIndexed is called on the Indexer goroutine: it must not block.
+func (h *EventHub) BlockIndexed(block *walker.ChainBlock) {
+	// the Indexer records history before notifying listeners
+	for _, entry := range h.history.GetBlockHistory() {
+		if entry.Hash == block.Hash {
+			h.publish(entry)
+			return
+		}
+	}
+}
+
+func (h *EventHub) publish(entry index.BlockHistory) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.clients {
+		select {
+		case ch <- entry:
+		default: // slow client: drop the event rather than stall indexing
+		}
+	}
+}
+
+// subscribe returns a channel of events (nil if the hub is closed.)
+func (h *EventHub) subscribe() chan index.BlockHistory {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil
+	}
+	ch := make(chan index.BlockHistory, eventBacklog)
+	h.clients[ch] = struct{}{}
+	return ch
+}
+
+func (h *EventHub) unsubscribe(ch chan index.BlockHistory) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[ch]; ok {
+		delete(h.clients, ch)
+		close(ch)
+	}
+}
+
+// Close ends all event streams (so http.Server.Shutdown isn't kept waiting.)
+func (h *EventHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for ch := range h.clients {
+		delete(h.clients, ch)
+		close(ch)
+	}
+}
+
+// getEvents streams a Server-Sent Events "block" event for each indexed block.
+func (a *WebAPI) getEvents(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	if r.Method != http.MethodGet {
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		sendError(w, CodeError, "streaming not supported", options, a.corsOrigin)
+		return
+	}
+	events := a.events.subscribe()
+	if events == nil {
+		sendError(w, CodeUnavailable, "server is shutting down", options, a.corsOrigin)
+		return
+	}
+	defer a.events.unsubscribe(events)
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Access-Control-Allow-Origin", a.corsOrigin)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	done := r.Context().Done()
+	for {
+		select {
+		case entry, ok := <-events:
+			if !ok {
+				return // hub closed
+			}
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: block\ndata: %s\n\n", data); err != nil {
+				return // client went away
+			}
+			flusher.Flush()
+		case <-done:
+			return // client disconnected
+		}
+	}
+}
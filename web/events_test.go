@@ -0,0 +1,65 @@
+package web
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dogeorg/dogewalker/walker"
+	"github.com/dogeorg/indexer/index"
+)
+
+func TestEventsStreamsIndexedBlocks(t *testing.T) {
+	mockIndexer := &MockIndexer{}
+	events := NewEventHub(mockIndexer)
+	server := New(Options{Bind: ":0", Store: &MockStore{}, Indexer: mockIndexer, Events: events})
+	webAPI := server.(*WebAPI)
+	ts := httptest.NewServer(webAPI.srv.Handler)
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/events")
+	if err != nil {
+		t.Fatalf("GET /events: %v", err)
+	}
+	defer res.Body.Close()
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected Content-Type text/event-stream, got %q", ct)
+	}
+
+	// the Indexer records history, then notifies listeners
+	mockIndexer.blockHistory = []index.BlockHistory{{
+		Height:    100,
+		Hash:      "abc123",
+		Timestamp: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		TxCount:   2,
+	}}
+	events.BlockIndexed(&walker.ChainBlock{Hash: "abc123", Height: 100})
+
+	reader := bufio.NewReader(res.Body)
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading event: %v (got %q)", err, lines)
+		}
+		if line == "\n" {
+			break // end of event
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+	if len(lines) != 2 || lines[0] != "event: block" {
+		t.Fatalf("expected a block event, got %q", lines)
+	}
+	if !strings.HasPrefix(lines[1], `data: {"height":100,"hash":"abc123"`) {
+		t.Fatalf("expected BlockHistory data, got %q", lines[1])
+	}
+
+	// closing the hub ends the stream
+	events.Close()
+	if _, err := reader.ReadString('\n'); err == nil {
+		t.Fatalf("expected the stream to end after Close")
+	}
+}
@@ -0,0 +1,104 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dogeorg/doge/koinu"
+	walkerspec "github.com/dogeorg/dogewalker/spec"
+)
+
+const feeRateCacheTTL = 30 * time.Second
+const defaultFeeRateBlocks = 2
+const maxFeeRateBlocks = 25 // Core's fee estimator does not track beyond 25 blocks
+const feeRateTimeout = 5 * time.Second
+
+// rpcMethodNotFound is Core's JSON-RPC error code for an unknown method.
+const rpcMethodNotFound = -32601
+
+// errNoFeeEstimate means Core doesn't have enough data for an estimate yet
+// (it returns -1), e.g. on a fresh node or on regtest.
+var errNoFeeEstimate = errors.New("no fee estimate")
+
+type cachedFeeRate struct {
+	feePerKB  koinu.Koinu
+	fetchedAt time.Time
+}
+
+// feeRateCache proxies Core's fee estimates (estimatesmartfee), keeping each
+// confirmation target for a short time so API clients cannot hammer Core.
+type feeRateCache struct {
+	core CoreRequester
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu    sync.Mutex
+	rates map[int]cachedFeeRate
+}
+
+// newFeeRateCache returns nil without Core RPC (core.CoreRPCClient can make
+// the raw requests.)
+func newFeeRateCache(blockchain walkerspec.Blockchain) *feeRateCache {
+	core, ok := blockchain.(CoreRequester)
+	if !ok {
+		return nil
+	}
+	return &feeRateCache{
+		core:  core,
+		ttl:   feeRateCacheTTL,
+		now:   time.Now,
+		rates: map[int]cachedFeeRate{},
+	}
+}
+
+// estimate returns the fee per kB (in koinu) to confirm within `blocks` blocks,
+// or errNoFeeEstimate.
+func (c *feeRateCache) estimate(ctx context.Context, blocks int) (koinu.Koinu, error) {
+	c.mu.Lock()
+	cached, found := c.rates[blocks]
+	c.mu.Unlock()
+	if found && c.now().Sub(cached.fetchedAt) < c.ttl {
+		return cached.feePerKB, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, feeRateTimeout)
+	defer cancel()
+
+	feePerKB, err := c.request(ctx, blocks)
+	if err != nil {
+		return 0, err
+	}
+	if feePerKB < 0 {
+		return 0, errNoFeeEstimate
+	}
+
+	c.mu.Lock()
+	c.rates[blocks] = cachedFeeRate{feePerKB: feePerKB, fetchedAt: c.now()}
+	c.mu.Unlock()
+	return feePerKB, nil
+}
+
+// request asks Core for estimatesmartfee, or estimatefee if Core doesn't
+// have it. Both give DOGE per kB, and -1 without enough data.
+func (c *feeRateCache) request(ctx context.Context, blocks int) (koinu.Koinu, error) {
+	var smart struct {
+		FeeRate *koinu.Koinu `json:"feerate"` // missing (with "errors") if there is no estimate
+	}
+	code, err := c.core.Request(ctx, "estimatesmartfee", []any{blocks}, &smart)
+	if err == nil {
+		if smart.FeeRate == nil {
+			return -1, nil
+		}
+		return *smart.FeeRate, nil
+	}
+	if code != rpcMethodNotFound {
+		return 0, err
+	}
+	var feePerKB koinu.Koinu
+	if _, err := c.core.Request(ctx, "estimatefee", []any{blocks}, &feePerKB); err != nil {
+		return 0, err
+	}
+	return feePerKB, nil
+}
@@ -0,0 +1,167 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeFeeCore answers Core's fee estimate requests (in DOGE per kB.)
+type fakeFeeCore struct {
+	fakeBlockchain
+	smartFee string // estimatesmartfee result ("" for an old Core without it)
+	fee      string // estimatefee result
+	err      error  // transport error
+	calls    *[]string
+}
+
+func (f fakeFeeCore) Request(_ context.Context, method string, params []any, result any) (int, error) {
+	if f.calls != nil {
+		*f.calls = append(*f.calls, method)
+	}
+	if f.err != nil {
+		return 0, f.err
+	}
+	res := f.fee
+	if method == "estimatesmartfee" {
+		if f.smartFee == "" {
+			return rpcMethodNotFound, errors.New("json-rpc error: -32601, Method not found")
+		}
+		res = f.smartFee
+	}
+	return 0, json.Unmarshal([]byte(res), result)
+}
+
+func TestFeeRateCacheReusesRecentEstimate(t *testing.T) {
+	now := time.Date(2026, time.June, 1, 12, 0, 0, 0, time.UTC)
+	var calls []string
+	cache := newFeeRateCache(fakeFeeCore{smartFee: `{"feerate":0.01,"blocks":2}`, calls: &calls})
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		fee, err := cache.estimate(context.Background(), 2)
+		if err != nil {
+			t.Fatalf("estimate: %v", err)
+		}
+		if fee != 1_000_000 {
+			t.Fatalf("estimate = %d, want 1000000", fee)
+		}
+	}
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 estimatesmartfee call, got %v", calls)
+	}
+
+	now = now.Add(feeRateCacheTTL)
+	if _, err := cache.estimate(context.Background(), 2); err != nil {
+		t.Fatalf("estimate: %v", err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected a fresh estimatesmartfee call after the TTL, got %v", calls)
+	}
+}
+
+func TestFeeRateCacheFallsBackToEstimateFee(t *testing.T) {
+	var calls []string
+	cache := newFeeRateCache(fakeFeeCore{fee: `0.0025`, calls: &calls})
+
+	fee, err := cache.estimate(context.Background(), 2)
+	if err != nil || fee != 250_000 {
+		t.Fatalf("estimate = %d, %v; want 250000", fee, err)
+	}
+	if len(calls) != 2 || calls[0] != "estimatesmartfee" || calls[1] != "estimatefee" {
+		t.Fatalf("expected estimatesmartfee then estimatefee, got %v", calls)
+	}
+}
+
+func TestGetFeeRate(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		core           *fakeFeeCore
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Known fee",
+			url:            "/feerate?blocks=6",
+			core:           &fakeFeeCore{smartFee: `{"feerate":0.01,"blocks":6}`},
+			expectedStatus: 200,
+			expectedBody:   `{"blocks":6,"koinu_per_kb":1000000}`,
+		},
+		{
+			name:           "Default target",
+			url:            "/feerate",
+			core:           &fakeFeeCore{smartFee: `{"feerate":0.0025,"blocks":2}`},
+			expectedStatus: 200,
+			expectedBody:   `{"blocks":2,"koinu_per_kb":250000}`,
+		},
+		{
+			name:           "No smart estimate yet",
+			url:            "/feerate",
+			core:           &fakeFeeCore{smartFee: `{"feerate":-1,"blocks":0}`},
+			expectedStatus: 503,
+			expectedBody:   `{"error":"unavailable","reason":"Core has no fee estimate yet (not enough recent blocks)"}`,
+		},
+		{
+			name:           "No smart estimate (newer Core)",
+			url:            "/feerate",
+			core:           &fakeFeeCore{smartFee: `{"errors":["Insufficient data or no feerate found"],"blocks":0}`},
+			expectedStatus: 503,
+			expectedBody:   `{"error":"unavailable","reason":"Core has no fee estimate yet (not enough recent blocks)"}`,
+		},
+		{
+			name:           "No estimate from estimatefee",
+			url:            "/feerate",
+			core:           &fakeFeeCore{fee: `-1`},
+			expectedStatus: 503,
+			expectedBody:   `{"error":"unavailable","reason":"Core has no fee estimate yet (not enough recent blocks)"}`,
+		},
+		{
+			name:           "Core unreachable",
+			url:            "/feerate",
+			core:           &fakeFeeCore{err: errors.New("json-rpc transport: context deadline exceeded")},
+			expectedStatus: 503,
+			expectedBody:   `{"error":"unavailable","reason":"cannot get a fee estimate from Core"}`,
+		},
+		{
+			name:           "Invalid target",
+			url:            "/feerate?blocks=0",
+			core:           &fakeFeeCore{smartFee: `{"feerate":0.01,"blocks":0}`},
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'blocks' must be between 1 and 25"}`,
+		},
+		{
+			name:           "No RPC client",
+			url:            "/feerate?blocks=6",
+			expectedStatus: 503,
+			expectedBody:   `{"error":"unavailable","reason":"fee estimation is not configured"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{}
+			server := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = mockStore
+			if tt.core != nil {
+				webAPI.feeRates = newFeeRateCache(*tt.core)
+			}
+
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+
+			webAPI.getFeeRate(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/dogeorg/indexer/spec"
+)
+
+type FirstSeenResponse struct {
+	Height int64 `json:"height"` // lowest height of the address's stored UTXOs
+	// UTXOs spent below TrimFloor have been deleted, so the address may have
+	// first received funds earlier than Height (at some height below TrimFloor.)
+	TrimFloor int64 `json:"trim_floor"`
+}
+
+func (a *WebAPI) getFirstSeen(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.firstSeen(store, r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) firstSeen(store spec.Store, query url.Values) (any, error) {
+	kind, hash, err := a.addressParam(query)
+	if err != nil {
+		return nil, err
+	}
+	height, err := store.GetAddressFirstSeen(kind, hash)
+	if err != nil {
+		return nil, err // spec.ErrNotFound if never paid (or all trimmed)
+	}
+	trimFloor, err := store.GetTrimmedBelow()
+	if err != nil {
+		return nil, err
+	}
+	return FirstSeenResponse{Height: height, TrimFloor: trimFloor}, nil
+}
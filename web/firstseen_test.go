@@ -0,0 +1,74 @@
+package web
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestGetFirstSeen(t *testing.T) {
+	validAddress := "D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS"
+	tests := []struct {
+		name           string
+		query          string
+		store          *MockStore
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "First seen with trim floor",
+			query:          "?address=" + validAddress,
+			store:          &MockStore{firstSeen: 1200, trimmedBelow: 3560},
+			expectedStatus: 200,
+			expectedBody:   `{"height":1200,"trim_floor":3560}`,
+		},
+		{
+			name:           "Never trimmed",
+			query:          "?address=" + validAddress,
+			store:          &MockStore{firstSeen: 10},
+			expectedStatus: 200,
+			expectedBody:   `{"height":10,"trim_floor":0}`,
+		},
+		{
+			name:           "Never paid",
+			query:          "?address=" + validAddress,
+			store:          &MockStore{firstSeenErr: spec.ErrNotFound},
+			expectedStatus: 404,
+			expectedBody:   `{"error":"not-found","reason":"not-found"}`,
+		},
+		{
+			name:           "Store error",
+			query:          "?address=" + validAddress,
+			store:          &MockStore{firstSeenErr: errors.New("db down")},
+			expectedStatus: 500,
+			expectedBody:   `{"error":"error","reason":"db down"}`,
+		},
+		{
+			name:           "Missing address",
+			query:          "",
+			store:          &MockStore{},
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"missing 'address' in the URL"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: tt.store, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = tt.store
+
+			w := httptest.NewRecorder()
+			webAPI.getFirstSeen(w, httptest.NewRequest("GET", "/first-seen"+tt.query, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/index"
+	"github.com/dogeorg/indexer/spec"
+	"github.com/dogeorg/indexer/web/indexerpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The gRPC server (Options.GRPCBind) serves the balance, UTXO, height and
+// block event APIs to internal clients without the JSON/HTTP overhead (see
+// indexerpb/indexer.proto.) The handlers use the same store methods, limits
+// and caches as the REST handlers, and it shuts down with the REST server.
+
+type grpcServer struct {
+	indexerpb.UnimplementedIndexerServer
+	a *WebAPI
+}
+
+func (a *WebAPI) newGRPCServer() *grpc.Server {
+	srv := grpc.NewServer()
+	indexerpb.RegisterIndexerServer(srv, &grpcServer{a: a})
+	return srv
+}
+
+// serveGRPC runs the gRPC server until Stop (goroutine.)
+func (a *WebAPI) serveGRPC() {
+	ln, err := listen(a.grpcBind)
+	if err != nil {
+		log.Printf("gRPC server: %v\n", err)
+		return
+	}
+	log.Printf("gRPC server listening on: %v\n", a.grpcBind)
+	if err := a.grpc.Serve(ln); err != nil {
+		log.Printf("gRPC server: %v\n", err)
+	}
+}
+
+// stopGRPC lets in-flight calls finish for up to `grace`, then closes them.
+// Block streams only end when the EventHub closes (before this.)
+func (a *WebAPI) stopGRPC(grace time.Duration) {
+	stopped := make(chan struct{})
+	go func() {
+		a.grpc.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(grace):
+		log.Printf("gRPC server: calls still running after %v, closing them\n", grace)
+		a.grpc.Stop()
+	}
+}
+
+// grpcError converts a handler error to a gRPC status, with the same reason
+// the REST API would send.
+func grpcError(err error) error {
+	code, reason := errorDetails(err)
+	switch code {
+	case CodeBadRequest:
+		return status.Error(codes.InvalidArgument, reason)
+	case CodeNotFound:
+		return status.Error(codes.NotFound, reason)
+	case CodeUnavailable:
+		return status.Error(codes.Unavailable, reason)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, reason)
+	}
+	return status.Error(codes.Internal, reason)
+}
+
+func (g *grpcServer) GetBalance(ctx context.Context, req *indexerpb.BalanceRequest) (*indexerpb.Balance, error) {
+	a := g.a
+	kind, hash, err := decodeAddress(req.Address, a.addressChains)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	confirmations := req.Confirmations
+	if confirmations < 0 {
+		return nil, grpcError(badRequest("'confirmations' must be a non-negative integer"))
+	}
+	if confirmations == 0 {
+		confirmations = a.confirmations
+	}
+	store, cancel := a.contextStore(ctx)
+	defer cancel()
+	bal, err := a.cachedBalance(store, kind, hash, confirmations)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	bal = withCurrent(bal)
+	return &indexerpb.Balance{
+		Incoming:  bal.Incoming.String(),
+		Available: bal.Available.String(),
+		Outgoing:  bal.Outgoing.String(),
+		Current:   bal.Current.String(),
+		UtxoCount: bal.UTXOCount,
+	}, nil
+}
+
+func (g *grpcServer) FindUTXOs(ctx context.Context, req *indexerpb.UTXORequest) (*indexerpb.UTXOList, error) {
+	a := g.a
+	kind, hash, err := decodeAddress(req.Address, a.addressChains)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	store, cancel := a.contextStore(ctx)
+	defer cancel()
+	list, err := store.FindUTXOsFiltered(kind, hash, spec.UTXOFilter{Limit: a.maxUTXOs + 1}) // one more to detect truncation
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	res := &indexerpb.UTXOList{}
+	if len(list) > a.maxUTXOs {
+		list = list[:a.maxUTXOs]
+		res.Truncated = true
+	}
+	res.Utxo = make([]*indexerpb.UTXO, 0, len(list))
+	for _, u := range list {
+		res.Utxo = append(res.Utxo, &indexerpb.UTXO{
+			Txid:       doge.HexEncodeReversed(u.TxID),
+			Vout:       u.VOut,
+			ValueKoinu: u.Value,
+			Type:       utxoKindStr(u.Type),
+			Script:     doge.ExpandScript(u.Type, u.Script),
+			Coinbase:   u.Coinbase,
+		})
+	}
+	return res, nil
+}
+
+func (g *grpcServer) GetHeight(ctx context.Context, req *indexerpb.HeightRequest) (*indexerpb.Height, error) {
+	a := g.a
+	store, cancel := a.contextStore(ctx)
+	defer cancel()
+	height, hash, err := store.GetTip() // one read, like /height
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &indexerpb.Height{Height: height, Hash: doge.HexEncode(hash), Chain: a.chainName}, nil
+}
+
+func (g *grpcServer) StreamBlocks(req *indexerpb.StreamBlocksRequest, stream indexerpb.Indexer_StreamBlocksServer) error {
+	a := g.a
+	if a.events == nil {
+		return status.Error(codes.Unavailable, "block events are not enabled")
+	}
+	events := a.events.subscribe()
+	if events == nil {
+		return status.Error(codes.Unavailable, "server is shutting down")
+	}
+	defer a.events.unsubscribe(events)
+	done := stream.Context().Done()
+	for {
+		select {
+		case entry, ok := <-events:
+			if !ok {
+				return nil // hub closed
+			}
+			if err := stream.Send(grpcBlock(entry)); err != nil {
+				return err // client went away
+			}
+		case <-done:
+			return nil // client cancelled
+		}
+	}
+}
+
+func grpcBlock(entry index.BlockHistory) *indexerpb.Block {
+	return &indexerpb.Block{
+		Height:            entry.Height,
+		Hash:              entry.Hash,
+		Timestamp:         entry.Timestamp.Unix(),
+		TxCount:           int64(entry.TxCount),
+		UtxoCreated:       int64(entry.UTXOCreated),
+		UtxoSpent:         int64(entry.UTXOSpent),
+		CreatedValueKoinu: int64(entry.CreatedValue),
+		SpentValueKoinu:   int64(entry.SpentValue),
+	}
+}
@@ -0,0 +1,218 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/dogewalker/walker"
+	"github.com/dogeorg/indexer/index"
+	"github.com/dogeorg/indexer/spec"
+	"github.com/dogeorg/indexer/web/indexerpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// grpcClient serves the WebAPI's gRPC server over an in-memory connection.
+func grpcClient(t *testing.T, webAPI *WebAPI) indexerpb.IndexerClient {
+	t.Helper()
+	if webAPI.grpc == nil {
+		t.Fatalf("expected a gRPC server with Options.GRPCBind set")
+	}
+	ln := bufconn.Listen(1 << 20)
+	go webAPI.grpc.Serve(ln)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc client: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		webAPI.grpc.Stop()
+	})
+	return indexerpb.NewIndexerClient(conn)
+}
+
+func newGRPCTestAPI(store *MockStore, events *EventHub) *WebAPI {
+	webAPI := New(Options{Bind: ":0", GRPCBind: ":0", Store: store, Indexer: &MockIndexer{}, Events: events, ChainName: "mainnet"}).(*WebAPI)
+	webAPI.store = store
+	return webAPI
+}
+
+func TestGRPCGetBalance(t *testing.T) {
+	pkh := bytes.Repeat([]byte{0x42}, 20)
+	address := string(doge.Hash160toAddress(pkh, doge.DogeMainNetChain.P2PKH_Address_Prefix))
+	balance := spec.Balance{
+		Incoming:  bigKoinu(100000000),
+		Available: bigKoinu(250000000),
+		Outgoing:  bigKoinu(50000000),
+		UTXOCount: 3,
+	}
+	tests := []struct {
+		name          string
+		req           *indexerpb.BalanceRequest
+		store         *MockStore
+		expected      *indexerpb.Balance
+		expectedCode  codes.Code
+		confirmations int64 // passed to the store
+	}{
+		{"Balance", &indexerpb.BalanceRequest{Address: address}, &MockStore{balance: balance},
+			&indexerpb.Balance{Incoming: "1", Available: "2.5", Outgoing: "0.5", Current: "3.5", UtxoCount: 3}, codes.OK, DefaultConfirmations},
+		{"Confirmations", &indexerpb.BalanceRequest{Address: address, Confirmations: 2}, &MockStore{}, nil, codes.OK, 2},
+		{"Bad address", &indexerpb.BalanceRequest{Address: "nope"}, &MockStore{}, nil, codes.InvalidArgument, 0},
+		{"Negative confirmations", &indexerpb.BalanceRequest{Address: address, Confirmations: -1}, &MockStore{}, nil, codes.InvalidArgument, 0},
+		{"Store error", &indexerpb.BalanceRequest{Address: address}, &MockStore{balanceErr: errors.New("db down")}, nil, codes.Internal, DefaultConfirmations},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := grpcClient(t, newGRPCTestAPI(tt.store, nil))
+			res, err := client.GetBalance(context.Background(), tt.req)
+			if status.Code(err) != tt.expectedCode {
+				t.Fatalf("expected code %v, got %v", tt.expectedCode, err)
+			}
+			if tt.store.balanceConf != tt.confirmations {
+				t.Errorf("expected confirmations %d, got %d", tt.confirmations, tt.store.balanceConf)
+			}
+			if tt.expected != nil {
+				if res.Incoming != tt.expected.Incoming || res.Available != tt.expected.Available ||
+					res.Outgoing != tt.expected.Outgoing || res.Current != tt.expected.Current || res.UtxoCount != tt.expected.UtxoCount {
+					t.Errorf("expected %v, got %v", tt.expected, res)
+				}
+			}
+		})
+	}
+}
+
+func TestGRPCFindUTXOs(t *testing.T) {
+	pkh := bytes.Repeat([]byte{0x42}, 20)
+	address := string(doge.Hash160toAddress(pkh, doge.DogeMainNetChain.P2PKH_Address_Prefix))
+	txid := make([]byte, 32)
+	txid[0] = 0x01
+	utxo := spec.UTXO{TxID: txid, VOut: 3, Value: 150000000, Type: doge.ScriptTypeP2PKH, Script: pkh, Coinbase: true}
+
+	store := &MockStore{utxos: []spec.UTXO{utxo, utxo, utxo}}
+	webAPI := newGRPCTestAPI(store, nil)
+	webAPI.maxUTXOs = 2
+	client := grpcClient(t, webAPI)
+
+	res, err := client.FindUTXOs(context.Background(), &indexerpb.UTXORequest{Address: address})
+	if err != nil {
+		t.Fatalf("FindUTXOs: %v", err)
+	}
+	if len(res.Utxo) != 2 || !res.Truncated {
+		t.Fatalf("expected 2 UTXOs and truncated, got %d (truncated %v)", len(res.Utxo), res.Truncated)
+	}
+	if store.utxoFilter.Limit != 3 || store.utxoKind != doge.ScriptTypeP2PKH || !bytes.Equal(store.utxoScript, pkh) {
+		t.Errorf("unexpected store query: %v %v %x", store.utxoFilter, store.utxoKind, store.utxoScript)
+	}
+	u := res.Utxo[0]
+	script := append(append([]byte{0x76, 0xa9, 0x14}, pkh...), 0x88, 0xac)
+	if u.Txid != strings.Repeat("00", 31)+"01" || u.Vout != 3 || u.ValueKoinu != 150000000 ||
+		u.Type != "P2PKH" || !bytes.Equal(u.Script, script) || !u.Coinbase {
+		t.Errorf("unexpected UTXO %v", u)
+	}
+
+	_, err = client.FindUTXOs(context.Background(), &indexerpb.UTXORequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a missing address, got %v", err)
+	}
+	store.utxoErr = spec.ErrNotFound
+	_, err = client.FindUTXOs(context.Background(), &indexerpb.UTXORequest{Address: address})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+}
+
+func TestGRPCGetHeight(t *testing.T) {
+	hash := []byte{0xab, 0xcd}
+	client := grpcClient(t, newGRPCTestAPI(&MockStore{currentHeight: 5000000, resumePoint: hash}, nil))
+	res, err := client.GetHeight(context.Background(), &indexerpb.HeightRequest{})
+	if err != nil {
+		t.Fatalf("GetHeight: %v", err)
+	}
+	if res.Height != 5000000 || res.Hash != "abcd" || res.Chain != "mainnet" {
+		t.Errorf("unexpected height %v", res)
+	}
+
+	client = grpcClient(t, newGRPCTestAPI(&MockStore{heightErr: errors.New("db down")}, nil))
+	_, err = client.GetHeight(context.Background(), &indexerpb.HeightRequest{})
+	if status.Code(err) != codes.Internal || status.Convert(err).Message() != "db down" {
+		t.Errorf("expected Internal 'db down', got %v", err)
+	}
+}
+
+func TestGRPCStreamBlocks(t *testing.T) {
+	mockIndexer := &MockIndexer{}
+	events := NewEventHub(mockIndexer)
+	webAPI := newGRPCTestAPI(&MockStore{}, events)
+	client := grpcClient(t, webAPI)
+
+	stream, err := client.StreamBlocks(context.Background(), &indexerpb.StreamBlocksRequest{})
+	if err != nil {
+		t.Fatalf("StreamBlocks: %v", err)
+	}
+	// wait for the handler to subscribe before publishing
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		events.mu.Lock()
+		subscribed := len(events.clients)
+		events.mu.Unlock()
+		if subscribed > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("StreamBlocks didn't subscribe to block events")
+		}
+	}
+
+	mockIndexer.blockHistory = []index.BlockHistory{{
+		Height:       100,
+		Hash:         "abc123",
+		Timestamp:    time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		TxCount:      2,
+		UTXOCreated:  3,
+		UTXOSpent:    1,
+		CreatedValue: 500,
+		SpentValue:   200,
+	}}
+	events.BlockIndexed(&walker.ChainBlock{Hash: "abc123", Height: 100})
+
+	block, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	if block.Height != 100 || block.Hash != "abc123" || block.Timestamp != 1704067200 || block.TxCount != 2 ||
+		block.UtxoCreated != 3 || block.UtxoSpent != 1 || block.CreatedValueKoinu != 500 || block.SpentValueKoinu != 200 {
+		t.Errorf("unexpected block %v", block)
+	}
+
+	// closing the hub ends the stream
+	events.Close()
+	if _, err := stream.Recv(); err == nil {
+		t.Fatalf("expected the stream to end after Close")
+	}
+	// and new streams are refused
+	stream, err = client.StreamBlocks(context.Background(), &indexerpb.StreamBlocksRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expected Unavailable after Close, got %v", err)
+	}
+}
+
+func TestGRPCDisabled(t *testing.T) {
+	webAPI := New(Options{Bind: ":0", Store: &MockStore{}, Indexer: &MockIndexer{}}).(*WebAPI)
+	if webAPI.grpc != nil {
+		t.Errorf("expected no gRPC server without Options.GRPCBind")
+	}
+}
@@ -0,0 +1,113 @@
+package web
+
+import (
+	"net/http"
+	"time"
+)
+
+type HealthDetailResponse struct {
+	OK        bool     `json:"ok"`
+	Height    int64    `json:"height"`               // indexed height
+	TipHeight *int64   `json:"tip_height,omitempty"` // Core headers height (if Core RPC is configured)
+	Lag       *int64   `json:"lag,omitempty"`        // blocks between Height and TipHeight
+	UTXOCount int64    `json:"utxo_count"`           // unspent UTXOs in the index
+	Orphans   int64    `json:"orphan_spends"`        // ignored spends of unindexed transactions
+	Conflicts int64    `json:"utxo_conflicts"`       // outputs already stored with different content
+	DB        DBHealth `json:"db"`
+
+	BlockQueue BlockQueueHealth `json:"block_queue"`
+}
+
+// BlockQueueHealth is the queue of blocks decoded ahead of indexing
+// (see index.QueueStats.)
+type BlockQueueHealth struct {
+	Length    int     `json:"length"`      // blocks waiting to be indexed
+	Capacity  int     `json:"capacity"`    // most blocks that can wait
+	Behind    bool    `json:"behind"`      // the queue is full: indexing can't keep up
+	FullForMs float64 `json:"full_for_ms"` // how long it has been full
+}
+
+type DBHealth struct {
+	PingMs         float64 `json:"ping_ms"`              // round trip of a database ping
+	MaxOpen        int     `json:"max_open_connections"` // pool limit (0 is unlimited)
+	Open           int     `json:"open_connections"`     // in use + idle
+	InUse          int     `json:"in_use"`
+	Idle           int     `json:"idle"`
+	WaitCount      int64   `json:"wait_count"`       // total waits for a free connection
+	WaitDurationMs float64 `json:"wait_duration_ms"` // total time spent waiting for a free connection
+}
+
+// healthDetail reports database latency and connection pool usage
+// along with indexing lag, for monitoring (e.g. pool exhaustion.)
+func (a *WebAPI) healthDetail(w http.ResponseWriter, r *http.Request) {
+	store, cancel := a.requestStore(r)
+	defer cancel()
+	stats, err := store.Stats()
+	if err != nil {
+		sendError(w, CodeError, err.Error(), "GET", a.corsOrigin)
+		return
+	}
+	height, err := store.GetCurrentHeight()
+	if err != nil {
+		sendError(w, CodeError, err.Error(), "GET", a.corsOrigin)
+		return
+	}
+	unspent, err := store.GetUnspentCount() // maintained by the store, not a COUNT(*)
+	if err != nil {
+		sendError(w, CodeError, err.Error(), "GET", a.corsOrigin)
+		return
+	}
+	orphans, err := store.GetOrphanSpends()
+	if err != nil {
+		sendError(w, CodeError, err.Error(), "GET", a.corsOrigin)
+		return
+	}
+	conflicts, err := store.GetUTXOConflicts()
+	if err != nil {
+		sendError(w, CodeError, err.Error(), "GET", a.corsOrigin)
+		return
+	}
+
+	response := HealthDetailResponse{
+		OK:        true,
+		Height:    height,
+		UTXOCount: unspent,
+		Orphans:   orphans,
+		Conflicts: conflicts,
+		DB: DBHealth{
+			PingMs:         millis(stats.PingLatency),
+			MaxOpen:        stats.Pool.MaxOpenConnections,
+			Open:           stats.Pool.OpenConnections,
+			InUse:          stats.Pool.InUse,
+			Idle:           stats.Pool.Idle,
+			WaitCount:      stats.Pool.WaitCount,
+			WaitDurationMs: millis(stats.Pool.WaitDuration),
+		},
+	}
+	queue := a.indexer.GetQueueStats()
+	response.BlockQueue = BlockQueueHealth{
+		Length:    queue.Length,
+		Capacity:  queue.Capacity,
+		Behind:    queue.Capacity > 0 && queue.Length >= queue.Capacity,
+		FullForMs: millis(queue.FullFor),
+	}
+	response.TipHeight, response.Lag = a.coreLag(height)
+	sendJson(w, response, "GET", a.corsOrigin)
+}
+
+// coreLag returns the Core headers height and how many blocks `height` is
+// behind it (both nil without Core RPC.)
+func (a *WebAPI) coreLag(height int64) (tip *int64, lag *int64) {
+	if a.syncHeights == nil {
+		return nil, nil
+	}
+	if tip = a.syncHeights.snapshot().CoreHeadersHeight; tip == nil {
+		return nil, nil
+	}
+	behind := max(*tip-height, 0)
+	return tip, &behind
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,90 @@
+package web
+
+import (
+	"database/sql"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dogeorg/indexer/index"
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestHealthDetail(t *testing.T) {
+	blocksHeight := int64(1005)
+	headersHeight := int64(1010)
+	syncUpdatedAt := time.Date(2026, time.June, 1, 4, 0, 0, 0, time.UTC)
+	stats := spec.DBStats{
+		PingLatency: 1500 * time.Microsecond,
+		Pool: sql.DBStats{
+			MaxOpenConnections: 10,
+			OpenConnections:    4,
+			InUse:              3,
+			Idle:               1,
+			WaitCount:          7,
+			WaitDuration:       250 * time.Millisecond,
+		},
+	}
+
+	tests := []struct {
+		name           string
+		store          *MockStore
+		snapshot       syncHeightSnapshot
+		queue          index.QueueStats
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Without Core",
+			store:          &MockStore{currentHeight: 1000, dbStats: stats, unspentCount: 42, orphanSpends: 3, conflicts: 1},
+			queue:          index.QueueStats{Length: 3, Capacity: 10},
+			expectedStatus: 200,
+			expectedBody:   `{"ok":true,"height":1000,"utxo_count":42,"orphan_spends":3,"utxo_conflicts":1,"db":{"ping_ms":1.5,"max_open_connections":10,"open_connections":4,"in_use":3,"idle":1,"wait_count":7,"wait_duration_ms":250},"block_queue":{"length":3,"capacity":10,"behind":false,"full_for_ms":0}}`,
+		},
+		{
+			name:  "With Core tip",
+			store: &MockStore{currentHeight: 1000, dbStats: stats, unspentCount: 42, orphanSpends: 3, conflicts: 1},
+			snapshot: syncHeightSnapshot{
+				CoreBlocksHeight:  &blocksHeight,
+				CoreHeadersHeight: &headersHeight,
+				CoreSyncUpdatedAt: &syncUpdatedAt,
+			},
+			queue:          index.QueueStats{Length: 3, Capacity: 10},
+			expectedStatus: 200,
+			expectedBody:   `{"ok":true,"height":1000,"tip_height":1010,"lag":10,"utxo_count":42,"orphan_spends":3,"utxo_conflicts":1,"db":{"ping_ms":1.5,"max_open_connections":10,"open_connections":4,"in_use":3,"idle":1,"wait_count":7,"wait_duration_ms":250},"block_queue":{"length":3,"capacity":10,"behind":false,"full_for_ms":0}}`,
+		},
+		{
+			name:           "Indexing behind",
+			store:          &MockStore{currentHeight: 1000, dbStats: stats, unspentCount: 42, orphanSpends: 3, conflicts: 1},
+			queue:          index.QueueStats{Length: 10, Capacity: 10, FullFor: 90 * time.Second},
+			expectedStatus: 200,
+			expectedBody:   `{"ok":true,"height":1000,"utxo_count":42,"orphan_spends":3,"utxo_conflicts":1,"db":{"ping_ms":1.5,"max_open_connections":10,"open_connections":4,"in_use":3,"idle":1,"wait_count":7,"wait_duration_ms":250},"block_queue":{"length":10,"capacity":10,"behind":true,"full_for_ms":90000}}`,
+		},
+		{
+			name:           "Ping failed",
+			store:          &MockStore{dbStatsErr: errors.New("connection refused")},
+			expectedStatus: 500,
+			expectedBody:   `{"error":"error","reason":"connection refused"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: tt.store, Indexer: &MockIndexer{queue: tt.queue}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = tt.store
+			webAPI.syncHeights = seededSyncHeightCache(tt.snapshot)
+
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/health/detail", nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -1,20 +1,30 @@
 package web
 
 import (
+	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dogeorg/indexer/spec"
 )
 
-// sendOptions sends a response to an OPTIONS request.
-func sendOptions(w http.ResponseWriter, r *http.Request, options string, corsOrigin string) {
+// sendOptions sends a response to an OPTIONS request, which browsers may
+// reuse for `maxAge` before sending another preflight.
+func sendOptions(w http.ResponseWriter, r *http.Request, options string, corsOrigin string, maxAge time.Duration) {
 	switch r.Method {
 	case http.MethodOptions:
 		w.Header().Set("Allow", options)
 		w.Header().Set("Access-Control-Allow-Origin", corsOrigin)
 		w.Header().Set("Access-Control-Allow-Methods", options)
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
+		w.Header().Set("Access-Control-Max-Age", strconv.FormatInt(int64(maxAge/time.Second), 10))
 		w.WriteHeader(http.StatusNoContent)
 
 	default:
@@ -30,35 +40,131 @@ func sendJson(w http.ResponseWriter, payload any, options string, corsOrigin str
 		http.Error(w, fmt.Sprintf("error encoding JSON: %s", err.Error()), http.StatusInternalServerError)
 		return
 	}
-	w.Header().Set("Cache-Control", "private; max-age=0")
+	setDefaultCacheControl(w)
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Content-Length", strconv.Itoa(len(bytes)))
 	w.Header().Set("Allow", options)
 	w.Header().Set("Access-Control-Allow-Origin", corsOrigin)
 	w.Header().Set("Access-Control-Allow-Methods", options)
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
 	w.Write(bytes)
 }
 
 type WebError struct {
-	Error  string `json:"error"`
-	Reason string `json:"reason"`
+	Error  ErrorCode `json:"error"`
+	Reason string    `json:"reason"`
 }
 
-// sendError sends a json error response to a web request.
-func sendError(w http.ResponseWriter, statusCode int, code string, reason string, options string, corsOrigin string) {
+// sendError sends a json error response to a web request,
+// with the canonical status for `code`.
+func sendError(w http.ResponseWriter, code ErrorCode, reason string, options string, corsOrigin string) {
+	statusCode := code.Status()
 	bytes, err := json.Marshal(WebError{Error: code, Reason: reason})
 	if err != nil {
-		bytes = []byte(fmt.Sprintf("{\"error\":\"json\",\"reason\":\"encoding JSON: %s\"}", err.Error()))
+		bytes = []byte(fmt.Sprintf("{\"error\":\"error\",\"reason\":\"encoding JSON: %s\"}", err.Error()))
 		statusCode = http.StatusInternalServerError
 	}
-	w.Header().Set("Cache-Control", "private; max-age=0")
+	w.Header().Set("Cache-Control", privateCacheControl)
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Content-Length", strconv.Itoa(len(bytes)))
 	w.Header().Set("Allow", options)
 	w.Header().Set("Access-Control-Allow-Origin", corsOrigin)
 	w.Header().Set("Access-Control-Allow-Methods", options)
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
 	w.WriteHeader(statusCode)
 	w.Write(bytes)
 }
+
+// apiError is an error reported to the client with its own code
+// (any other error is reported as a 500 "error".)
+type apiError struct {
+	code   ErrorCode
+	reason string
+}
+
+func (e *apiError) Error() string {
+	return e.reason
+}
+
+func badRequest(reason string) error {
+	return &apiError{code: CodeBadRequest, reason: reason}
+}
+
+func tooLarge(reason string) error {
+	return &apiError{code: CodeTooLarge, reason: reason}
+}
+
+func notFound(reason string) error {
+	return &apiError{code: CodeNotFound, reason: reason}
+}
+
+// errorDetails returns the code and reason to report for `err`.
+func errorDetails(err error) (code ErrorCode, reason string) {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr.code, apiErr.reason
+	}
+	switch {
+	case errors.Is(err, spec.ErrNotFound):
+		return CodeNotFound, err.Error()
+	case errors.Is(err, spec.ErrAlreadyExists):
+		return CodeAlreadyExists, err.Error()
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeUnavailable, "query timed out"
+	}
+	return CodeError, err.Error()
+}
+
+// sendResult sends `payload` as a JSON response, or `err` as a json error response.
+func sendResult(w http.ResponseWriter, payload any, err error, options string, corsOrigin string) {
+	if err != nil {
+		code, reason := errorDetails(err)
+		sendError(w, code, reason, options, corsOrigin)
+		return
+	}
+	sendJson(w, payload, options, corsOrigin)
+}
+
+// csvTable is a response payload that can also be sent as CSV.
+type csvTable interface {
+	csvRows() (header []string, rows [][]string)
+}
+
+// wantsCSV reports whether the client asked for CSV (?format=csv or Accept: text/csv)
+func wantsCSV(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "csv"
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/csv")
+}
+
+// sendNegotiated sends `payload` as CSV if the client asked for it (and the
+// payload supports it), otherwise as JSON; or `err` as a json error response.
+func sendNegotiated(w http.ResponseWriter, r *http.Request, payload any, err error, options string, corsOrigin string) {
+	if table, ok := payload.(csvTable); ok && err == nil && wantsCSV(r) {
+		sendCsv(w, table, options, corsOrigin)
+		return
+	}
+	sendResult(w, payload, err, options, corsOrigin)
+}
+
+// sendCsv sends a CSV response (with a header row) to a web request.
+func sendCsv(w http.ResponseWriter, table csvTable, options string, corsOrigin string) {
+	header, rows := table.csvRows()
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write(header)
+	writer.WriteAll(rows) // flushes
+	if err := writer.Error(); err != nil {
+		http.Error(w, fmt.Sprintf("error encoding CSV: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+	setDefaultCacheControl(w)
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.Header().Set("Allow", options)
+	w.Header().Set("Access-Control-Allow-Origin", corsOrigin)
+	w.Header().Set("Access-Control-Allow-Methods", options)
+	w.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,32 @@
+package web
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// Integers (heights, counts, koinu values) are sent as JSON numbers with
+// every digit: encoding/json never writes an int64 as a float or in exponent
+// form. Clients should parse them as 64-bit integers, because JavaScript's
+// JSON.parse (and any decoder into float64) rounds integers above 2^53. With
+// ?ints=both, /height and /utxo-psbt also send their int64 fields as decimal
+// strings (height_str, value_koinu_str) for clients that can't.
+
+// intsParam parses ?ints: true for both (numbers and strings), false for the default (numbers.)
+func intsParam(query url.Values) (both bool, err error) {
+	switch query.Get("ints") {
+	case "", "number":
+		return false, nil
+	case "both":
+		return true, nil
+	}
+	return false, badRequest("'ints' must be one of: number, both")
+}
+
+// intString formats an int64 field for ?ints=both ("" to omit it otherwise.)
+func intString(value int64, both bool) string {
+	if !both {
+		return ""
+	}
+	return strconv.FormatInt(value, 10)
+}
@@ -0,0 +1,88 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestInts(t *testing.T) {
+	pkh := bytes.Repeat([]byte{0x42}, 20)
+	address := string(doge.Hash160toAddress(pkh, doge.DogeMainNetChain.P2PKH_Address_Prefix))
+	store := &MockStore{
+		currentHeight: 123456,
+		utxos:         []spec.UTXO{{TxID: make([]byte, 32), Value: math.MaxInt64, Type: doge.ScriptTypeP2PKH, Script: pkh}},
+	}
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string // substring of the response
+	}{
+		{"Height, default", "/height", 200, `{"height":123456,"chain":"mainnet"}`},
+		{"Height, number", "/height?ints=number", 200, `{"height":123456,"chain":"mainnet"}`},
+		{"Height, both", "/height?ints=both", 200, `{"height":123456,"height_str":"123456","chain":"mainnet"}`},
+		{"PSBT, default", "/utxo-psbt?address=" + address, 200, `"value_koinu":9223372036854775807,"value":"92233720368.54775807"`},
+		{"PSBT, both", "/utxo-psbt?ints=both&address=" + address, 200, `"value_koinu":9223372036854775807,"value_koinu_str":"9223372036854775807","value":"92233720368.54775807"`},
+		{"Invalid ints", "/height?ints=string", 400, `{"error":"bad-request","reason":"'ints' must be one of: number, both"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: store, Indexer: &MockIndexer{}, ChainName: "mainnet"})
+			webAPI := server.(*WebAPI)
+			webAPI.store = store
+
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body containing %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestLargeHeightRoundTrip(t *testing.T) {
+	// 2^53+1 is the smallest integer a float64 can't hold
+	for _, height := range []int64{1<<53 + 1, math.MaxInt64} {
+		store := &MockStore{currentHeight: height}
+		server := New(Options{Bind: ":0", Store: store, Indexer: &MockIndexer{}})
+		webAPI := server.(*WebAPI)
+		webAPI.store = store
+
+		w := httptest.NewRecorder()
+		webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/height?ints=both", nil))
+
+		var response struct {
+			Height    int64  `json:"height"`
+			HeightStr string `json:"height_str"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to unmarshal %q: %v", w.Body.String(), err)
+		}
+		if response.Height != height {
+			t.Errorf("expected height %d, got %d", height, response.Height)
+		}
+		if response.HeightStr != intString(height, true) {
+			t.Errorf("expected height_str %d, got %q", height, response.HeightStr)
+		}
+		// a float64 decoder rounds it: the reason for height_str
+		var loose map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &loose); err != nil {
+			t.Fatalf("failed to unmarshal %q: %v", w.Body.String(), err)
+		}
+		if f := loose["height"].(float64); height == 1<<53+1 && f != 1<<53 {
+			t.Errorf("expected float64 to round %d to 2^53, got %v", height, f)
+		}
+	}
+}
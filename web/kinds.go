@@ -0,0 +1,56 @@
+package web
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/url"
+
+	"github.com/dogeorg/indexer/spec"
+)
+
+// The same 20-byte hash can be paid as P2PKH and P2PKHW (legacy and witness
+// forms of one key), or P2SH; /kinds finds every kind holding funds for it.
+
+type KindItem struct {
+	Type    string        `json:"type"`       // UTXO type, as in /utxo
+	Count   int64         `json:"utxo_count"` // number of unspent UTXOs
+	Balance spec.BigKoinu `json:"balance"`    // sum of their values
+}
+
+type KindsResponse struct {
+	Hash  string     `json:"hash"`
+	Kinds []KindItem `json:"kinds"` // only kinds with unspent UTXOs
+}
+
+func (a *WebAPI) getKinds(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.kinds(store, r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) kinds(store spec.Store, query url.Values) (any, error) {
+	param := query.Get("hash")
+	if param == "" {
+		return nil, badRequest("missing 'hash' in the URL")
+	}
+	hash, err := hex.DecodeString(param)
+	if err != nil || (len(hash) != 20 && len(hash) != 32) {
+		return nil, badRequest("'hash' must be 20 or 32 bytes of hex")
+	}
+	balances, err := store.GetKindBalances(hash)
+	if err != nil {
+		return nil, err
+	}
+	kinds := []KindItem{}
+	for _, kb := range balances {
+		kinds = append(kinds, KindItem{Type: utxoKindStr(kb.Kind), Count: kb.Count, Balance: kb.Balance})
+	}
+	return KindsResponse{Hash: hex.EncodeToString(hash), Kinds: kinds}, nil
+}
@@ -0,0 +1,83 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestGetKinds(t *testing.T) {
+	hash := "0102030405060708090a0b0c0d0e0f1011121314"
+	hashBytes := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}
+	tests := []struct {
+		name           string
+		query          string
+		store          *MockStore
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "Legacy and witness",
+			query: "?hash=" + hash,
+			store: &MockStore{kindBalances: []spec.KindBalance{
+				{Kind: doge.ScriptTypeP2PKH, Count: 2, Balance: bigKoinu(150000000)},
+				{Kind: doge.ScriptTypeP2PKHW, Count: 1, Balance: bigKoinu(1)},
+			}},
+			expectedStatus: 200,
+			expectedBody:   `{"hash":"` + hash + `","kinds":[{"type":"P2PKH","utxo_count":2,"balance":"1.5"},{"type":"P2PKHW","utxo_count":1,"balance":"0.00000001"}]}`,
+		},
+		{
+			name:           "Never paid",
+			query:          "?hash=" + hash,
+			store:          &MockStore{},
+			expectedStatus: 200,
+			expectedBody:   `{"hash":"` + hash + `","kinds":[]}`,
+		},
+		{
+			name:           "Store error",
+			query:          "?hash=" + hash,
+			store:          &MockStore{balanceErr: errors.New("db down")},
+			expectedStatus: 500,
+			expectedBody:   `{"error":"error","reason":"db down"}`,
+		},
+		{
+			name:           "Missing hash",
+			query:          "",
+			store:          &MockStore{},
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"missing 'hash' in the URL"}`,
+		},
+		{
+			name:           "Wrong length",
+			query:          "?hash=0102",
+			store:          &MockStore{},
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'hash' must be 20 or 32 bytes of hex"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: tt.store, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = tt.store
+
+			w := httptest.NewRecorder()
+			webAPI.getKinds(w, httptest.NewRequest("GET", "/kinds"+tt.query, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+			if tt.expectedStatus != 400 && !bytes.Equal(tt.store.kindsScript, hashBytes) {
+				t.Errorf("expected GetKindBalances(%s), got %x", hash, tt.store.kindsScript)
+			}
+		})
+	}
+}
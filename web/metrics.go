@@ -0,0 +1,84 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dogeorg/indexer/spec"
+)
+
+// /metrics exports sync gauges in the Prometheus text format, for alerting
+// without parsing /blocks. The tip, lag and synced gauges need Core RPC
+// (they are left out until the Core heights are known.) The block queue
+// gauges match block_queue in /health/detail. The UTXO conflicts counter
+// should stay at 0 (see spec.StoreTx.CreateUTXOs.)
+
+func (a *WebAPI) getMetrics(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		text, err := a.metrics(store)
+		if err != nil {
+			sendResult(w, nil, err, options, a.corsOrigin)
+			return
+		}
+		setDefaultCacheControl(w)
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Header().Set("Content-Length", strconv.Itoa(len(text)))
+		w.Header().Set("Access-Control-Allow-Origin", a.corsOrigin)
+		w.Write([]byte(text))
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) metrics(store spec.Store) (string, error) {
+	height, err := store.GetCurrentHeight()
+	if err != nil {
+		return "", err
+	}
+	unspent, err := store.GetUnspentCount() // maintained by the store, not a COUNT(*)
+	if err != nil {
+		return "", err
+	}
+	conflicts, err := store.GetUTXOConflicts()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	gauge(&b, "indexer_height", "Indexed block height.", height)
+	gauge(&b, "indexer_utxo_unspent", "Unspent UTXOs in the index.", unspent)
+	if tip, lag := a.coreLag(height); tip != nil {
+		synced := int64(0)
+		if *lag <= a.syncedLag {
+			synced = 1
+		}
+		gauge(&b, "indexer_tip_height", "Core headers height.", *tip)
+		gauge(&b, "indexer_lag_blocks", "Blocks the index is behind Core.", *lag)
+		gauge(&b, "indexer_synced", fmt.Sprintf("1 if the index is at most %d blocks behind Core, otherwise 0.", a.syncedLag), synced)
+	}
+	queue := a.indexer.GetQueueStats()
+	behind := int64(0)
+	if queue.Capacity > 0 && queue.Length >= queue.Capacity {
+		behind = 1
+	}
+	gauge(&b, "indexer_block_queue_length", "Blocks fetched from Core waiting to be indexed.", int64(queue.Length))
+	gauge(&b, "indexer_block_queue_capacity", "Most blocks that can wait to be indexed.", int64(queue.Capacity))
+	gauge(&b, "indexer_block_queue_behind", "1 if the block queue is full (indexing can't keep up), otherwise 0.", behind)
+	metric(&b, "counter", "indexer_utxo_conflicts_total", "Outputs already stored with different content (kept, not replaced).", conflicts)
+	return b.String(), nil
+}
+
+// gauge writes a gauge with its HELP and TYPE lines.
+func gauge(b *strings.Builder, name string, help string, value int64) {
+	metric(b, "gauge", name, help, value)
+}
+
+// metric writes a metric of type `kind` with its HELP and TYPE lines.
+func metric(b *strings.Builder, kind string, name string, help string, value int64) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help, name, kind, name, value)
+}
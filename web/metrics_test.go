@@ -0,0 +1,113 @@
+package web
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dogeorg/indexer/index"
+)
+
+func TestMetricsSynced(t *testing.T) {
+	updated := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tip := func(height int64) syncHeightSnapshot {
+		return syncHeightSnapshot{CoreBlocksHeight: &height, CoreHeadersHeight: &height, CoreSyncUpdatedAt: &updated}
+	}
+	tests := []struct {
+		name           string
+		store          *MockStore
+		snapshot       syncHeightSnapshot
+		syncedLag      int64
+		expectedStatus int
+		expected       []string // lines of the body (all of them)
+	}{
+		{"At the tip", &MockStore{currentHeight: 1000}, tip(1000), 0, 200, []string{"indexer_height 1000", "indexer_utxo_unspent 0", "indexer_tip_height 1000", "indexer_lag_blocks 0", "indexer_synced 1", "indexer_block_queue_length 0", "indexer_block_queue_capacity 0", "indexer_block_queue_behind 0", "indexer_utxo_conflicts_total 0"}},
+		{"Within the default lag", &MockStore{currentHeight: 998}, tip(1000), 0, 200, []string{"indexer_height 998", "indexer_utxo_unspent 0", "indexer_tip_height 1000", "indexer_lag_blocks 2", "indexer_synced 1", "indexer_block_queue_length 0", "indexer_block_queue_capacity 0", "indexer_block_queue_behind 0", "indexer_utxo_conflicts_total 0"}},
+		{"Beyond the default lag", &MockStore{currentHeight: 997}, tip(1000), 0, 200, []string{"indexer_height 997", "indexer_utxo_unspent 0", "indexer_tip_height 1000", "indexer_lag_blocks 3", "indexer_synced 0", "indexer_block_queue_length 0", "indexer_block_queue_capacity 0", "indexer_block_queue_behind 0", "indexer_utxo_conflicts_total 0"}},
+		{"Within a configured lag", &MockStore{currentHeight: 990}, tip(1000), 10, 200, []string{"indexer_height 990", "indexer_utxo_unspent 0", "indexer_tip_height 1000", "indexer_lag_blocks 10", "indexer_synced 1", "indexer_block_queue_length 0", "indexer_block_queue_capacity 0", "indexer_block_queue_behind 0", "indexer_utxo_conflicts_total 0"}},
+		{"Beyond a configured lag", &MockStore{currentHeight: 989}, tip(1000), 10, 200, []string{"indexer_height 989", "indexer_utxo_unspent 0", "indexer_tip_height 1000", "indexer_lag_blocks 11", "indexer_synced 0", "indexer_block_queue_length 0", "indexer_block_queue_capacity 0", "indexer_block_queue_behind 0", "indexer_utxo_conflicts_total 0"}},
+		{"Without Core RPC", &MockStore{currentHeight: 1000}, syncHeightSnapshot{}, 0, 200, []string{"indexer_height 1000", "indexer_utxo_unspent 0", "indexer_block_queue_length 0", "indexer_block_queue_capacity 0", "indexer_block_queue_behind 0", "indexer_utxo_conflicts_total 0"}},
+		{"Unspent UTXOs", &MockStore{currentHeight: 1000, unspentCount: 42}, syncHeightSnapshot{}, 0, 200, []string{"indexer_height 1000", "indexer_utxo_unspent 42", "indexer_block_queue_length 0", "indexer_block_queue_capacity 0", "indexer_block_queue_behind 0", "indexer_utxo_conflicts_total 0"}},
+		{"UTXO conflicts", &MockStore{currentHeight: 1000, conflicts: 2}, syncHeightSnapshot{}, 0, 200, []string{"indexer_height 1000", "indexer_utxo_unspent 0", "indexer_block_queue_length 0", "indexer_block_queue_capacity 0", "indexer_block_queue_behind 0", "indexer_utxo_conflicts_total 2"}},
+		{"Store error", &MockStore{heightErr: errors.New("db down")}, tip(1000), 0, 500, []string{`{"error":"error","reason":"db down"}`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: tt.store, Indexer: &MockIndexer{}, SyncedLag: tt.syncedLag})
+			webAPI := server.(*WebAPI)
+			webAPI.store = tt.store
+			webAPI.syncHeights = seededSyncHeightCache(tt.snapshot)
+
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			var values []string
+			for _, line := range strings.Split(strings.TrimSpace(w.Body.String()), "\n") {
+				if !strings.HasPrefix(line, "#") {
+					values = append(values, line)
+				}
+			}
+			if strings.Join(values, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("expected %q, got %q", tt.expected, values)
+			}
+		})
+	}
+}
+
+func TestMetricsFormat(t *testing.T) {
+	store := &MockStore{currentHeight: 5, unspentCount: 3, conflicts: 1}
+	server := New(Options{Bind: ":0", Store: store, Indexer: &MockIndexer{queue: index.QueueStats{Length: 4, Capacity: 10}}})
+	webAPI := server.(*WebAPI)
+	webAPI.store = store
+
+	w := httptest.NewRecorder()
+	webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	expected := "# HELP indexer_height Indexed block height.\n# TYPE indexer_height gauge\nindexer_height 5\n" +
+		"# HELP indexer_utxo_unspent Unspent UTXOs in the index.\n# TYPE indexer_utxo_unspent gauge\nindexer_utxo_unspent 3\n" +
+		"# HELP indexer_block_queue_length Blocks fetched from Core waiting to be indexed.\n# TYPE indexer_block_queue_length gauge\nindexer_block_queue_length 4\n" +
+		"# HELP indexer_block_queue_capacity Most blocks that can wait to be indexed.\n# TYPE indexer_block_queue_capacity gauge\nindexer_block_queue_capacity 10\n" +
+		"# HELP indexer_block_queue_behind 1 if the block queue is full (indexing can't keep up), otherwise 0.\n# TYPE indexer_block_queue_behind gauge\nindexer_block_queue_behind 0\n" +
+		"# HELP indexer_utxo_conflicts_total Outputs already stored with different content (kept, not replaced).\n# TYPE indexer_utxo_conflicts_total counter\nindexer_utxo_conflicts_total 1\n"
+	if w.Body.String() != expected {
+		t.Errorf("expected body %q, got %q", expected, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("expected the Prometheus text content type, got %q", ct)
+	}
+}
+
+func TestMetricsBlockQueue(t *testing.T) {
+	tests := []struct {
+		name     string
+		queue    index.QueueStats
+		expected []string
+	}{
+		{"Empty", index.QueueStats{Capacity: 10}, []string{"indexer_block_queue_length 0", "indexer_block_queue_capacity 10", "indexer_block_queue_behind 0"}},
+		{"Filling", index.QueueStats{Length: 9, Capacity: 10}, []string{"indexer_block_queue_length 9", "indexer_block_queue_capacity 10", "indexer_block_queue_behind 0"}},
+		{"Full", index.QueueStats{Length: 10, Capacity: 10, FullFor: time.Minute}, []string{"indexer_block_queue_length 10", "indexer_block_queue_capacity 10", "indexer_block_queue_behind 1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &MockStore{currentHeight: 1000}
+			server := New(Options{Bind: ":0", Store: store, Indexer: &MockIndexer{queue: tt.queue}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = store
+
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+			for _, line := range tt.expected {
+				if !strings.Contains(w.Body.String(), "\n"+line+"\n") {
+					t.Errorf("expected %q in %q", line, w.Body.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// corsAllowHeaders are the request headers browsers may send cross-origin.
+const corsAllowHeaders = "Content-Type, X-Request-ID"
+
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// withRequestID tags each request with the client's X-Request-ID (or a new
+// random one) for correlating logs across services, and echoes it back.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !isValidRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", id)
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// requestID gets the request's ID from its context ("-" if none.)
+func requestID(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+		return id
+	}
+	return "-"
+}
+
+// isValidRequestID accepts IDs of printable ASCII (so they're safe to log.)
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var id [16]byte
+	rand.Read(id[:]) // only fails without an OS randomness source
+	return hex.EncodeToString(id[:])
+}
+
+// recoverPanics turns a panic in a handler into a 500 JSON error,
+// logging the panic and stack instead of dropping the connection.
+func recoverPanics(next http.Handler, corsOrigin string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if err := recover(); err != nil {
+				if err == http.ErrAbortHandler {
+					panic(err) // deliberate abort: let net/http handle it
+				}
+				log.Printf("HTTP handler panic: %s %s [%s]: %v\n%s", r.Method, r.URL.Path, requestID(r.Context()), err, debug.Stack())
+				sendError(w, CodeInternal, "unexpected error", "GET, OPTIONS", corsOrigin)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
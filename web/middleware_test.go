@@ -0,0 +1,100 @@
+package web
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecoverPanics(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		var list []int
+		_ = list[1] // index out of range
+	})
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	srv := httptest.NewServer(recoverPanics(mux, ""))
+	defer srv.Close()
+
+	for i := 0; i < 2; i++ {
+		resp, err := http.Get(srv.URL + "/panic")
+		if err != nil {
+			t.Fatalf("GET /panic: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusInternalServerError {
+			t.Errorf("expected status %d, got %d", http.StatusInternalServerError, resp.StatusCode)
+		}
+		if string(body) != `{"error":"internal","reason":"unexpected error"}` {
+			t.Errorf("unexpected body %q", body)
+		}
+	}
+
+	// server is still up
+	resp, err := http.Get(srv.URL + "/ok")
+	if err != nil {
+		t.Fatalf("GET /ok after panic: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		generated bool
+	}{
+		{"Echoed", "abc-123", false},
+		{"Generated when absent", "", true},
+		{"Replaced when not printable", "abc 123", true},
+		{"Replaced when too long", strings.Repeat("a", maxRequestIDLength+1), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			handler := withRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = requestID(r.Context())
+			}))
+			req := httptest.NewRequest("GET", "/height", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Request-ID", tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			id := w.Header().Get("X-Request-ID")
+			if tt.generated {
+				if len(id) != 32 || id == tt.header {
+					t.Errorf("expected a generated ID, got %q", id)
+				}
+			} else if id != tt.header {
+				t.Errorf("expected ID %q, got %q", tt.header, id)
+			}
+			if seen != id {
+				t.Errorf("handler saw ID %q, response has %q", seen, id)
+			}
+		})
+	}
+}
+
+func TestRequestIDOnEndpoints(t *testing.T) {
+	webAPI := New(Options{Bind: ":0", Store: &MockStore{currentHeight: 100}, Indexer: &MockIndexer{}}).(*WebAPI)
+
+	first, second := httptest.NewRecorder(), httptest.NewRecorder()
+	webAPI.srv.Handler.ServeHTTP(first, httptest.NewRequest("GET", "/height", nil))
+	webAPI.srv.Handler.ServeHTTP(second, httptest.NewRequest("GET", "/nowhere", nil))
+
+	a, b := first.Header().Get("X-Request-ID"), second.Header().Get("X-Request-ID")
+	if a == "" || b == "" || a == b {
+		t.Errorf("expected distinct generated IDs, got %q and %q", a, b)
+	}
+}
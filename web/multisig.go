@@ -0,0 +1,101 @@
+package web
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+// Bare multisig outputs (OP_m <pubkeys> OP_n OP_CHECKMULTISIG) have no address.
+// They are indexed by their compact form: the script without the final
+// OP_CHECKMULTISIG. Look them up by the full or compact script, or by the
+// Electrum script hash of the full script.
+
+func (a *WebAPI) getMultiSigBalance(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.multiSigBalance(store, r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) multiSigBalance(store spec.Store, query url.Values) (any, error) {
+	script, err := a.multiSigParam(store, query)
+	if errors.Is(err, spec.ErrNotFound) {
+		return BalanceResponse{}, nil // never paid
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a.balanceFor(store, doge.ScriptTypeMultiSig, script, query)
+}
+
+func (a *WebAPI) getMultiSigUtxo(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.multiSigUtxos(store, r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) multiSigUtxos(store spec.Store, query url.Values) (any, error) {
+	script, err := a.multiSigParam(store, query)
+	if errors.Is(err, spec.ErrNotFound) {
+		return utxoResponse([]UTXOItem{}), nil // never paid
+	}
+	if err != nil {
+		return nil, err
+	}
+	query.Del("script") // the lookup key here, not a /utxo script form
+	return a.utxosFor(store, doge.ScriptTypeMultiSig, script, query)
+}
+
+// multiSigParam resolves the 'script' (full or compact multisig script, hex)
+// or 'scripthash' parameter to the compact multisig script.
+func (a *WebAPI) multiSigParam(store spec.Store, query url.Values) (compact []byte, err error) {
+	if param := query.Get("script"); param != "" {
+		script, err := hex.DecodeString(param)
+		if err != nil {
+			return nil, badRequest("'script' must be hex")
+		}
+		return compactMultiSig(script)
+	}
+	if query.Get("scripthash") != "" {
+		kind, script, err := a.scriptHashParam(store, query)
+		if err != nil {
+			return nil, err
+		}
+		if kind != doge.ScriptTypeMultiSig {
+			return nil, badRequest("'scripthash' is not a multisig script")
+		}
+		return script, nil
+	}
+	return nil, badRequest("missing 'script' or 'scripthash' in the URL")
+}
+
+// compactMultiSig accepts a full or compact standard multisig script
+// and returns the compact form.
+func compactMultiSig(script []byte) ([]byte, error) {
+	if kind, compact := doge.ClassifyScript(script); kind == doge.ScriptTypeMultiSig {
+		return compact, nil
+	}
+	full := append(append([]byte{}, script...), doge.OP_CHECKMULTISIG)
+	if kind, _ := doge.ClassifyScript(full); kind == doge.ScriptTypeMultiSig {
+		return script, nil
+	}
+	return nil, badRequest("'script' must be a standard multisig script")
+}
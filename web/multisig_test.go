@@ -0,0 +1,109 @@
+package web
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestMultiSigEndpoints(t *testing.T) {
+	// 1-of-2 bare multisig: OP_1 <33-byte pubkey> <33-byte pubkey> OP_2 OP_CHECKMULTISIG
+	fullScript := append([]byte{0x51, 0x21}, bytes.Repeat([]byte{0x02}, 33)...)
+	fullScript = append(append(append(fullScript, 0x21), bytes.Repeat([]byte{0x03}, 33)...), 0x52, 0xae)
+	compact := fullScript[:len(fullScript)-1]
+	scriptHash := hex.EncodeToString(reversed(spec.ScriptHash(doge.ScriptTypeMultiSig, compact)))
+	pubKeyHash := bytes.Repeat([]byte{0x62}, 20)
+	pubKeyHashHash := hex.EncodeToString(reversed(spec.ScriptHash(doge.ScriptTypeP2PKH, pubKeyHash)))
+	utxos := []spec.UTXO{
+		{TxID: []byte{1, 2}, VOut: 0, Value: 100000000, Type: doge.ScriptTypeMultiSig, Script: compact},
+		{TxID: []byte{3}, VOut: 1, Value: 5000, Type: doge.ScriptTypeP2PKH, Script: pubKeyHash}, // only for the scripthash lookup
+	}
+	balance := spec.Balance{Available: bigKoinu(100000000), Incoming: bigKoinu(0), Outgoing: bigKoinu(0)}
+	multiSigUTXOs := `{"utxo":[{"tx":"0201","vout":0,"value":"1","type":"MultiSig","script":"` + hex.EncodeToString(fullScript) + `"},{"tx":"03","vout":1,"value":"0.00005","type":"P2PKH","script":"76a914626262626262626262626262626262626262626288ac"}],"count":2,"total":"1.00005"}`
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+		expectLookup   bool // expect a FindUTXOsFiltered lookup of the compact script
+	}{
+		{
+			name:           "Balance by full script",
+			path:           "/multisig/balance?script=" + hex.EncodeToString(fullScript),
+			expectedStatus: 200,
+			expectedBody:   `{"incoming":"0","available":"1","outgoing":"0","current":"1","utxo_count":0}`,
+		},
+		{
+			name:           "UTXOs by full script",
+			path:           "/multisig/utxo?script=" + hex.EncodeToString(fullScript),
+			expectedStatus: 200,
+			expectedBody:   multiSigUTXOs,
+			expectLookup:   true,
+		},
+		{
+			name:           "UTXOs by compact script",
+			path:           "/multisig/utxo?script=" + hex.EncodeToString(compact),
+			expectedStatus: 200,
+			expectedBody:   multiSigUTXOs,
+			expectLookup:   true,
+		},
+		{
+			name:           "UTXOs by scripthash",
+			path:           "/multisig/utxo?scripthash=" + scriptHash,
+			expectedStatus: 200,
+			expectedBody:   multiSigUTXOs,
+			expectLookup:   true,
+		},
+		{
+			name:           "Unknown scripthash",
+			path:           "/multisig/balance?scripthash=" + "00" + scriptHash[2:],
+			expectedStatus: 200,
+			expectedBody:   `{"incoming":"0","available":"0","outgoing":"0","current":"0","utxo_count":0}`,
+		},
+		{
+			name:           "Scripthash of another kind",
+			path:           "/multisig/utxo?scripthash=" + pubKeyHashHash,
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'scripthash' is not a multisig script"}`,
+		},
+		{
+			name:           "Not a multisig script",
+			path:           "/multisig/utxo?script=76a914626262626262626262626262626262626262626288ac",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'script' must be a standard multisig script"}`,
+		},
+		{
+			name:           "Missing script",
+			path:           "/multisig/balance",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"missing 'script' or 'scripthash' in the URL"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{utxos: utxos, balance: balance}
+			server := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = mockStore
+
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+			if tt.expectLookup && (mockStore.utxoKind != doge.ScriptTypeMultiSig || !bytes.Equal(mockStore.utxoScript, compact)) {
+				t.Errorf("expected lookup of kind 4 script %x, got kind %d script %x", compact, mockStore.utxoKind, mockStore.utxoScript)
+			}
+		})
+	}
+}
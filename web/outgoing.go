@@ -0,0 +1,53 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/dogeorg/indexer/spec"
+)
+
+type OutgoingItem struct {
+	UTXOItem
+	SpentHeight int64 `json:"spent_height"` // height of the block that spent the UTXO
+}
+
+type OutgoingResponse struct {
+	Confirmations int64          `json:"confirmations"`
+	Outgoing      []OutgoingItem `json:"outgoing"`
+}
+
+// getOutgoing lists the spent UTXOs counted as Outgoing in /balance
+// (spent less than 'confirmations' blocks ago.)
+func (a *WebAPI) getOutgoing(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.outgoing(store, r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) outgoing(store spec.Store, query url.Values) (any, error) {
+	kind, hash, err := a.addressParam(query)
+	if err != nil {
+		return nil, err
+	}
+	confirmations, err := a.confirmationsOrDefault(query) // same as /balance
+	if err != nil {
+		return nil, err
+	}
+	list, err := store.FindOutgoing(kind, hash, confirmations)
+	if err != nil {
+		return nil, err
+	}
+	items := []OutgoingItem{}
+	for _, c := range list {
+		items = append(items, OutgoingItem{UTXOItem: utxoItem(c.UTXO), SpentHeight: c.Height})
+	}
+	return OutgoingResponse{Confirmations: confirmations, Outgoing: items}, nil
+}
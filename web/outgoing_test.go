@@ -0,0 +1,71 @@
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestGetOutgoing(t *testing.T) {
+	validAddress := "D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS"
+	outgoing := []spec.UTXOChange{{UTXO: spec.UTXO{TxID: []byte{1, 2}, VOut: 3, Value: 50000000, Type: doge.ScriptTypeP2PKH, Script: make([]byte, 20)}, Height: 998}}
+
+	tests := []struct {
+		name                  string
+		query                 string
+		expectedStatus        int
+		expectedConfirmations int64
+		expectedBody          string
+	}{
+		{
+			name:                  "Default confirmations",
+			query:                 "?address=" + validAddress,
+			expectedStatus:        200,
+			expectedConfirmations: 6,
+			expectedBody:          `{"confirmations":6,"outgoing":[{"tx":"0201","vout":3,"value":"0.5","type":"P2PKH","script":"76a914000000000000000000000000000000000000000088ac","spent_height":998}]}`,
+		},
+		{
+			name:                  "Explicit zero confirmations",
+			query:                 "?confirmations=0&address=" + validAddress,
+			expectedStatus:        200,
+			expectedConfirmations: 0,
+			expectedBody:          `{"confirmations":0,"outgoing":[{"tx":"0201","vout":3,"value":"0.5","type":"P2PKH","script":"76a914000000000000000000000000000000000000000088ac","spent_height":998}]}`,
+		},
+		{
+			name:           "Invalid confirmations",
+			query:          "?confirmations=x&address=" + validAddress,
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'confirmations' must be a non-negative integer"}`,
+		},
+		{
+			name:           "Missing address",
+			query:          "",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"missing 'address' in the URL"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{outgoing: outgoing, outgoingConfirmations: -1}
+			server := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = mockStore
+
+			w := httptest.NewRecorder()
+			webAPI.getOutgoing(w, httptest.NewRequest("GET", "/outgoing"+tt.query, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+			if tt.expectedStatus == 200 && mockStore.outgoingConfirmations != tt.expectedConfirmations {
+				t.Errorf("expected FindOutgoing confirmations %d, got %d", tt.expectedConfirmations, mockStore.outgoingConfirmations)
+			}
+		})
+	}
+}
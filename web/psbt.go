@@ -0,0 +1,113 @@
+package web
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/url"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/doge/koinu"
+	"github.com/dogeorg/indexer/spec"
+)
+
+// /utxo-psbt lists an address's UTXOs with the fields of a PSBT (BIP 174)
+// input, so wallets don't have to get the byte orders right themselves.
+//
+// Byte order: `txid_wire` is the transaction hash in the order it is
+// serialized in a transaction input and in PSBT_IN_PREVIOUS_TXID (the raw
+// double-SHA256 output); `txid` is the same hash byte-reversed, as shown by
+// Core and block explorers (and the other endpoints). `witness_utxo` is a
+// serialized transaction output: the value as an 8-byte little-endian
+// integer, then the script length as a compact-size integer and the
+// scriptPubKey, ready for PSBT_IN_WITNESS_UTXO.
+
+type PSBTResponse struct {
+	UTXO      []PSBTInput `json:"utxo"`
+	Count     int         `json:"count"`               // number of UTXOs returned
+	Truncated bool        `json:"truncated,omitempty"` // more UTXOs than the server's limit (the rest are missing)
+}
+
+type PSBTInput struct {
+	TxIDWire      string      `json:"txid_wire"`                 // hex-encoded transaction ID in wire order (PSBT_IN_PREVIOUS_TXID)
+	TxID          string      `json:"txid"`                      // hex-encoded transaction ID in display order (byte-reversed)
+	VOut          uint32      `json:"vout"`                      // transaction output number (PSBT_IN_OUTPUT_INDEX)
+	ValueKoinu    int64       `json:"value_koinu"`               // UTXO value in koinu
+	ValueKoinuStr string      `json:"value_koinu_str,omitempty"` // value_koinu as a decimal string (with ?ints=both)
+	Value         koinu.Koinu `json:"value"`                     // UTXO value to 8 decimal places, as a decimal string
+	Type          string      `json:"type"`                      // UTXO type (determines what you need to sign it)
+	ScriptPubKey  string      `json:"script_pubkey"`             // hex-encoded full locking script
+	WitnessUTXO   string      `json:"witness_utxo"`              // hex-encoded serialized output (PSBT_IN_WITNESS_UTXO)
+	Coinbase      bool        `json:"coinbase,omitempty"`        // created by a coinbase tx (not spendable until mature)
+}
+
+func (a *WebAPI) getUTXOPSBT(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.utxoPSBT(store, r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) utxoPSBT(store spec.Store, query url.Values) (any, error) {
+	kind, hash, err := a.addressParam(query)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSeen(store, kind, hash, query); err != nil {
+		return nil, err
+	}
+	filter := spec.UTXOFilter{Limit: a.maxUTXOs + 1} // one more to detect truncation
+	filter.Order, err = sortParam(query)
+	if err != nil {
+		return nil, err
+	}
+	both, err := intsParam(query)
+	if err != nil {
+		return nil, err
+	}
+	list, err := store.FindUTXOsFiltered(kind, hash, filter)
+	if err != nil {
+		return nil, err
+	}
+	res := PSBTResponse{UTXO: []PSBTInput{}}
+	if len(list) > a.maxUTXOs {
+		list = list[:a.maxUTXOs]
+		res.Truncated = true
+	}
+	for _, u := range list {
+		input := psbtInput(u)
+		input.ValueKoinuStr = intString(u.Value, both)
+		res.UTXO = append(res.UTXO, input)
+	}
+	res.Count = len(res.UTXO)
+	return res, nil
+}
+
+func psbtInput(u spec.UTXO) PSBTInput {
+	script := doge.ExpandScript(u.Type, u.Script)
+	return PSBTInput{
+		TxIDWire:     hex.EncodeToString(u.TxID),
+		TxID:         doge.HexEncodeReversed(u.TxID),
+		VOut:         u.VOut,
+		ValueKoinu:   u.Value,
+		Value:        koinu.Koinu(u.Value),
+		Type:         utxoKindStr(u.Type),
+		ScriptPubKey: hex.EncodeToString(script),
+		WitnessUTXO:  hex.EncodeToString(serializeTxOut(u.Value, script)),
+		Coinbase:     u.Coinbase,
+	}
+}
+
+// serializeTxOut encodes a transaction output as it appears in a transaction.
+func serializeTxOut(value int64, script []byte) []byte {
+	e := doge.Encode(8 + 9 + len(script))
+	e.Int64(value)
+	e.VarUInt(uint64(len(script)))
+	e.Bytes(script)
+	return e.Result()
+}
@@ -0,0 +1,101 @@
+package web
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestGetUTXOPSBT(t *testing.T) {
+	pkh := bytes.Repeat([]byte{0x42}, 20)
+	address := string(doge.Hash160toAddress(pkh, doge.DogeMainNetChain.P2PKH_Address_Prefix))
+	txid := make([]byte, 32) // wire order: 00 01 02 ... 1f
+	for i := range txid {
+		txid[i] = byte(i)
+	}
+	utxos := []spec.UTXO{{TxID: txid, VOut: 3, Value: 150000000, Type: doge.ScriptTypeP2PKH, Script: pkh}}
+	script := "76a914" + strings.Repeat("42", 20) + "88ac"
+	wire := "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	display := "1f1e1d1c1b1a191817161514131211100f0e0d0c0b0a09080706050403020100"
+	// 1.5 DOGE as 8-byte little-endian, compact-size 25, then the script
+	witness := "80d1f00800000000" + "19" + script
+	tests := []struct {
+		name           string
+		query          string
+		store          *MockStore
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Input fields", "?address=" + address, &MockStore{utxos: utxos}, 200,
+			`{"utxo":[{"txid_wire":"` + wire + `","txid":"` + display + `","vout":3,"value_koinu":150000000,"value":"1.5",` +
+				`"type":"P2PKH","script_pubkey":"` + script + `","witness_utxo":"` + witness + `"}],"count":1}`},
+		{"No UTXOs", "?address=" + address, &MockStore{}, 200, `{"utxo":[],"count":0}`},
+		{"Store error", "?address=" + address, &MockStore{utxoErr: errors.New("db down")}, 500, `{"error":"error","reason":"db down"}`},
+		{"Missing address", "", &MockStore{}, 400, `{"error":"bad-request","reason":"missing 'address' in the URL"}`},
+		{"Bad sort", "?address=" + address + "&sort=nope", &MockStore{}, 400,
+			`{"error":"bad-request","reason":"'sort' must be height, height_desc, value_desc or value_asc"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: tt.store, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = tt.store
+
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/utxo-psbt"+tt.query, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestPSBTInputByteOrder(t *testing.T) {
+	txid := bytes.Repeat([]byte{0xAB}, 31)
+	txid = append(txid, 0x01)
+	sh := bytes.Repeat([]byte{0x07}, 20)
+	input := psbtInput(spec.UTXO{TxID: txid, VOut: 0, Value: 1000, Type: doge.ScriptTypeP2SH, Script: sh})
+
+	// PSBT_IN_PREVIOUS_TXID is the hash as serialized, not as displayed
+	wire, err := hex.DecodeString(input.TxIDWire)
+	if err != nil || !bytes.Equal(wire, txid) {
+		t.Errorf("expected txid_wire %x, got %s", txid, input.TxIDWire)
+	}
+	if input.TxID != doge.HexEncodeReversed(txid) || !strings.HasPrefix(input.TxID, "01") {
+		t.Errorf("expected display txid to be byte-reversed, got %s", input.TxID)
+	}
+	// P2SH scriptPubKey: OP_HASH160 <20-byte hash> OP_EQUAL
+	script := "a914" + strings.Repeat("07", 20) + "87"
+	if input.ScriptPubKey != script {
+		t.Errorf("expected script_pubkey %s, got %s", script, input.ScriptPubKey)
+	}
+	// 1000 koinu (0x3e8) little-endian, length 23 (0x17)
+	if witness := "e803000000000000" + "17" + script; input.WitnessUTXO != witness {
+		t.Errorf("expected witness_utxo %s, got %s", witness, input.WitnessUTXO)
+	}
+	js, _ := json.Marshal(input)
+	if !bytes.Contains(js, []byte(`"value_koinu":1000`)) {
+		t.Errorf("expected value_koinu in %s", js)
+	}
+}
+
+func TestSerializeTxOutLongScript(t *testing.T) {
+	script := bytes.Repeat([]byte{0x51}, 300)
+	out := serializeTxOut(1, script)
+	// compact-size: 0xfd then the length as 16-bit little-endian
+	if !bytes.Equal(out[:11], []byte{1, 0, 0, 0, 0, 0, 0, 0, 0xfd, 0x2c, 0x01}) || len(out) != 11+300 {
+		t.Errorf("bad serialized output header %x (len %d)", out[:11], len(out))
+	}
+}
@@ -0,0 +1,24 @@
+package web
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// QueryEndpoints lists the endpoints Query can run.
+var QueryEndpoints = []string{"balance", "utxo"}
+
+// Query runs an endpoint's query against options.Store without serving HTTP
+// (for the `indexer query` command), returning the same payload as the API.
+// `query` holds the endpoint's query parameters, e.g. address.
+func Query(options Options, endpoint string, query url.Values) (any, error) {
+	a := New(options).(*WebAPI)
+	switch endpoint {
+	case "balance":
+		return a.balance(options.Store, query)
+	case "utxo":
+		return a.utxos(options.Store, query)
+	default:
+		return nil, &apiError{code: CodeUnknownMethod, reason: fmt.Sprintf("unknown query '%s'", endpoint)}
+	}
+}
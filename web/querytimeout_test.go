@@ -0,0 +1,75 @@
+package web
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+// slowStore's GetBalance runs until its context is done, like a long query.
+type slowStore struct {
+	*MockStore
+	ctx      context.Context
+	canceled chan error // receives ctx.Err() when the query is aborted
+}
+
+func (s *slowStore) WithCtx(ctx context.Context) spec.Store {
+	return &slowStore{MockStore: s.MockStore, ctx: ctx, canceled: s.canceled}
+}
+
+func (s *slowStore) GetBalance(kind doge.ScriptType, address []byte, confirmations int64) (spec.Balance, error) {
+	<-s.ctx.Done()
+	s.canceled <- s.ctx.Err()
+	return spec.Balance{}, s.ctx.Err()
+}
+
+func TestQueryTimeout(t *testing.T) {
+	store := &slowStore{MockStore: &MockStore{}, canceled: make(chan error, 1)}
+	server := New(Options{Bind: ":0", Store: store, Indexer: &MockIndexer{}, QueryTimeout: 20 * time.Millisecond})
+	webAPI := server.(*WebAPI)
+	webAPI.store = store
+
+	w := httptest.NewRecorder()
+	webAPI.getBalance(w, httptest.NewRequest("GET", "/balance?address=D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS", nil))
+
+	if err := <-store.canceled; err != context.DeadlineExceeded {
+		t.Fatalf("query ended with %v, want %v", err, context.DeadlineExceeded)
+	}
+	if w.Code != 503 {
+		t.Errorf("expected status 503, got %d", w.Code)
+	}
+	if expected := `{"error":"unavailable","reason":"query timed out"}`; w.Body.String() != expected {
+		t.Errorf("expected body %q, got %q", expected, w.Body.String())
+	}
+}
+
+func TestQueryCanceledWithRequest(t *testing.T) {
+	store := &slowStore{MockStore: &MockStore{}, canceled: make(chan error, 1)}
+	server := New(Options{Bind: ":0", Store: store, Indexer: &MockIndexer{}})
+	webAPI := server.(*WebAPI)
+	webAPI.store = store
+
+	// the client goes away while the query is running
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/balance?address=D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS", nil).WithContext(ctx)
+	done := make(chan struct{})
+	go func() {
+		webAPI.getBalance(httptest.NewRecorder(), req)
+		close(done)
+	}()
+	cancel()
+
+	select {
+	case err := <-store.canceled:
+		if err != context.Canceled {
+			t.Fatalf("query ended with %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("query was not aborted when the request was cancelled")
+	}
+	<-done
+}
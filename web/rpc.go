@@ -0,0 +1,121 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/dogeorg/indexer/spec"
+)
+
+const maxBatchSize = 100          // calls per /rpc request
+const maxBatchBytes = 1024 * 1024 // size of a /rpc request body
+
+// RPCCall is one call in a /rpc batch, e.g. {"method":"getBalance","params":{"address":"D..."}}
+// Params are the query parameters of the equivalent REST endpoint.
+type RPCCall struct {
+	Method string                 `json:"method"`
+	Params map[string]interface{} `json:"params"`
+}
+
+// RPCResult is the outcome of one call in a /rpc batch.
+type RPCResult struct {
+	Result any       `json:"result,omitempty"`
+	Error  *WebError `json:"error,omitempty"`
+}
+
+// rpcBatch runs a batch of read calls, returning the results in order.
+func (a *WebAPI) rpcBatch(w http.ResponseWriter, r *http.Request) {
+	options := "POST, OPTIONS"
+	switch r.Method {
+	case http.MethodPost:
+		var calls []RPCCall
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBytes))
+		decoder.UseNumber() // keep integer params exact
+		if err := decoder.Decode(&calls); err != nil {
+			sendError(w, CodeBadRequest, "expecting a JSON array of {method, params}", options, a.corsOrigin)
+			return
+		}
+		limit := a.batchLimit(maxBatchSize)
+		if len(calls) == 0 || len(calls) > limit {
+			sendError(w, CodeBadRequest, fmt.Sprintf("batch must contain between 1 and %d calls", limit), options, a.corsOrigin)
+			return
+		}
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		results := make([]RPCResult, len(calls))
+		rows := 0
+		for n, call := range calls {
+			payload, err := a.rpcCall(store, call)
+			if err != nil {
+				code, reason := errorDetails(err)
+				results[n].Error = &WebError{Error: code, Reason: reason}
+				continue
+			}
+			results[n].Result = payload
+			rows += resultRows(payload)
+			if rows > a.maxBatchRows {
+				// stop running calls: the whole batch is refused
+				sendError(w, CodeTooLarge, fmt.Sprintf("batch would return more than %d rows", a.maxBatchRows), options, a.corsOrigin)
+				return
+			}
+		}
+		sendJson(w, results, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) rpcCall(store spec.Store, call RPCCall) (any, error) {
+	query, err := rpcParams(call.Params)
+	if err != nil {
+		return nil, err
+	}
+	switch call.Method {
+	case "getBalance":
+		return a.balance(store, query)
+	case "getUtxo":
+		return a.utxos(store, query)
+	case "getHeight":
+		return a.height(store, query)
+	case "getBlocks":
+		return a.recentBlocks(), nil
+	default:
+		return nil, &apiError{code: CodeUnknownMethod, reason: fmt.Sprintf("unknown method '%s'", call.Method)}
+	}
+}
+
+// resultRows counts the rows in a call's result: one per UTXO in a list,
+// otherwise one.
+func resultRows(payload any) int {
+	if table, ok := payload.(csvTable); ok {
+		_, rows := table.csvRows()
+		return max(len(rows), 1)
+	}
+	return 1
+}
+
+// batchLimit is the most items in one request to a batch endpoint that
+// allows at most `endpointMax` (the configured limit may be lower.)
+func (a *WebAPI) batchLimit(endpointMax int) int {
+	return min(endpointMax, a.maxBatchItems)
+}
+
+// rpcParams converts call params to the query parameters the REST handlers use.
+func rpcParams(params map[string]interface{}) (url.Values, error) {
+	query := url.Values{}
+	for name, value := range params {
+		switch v := value.(type) {
+		case string:
+			query.Set(name, v)
+		case json.Number:
+			query.Set(name, v.String())
+		case bool:
+			query.Set(name, fmt.Sprint(v))
+		default:
+			return nil, badRequest(fmt.Sprintf("param '%s' must be a string, number or boolean", name))
+		}
+	}
+	return query, nil
+}
@@ -0,0 +1,151 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dogeorg/indexer/index"
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestRPCBatch(t *testing.T) {
+	validAddress := "D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS"
+
+	tests := []struct {
+		name           string
+		method         string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "Mixed batch with a failing item",
+			method: "POST",
+			body: `[
+				{"method":"getHeight"},
+				{"method":"getBalance","params":{"address":"` + validAddress + `"}},
+				{"method":"getBalance","params":{"address":"invalid-address"}},
+				{"method":"getUtxo","params":{"address":"` + validAddress + `","min_conf":6}},
+				{"method":"getBlocks"},
+				{"method":"sendRawTransaction"}
+			]`,
+			expectedStatus: 200,
+			expectedBody: `[{"result":{"height":1000,"hash":"abcd"}},` +
+				`{"result":{"incoming":"0","available":"1","outgoing":"0","current":"1","utxo_count":0}},` +
+				`{"error":{"error":"bad-request","reason":"invalid Dogecoin address"}},` +
+				`{"result":{"utxo":[],"count":0,"total":"0"}},` +
+				`{"result":{"blocks":[{"height":1000,"hash":"abcd","timestamp":"0001-01-01T00:00:00Z","processed_at":"0001-01-01T00:00:00Z","tx_count":1,"utxo_created":0,"utxo_spent":0,"created_value":"0","spent_value":"0","processing_time_ms":0}]}},` +
+				`{"error":{"error":"unknown-method","reason":"unknown method 'sendRawTransaction'"}}]`,
+		},
+		{
+			name:           "Not an array",
+			method:         "POST",
+			body:           `{"method":"getHeight"}`,
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"expecting a JSON array of {method, params}"}`,
+		},
+		{
+			name:           "Empty batch",
+			method:         "POST",
+			body:           `[]`,
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"batch must contain between 1 and 100 calls"}`,
+		},
+		{
+			name:           "Unsupported param type",
+			method:         "POST",
+			body:           `[{"method":"getUtxo","params":{"address":["x"]}}]`,
+			expectedStatus: 200,
+			expectedBody:   `[{"error":{"error":"bad-request","reason":"param 'address' must be a string, number or boolean"}}]`,
+		},
+		{
+			name:           "OPTIONS method",
+			method:         "OPTIONS",
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{
+				balance:       spec.Balance{Available: bigKoinu(100000000), Incoming: bigKoinu(0), Outgoing: bigKoinu(0)},
+				currentHeight: 1000,
+				resumePoint:   []byte{0xab, 0xcd},
+			}
+			mockIndexer := &MockIndexer{blockHistory: []index.BlockHistory{{Height: 1000, Hash: "abcd", TxCount: 1}}}
+			server := New(Options{Bind: ":0", Store: mockStore, Indexer: mockIndexer})
+			webAPI := server.(*WebAPI)
+			webAPI.store = mockStore
+
+			req := httptest.NewRequest(tt.method, "/rpc", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			webAPI.rpcBatch(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.method == "OPTIONS" {
+				if w.Header().Get("Allow") != "POST, OPTIONS" {
+					t.Errorf("expected Allow header 'POST, OPTIONS', got %q", w.Header().Get("Allow"))
+				}
+			} else if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %s, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestBatchBudgets(t *testing.T) {
+	validAddress := "D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS"
+	getUtxo := `{"method":"getUtxo","params":{"address":"` + validAddress + `"}}`
+	outpoint := `{"txid":"` + strings.Repeat("11", 32) + `","vout":0}`
+	batch := func(items ...string) string { return "[" + strings.Join(items, ",") + "]" }
+	utxos := []spec.UTXO{{TxID: []byte{1}, Value: 1}, {TxID: []byte{2}, Value: 1}, {TxID: []byte{3}, Value: 1}}
+
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		expectedStatus int
+		expectedReason string // for errors
+	}{
+		{"RPC at the item limit", "/rpc", batch(`{"method":"getHeight"}`, `{"method":"getHeight"}`, `{"method":"getHeight"}`), 200, ""},
+		{"RPC beyond the item limit", "/rpc", batch(`{"method":"getHeight"}`, `{"method":"getHeight"}`, `{"method":"getHeight"}`, `{"method":"getHeight"}`), 400, "batch must contain between 1 and 3 calls"},
+		{"RPC at the row budget", "/rpc", batch(getUtxo, getUtxo), 200, ""},
+		{"RPC beyond the row budget", "/rpc", batch(getUtxo, getUtxo, `{"method":"getHeight"}`), 413, "batch would return more than 6 rows"},
+		{"Outpoints at the row budget", "/check-utxos", batch(outpoint, outpoint, outpoint), 200, ""},
+		{"Outpoints at the item limit", "/check-utxos", batch(outpoint, outpoint, outpoint, outpoint), 413, "batch would return more than 3 rows"},
+		{"Outpoints beyond the item limit", "/check-utxos", batch(outpoint, outpoint, outpoint, outpoint, outpoint), 400, "expecting between 1 and 4 outpoints"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{currentHeight: 1000, resumePoint: []byte{0xab}, utxos: utxos}
+			webAPI := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}}).(*WebAPI)
+			webAPI.store = mockStore
+			webAPI.maxBatchItems = 3
+			webAPI.maxBatchRows = 6
+			if tt.path == "/check-utxos" {
+				webAPI.maxBatchItems = 4
+				webAPI.maxBatchRows = 3
+			}
+
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body)))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedReason != "" {
+				var res WebError
+				if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || res.Reason != tt.expectedReason {
+					t.Errorf("expected reason %q, got %s", tt.expectedReason, w.Body.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package web
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/index"
+)
+
+// ScriptSchema describes how one type of script is stored, for clients that
+// read or write the compact scripts themselves. Lengths and templates are
+// derived from doge.ExpandScript, so they match what the indexer stores.
+type ScriptSchema struct {
+	Type      string   `json:"type"`              // UTXO type, as in /utxo
+	Kind      int      `json:"kind"`              // stored kind (doge.ScriptType)
+	Indexed   bool     `json:"indexed"`           // outputs of this type are indexed
+	Compact   string   `json:"compact"`           // what the compact script holds
+	Lengths   []int    `json:"lengths,omitempty"` // valid compact lengths in bytes (any length if empty)
+	Templates []string `json:"templates"`         // hex scriptPubKey with {compact} in place of the compact script (none if it can't be expanded)
+}
+
+type ScriptSchemaResponse struct {
+	Scripts []ScriptSchema `json:"scripts"` // in kind order
+}
+
+// compactScriptDocs describes the compact script of each type (see doge.ClassifyScript.)
+var compactScriptDocs = map[doge.ScriptType]string{
+	doge.ScriptTypeP2PK:        "public key (compressed or uncompressed)",
+	doge.ScriptTypeP2PKH:       "public key hash (HASH160)",
+	doge.ScriptTypeP2SH:        "script hash (HASH160)",
+	doge.ScriptTypeMultiSig:    "the script without its final OP_CHECKMULTISIG (OP_m, the length-prefixed public keys, OP_n)",
+	doge.ScriptTypeP2PKHW:      "witness public key hash (not produced by the indexer)",
+	doge.ScriptTypeP2SHW:       "witness script hash (not produced by the indexer)",
+	doge.ScriptTypeNullData:    "the data after OP_RETURN (push opcodes included)",
+	doge.ScriptTypeNonStandard: "the whole script",
+}
+
+// compactPlaceholder is expanded to find where the compact script goes in a template.
+const compactPlaceholder = 0xEE
+
+func (a *WebAPI) getScriptSchema(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		sendJson(w, ScriptSchemaResponse{Scripts: scriptSchemas()}, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func scriptSchemas() []ScriptSchema {
+	var res []ScriptSchema
+	for kind := doge.ScriptTypeP2PK; kind <= doge.ScriptTypeNonStandard; kind++ {
+		schema := ScriptSchema{
+			Type:      utxoKindStr(kind),
+			Kind:      int(kind),
+			Indexed:   index.IsIndexedType(kind),
+			Compact:   compactScriptDocs[kind],
+			Lengths:   addressPayloadLengths[kind],
+			Templates: []string{},
+		}
+		samples := schema.Lengths
+		if len(samples) == 0 {
+			samples = []int{1} // variable length
+		}
+		for _, length := range samples {
+			compact := bytes.Repeat([]byte{compactPlaceholder}, length)
+			if script := doge.ExpandScript(kind, compact); script != nil {
+				template := strings.Replace(hex.EncodeToString(script), hex.EncodeToString(compact), "{compact}", 1)
+				schema.Templates = append(schema.Templates, template)
+			}
+		}
+		res = append(res, schema)
+	}
+	return res
+}
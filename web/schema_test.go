@@ -0,0 +1,81 @@
+package web
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/index"
+)
+
+func TestGetScriptSchema(t *testing.T) {
+	mockStore := &MockStore{}
+	webAPI := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}}).(*WebAPI)
+	w := httptest.NewRecorder()
+	webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/schema/scripts", nil))
+	if w.Code != 200 {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var res ScriptSchemaResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	schemas := map[string]ScriptSchema{}
+	for _, s := range res.Scripts {
+		schemas[s.Type] = s
+	}
+	p2pkh := `{"type":"P2PKH","kind":2,"indexed":true,"compact":"public key hash (HASH160)","lengths":[20],"templates":["76a914{compact}88ac"]}`
+	if got, _ := json.Marshal(schemas["P2PKH"]); string(got) != p2pkh {
+		t.Errorf("expected P2PKH schema %s, got %s", p2pkh, got)
+	}
+
+	// the schema describes what the indexer actually stores for real scripts
+	key33 := append([]byte{0x02}, bytes.Repeat([]byte{0x11}, 32)...)
+	key65 := append([]byte{0x04}, bytes.Repeat([]byte{0x22}, 64)...)
+	hash := bytes.Repeat([]byte{0x33}, 20)
+	p2pkhScript, _ := doge.P2PKHScript(hash)
+	scripts := []struct {
+		typ    string
+		script []byte
+	}{
+		{"P2PK", append(append([]byte{33}, key33...), doge.OP_CHECKSIG)},
+		{"P2PK", append(append([]byte{65}, key65...), doge.OP_CHECKSIG)},
+		{"P2PKH", p2pkhScript},
+		{"P2SH", append(append([]byte{doge.OP_HASH160, 20}, hash...), doge.OP_EQUAL)},
+		{"MultiSig", append(append(append(append([]byte{doge.OP_1, 33}, key33...), 65), key65...), doge.OP_2, doge.OP_CHECKMULTISIG)},
+		{"NullData", []byte{doge.OP_RETURN, 3, 0xab, 0xcd, 0xef}},
+		{"NonStandard", []byte{doge.OP_1, doge.OP_CHECKSIG}},
+	}
+	for _, tt := range scripts {
+		kind, compact, indexed := index.ClassifyScript(tt.script)
+		schema, found := schemas[utxoKindStr(kind)]
+		if !found || schema.Type != tt.typ || schema.Kind != int(kind) {
+			t.Errorf("%s: classified as %s, schema %+v", tt.typ, utxoKindStr(kind), schema)
+			continue
+		}
+		if schema.Indexed != indexed {
+			t.Errorf("%s: schema indexed = %v, ClassifyScript says %v", tt.typ, schema.Indexed, indexed)
+		}
+		if len(schema.Lengths) > 0 && !isValidLength(len(compact), schema.Lengths) {
+			t.Errorf("%s: compact script is %d bytes, schema says %v", tt.typ, len(compact), schema.Lengths)
+		}
+		matched := false
+		for _, template := range schema.Templates {
+			if strings.Replace(template, "{compact}", hex.EncodeToString(compact), 1) == hex.EncodeToString(tt.script) {
+				matched = true
+			}
+		}
+		if !matched {
+			t.Errorf("%s: no template in %v gives %x from compact %x", tt.typ, schema.Templates, tt.script, compact)
+		}
+	}
+	for _, typ := range []string{"P2PKHW", "P2SHW"} {
+		if s := schemas[typ]; len(s.Lengths) != 1 || len(s.Templates) != 0 {
+			t.Errorf("%s: expected one length and no templates, got %+v", typ, s)
+		}
+	}
+}
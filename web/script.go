@@ -0,0 +1,115 @@
+package web
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dogeorg/doge"
+)
+
+// The store keeps scripts in compact form (e.g. only the 20-byte hash of a
+// P2PKH script); /script expands one the same way /utxo does, so wallet code
+// can check its own compaction round-trips, and /scriptpubkey expands the
+// script an address pays. Neither touches the store.
+
+type ScriptResponse struct {
+	Type    string `json:"type"`              // UTXO type, as in /utxo
+	Compact string `json:"compact"`           // hex-encoded compact script, as stored
+	Script  string `json:"script"`            // hex-encoded expanded scriptPubKey
+	Address string `json:"address,omitempty"` // base58 address (P2PKH and P2SH only)
+}
+
+func (a *WebAPI) getScript(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		payload, err := a.script(r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) script(query url.Values) (any, error) {
+	param := query.Get("type")
+	if param == "" {
+		return nil, badRequest("missing 'type' in the URL")
+	}
+	kind := utxoKindFromStr(param)
+	if kind == doge.ScriptTypeNone {
+		return nil, badRequest(fmt.Sprintf("unknown 'type': %s", param))
+	}
+	param = query.Get("compact")
+	if param == "" {
+		return nil, badRequest("missing 'compact' in the URL")
+	}
+	compact, err := hex.DecodeString(param)
+	if err != nil {
+		return nil, badRequest("'compact' must be hex")
+	}
+	if kind == doge.ScriptTypeP2PK || kind == doge.ScriptTypeP2PKH || kind == doge.ScriptTypeP2SH {
+		if lengths := addressPayloadLengths[kind]; !isValidLength(len(compact), lengths) {
+			return nil, badRequest(fmt.Sprintf("%s 'compact' is %d bytes, not %s", utxoKindStr(kind), len(compact), joinInts(lengths, " or ")))
+		}
+	}
+	script := doge.ExpandScript(kind, compact)
+	if script == nil {
+		return nil, badRequest(fmt.Sprintf("%s scripts cannot be expanded", utxoKindStr(kind)))
+	}
+	res := ScriptResponse{
+		Type:    utxoKindStr(kind),
+		Compact: hex.EncodeToString(compact),
+		Script:  hex.EncodeToString(script),
+	}
+	if kind == doge.ScriptTypeP2PKH || kind == doge.ScriptTypeP2SH {
+		res.Address, err = encodeAddress(a.chain, kind, compact)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+type ScriptPubKeyResponse struct {
+	Address string `json:"address"` // the address, as given
+	Type    string `json:"type"`    // UTXO type, as in /utxo
+	Script  string `json:"script"`  // hex-encoded scriptPubKey that pays the address
+}
+
+func (a *WebAPI) getScriptPubKey(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		payload, err := a.scriptPubKey(r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+// scriptPubKey expands an address the same way as a stored UTXO paying it.
+func (a *WebAPI) scriptPubKey(query url.Values) (any, error) {
+	kind, payload, err := a.addressParam(query)
+	if err != nil {
+		return nil, err
+	}
+	script := doge.ExpandScript(kind, payload)
+	if script == nil {
+		return nil, badRequest(fmt.Sprintf("%s addresses have no scriptPubKey", utxoKindStr(kind)))
+	}
+	return ScriptPubKeyResponse{Address: addressString(query), Type: utxoKindStr(kind), Script: hex.EncodeToString(script)}, nil
+}
+
+// utxoKindFromStr is the inverse of utxoKindStr (ignoring case); it returns
+// ScriptTypeNone for unknown names.
+func utxoKindFromStr(name string) doge.ScriptType {
+	for kind := doge.ScriptTypeP2PK; kind <= doge.ScriptTypeNonStandard; kind++ {
+		if strings.EqualFold(utxoKindStr(kind), name) {
+			return kind
+		}
+	}
+	return doge.ScriptTypeNone
+}
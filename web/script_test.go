@@ -0,0 +1,194 @@
+package web
+
+import (
+	"encoding/hex"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/dogeorg/doge"
+)
+
+func TestGetScript(t *testing.T) {
+	hash := strings.Repeat("42", 20)
+	pubKey := "02" + strings.Repeat("42", 32)
+	longPubKey := "04" + strings.Repeat("42", 64)
+	multiSig := "5121" + pubKey + "51" // 1-of-1, without OP_CHECKMULTISIG
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "P2PKH",
+			query:          "?type=P2PKH&compact=" + hash,
+			expectedStatus: 200,
+			expectedBody:   `{"type":"P2PKH","compact":"` + hash + `","script":"76a914` + hash + `88ac","address":"DBBSWfQdrDxq7S7YwZ6vi67BXZMvNKkAxe"}`,
+		},
+		{
+			name:           "P2SH",
+			query:          "?type=P2SH&compact=" + hash,
+			expectedStatus: 200,
+			expectedBody:   `{"type":"P2SH","compact":"` + hash + `","script":"a914` + hash + `87","address":"9xUcdo2LAnFpZxzrkCSNq5vtVXJNdt2if3"}`,
+		},
+		{
+			name:           "P2PK compressed",
+			query:          "?type=P2PK&compact=" + pubKey,
+			expectedStatus: 200,
+			expectedBody:   `{"type":"P2PK","compact":"` + pubKey + `","script":"21` + pubKey + `ac"}`,
+		},
+		{
+			name:           "P2PK uncompressed",
+			query:          "?type=P2PK&compact=" + longPubKey,
+			expectedStatus: 200,
+			expectedBody:   `{"type":"P2PK","compact":"` + longPubKey + `","script":"41` + longPubKey + `ac"}`,
+		},
+		{
+			name:           "MultiSig",
+			query:          "?type=MultiSig&compact=" + multiSig,
+			expectedStatus: 200,
+			expectedBody:   `{"type":"MultiSig","compact":"` + multiSig + `","script":"` + multiSig + `ae"}`,
+		},
+		{
+			name:           "NullData",
+			query:          "?type=NullData&compact=0401020304",
+			expectedStatus: 200,
+			expectedBody:   `{"type":"NullData","compact":"0401020304","script":"6a0401020304"}`,
+		},
+		{
+			name:           "NonStandard",
+			query:          "?type=NonStandard&compact=51ab",
+			expectedStatus: 200,
+			expectedBody:   `{"type":"NonStandard","compact":"51ab","script":"51ab"}`,
+		},
+		{
+			name:           "Type ignores case",
+			query:          "?type=p2pkh&compact=" + hash,
+			expectedStatus: 200,
+			expectedBody:   `{"type":"P2PKH","compact":"` + hash + `","script":"76a914` + hash + `88ac","address":"DBBSWfQdrDxq7S7YwZ6vi67BXZMvNKkAxe"}`,
+		},
+		{
+			name:           "P2PKHW",
+			query:          "?type=P2PKHW&compact=" + hash,
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"P2PKHW scripts cannot be expanded"}`,
+		},
+		{
+			name:           "P2SHW",
+			query:          "?type=P2SHW&compact=" + hash + hash[:24],
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"P2SHW scripts cannot be expanded"}`,
+		},
+		{
+			name:           "Wrong length",
+			query:          "?type=P2PKH&compact=0102",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"P2PKH 'compact' is 2 bytes, not 20"}`,
+		},
+		{
+			name:           "Missing type",
+			query:          "?compact=" + hash,
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"missing 'type' in the URL"}`,
+		},
+		{
+			name:           "Unknown type",
+			query:          "?type=P2WPKH&compact=" + hash,
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"unknown 'type': P2WPKH"}`,
+		},
+		{
+			name:           "Missing compact",
+			query:          "?type=P2PKH",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"missing 'compact' in the URL"}`,
+		},
+		{
+			name:           "Not hex",
+			query:          "?type=P2PKH&compact=xyz",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'compact' must be hex"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webAPI := New(Options{Bind: ":0", Store: &MockStore{}, Indexer: &MockIndexer{}}).(*WebAPI)
+
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/script"+tt.query, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetScriptPubKey(t *testing.T) {
+	hash := strings.Repeat("42", 20)
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "P2PKH",
+			query:          "?address=DBBSWfQdrDxq7S7YwZ6vi67BXZMvNKkAxe",
+			expectedStatus: 200,
+			expectedBody:   `{"address":"DBBSWfQdrDxq7S7YwZ6vi67BXZMvNKkAxe","type":"P2PKH","script":"76a914` + hash + `88ac"}`,
+		},
+		{
+			name:           "P2SH",
+			query:          "?address=9xUcdo2LAnFpZxzrkCSNq5vtVXJNdt2if3",
+			expectedStatus: 200,
+			expectedBody:   `{"address":"9xUcdo2LAnFpZxzrkCSNq5vtVXJNdt2if3","type":"P2SH","script":"a914` + hash + `87"}`,
+		},
+		{
+			name:           "Missing address",
+			query:          "",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"missing 'address' in the URL"}`,
+		},
+		{
+			name:           "Invalid address",
+			query:          "?address=DBBSWfQdrDxq7S7YwZ6vi67BXZMvNKkAxf",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"invalid Dogecoin address"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webAPI := New(Options{Bind: ":0", Store: &MockStore{}, Indexer: &MockIndexer{}}).(*WebAPI)
+
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/scriptpubkey"+tt.query, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+
+	// the full 25-byte P2PKH and 23-byte P2SH templates
+	for address, length := range map[string]int{"DBBSWfQdrDxq7S7YwZ6vi67BXZMvNKkAxe": 25, "9xUcdo2LAnFpZxzrkCSNq5vtVXJNdt2if3": 23} {
+		res, err := (&WebAPI{addressChains: []*doge.ChainParams{&doge.DogeMainNetChain}}).scriptPubKey(url.Values{"address": {address}})
+		if err != nil {
+			t.Fatalf("%s: %v", address, err)
+		}
+		script, _ := hex.DecodeString(res.(ScriptPubKeyResponse).Script)
+		if len(script) != length {
+			t.Errorf("%s: expected a %d-byte script, got %d bytes", address, length, len(script))
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package web
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+// Electrum-protocol clients identify addresses by script hash: the sha256
+// of the scriptPubKey, hex-encoded in reverse byte order (see spec.ScriptHash.)
+
+func (a *WebAPI) getScriptHashBalance(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.scriptHashBalance(store, r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) scriptHashBalance(store spec.Store, query url.Values) (any, error) {
+	kind, script, err := a.scriptHashParam(store, query)
+	if errors.Is(err, spec.ErrNotFound) {
+		return BalanceResponse{}, nil // never paid
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a.balanceFor(store, kind, script, query)
+}
+
+func (a *WebAPI) getScriptHashUtxo(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.scriptHashUtxos(store, r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) scriptHashUtxos(store spec.Store, query url.Values) (any, error) {
+	kind, script, err := a.scriptHashParam(store, query)
+	if errors.Is(err, spec.ErrNotFound) {
+		return utxoResponse([]UTXOItem{}), nil // never paid
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a.utxosFor(store, kind, script, query)
+}
+
+// scriptHashParam resolves the required 'scripthash' parameter to a stored script.
+func (a *WebAPI) scriptHashParam(store spec.Store, query url.Values) (kind doge.ScriptType, script []byte, err error) {
+	param := query.Get("scripthash")
+	if param == "" {
+		return 0, nil, badRequest("missing 'scripthash' in the URL")
+	}
+	scriptHash, err := hex.DecodeString(param)
+	if err != nil || len(scriptHash) != 32 {
+		return 0, nil, badRequest("'scripthash' must be 32 bytes of hex")
+	}
+	return store.FindScriptByHash(reversed(scriptHash))
+}
+
+func reversed(b []byte) []byte {
+	res := make([]byte, len(b))
+	for i, v := range b {
+		res[len(b)-1-i] = v
+	}
+	return res
+}
@@ -0,0 +1,85 @@
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestScriptHashEndpoints(t *testing.T) {
+	// scripthash of 76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac (Electrum docs example)
+	scriptHash := "8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161"
+	pubKeyHash := []byte{0x62, 0xe9, 0x07, 0xb1, 0x5c, 0xbf, 0x27, 0xd5, 0x42, 0x53, 0x99, 0xeb, 0xf6, 0xf0, 0xfb, 0x50, 0xeb, 0xb8, 0x8f, 0x18}
+	utxos := []spec.UTXO{{TxID: []byte{1, 2, 3, 4}, VOut: 1, Value: 100000000, Type: doge.ScriptTypeP2PKH, Script: pubKeyHash}}
+	balance := spec.Balance{Available: bigKoinu(100000000), Incoming: bigKoinu(0), Outgoing: bigKoinu(0)}
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Balance",
+			path:           "/scripthash/balance?scripthash=" + scriptHash,
+			expectedStatus: 200,
+			expectedBody:   `{"incoming":"0","available":"1","outgoing":"0","current":"1","utxo_count":0}`,
+		},
+		{
+			name:           "UTXOs",
+			path:           "/scripthash/utxo?scripthash=" + scriptHash,
+			expectedStatus: 200,
+			expectedBody:   `{"utxo":[{"tx":"04030201","vout":1,"value":"1","type":"P2PKH","script":"76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac"}],"count":1,"total":"1"}`,
+		},
+		{
+			name:           "Unknown scripthash balance",
+			path:           "/scripthash/balance?scripthash=" + "00" + scriptHash[2:],
+			expectedStatus: 200,
+			expectedBody:   `{"incoming":"0","available":"0","outgoing":"0","current":"0","utxo_count":0}`,
+		},
+		{
+			name:           "Unknown scripthash UTXOs",
+			path:           "/scripthash/utxo?scripthash=" + "00" + scriptHash[2:],
+			expectedStatus: 200,
+			expectedBody:   `{"utxo":[],"count":0,"total":"0"}`,
+		},
+		{
+			name:           "Missing scripthash",
+			path:           "/scripthash/utxo",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"missing 'scripthash' in the URL"}`,
+		},
+		{
+			name:           "Invalid scripthash",
+			path:           "/scripthash/balance?scripthash=abcd",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'scripthash' must be 32 bytes of hex"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{utxos: utxos, balance: balance}
+			server := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = mockStore
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+
+			webAPI.srv.Handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+			if w.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("expected JSON response, got %q", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
@@ -3,8 +3,16 @@ package web
 import (
 	"context"
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/dogeorg/doge"
@@ -12,27 +20,222 @@ import (
 	walkerspec "github.com/dogeorg/dogewalker/spec"
 	"github.com/dogeorg/governor"
 	"github.com/dogeorg/indexer/index"
+	"github.com/dogeorg/indexer/mempool"
 	"github.com/dogeorg/indexer/spec"
+	"google.golang.org/grpc"
 )
 
-func New(bind string, store spec.Store, indexer index.IndexerMonitor, blockchain walkerspec.Blockchain, corsOrigin string) governor.Service {
+// Options configures the WebAPI service.
+type Options struct {
+	Bind       string                // API bind address, e.g. "localhost:8000"
+	Store      spec.Store            // index store
+	Indexer    index.IndexerMonitor  // recent block history
+	Blockchain walkerspec.Blockchain // Core RPC (optional: Core sync heights, fee rates and /txproof block hashes)
+	Mempool    mempool.Monitor       // pending balances (optional)
+	CORSOrigin string                // CORS allowed origin
+	ChainName  string                // reported by /height
+	Chain      *doge.ChainParams     // address prefixes accepted, and for /utxo?address=true (default mainnet)
+	TLSCert    string                // TLS certificate file (serve HTTPS if TLSCert and TLSKey are set)
+	TLSKey     string                // TLS private key file
+	Events     *EventHub             // block events for /events (optional)
+	Control    index.IndexerControl  // pause/resume/resync for /admin (optional)
+	AdminToken string                // bearer token for /admin endpoints (admin disabled if empty)
+	Shadow     spec.Store            // shadow store for /admin/compare (optional)
+	Changes    bool                  // serve /changes (the store must record changes)
+	AnyChain   bool                  // accept addresses of any Dogecoin or Bitcoin network, not just Chain (for testing)
+	DevTools   CoreRequester         // Core RPC for /dev endpoints (optional, ignored unless Chain is regtest)
+	GRPCBind   string                // gRPC bind address, e.g. "localhost:8001" (gRPC disabled if empty)
+
+	Confirmations    int64         // default confirmations for /balance and /outgoing (0 for DefaultConfirmations)
+	QueryTimeout     time.Duration // database time limit per request (0 for DefaultQueryTimeout)
+	CORSMaxAge       time.Duration // how long browsers may cache a CORS preflight (0 for DefaultCORSMaxAge)
+	ShutdownGrace    time.Duration // how long in-flight requests can run after Stop (0 for DefaultShutdownGrace)
+	MaxUTXOs         int           // most UTXOs in one response (0 for DefaultMaxUTXOs)
+	MaxExpensive     int           // most concurrent expensive requests, e.g. /diff (0 for DefaultMaxExpensive)
+	SyncedLag        int64         // most blocks behind Core that /metrics reports as synced (0 for DefaultSyncedLag)
+	MaxBatchItems    int           // most items in one batch request, /rpc or /check-utxos (0 for DefaultMaxBatchItems)
+	MaxBatchRows     int           // most rows one batch request can return in total (0 for DefaultMaxBatchRows)
+	BalanceCacheSize int           // number of address balances to cache (0 to disable)
+	BalanceCacheTTL  time.Duration // how long to cache each balance (also dropped when a block is indexed)
+
+	CacheTTL map[string]time.Duration // public cache lifetime by path (only CacheableEndpoints; see ParseCacheTTL)
+
+	Build         BuildInfo // reported by /version
+	SchemaVersion int       // reported by /version (see store.SchemaVersion)
+}
+
+// DefaultConfirmations is the default number of confirmations
+// before funds count as available (see Options.Confirmations.)
+const DefaultConfirmations = 6
+
+// DefaultMaxUTXOs is the most UTXOs returned by /utxo and the other UTXO lists
+// (see Options.MaxUTXOs); longer lists are truncated.
+const DefaultMaxUTXOs = 10000
+
+// DefaultMaxExpensive is how many expensive (analytics) requests can run at
+// once (see Options.MaxExpensive); more are refused until one finishes.
+const DefaultMaxExpensive = 4
+
+// DefaultMaxBatchItems is the most calls in one /rpc request or outpoints in
+// one /check-utxos request (see Options.MaxBatchItems); each endpoint also
+// has its own lower limit (100 /rpc calls.)
+const DefaultMaxBatchItems = 1000
+
+// DefaultMaxBatchRows is the most rows (UTXOs, outpoints, balances) one
+// batch request can return in total (see Options.MaxBatchRows.)
+const DefaultMaxBatchRows = 50000
+
+// DefaultSyncedLag is how many blocks the index can be behind Core's headers
+// while /metrics still reports it as synced (see Options.SyncedLag.)
+const DefaultSyncedLag = 2
+
+// DefaultQueryTimeout is how long a request's database queries can run
+// before they are cancelled (see Options.QueryTimeout.)
+const DefaultQueryTimeout = 30 * time.Second
+
+// DefaultCORSMaxAge is how long browsers may reuse a CORS preflight response
+// (Access-Control-Max-Age) before sending another OPTIONS request.
+const DefaultCORSMaxAge = 10 * time.Minute
+
+// DefaultShutdownGrace is how long in-flight requests can run after Stop
+// before their connections are closed (see Options.ShutdownGrace.)
+const DefaultShutdownGrace = 10 * time.Second
+
+func New(options Options) governor.Service {
 	mux := http.NewServeMux()
+	if options.Confirmations == 0 {
+		options.Confirmations = DefaultConfirmations
+	}
+	if options.QueryTimeout == 0 {
+		options.QueryTimeout = DefaultQueryTimeout
+	}
+	if options.MaxUTXOs <= 0 {
+		options.MaxUTXOs = DefaultMaxUTXOs
+	}
+	if options.MaxExpensive <= 0 {
+		options.MaxExpensive = DefaultMaxExpensive
+	}
+	if options.MaxBatchItems <= 0 {
+		options.MaxBatchItems = DefaultMaxBatchItems
+	}
+	if options.MaxBatchRows <= 0 {
+		options.MaxBatchRows = DefaultMaxBatchRows
+	}
+	if options.SyncedLag <= 0 {
+		options.SyncedLag = DefaultSyncedLag
+	}
+	if options.CORSMaxAge <= 0 {
+		options.CORSMaxAge = DefaultCORSMaxAge
+	}
+	if options.ShutdownGrace <= 0 {
+		options.ShutdownGrace = DefaultShutdownGrace
+	}
 	a := &WebAPI{
-		_store:      store,
-		indexer:     indexer,
-		syncHeights: newSyncHeightCache(blockchain),
-		corsOrigin:  corsOrigin,
+		_store:      options.Store,
+		_shadow:     options.Shadow,
+		indexer:     options.Indexer,
+		mempool:     options.Mempool,
+		syncHeights: newSyncHeightCache(options.Blockchain),
+		feeRates:    newFeeRateCache(options.Blockchain),
+		blockchain:  options.Blockchain,
+		balances:    newBalanceCache(options.BalanceCacheSize, options.BalanceCacheTTL),
+		corsOrigin:  options.CORSOrigin,
+		corsMaxAge:  options.CORSMaxAge,
+		chainName:   options.ChainName,
+		tlsCert:     options.TLSCert,
+		tlsKey:      options.TLSKey,
+		events:      options.Events,
 		srv: http.Server{
-			Addr:    bind,
-			Handler: mux,
+			Addr:    options.Bind,
+			Handler: withRequestID(recoverPanics(mux, options.CORSOrigin)),
 		},
+		confirmations: options.Confirmations,
+		control:       options.Control,
+		adminToken:    options.AdminToken,
+		chain:         options.Chain,
+		queryTimeout:  options.QueryTimeout,
+		maxUTXOs:      options.MaxUTXOs,
+		expensive:     make(chan struct{}, options.MaxExpensive),
+		syncedLag:     options.SyncedLag,
+		maxBatchItems: options.MaxBatchItems,
+		maxBatchRows:  options.MaxBatchRows,
+		build:         options.Build,
+		schemaVersion: options.SchemaVersion,
+		shutdownGrace: options.ShutdownGrace,
+		drained:       make(chan struct{}),
+	}
+	if options.GRPCBind != "" {
+		a.grpc = a.newGRPCServer()
+		a.grpcBind = options.GRPCBind
+	}
+	if a.chain == nil {
+		a.chain = &doge.DogeMainNetChain
+	}
+	a.addressChains = []*doge.ChainParams{a.chain}
+	if options.AnyChain {
+		a.addressChains = anyChainAddresses
 	}
 
 	mux.HandleFunc("/health", a.healthCheck)
+	mux.HandleFunc("/health/detail", a.healthDetail)
+	mux.HandleFunc("/metrics", a.getMetrics)
+	mux.HandleFunc("/chain-health", a.getChainHealth)
+	mux.HandleFunc("/version", a.getVersion)
 	mux.HandleFunc("/balance", a.getBalance)
 	mux.HandleFunc("/utxo", a.getUtxo)
-	mux.HandleFunc("/height", a.getHeight)
-	mux.HandleFunc("/blocks", a.getRecentBlocks)
+	mux.HandleFunc("/utxo-age", a.getUTXOAge)
+	mux.HandleFunc("/utxo-psbt", a.getUTXOPSBT)
+	mux.HandleFunc("/height", cacheFor(options.CacheTTL["/height"], a.getHeight))
+	mux.HandleFunc("/blocks", cacheFor(options.CacheTTL["/blocks"], a.getRecentBlocks))
+	mux.HandleFunc("/feerate", cacheFor(options.CacheTTL["/feerate"], a.getFeeRate))
+	mux.HandleFunc("/rpc", a.rpcBatch)
+	mux.HandleFunc("/diff", a.limitExpensive(a.getDiff))
+	mux.HandleFunc("/outgoing", a.getOutgoing)
+	mux.HandleFunc("/utxo-by-script", a.getUtxoByScript)
+	mux.HandleFunc("/first-seen", a.getFirstSeen)
+	mux.HandleFunc("/kinds", a.limitExpensive(a.getKinds))
+	mux.HandleFunc("/script", a.getScript)
+	mux.HandleFunc("/scriptpubkey", a.getScriptPubKey)
+	mux.HandleFunc("/schema/scripts", a.getScriptSchema)
+	mux.HandleFunc("/balance-at", a.limitExpensive(a.getBalanceAt))
+	mux.HandleFunc("/trimmed", cacheFor(options.CacheTTL["/trimmed"], a.getTrimmed))
+	mux.HandleFunc("/txcount", cacheFor(options.CacheTTL["/txcount"], a.limitExpensive(a.getTxCount)))
+	mux.HandleFunc("/block", a.getBlock)
+	mux.HandleFunc("/txinfo", a.getTxInfo)
+	mux.HandleFunc("/txproof", a.getTxProof)
+	mux.HandleFunc("/tx/", a.getTx)
+	mux.HandleFunc("/check-utxos", a.limitExpensive(a.postCheckUTXOs))
+	mux.HandleFunc("/block-stats", a.limitExpensive(a.getBlockStats))
+	mux.HandleFunc("/txtotal", cacheFor(options.CacheTTL["/txtotal"], a.limitExpensive(a.getTxTotal)))
+	mux.HandleFunc("/scripthash/balance", a.getScriptHashBalance)
+	mux.HandleFunc("/scripthash/utxo", a.getScriptHashUtxo)
+	mux.HandleFunc("/multisig/balance", a.getMultiSigBalance)
+	mux.HandleFunc("/multisig/utxo", a.getMultiSigUtxo)
+	if options.Changes {
+		mux.HandleFunc("/changes", a.limitExpensive(a.getChanges))
+	}
+	if options.Events != nil {
+		mux.HandleFunc("/events", a.getEvents)
+	}
+	if options.DevTools != nil {
+		if isRegTest(a.chain) {
+			a.devCore = options.DevTools
+			mux.HandleFunc("/dev/generate", a.devGenerate)
+		} else {
+			log.Printf("[API] dev endpoints are only available on regtest")
+		}
+	}
+	if options.AdminToken != "" && options.Control != nil {
+		mux.HandleFunc("/admin/pause", a.adminPause)
+		mux.HandleFunc("/admin/resume", a.adminResume)
+		mux.HandleFunc("/admin/resync", a.adminResync)
+	}
+	if options.AdminToken != "" {
+		mux.HandleFunc("/admin/repair-orphans", a.adminRepairOrphans)
+	}
+	if options.AdminToken != "" && options.Shadow != nil {
+		mux.HandleFunc("/admin/compare", a.adminCompare)
+	}
 
 	return a
 }
@@ -41,45 +244,152 @@ type WebAPI struct {
 	governor.ServiceCtx
 	_store      spec.Store
 	store       spec.Store
+	_shadow     spec.Store // nil unless -shadowdb is set
+	shadow      spec.Store
 	indexer     index.IndexerMonitor
+	mempool     mempool.Monitor // nil unless mempool tracking is enabled
 	syncHeights *syncHeightCache
+	feeRates    *feeRateCache
+	blockchain  walkerspec.Blockchain // nil unless Core RPC is configured
+	balances    *balanceCache         // nil unless balance caching is enabled
 	corsOrigin  string
+	corsMaxAge  time.Duration // Access-Control-Max-Age for preflights
+	chainName   string
+	tlsCert     string
+	tlsKey      string
+	events      *EventHub // nil if /events is disabled
 	srv         http.Server
+	grpc        *grpc.Server // nil unless Options.GRPCBind is set
+	grpcBind    string
+
+	confirmations int64                // default when a request doesn't specify 'confirmations'
+	control       index.IndexerControl // nil unless admin endpoints are enabled
+	adminToken    string
+	chain         *doge.ChainParams   // for deriving addresses in /utxo
+	addressChains []*doge.ChainParams // whose address prefixes are accepted
+	queryTimeout  time.Duration
+	maxUTXOs      int           // truncate UTXO lists to this many
+	expensive     chan struct{} // semaphore: one slot per running expensive request
+	syncedLag     int64         // /metrics reports synced within this many blocks of Core
+	maxBatchItems int           // most items in one batch request (see batchLimit)
+	maxBatchRows  int           // most rows one batch request can return
+	devCore       CoreRequester // nil unless dev endpoints are enabled (regtest)
+	build         BuildInfo
+	schemaVersion int
+	shutdownGrace time.Duration // how long Stop lets in-flight requests run
+	draining      atomic.Bool   // set by Stop: /health reports unavailable
+	drained       chan struct{} // closed once Stop has shut down the server
 }
 
 // called on any Goroutine
 func (a *WebAPI) Stop() {
+	if a.draining.Swap(true) {
+		return // already stopping
+	}
+	if a.events != nil {
+		a.events.Close() // end event streams so Shutdown can finish
+	}
 	// new goroutine because Shutdown() blocks
 	go func() {
+		defer close(a.drained)
+		if a.grpc != nil {
+			defer a.stopGRPC(a.shutdownGrace)
+		}
 		// cannot use ServiceCtx here because it's already cancelled
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		a.srv.Shutdown(ctx) // blocking call
-		cancel()
+		ctx, cancel := context.WithTimeout(context.Background(), a.shutdownGrace)
+		defer cancel()
+		// closes the listeners, then waits for in-flight requests to finish
+		if err := a.srv.Shutdown(ctx); err != nil {
+			log.Printf("HTTP server: requests still running after %v, closing them\n", a.shutdownGrace)
+			a.srv.Close()
+		}
 	}()
 }
 
 // goroutine
 func (a *WebAPI) Run() {
 	a.store = a._store.WithCtx(a.Context) // Service Context is first available here
+	if a._shadow != nil {
+		a.shadow = a._shadow.WithCtx(a.Context)
+	}
 	if a.syncHeights != nil {
 		go a.syncHeights.run(a.Context)
 	}
+	if a.grpc != nil {
+		go a.serveGRPC()
+	}
+	ln, err := listen(a.srv.Addr)
+	if err != nil {
+		log.Printf("HTTP server: %v\n", err)
+		return
+	}
 	log.Printf("HTTP server listening on: %v\n", a.srv.Addr)
-	if err := a.srv.ListenAndServe(); err != http.ErrServerClosed { // blocking call
+	if a.serve(ln) == http.ErrServerClosed {
+		<-a.drained // Serve returns as soon as Stop begins: let in-flight requests finish
+	}
+}
+
+// requestStore binds the store to the request's context with the query timeout,
+// so queries are cancelled if the client goes away or they take too long.
+func (a *WebAPI) requestStore(r *http.Request) (spec.Store, context.CancelFunc) {
+	return a.contextStore(r.Context())
+}
+
+// contextStore binds the store to `ctx` with the query timeout (see requestStore.)
+func (a *WebAPI) contextStore(ctx context.Context) (spec.Store, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(ctx, a.queryTimeout)
+	return a.store.WithCtx(ctx), cancel
+}
+
+// listen listens on a TCP address, or on a unix socket given as "unix:/path/to.sock".
+func listen(bind string) (net.Listener, error) {
+	path, isUnix := strings.CutPrefix(bind, "unix:")
+	if !isUnix {
+		return net.Listen("tcp", bind)
+	}
+	// remove a socket left behind by a previous run that didn't shut down cleanly
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	ln.(*net.UnixListener).SetUnlinkOnClose(true) // remove the socket file on shutdown
+	return ln, nil
+}
+
+// serve accepts connections on `ln` until the server is shut down,
+// using TLS if a certificate and key were configured.
+func (a *WebAPI) serve(ln net.Listener) error {
+	var err error
+	if a.tlsCert != "" && a.tlsKey != "" {
+		err = a.srv.ServeTLS(ln, a.tlsCert, a.tlsKey) // blocking call
+	} else {
+		err = a.srv.Serve(ln) // blocking call
+	}
+	if err != http.ErrServerClosed {
 		log.Printf("HTTP server: %v\n", err)
 	}
+	return err
 }
 
 func (a *WebAPI) healthCheck(w http.ResponseWriter, r *http.Request) {
-	_, err := a.store.GetResumePoint()
+	if a.draining.Load() {
+		sendError(w, CodeUnavailable, "shutting down", "GET", a.corsOrigin) // so load balancers stop routing here
+		return
+	}
+	store, cancel := a.requestStore(r)
+	defer cancel()
+	_, err := store.GetResumePoint()
 	if err != nil {
-		sendError(w, 500, "error", err.Error(), "GET", a.corsOrigin)
+		sendError(w, CodeError, err.Error(), "GET", a.corsOrigin)
 		return
 	}
 
-	height, err := a.store.GetCurrentHeight()
+	height, err := store.GetCurrentHeight()
 	if err != nil {
-		sendError(w, 500, "error", err.Error(), "GET", a.corsOrigin)
+		sendError(w, CodeError, err.Error(), "GET", a.corsOrigin)
 		return
 	}
 
@@ -100,112 +410,513 @@ func (a *WebAPI) getBalance(w http.ResponseWriter, r *http.Request) {
 	options := "GET, OPTIONS"
 	switch r.Method {
 	case http.MethodGet:
-		address := r.URL.Query().Get("address")
-		if address == "" {
-			sendError(w, 400, "bad-request", "missing 'address' in the URL", options, a.corsOrigin)
-			return
-		}
-		pubkeyHash, err := doge.Base58DecodeCheck(address)
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.balance(store, r.URL.Query())
+		sendNegotiated(w, r, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) balance(store spec.Store, query url.Values) (any, error) {
+	kind, hash, err := a.addressParam(query)
+	if err != nil {
+		return nil, err
+	}
+	return a.balanceFor(store, kind, hash, query)
+}
+
+// balanceFor gets the balance with the 'confirmations', 'include_unconfirmed' and 'decimals' parameters.
+func (a *WebAPI) balanceFor(store spec.Store, kind doge.ScriptType, hash []byte, query url.Values) (any, error) {
+	confirmations, err := a.confirmationsOrDefault(query)
+	if err != nil {
+		return nil, err
+	}
+	fixed, err := decimalsParam(query)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSeen(store, kind, hash, query); err != nil {
+		return nil, err
+	}
+	unconfirmed := false
+	if param := query.Get("include_unconfirmed"); param != "" {
+		unconfirmed, err = strconv.ParseBool(param)
 		if err != nil {
-			sendError(w, 400, "bad-request", "invalid Dogecoin address", options, a.corsOrigin)
-			return
-		}
-		if len(pubkeyHash) != 21 {
-			sendError(w, 400, "bad-request", "invalid Dogecoin address", options, a.corsOrigin)
-			return
+			return nil, badRequest("'include_unconfirmed' must be true or false")
 		}
-		kind := utxoKindFromVersionByte(pubkeyHash[0])
-		hash := pubkeyHash[1:]
-		bal, err := a.store.GetBalance(kind, hash, 6)
+	}
+	bal, err := a.cachedBalance(store, kind, hash, confirmations)
+	if err != nil {
+		return nil, err
+	}
+	bal.Current = bal.Available.Add(bal.Incoming)
+	response := BalanceResponse{Balance: bal}
+	if unconfirmed {
+		zeroConf, err := a.cachedBalance(store, kind, hash, 0)
 		if err != nil {
-			sendError(w, 500, "error", err.Error(), options, a.corsOrigin)
-		} else {
-			bal.Current = bal.Available.Add(bal.Incoming)
-			sendJson(w, bal, options, a.corsOrigin)
+			return nil, err
 		}
-	case http.MethodOptions:
-		sendOptions(w, r, options, a.corsOrigin)
+		zeroConf.Current = zeroConf.Available.Add(zeroConf.Incoming)
+		response.Unconfirmed = &zeroConf
+	}
+	if a.mempool != nil {
+		pending := a.mempool.PendingBalance(kind, hash)
+		response.Mempool = &pending
+	}
+	return withDecimals(response, fixed), nil
+}
+
+// checkSeen implements 'strict=true': the address must have stored UTXOs
+// (spent or unspent, above the trim floor), otherwise it is not found.
+// Without it, an unknown address is just empty.
+func checkSeen(store spec.Store, kind doge.ScriptType, hash []byte, query url.Values) error {
+	param := query.Get("strict")
+	if param == "" {
+		return nil
+	}
+	strict, err := strconv.ParseBool(param)
+	if err != nil {
+		return badRequest("'strict' must be true or false")
+	}
+	if !strict {
+		return nil
+	}
+	found, err := store.HasAddressUTXOs(kind, hash)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return notFound("no UTXOs (spent or unspent) for the address since the trim horizon")
+	}
+	return nil
+}
+
+// cachedBalance gets a balance from the balance cache (if enabled) or the store.
+func (a *WebAPI) cachedBalance(store spec.Store, kind doge.ScriptType, hash []byte, confirmations int64) (spec.Balance, error) {
+	if a.balances == nil {
+		return store.GetBalance(kind, hash, confirmations)
+	}
+	tip, err := store.GetResumePoint()
+	if err != nil {
+		return spec.Balance{}, err
 	}
+	if bal, found := a.balances.get(kind, hash, confirmations, tip); found {
+		return bal, nil
+	}
+	bal, err := store.GetBalance(kind, hash, confirmations)
+	if err != nil {
+		return spec.Balance{}, err
+	}
+	a.balances.put(kind, hash, confirmations, tip, bal)
+	return bal, nil
 }
 
 func (a *WebAPI) getUtxo(w http.ResponseWriter, r *http.Request) {
 	options := "GET, OPTIONS"
 	switch r.Method {
 	case http.MethodGet:
-		address := r.URL.Query().Get("address")
-		if address == "" {
-			sendError(w, 400, "bad-request", "missing 'address' in the URL", options, a.corsOrigin)
-			return
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.utxos(store, r.URL.Query())
+		sendNegotiated(w, r, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) utxos(store spec.Store, query url.Values) (any, error) {
+	kind, hash, err := a.addressParam(query)
+	if err != nil {
+		return nil, err
+	}
+	return a.utxosFor(store, kind, hash, query)
+}
+
+func (a *WebAPI) utxosFor(store spec.Store, kind doge.ScriptType, hash []byte, query url.Values) (any, error) {
+	minConf, err := confirmationsParam(query, "min_conf")
+	if err != nil {
+		return nil, err
+	}
+	maxConf, err := confirmationsParam(query, "max_conf")
+	if err != nil {
+		return nil, err
+	}
+	if maxConf > 0 && maxConf < minConf {
+		return nil, badRequest("'max_conf' must not be less than 'min_conf'")
+	}
+	format, err := a.utxoFormatParam(query)
+	if err != nil {
+		return nil, err
+	}
+	fixed, err := decimalsParam(query)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSeen(store, kind, hash, query); err != nil {
+		return nil, err
+	}
+	var filter spec.UTXOFilter
+	if param := query.Get("min_value"); param != "" {
+		filter.MinValue, err = strconv.ParseInt(param, 10, 64)
+		if err != nil || filter.MinValue < 0 {
+			return nil, badRequest("'min_value' must be a non-negative koinu amount")
 		}
-		pubkeyHash, err := doge.Base58DecodeCheck(address)
+	}
+	filter.Order, err = sortParam(query)
+	if err != nil {
+		return nil, err
+	}
+	spent := false
+	if param := query.Get("include_spent"); param != "" {
+		spent, err = strconv.ParseBool(param)
 		if err != nil {
-			sendError(w, 400, "bad-request", "invalid Dogecoin address", options, a.corsOrigin)
-			return
-		}
-		if len(pubkeyHash) != 21 {
-			sendError(w, 400, "bad-request", "invalid Dogecoin address", options, a.corsOrigin)
-			return
+			return nil, badRequest("'include_spent' must be true or false")
 		}
-		kind := utxoKindFromVersionByte(pubkeyHash[0])
-		hash := pubkeyHash[1:]
-		list, err := a.store.FindUTXOs(kind, hash)
+	}
+	if minConf > 0 || maxConf > 0 {
+		// a UTXO at `height` has (current - height + 1) confirmations
+		current, err := store.GetCurrentHeight()
 		if err != nil {
-			sendError(w, 500, "error", err.Error(), options, a.corsOrigin)
-		} else {
-			utxo := []UTXOItem{}
-			for _, u := range list {
-				utxo = append(utxo, UTXOItem{
-					TxID:   doge.HexEncodeReversed(u.TxID),
-					VOut:   u.VOut,
-					Value:  koinu.Koinu(u.Value),
-					Type:   utxoKindStr(u.Type),
-					Script: hex.EncodeToString(doge.ExpandScript(u.Type, u.Script)),
-				})
+			return nil, err
+		}
+		if minConf > 0 {
+			filter.MaxHeight = current - minConf + 1
+			if filter.MaxHeight < 1 {
+				return withDecimals(utxoResponse([]UTXOItem{}), fixed), nil // nothing has that many confirmations yet
 			}
-			sendJson(w, UTXOResponse{UTXO: utxo}, options, a.corsOrigin)
 		}
-	case http.MethodOptions:
-		sendOptions(w, r, options, a.corsOrigin)
+		if maxConf > 0 {
+			filter.MinHeight = current - maxConf + 1
+		}
 	}
+	filter.Limit = a.maxUTXOs + 1 // one more to detect truncation
+	var list []spec.UTXOState
+	if spent {
+		list, err = store.FindUTXOHistory(kind, hash, filter)
+	} else {
+		var unspent []spec.UTXO
+		unspent, err = store.FindUTXOsFiltered(kind, hash, filter)
+		for _, u := range unspent {
+			list = append(list, spec.UTXOState{UTXO: u})
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	truncated := len(list) > a.maxUTXOs
+	if truncated {
+		list = list[:a.maxUTXOs]
+	}
+	utxo := []UTXOItem{}
+	for _, u := range list {
+		item := format.item(u.UTXO)
+		item.SpentHeight = u.Spent
+		utxo = append(utxo, item)
+	}
+	res := utxoResponse(utxo)
+	if truncated {
+		// totals of the whole list, so they still agree with /balance
+		filter.Limit = 0
+		count, total, err := store.SumUTXOsFiltered(kind, hash, filter, spent)
+		if err != nil {
+			return nil, err
+		}
+		res.Truncated = true
+		res.TotalCount, res.TotalValue = &count, &total
+	}
+	return withDecimals(res, fixed), nil
 }
 
 func (a *WebAPI) getHeight(w http.ResponseWriter, r *http.Request) {
 	options := "GET, OPTIONS"
 	switch r.Method {
 	case http.MethodGet:
-		height, err := a.store.GetCurrentHeight()
-		if err != nil {
-			sendError(w, 500, "error", err.Error(), options, a.corsOrigin)
-		} else {
-			response := HeightResponse{
-				Height: height,
-			}
-			if a.syncHeights != nil {
-				snapshot := a.syncHeights.snapshot()
-				response.CoreBlocksHeight = snapshot.CoreBlocksHeight
-				response.CoreHeadersHeight = snapshot.CoreHeadersHeight
-				response.CoreSyncUpdatedAt = snapshot.CoreSyncUpdatedAt
-			}
-			sendJson(w, response, options, a.corsOrigin)
-		}
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.height(store, r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
 	case http.MethodOptions:
-		sendOptions(w, r, options, a.corsOrigin)
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
 	}
 }
 
+func (a *WebAPI) height(store spec.Store, query url.Values) (any, error) {
+	both, err := intsParam(query)
+	if err != nil {
+		return nil, err
+	}
+	height, hash, err := store.GetTip() // one read: a block can commit in between
+	if err != nil {
+		return nil, err
+	}
+	response := HeightResponse{
+		Height:    height,
+		HeightStr: intString(height, both),
+		Hash:      doge.HexEncode(hash), // stored in display order (see main.go)
+		Chain:     a.chainName,
+	}
+	if a.syncHeights != nil {
+		snapshot := a.syncHeights.snapshot()
+		response.CoreBlocksHeight = snapshot.CoreBlocksHeight
+		response.CoreHeadersHeight = snapshot.CoreHeadersHeight
+		response.CoreSyncUpdatedAt = snapshot.CoreSyncUpdatedAt
+	}
+	return response, nil
+}
+
 func (a *WebAPI) getRecentBlocks(w http.ResponseWriter, r *http.Request) {
 	options := "GET, OPTIONS"
 	switch r.Method {
 	case http.MethodGet:
-		blocks := a.indexer.GetBlockHistory()
-		sendJson(w, map[string]interface{}{"blocks": blocks}, options, a.corsOrigin)
+		sendJson(w, a.recentBlocks(), options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) recentBlocks() any {
+	return map[string]interface{}{"blocks": a.indexer.GetBlockHistory()}
+}
+
+func (a *WebAPI) getFeeRate(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		blocks := defaultFeeRateBlocks
+		if param := r.URL.Query().Get("blocks"); param != "" {
+			n, err := strconv.Atoi(param)
+			if err != nil || n < 1 || n > maxFeeRateBlocks {
+				sendError(w, CodeBadRequest, fmt.Sprintf("'blocks' must be between 1 and %d", maxFeeRateBlocks), options, a.corsOrigin)
+				return
+			}
+			blocks = n
+		}
+		if a.feeRates == nil {
+			sendError(w, CodeUnavailable, "fee estimation is not configured", options, a.corsOrigin)
+			return
+		}
+		feePerKB, err := a.feeRates.estimate(r.Context(), blocks)
+		if errors.Is(err, errNoFeeEstimate) {
+			sendError(w, CodeUnavailable, "Core has no fee estimate yet (not enough recent blocks)", options, a.corsOrigin)
+		} else if err != nil {
+			log.Printf("[API] fee estimate for %d blocks: %v", blocks, err)
+			sendError(w, CodeUnavailable, "cannot get a fee estimate from Core", options, a.corsOrigin)
+		} else {
+			sendJson(w, FeeRateResponse{Blocks: blocks, KoinuPerKB: int64(feePerKB)}, options, a.corsOrigin)
+		}
 	case http.MethodOptions:
-		sendOptions(w, r, options, a.corsOrigin)
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+// utxoSortOrders are the values of the 'sort' parameter for UTXO lists.
+var utxoSortOrders = map[string]spec.UTXOOrder{
+	"height":      spec.UTXOOrderHeight,
+	"height_desc": spec.UTXOOrderHeightDesc,
+	"value_desc":  spec.UTXOOrderValueDesc,
+	"value_asc":   spec.UTXOOrderValueAsc,
+}
+
+// sortParam decodes the optional 'sort' parameter (default: oldest first.)
+func sortParam(query url.Values) (spec.UTXOOrder, error) {
+	param := query.Get("sort")
+	if param == "" {
+		return spec.UTXOOrderHeight, nil
+	}
+	order, ok := utxoSortOrders[param]
+	if !ok {
+		return 0, badRequest("'sort' must be height, height_desc, value_desc or value_asc")
+	}
+	return order, nil
+}
+
+// addressParam decodes the required 'address' parameter.
+func (a *WebAPI) addressParam(query url.Values) (kind doge.ScriptType, hash []byte, err error) {
+	address := addressString(query)
+	if address == "" {
+		return 0, nil, badRequest("missing 'address' in the URL")
+	}
+	return decodeAddress(address, a.addressChains)
+}
+
+// addressString gets the 'address' parameter without surrounding whitespace
+// (copy-paste artifacts.) Base58 is case-sensitive, so that's all it changes.
+func addressString(query url.Values) string {
+	return strings.TrimSpace(query.Get("address"))
+}
+
+// addressPayloadLengths are the valid payload lengths (after the version byte)
+// for each kind of address.
+var addressPayloadLengths = map[doge.ScriptType][]int{
+	doge.ScriptTypeP2PK:   {33, 65}, // compressed or uncompressed public key (compact script only)
+	doge.ScriptTypeP2PKH:  {20},
+	doge.ScriptTypeP2SH:   {20},
+	doge.ScriptTypeP2PKHW: {20},
+	doge.ScriptTypeP2SHW:  {32},
+}
+
+// decodeAddress decodes a base58 address on one of `chains` into the kind and
+// payload stored in the index, checking the payload length is right for the kind.
+func decodeAddress(address string, chains []*doge.ChainParams) (kind doge.ScriptType, payload []byte, err error) {
+	decoded, err := doge.Base58DecodeCheck(address)
+	if err != nil || len(decoded) < 2 {
+		return 0, nil, badRequest("invalid Dogecoin address")
+	}
+	if utxoKindFromVersionByte(decoded[0], anyChainAddresses) == doge.ScriptTypeP2PK {
+		// the PKey version byte is for WIF private keys: P2PK has no address form
+		return 0, nil, badRequest("invalid Dogecoin address")
+	}
+	kind, payload = utxoKindFromVersionByte(decoded[0], chains), decoded[1:]
+	if kind == doge.ScriptTypeNone {
+		if len(chains) == 1 && utxoKindFromVersionByte(decoded[0], anyChainAddresses) != doge.ScriptTypeNone {
+			return 0, nil, badRequest(fmt.Sprintf("invalid Dogecoin address: version byte 0x%02x is for another network, not %s", decoded[0], chains[0].ChainName))
+		}
+		return 0, nil, badRequest(fmt.Sprintf("invalid Dogecoin address: unknown version byte 0x%02x", decoded[0]))
+	}
+	if err := checkPayloadLength(kind, payload); err != nil {
+		return 0, nil, err
+	}
+	return kind, payload, nil
+}
+
+// checkPayloadLength checks an address payload has a valid length for `kind`.
+func checkPayloadLength(kind doge.ScriptType, payload []byte) error {
+	lengths, found := addressPayloadLengths[kind]
+	if !found {
+		return badRequest(fmt.Sprintf("invalid Dogecoin address: %s has no address form", utxoKindStr(kind)))
+	}
+	if !isValidLength(len(payload), lengths) {
+		return badRequest(fmt.Sprintf("invalid Dogecoin address: %s payload is %d bytes, not %s", utxoKindStr(kind), len(payload), joinInts(lengths, " or ")))
 	}
+	return nil
+}
+
+func isValidLength(length int, lengths []int) bool {
+	for _, l := range lengths {
+		if length == l {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeAddress is the inverse of decodeAddress: it encodes a stored kind and
+// payload as the canonical base58 address on `chain`. Dogecoin has no bech32
+// (segwit) address format, so witness kinds have no address form; nor does
+// P2PK, whose PKey version byte is for WIF private keys, not public keys.
+func encodeAddress(chain *doge.ChainParams, kind doge.ScriptType, payload []byte) (string, error) {
+	var version byte
+	switch kind {
+	case doge.ScriptTypeP2PKH:
+		version = chain.P2PKH_Address_Prefix
+	case doge.ScriptTypeP2SH:
+		version = chain.P2SH_Address_Prefix
+	default:
+		return "", fmt.Errorf("%s has no address form on %s", utxoKindStr(kind), chain.ChainName)
+	}
+	if lengths := addressPayloadLengths[kind]; !isValidLength(len(payload), lengths) {
+		return "", fmt.Errorf("%s payload is %d bytes, not %s", utxoKindStr(kind), len(payload), joinInts(lengths, " or "))
+	}
+	return doge.Base58EncodeCheck(append([]byte{version}, payload...)), nil
+}
+
+func joinInts(values []int, sep string) string {
+	parts := make([]string, len(values))
+	for n, v := range values {
+		parts[n] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, sep)
+}
+
+// confirmationsOrDefault parses the optional 'confirmations' parameter,
+// defaulting to the server's configured confirmations.
+func (a *WebAPI) confirmationsOrDefault(query url.Values) (int64, error) {
+	if !query.Has("confirmations") {
+		return a.confirmations, nil
+	}
+	return confirmationsParam(query, "confirmations")
+}
+
+// confirmationsParam parses an optional non-negative confirmation count (0 if absent.)
+func confirmationsParam(query url.Values, name string) (int64, error) {
+	param := query.Get(name)
+	if param == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(param, 10, 64)
+	if err != nil || n < 0 {
+		return 0, badRequest(fmt.Sprintf("'%s' must be a non-negative integer", name))
+	}
+	return n, nil
+}
+
+// heightRangeParam parses the required 'from' and 'to' block heights
+// (inclusive, at most maxBlocks apart.)
+func heightRangeParam(query url.Values, maxBlocks int64) (from int64, to int64, err error) {
+	from, err = strconv.ParseInt(query.Get("from"), 10, 64)
+	if err != nil || from < 0 {
+		return 0, 0, badRequest("'from' must be a block height")
+	}
+	to, err = strconv.ParseInt(query.Get("to"), 10, 64)
+	if err != nil || to < from {
+		return 0, 0, badRequest("'to' must be a block height not less than 'from'")
+	}
+	if to-from+1 > maxBlocks {
+		return 0, 0, badRequest(fmt.Sprintf("at most %d blocks per request", maxBlocks))
+	}
+	return from, to, nil
+}
+
+type BalanceResponse struct {
+	spec.Balance
+	Unconfirmed *spec.Balance    `json:"unconfirmed,omitempty"` // with ?include_unconfirmed=true: the same balance at 0 confirmations
+	Mempool     *mempool.Balance `json:"mempool,omitempty"`     // pending (unconfirmed) incoming funds
+}
+
+func (b BalanceResponse) csvRows() (header []string, rows [][]string) {
+	header = []string{"incoming", "available", "outgoing", "current", "utxo_count"}
+	row := []string{b.Incoming.String(), b.Available.String(), b.Outgoing.String(), b.Current.String(), strconv.FormatInt(b.UTXOCount, 10)}
+	if b.Unconfirmed != nil {
+		header = append(header, "unconfirmed_incoming", "unconfirmed_available", "unconfirmed_outgoing", "unconfirmed_current")
+		row = append(row, b.Unconfirmed.Incoming.String(), b.Unconfirmed.Available.String(), b.Unconfirmed.Outgoing.String(), b.Unconfirmed.Current.String())
+	}
+	if b.Mempool != nil {
+		header = append(header, "mempool_incoming", "mempool_tx_count")
+		row = append(row, b.Mempool.Incoming.String(), strconv.Itoa(b.Mempool.TxCount))
+	}
+	return header, [][]string{row}
 }
 
 type UTXOResponse struct {
-	UTXO []UTXOItem `json:"utxo"`
+	UTXO      []UTXOItem    `json:"utxo"`
+	Count     int           `json:"count"`               // number of UTXOs returned
+	Total     spec.BigKoinu `json:"total"`               // sum of the returned unspent UTXO values
+	Truncated bool          `json:"truncated,omitempty"` // more UTXOs than the server's limit (the rest are missing)
+
+	// only when truncated: count and total of the whole list
+	TotalCount *int64         `json:"total_count,omitempty"`
+	TotalValue *spec.BigKoinu `json:"total_value,omitempty"`
+}
+
+// utxoResponse totals the returned UTXO list (which may be truncated.)
+func utxoResponse(utxo []UTXOItem) UTXOResponse {
+	res := UTXOResponse{UTXO: utxo, Count: len(utxo)}
+	for _, item := range utxo {
+		if item.SpentHeight == 0 {
+			res.Total = res.Total.AddKoinu(item.Value)
+		}
+	}
+	return res
+}
+
+func (u UTXOResponse) csvRows() (header []string, rows [][]string) {
+	header = []string{"tx", "vout", "value", "type", "script"}
+	for _, item := range u.UTXO {
+		rows = append(rows, []string{item.TxID, strconv.FormatUint(uint64(item.VOut), 10), item.Value.String(), item.Type, item.Script})
+	}
+	return header, rows
 }
 
 type HealthResponse struct {
@@ -218,43 +929,115 @@ type HealthResponse struct {
 
 type HeightResponse struct {
 	Height            int64      `json:"height"`
+	HeightStr         string     `json:"height_str,omitempty"` // height as a decimal string (with ?ints=both)
+	Hash              string     `json:"hash,omitempty"`       // resume point (tip) block hash, hex
+	Chain             string     `json:"chain,omitempty"`      // configured chain name
 	CoreBlocksHeight  *int64     `json:"core_blocks_height,omitempty"`
 	CoreHeadersHeight *int64     `json:"core_headers_height,omitempty"`
 	CoreSyncUpdatedAt *time.Time `json:"core_sync_updated_at,omitempty"`
 }
 
+type FeeRateResponse struct {
+	Blocks     int   `json:"blocks"`       // confirmation target in blocks
+	KoinuPerKB int64 `json:"koinu_per_kb"` // estimated fee rate in koinu per kilobyte
+}
+
 type UTXOItem struct {
-	TxID   string      `json:"tx"`     // hex-encoded transaction ID (byte-reversed)
-	VOut   uint32      `json:"vout"`   // transaction output number
-	Value  koinu.Koinu `json:"value"`  // UTXO value to 8 decimal places, as a decimal string
-	Type   string      `json:"type"`   // UTXO type (determines what you need to sign it)
-	Script string      `json:"script"` // hex-encoded UTXO locking script (needed to sign the UTXO)
-}
-
-func utxoKindFromVersionByte(version byte) doge.ScriptType {
-	switch version {
-	case doge.DogeMainNetChain.P2PKH_Address_Prefix,
-		doge.DogeTestNetChain.P2PKH_Address_Prefix,
-		doge.DogeRegTestChain.P2PKH_Address_Prefix,
-		doge.BitcoinMainChain.P2PKH_Address_Prefix,
-		doge.BitcoinTestChain.P2PKH_Address_Prefix:
-		return doge.ScriptTypeP2PKH
-	case doge.DogeMainNetChain.P2SH_Address_Prefix,
-		doge.DogeTestNetChain.P2SH_Address_Prefix,
-		doge.DogeRegTestChain.P2SH_Address_Prefix,
-		doge.BitcoinMainChain.P2SH_Address_Prefix,
-		doge.BitcoinTestChain.P2SH_Address_Prefix:
-		return doge.ScriptTypeP2SH
-	case doge.DogeMainNetChain.PKey_Prefix,
-		doge.DogeTestNetChain.PKey_Prefix,
-		doge.DogeRegTestChain.PKey_Prefix,
-		doge.BitcoinMainChain.PKey_Prefix,
-		doge.BitcoinTestChain.PKey_Prefix:
-		return doge.ScriptTypeP2PK
+	TxID        string      `json:"tx"`                     // hex-encoded transaction ID (byte-reversed)
+	VOut        uint32      `json:"vout"`                   // transaction output number
+	Value       koinu.Koinu `json:"value"`                  // UTXO value to 8 decimal places, as a decimal string
+	Type        string      `json:"type"`                   // UTXO type (determines what you need to sign it)
+	Script      string      `json:"script,omitempty"`       // hex-encoded UTXO locking script (needed to sign the UTXO)
+	Address     string      `json:"address,omitempty"`      // only with ?include_address=true, for P2PKH and P2SH outputs
+	SpentHeight int64       `json:"spent_height,omitempty"` // only with ?include_spent=true, the height of the block that spent it
+}
+
+func utxoItem(u spec.UTXO) UTXOItem {
+	return utxoFormat{}.item(u)
+}
+
+// Script forms for /utxo?script=
+const (
+	scriptFull    = "full"    // full scriptPubKey (the default)
+	scriptCompact = "compact" // compact form as stored in the index
+	scriptNone    = "none"    // omit the script
+)
+
+// utxoFormat selects the optional fields of each UTXOItem.
+type utxoFormat struct {
+	script  string            // one of the script* forms ("" is scriptFull)
+	address bool              // include the address derived from the script
+	chain   *doge.ChainParams // address prefixes (if address is set)
+}
+
+func (a *WebAPI) utxoFormatParam(query url.Values) (utxoFormat, error) {
+	format := utxoFormat{script: query.Get("script"), chain: a.chain}
+	switch format.script {
+	case "", scriptFull, scriptCompact, scriptNone:
+	default:
+		return utxoFormat{}, badRequest("'script' must be one of: full, compact, none")
+	}
+	if param := query.Get("include_address"); param != "" {
+		address, err := strconv.ParseBool(param)
+		if err != nil {
+			return utxoFormat{}, badRequest("'include_address' must be true or false")
+		}
+		format.address = address
+	}
+	return format, nil
+}
+
+func (f utxoFormat) item(u spec.UTXO) UTXOItem {
+	item := UTXOItem{
+		TxID:  doge.HexEncodeReversed(u.TxID),
+		VOut:  u.VOut,
+		Value: koinu.Koinu(u.Value),
+		Type:  utxoKindStr(u.Type),
+	}
+	switch f.script {
+	case scriptCompact:
+		item.Script = hex.EncodeToString(u.Script)
+	case scriptNone:
+	default:
+		item.Script = hex.EncodeToString(doge.ExpandScript(u.Type, u.Script))
+	}
+	if f.address && (u.Type == doge.ScriptTypeP2PKH || u.Type == doge.ScriptTypeP2SH) {
+		item.Address, _ = encodeAddress(f.chain, u.Type, u.Script) // empty if the script is malformed
+	}
+	return item
+}
+
+// anyChainAddresses are the networks whose address prefixes are accepted
+// with Options.AnyChain (otherwise only the index's chain.)
+var anyChainAddresses = []*doge.ChainParams{
+	&doge.DogeMainNetChain,
+	&doge.DogeTestNetChain,
+	&doge.DogeRegTestChain,
+	&doge.BitcoinMainChain,
+	&doge.BitcoinTestChain,
+}
+
+// utxoKindFromVersionByte maps an address version byte to the kind of UTXO it
+// pays, using the prefixes of `chains` (ScriptTypeNone if none match.)
+func utxoKindFromVersionByte(version byte, chains []*doge.ChainParams) doge.ScriptType {
+	for _, chain := range chains {
+		switch version {
+		case chain.P2PKH_Address_Prefix:
+			return doge.ScriptTypeP2PKH
+		case chain.P2SH_Address_Prefix:
+			return doge.ScriptTypeP2SH
+		case chain.PKey_Prefix:
+			return doge.ScriptTypeP2PK
+		}
 	}
 	return doge.ScriptTypeNone
 }
 
+// KindName is the name of a UTXO type, as in API responses (e.g. "P2PKH".)
+func KindName(scriptType doge.ScriptType) string {
+	return utxoKindStr(scriptType)
+}
+
 func utxoKindStr(scriptType doge.ScriptType) string {
 	switch scriptType {
 	case doge.ScriptTypeNone:
@@ -1,17 +1,25 @@
 package web
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/dogeorg/doge"
+	"github.com/dogeorg/doge/koinu"
 	"github.com/dogeorg/indexer/index"
+	"github.com/dogeorg/indexer/mempool"
 	"github.com/dogeorg/indexer/spec"
+	idxstore "github.com/dogeorg/indexer/store"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 func bigKoinu(value int64) spec.BigKoinu {
@@ -24,39 +32,237 @@ func bigKoinu(value int64) spec.BigKoinu {
 type MockStore struct {
 	balance       spec.Balance
 	utxos         []spec.UTXO
+	utxoFilter    spec.UTXOFilter // last filter passed to FindUTXOsFiltered
+	repair        spec.OrphanRepair
+	repairErr     error
+	repaired      bool // RepairOrphans was called
 	currentHeight int64
 	resumePoint   []byte
 	balanceErr    error
+	balanceConf   int64 // last confirmations passed to GetBalance
 	utxoErr       error
 	heightErr     error
 	resumeErr     error
+	diff          spec.UTXODiff
+	diffErr       error
+	utxoKind      doge.ScriptType // last kind and script passed to FindUTXOsFiltered
+	utxoScript    []byte
+	outgoing      []spec.UTXOChange
+	firstSeen     int64
+	firstSeenErr  error
+	trimmedBelow  int64
+	trimmedErr    error
+	blockStats    []spec.BlockStats
+	blockStatsErr error
+	dbStats       spec.DBStats
+	dbStatsErr    error
+	// last confirmations passed to FindOutgoing
+	outgoingConfirmations int64
+
+	balanceAt       spec.BigKoinu
+	balanceAtHeight int64 // last height passed to GetBalanceAtHeight
+	events          []spec.UTXOEvent
+	eventsErr       error
+	kindBalances    []spec.KindBalance
+	kindsScript     []byte // last script passed to GetKindBalances
+	sample          []spec.AddressKey
+	ageHistogram    []spec.UTXOAgeBucket
+
+	txHeights map[string]int64 // tx hash -> height for GetTxHeight
+	txErr     error
+
+	unspentCount int64
+	orphanSpends int64
+	conflicts    int64
+
+	utxoStates []spec.UTXOState   // stored UTXOs for GetUTXOs
+	outpoints  []spec.OutPointKey // last outpoints passed to GetUTXOs
+
+	unseen bool // HasAddressUTXOs finds nothing
+
+	txOutputs []spec.TxOutput // stored outputs for GetTxOutputs (all in one tx)
+	txInputs  []spec.TxInput  // recorded inputs for GetTxInputs (all in one tx)
 }
 
 // MockIndexer implements index.IndexerMonitor for testing
 type MockIndexer struct {
 	blockHistory []index.BlockHistory
+	reorgHistory []index.Reorg
+	queue        index.QueueStats
+	shadow       index.ShadowStatus
 }
 
 func (m *MockIndexer) GetBlockHistory() []index.BlockHistory {
 	return m.blockHistory
 }
 
+func (m *MockIndexer) GetReorgHistory() []index.Reorg {
+	return m.reorgHistory
+}
+
+func (m *MockIndexer) GetQueueStats() index.QueueStats {
+	return m.queue
+}
+
+func (m *MockIndexer) GetShadowStatus() index.ShadowStatus {
+	return m.shadow
+}
+
 func (m *MockStore) GetCurrentHeight() (int64, error) {
 	return m.currentHeight, m.heightErr
 }
 
+func (m *MockStore) GetMaxIndexedHeight() (int64, error) {
+	return m.currentHeight, m.heightErr
+}
+
+func (m *MockStore) Stats() (spec.DBStats, error) {
+	return m.dbStats, m.dbStatsErr
+}
+
 func (m *MockStore) GetResumePoint() ([]byte, error) {
 	return m.resumePoint, m.resumeErr
 }
 
+func (m *MockStore) GetTip() (int64, []byte, error) {
+	if m.heightErr != nil {
+		return 0, nil, m.heightErr
+	}
+	return m.currentHeight, m.resumePoint, m.resumeErr
+}
+
 func (m *MockStore) GetBalance(kind doge.ScriptType, address []byte, confirmations int64) (spec.Balance, error) {
+	m.balanceConf = confirmations
 	return m.balance, m.balanceErr
 }
 
+func (m *MockStore) GetBalanceAtHeight(kind doge.ScriptType, address []byte, height int64) (spec.BigKoinu, error) {
+	m.balanceAtHeight = height
+	return m.balanceAt, m.balanceErr
+}
+
+func (m *MockStore) GetConfirmations(hash []byte) (int64, int64, error) {
+	height, err := m.GetTxHeight(hash)
+	if err != nil {
+		return 0, 0, err
+	}
+	if m.heightErr != nil {
+		return 0, 0, m.heightErr
+	}
+	return height, m.currentHeight - height + 1, nil
+}
+
+func (m *MockStore) GetTxHeight(hash []byte) (int64, error) {
+	if m.txErr != nil {
+		return 0, m.txErr
+	}
+	height, found := m.txHeights[string(hash)]
+	if !found {
+		return 0, spec.ErrNotFound
+	}
+	return height, nil
+}
+
+func (m *MockStore) CreateTxInputs(inputs []spec.TxInput, height int64) error {
+	return nil
+}
+
+func (m *MockStore) GetTxInputs(hash []byte) ([]spec.TxInput, error) {
+	var res []spec.TxInput
+	for _, in := range m.txInputs {
+		if bytes.Equal(in.TxID, hash) {
+			res = append(res, in)
+		}
+	}
+	return res, m.txErr
+}
+
+func (m *MockStore) GetTxOutputs(hash []byte) ([]spec.TxOutput, error) {
+	var res []spec.TxOutput
+	for _, out := range m.txOutputs {
+		if bytes.Equal(out.TxID, hash) {
+			res = append(res, out)
+		}
+	}
+	return res, m.txErr
+}
+
+func (m *MockStore) GetTxTotal() (int64, error) {
+	return int64(len(m.txHeights)), m.txErr
+}
+
+func (m *MockStore) GetUnspentCount() (int64, error) {
+	return m.unspentCount, m.heightErr
+}
+
+func (m *MockStore) GetOrphanSpends() (int64, error) {
+	return m.orphanSpends, m.heightErr
+}
+
+func (m *MockStore) GetUTXOConflicts() (int64, error) {
+	return m.conflicts, m.heightErr
+}
+
+func (m *MockStore) GetUTXOs(outpoints []spec.OutPointKey) (res []spec.UTXOState, err error) {
+	m.outpoints = outpoints
+	for _, out := range outpoints {
+		for _, u := range m.utxoStates {
+			if bytes.Equal(u.TxID, out.Tx) && u.VOut == out.VOut {
+				res = append(res, u)
+			}
+		}
+	}
+	return res, m.utxoErr
+}
+
+func (m *MockStore) GetUTXOAgeHistogram(kind doge.ScriptType, address []byte) ([]spec.UTXOAgeBucket, error) {
+	return m.ageHistogram, m.utxoErr
+}
+
+func (m *MockStore) SampleAddresses(limit int) ([]spec.AddressKey, error) {
+	if len(m.sample) > limit {
+		return m.sample[:limit], m.balanceErr
+	}
+	return m.sample, m.balanceErr
+}
+
+func (m *MockStore) GetKindBalances(script []byte) ([]spec.KindBalance, error) {
+	m.kindsScript = script
+	return m.kindBalances, m.balanceErr
+}
+
 func (m *MockStore) FindUTXOs(kind doge.ScriptType, address []byte) ([]spec.UTXO, error) {
+	return m.FindUTXOsFiltered(kind, address, spec.UTXOFilter{})
+}
+
+func (m *MockStore) FindUTXOsFiltered(kind doge.ScriptType, address []byte, filter spec.UTXOFilter) ([]spec.UTXO, error) {
+	m.utxoKind, m.utxoScript, m.utxoFilter = kind, address, filter
+	if filter.Limit > 0 && len(m.utxos) > filter.Limit {
+		return m.utxos[:filter.Limit], m.utxoErr
+	}
 	return m.utxos, m.utxoErr
 }
 
+func (m *MockStore) SumUTXOsFiltered(kind doge.ScriptType, address []byte, filter spec.UTXOFilter, includeSpent bool) (count int64, unspent spec.BigKoinu, err error) {
+	if includeSpent {
+		for _, u := range m.utxoStates {
+			if u.Spent == 0 {
+				unspent = unspent.AddKoinu(koinu.Koinu(u.Value))
+			}
+		}
+		return int64(len(m.utxoStates)), unspent, m.utxoErr
+	}
+	for _, u := range m.utxos {
+		unspent = unspent.AddKoinu(koinu.Koinu(u.Value))
+	}
+	return int64(len(m.utxos)), unspent, m.utxoErr
+}
+
+func (m *MockStore) FindUTXOHistory(kind doge.ScriptType, address []byte, filter spec.UTXOFilter) ([]spec.UTXOState, error) {
+	m.utxoKind, m.utxoScript, m.utxoFilter = kind, address, filter
+	return m.utxoStates, m.utxoErr
+}
+
 // Implement other required methods with no-op implementations
 func (m *MockStore) WithCtx(ctx context.Context) spec.Store {
 	return m
@@ -82,6 +288,14 @@ func (m *MockStore) SetResumePoint(hash []byte, height int64) error {
 	return nil
 }
 
+func (m *MockStore) GetMonitorState() ([]byte, error) {
+	return nil, nil
+}
+
+func (m *MockStore) SetMonitorState(state []byte) error {
+	return nil
+}
+
 func (m *MockStore) RemoveUTXOs(removeUTXOs []spec.OutPointKey, height int64) error {
 	return nil
 }
@@ -98,6 +312,73 @@ func (m *MockStore) TrimSpentUTXOs(height int64) error {
 	return nil
 }
 
+func (m *MockStore) FindScriptByHash(scriptHash []byte) (doge.ScriptType, []byte, error) {
+	for _, u := range m.utxos {
+		if bytes.Equal(spec.ScriptHash(u.Type, u.Script), scriptHash) {
+			return u.Type, u.Script, nil
+		}
+	}
+	return 0, nil, spec.ErrNotFound
+}
+
+func (m *MockStore) FindOutgoing(kind doge.ScriptType, address []byte, confirmations int64) ([]spec.UTXOChange, error) {
+	m.outgoingConfirmations = confirmations
+	return m.outgoing, m.utxoErr
+}
+
+func (m *MockStore) GetTrimmedBelow() (int64, error) {
+	return m.trimmedBelow, m.trimmedErr
+}
+
+func (m *MockStore) GetUTXOEvents(since int64, limit int) ([]spec.UTXOEvent, error) {
+	var res []spec.UTXOEvent
+	for _, e := range m.events {
+		if e.Seq > since && len(res) < limit {
+			res = append(res, e)
+		}
+	}
+	return res, m.eventsErr
+}
+
+func (m *MockStore) GetAddressFirstSeen(kind doge.ScriptType, address []byte) (int64, error) {
+	return m.firstSeen, m.firstSeenErr
+}
+
+func (m *MockStore) HasAddressUTXOs(kind doge.ScriptType, address []byte) (bool, error) {
+	return !m.unseen, m.utxoErr
+}
+
+func (m *MockStore) SetBlockStats(stats spec.BlockStats) error {
+	return nil
+}
+
+func (m *MockStore) GetBlockStats(fromHeight int64, toHeight int64) ([]spec.BlockStats, error) {
+	var res []spec.BlockStats
+	for _, s := range m.blockStats {
+		if s.Height >= fromHeight && s.Height <= toHeight {
+			res = append(res, s)
+		}
+	}
+	return res, m.blockStatsErr
+}
+
+func (m *MockStore) GetUTXODiff(fromHeight int64, toHeight int64) (spec.UTXODiff, error) {
+	return m.diff, m.diffErr
+}
+
+func (m *MockStore) ClearIndex() error {
+	return nil
+}
+
+func (m *MockStore) CheckIntegrity() ([]string, error) {
+	return nil, nil
+}
+
+func (m *MockStore) RepairOrphans() (spec.OrphanRepair, error) {
+	m.repaired = true
+	return m.repair, m.repairErr
+}
+
 func (m *MockStore) Transact(fn func(spec.StoreTx) error) error {
 	return fn(m)
 }
@@ -208,13 +489,13 @@ func TestUtxoKindFromVersionByte(t *testing.T) {
 		{
 			name:        "Zero byte (Bitcoin P2PKH)",
 			versionByte: 0x00,
-			expected:    doge.ScriptTypeP2PKH, // 0x00 matches Bitcoin P2PKH prefix
+			expected:    doge.ScriptTypeP2PKH, // 0x00 matches Bitcoin P2PKH prefix (only with AnyChain)
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := utxoKindFromVersionByte(tt.versionByte)
+			result := utxoKindFromVersionByte(tt.versionByte, anyChainAddresses)
 			if result != tt.expected {
 				t.Errorf("utxoKindFromVersionByte(%#x) = %v, expected %v", tt.versionByte, result, tt.expected)
 			}
@@ -222,6 +503,45 @@ func TestUtxoKindFromVersionByte(t *testing.T) {
 	}
 }
 
+func TestAddressChains(t *testing.T) {
+	hash := bytes.Repeat([]byte{7}, 20)
+	bitcoin := string(doge.Hash160toAddress(hash, doge.BitcoinMainChain.P2PKH_Address_Prefix)) // version byte 0x00
+	testnet := string(doge.Hash160toAddress(hash, doge.DogeTestNetChain.P2PKH_Address_Prefix))
+	mainnet := string(doge.Hash160toAddress(hash, doge.DogeMainNetChain.P2PKH_Address_Prefix))
+	tests := []struct {
+		name        string
+		chain       *doge.ChainParams
+		anyChain    bool
+		address     string
+		expectedErr string
+	}{
+		{"Mainnet address on mainnet", nil, false, mainnet, ""},
+		{"Bitcoin address on mainnet", nil, false, bitcoin, "invalid Dogecoin address: version byte 0x00 is for another network, not doge_main"},
+		{"Testnet address on mainnet", nil, false, testnet, "invalid Dogecoin address: version byte 0x71 is for another network, not doge_main"},
+		{"Mainnet address on testnet", &doge.DogeTestNetChain, false, mainnet, "invalid Dogecoin address: version byte 0x1e is for another network, not doge_test"},
+		{"Testnet address on testnet", &doge.DogeTestNetChain, false, testnet, ""},
+		{"Bitcoin address with AnyChain", nil, true, bitcoin, ""},
+		{"Testnet address with AnyChain", nil, true, testnet, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webAPI := New(Options{Bind: ":0", Store: &MockStore{}, Indexer: &MockIndexer{}, Chain: tt.chain, AnyChain: tt.anyChain}).(*WebAPI)
+			kind, payload, err := webAPI.addressParam(url.Values{"address": {tt.address}})
+			reason := ""
+			if err != nil {
+				_, reason = errorDetails(err)
+			}
+			if reason != tt.expectedErr {
+				t.Fatalf("expected error %q, got %q", tt.expectedErr, reason)
+			}
+			if err == nil && (kind != doge.ScriptTypeP2PKH || !bytes.Equal(payload, hash)) {
+				t.Errorf("expected P2PKH %x, got %v %x", hash, kind, payload)
+			}
+		})
+	}
+}
+
 func TestHealthCheck(t *testing.T) {
 	blocksHeight := int64(200000)
 	headersHeight := int64(200100)
@@ -245,8 +565,8 @@ func TestHealthCheck(t *testing.T) {
 			expectedBody:   `{"ok":true,"height":123456}`,
 		},
 		{
-			name:      "Healthy with sync heights",
-			height:    123456,
+			name:   "Healthy with sync heights",
+			height: 123456,
 			snapshot: syncHeightSnapshot{
 				CoreBlocksHeight:  &blocksHeight,
 				CoreHeadersHeight: &headersHeight,
@@ -271,7 +591,7 @@ func TestHealthCheck(t *testing.T) {
 				currentHeight: tt.height,
 			}
 			mockIndexer := &MockIndexer{}
-			server := New(":0", mockStore, mockIndexer, nil, "")
+			server := New(Options{Bind: ":0", Store: mockStore, Indexer: mockIndexer})
 			webAPI := server.(*WebAPI)
 			webAPI.store = mockStore
 			webAPI.syncHeights = seededSyncHeightCache(tt.snapshot)
@@ -297,14 +617,34 @@ func TestGetHeight(t *testing.T) {
 	headersHeight := int64(200100)
 	syncUpdatedAt := time.Date(2026, time.June, 1, 4, 0, 0, 0, time.UTC)
 
+	tipHash := []byte{0x1a, 0x91, 0xe3, 0xda, 0xce, 0x36, 0xe2, 0xbe}
+
 	tests := []struct {
 		name           string
 		height         int64
+		resumePoint    []byte
+		chain          string
 		snapshot       syncHeightSnapshot
 		heightErr      error
+		resumeErr      error
 		expectedStatus int
 		expectedBody   string
 	}{
+		{
+			name:           "Includes tip hash and chain",
+			height:         123456,
+			resumePoint:    tipHash,
+			chain:          "mainnet",
+			expectedStatus: 200,
+			expectedBody:   `{"height":123456,"hash":"1a91e3dace36e2be","chain":"mainnet"}`,
+		},
+		{
+			name:           "Resume point error",
+			height:         123456,
+			resumeErr:      fmt.Errorf("connection failed"),
+			expectedStatus: 500,
+			expectedBody:   `{"error":"error","reason":"connection failed"}`,
+		},
 		{
 			name:           "Success",
 			height:         123456,
@@ -345,9 +685,11 @@ func TestGetHeight(t *testing.T) {
 			mockStore := &MockStore{
 				currentHeight: tt.height,
 				heightErr:     tt.heightErr,
+				resumePoint:   tt.resumePoint,
+				resumeErr:     tt.resumeErr,
 			}
 			mockIndexer := &MockIndexer{}
-			server := New(":0", mockStore, mockIndexer, nil, "")
+			server := New(Options{Bind: ":0", Store: mockStore, Indexer: mockIndexer, ChainName: tt.chain})
 			webAPI := server.(*WebAPI)
 			webAPI.store = mockStore
 			webAPI.syncHeights = seededSyncHeightCache(tt.snapshot)
@@ -371,7 +713,7 @@ func TestGetHeight(t *testing.T) {
 func TestGetHeightOptions(t *testing.T) {
 	mockStore := &MockStore{currentHeight: 123456}
 	mockIndexer := &MockIndexer{}
-	server := New(":0", mockStore, mockIndexer, nil, "")
+	server := New(Options{Bind: ":0", Store: mockStore, Indexer: mockIndexer})
 	webAPI := server.(*WebAPI)
 	webAPI.store = mockStore
 
@@ -389,12 +731,53 @@ func TestGetHeightOptions(t *testing.T) {
 	}
 }
 
+func TestPreflightMaxAge(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxAge   time.Duration
+		path     string
+		expected string
+	}{
+		{"Default", 0, "/height", "600"},
+		{"Configured", time.Hour, "/height", "3600"},
+		{"POST endpoint", 90 * time.Second, "/rpc", "90"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{}
+			webAPI := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}, CORSMaxAge: tt.maxAge}).(*WebAPI)
+			webAPI.store = mockStore
+
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("OPTIONS", tt.path, nil))
+
+			if w.Code != http.StatusNoContent {
+				t.Errorf("expected status %d, got %d", http.StatusNoContent, w.Code)
+			}
+			if got := w.Header().Get("Access-Control-Max-Age"); got != tt.expected {
+				t.Errorf("expected Access-Control-Max-Age %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	// only preflights are cached
+	webAPI := New(Options{Bind: ":0", Store: &MockStore{}, Indexer: &MockIndexer{}}).(*WebAPI)
+	webAPI.store = &MockStore{}
+	w := httptest.NewRecorder()
+	webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/height", nil))
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "" {
+		t.Errorf("expected no Access-Control-Max-Age on GET, got %q", got)
+	}
+}
+
 func TestGetBalance(t *testing.T) {
 	validAddress := "D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS"
 	validBalance := spec.Balance{
 		Available: bigKoinu(100000000), // 1.0 DOGE in koinu
 		Incoming:  bigKoinu(50000000),  // 0.5 DOGE in koinu
 		Outgoing:  bigKoinu(0),
+		UTXOCount: 2,
 	}
 
 	tests := []struct {
@@ -413,7 +796,7 @@ func TestGetBalance(t *testing.T) {
 			balance:        validBalance,
 			balanceErr:     nil,
 			expectedStatus: 200,
-			expectedBody:   `{"incoming":"0.5","available":"1","outgoing":"0","current":"1.5"}`,
+			expectedBody:   `{"incoming":"0.5","available":"1","outgoing":"0","current":"1.5","utxo_count":2}`,
 		},
 		{
 			name:           "Missing address",
@@ -433,6 +816,33 @@ func TestGetBalance(t *testing.T) {
 			expectedStatus: 400,
 			expectedBody:   `{"error":"bad-request","reason":"invalid Dogecoin address"}`,
 		},
+		{
+			name:           "Padded address",
+			method:         "GET",
+			address:        "%20" + validAddress + "%20%0A",
+			balance:        validBalance,
+			balanceErr:     nil,
+			expectedStatus: 200,
+			expectedBody:   `{"incoming":"0.5","available":"1","outgoing":"0","current":"1.5","utxo_count":2}`,
+		},
+		{
+			name:           "Blank address",
+			method:         "GET",
+			address:        "%20%09",
+			balance:        spec.Balance{},
+			balanceErr:     nil,
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"missing 'address' in the URL"}`,
+		},
+		{
+			name:           "Wrong case address",
+			method:         "GET",
+			address:        "%20" + strings.ToLower(validAddress),
+			balance:        spec.Balance{},
+			balanceErr:     nil,
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"invalid Dogecoin address"}`,
+		},
 		{
 			name:           "Database error",
 			method:         "GET",
@@ -460,7 +870,7 @@ func TestGetBalance(t *testing.T) {
 				balanceErr: tt.balanceErr,
 			}
 			mockIndexer := &MockIndexer{}
-			server := New(":0", mockStore, mockIndexer, nil, "")
+			server := New(Options{Bind: ":0", Store: mockStore, Indexer: mockIndexer})
 			webAPI := server.(*WebAPI)
 			webAPI.store = mockStore
 
@@ -516,7 +926,7 @@ func TestGetUtxo(t *testing.T) {
 			utxos:          validUtxos,
 			utxoErr:        nil,
 			expectedStatus: 200,
-			expectedBody:   `{"utxo":[{"tx":"04030201","vout":0,"value":"1","type":"P2PKH","script":"76a91476a91488ac00000000000000000000000000000088ac"}]}`,
+			expectedBody:   `{"utxo":[{"tx":"04030201","vout":0,"value":"1","type":"P2PKH","script":"76a91476a91488ac00000000000000000000000000000088ac"}],"count":1,"total":"1"}`,
 		},
 		{
 			name:           "Missing address",
@@ -536,6 +946,15 @@ func TestGetUtxo(t *testing.T) {
 			expectedStatus: 400,
 			expectedBody:   `{"error":"bad-request","reason":"invalid Dogecoin address"}`,
 		},
+		{
+			name:           "Padded address",
+			method:         "GET",
+			address:        "+" + validAddress + "%0D%0A",
+			utxos:          validUtxos,
+			utxoErr:        nil,
+			expectedStatus: 200,
+			expectedBody:   `{"utxo":[{"tx":"04030201","vout":0,"value":"1","type":"P2PKH","script":"76a91476a91488ac00000000000000000000000000000088ac"}],"count":1,"total":"1"}`,
+		},
 		{
 			name:           "Database error",
 			method:         "GET",
@@ -563,7 +982,7 @@ func TestGetUtxo(t *testing.T) {
 				utxoErr: tt.utxoErr,
 			}
 			mockIndexer := &MockIndexer{}
-			server := New(":0", mockStore, mockIndexer, nil, "")
+			server := New(Options{Bind: ":0", Store: mockStore, Indexer: mockIndexer})
 			webAPI := server.(*WebAPI)
 			webAPI.store = mockStore
 
@@ -591,10 +1010,488 @@ func TestGetUtxo(t *testing.T) {
 	}
 }
 
+func TestGetUtxoFilters(t *testing.T) {
+	validAddress := "D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS"
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedFilter spec.UTXOFilter
+		expectedBody   string
+	}{
+		{
+			name:           "No filter",
+			query:          "",
+			expectedStatus: 200,
+			expectedFilter: spec.UTXOFilter{Limit: DefaultMaxUTXOs + 1},
+		},
+		{
+			name:           "Minimum confirmations",
+			query:          "&min_conf=6",
+			expectedStatus: 200,
+			expectedFilter: spec.UTXOFilter{MaxHeight: 995, Limit: DefaultMaxUTXOs + 1},
+		},
+		{
+			name:           "Confirmation window",
+			query:          "&min_conf=1&max_conf=100",
+			expectedStatus: 200,
+			expectedFilter: spec.UTXOFilter{MinHeight: 901, MaxHeight: 1000, Limit: DefaultMaxUTXOs + 1},
+		},
+		{
+			name:           "More confirmations than blocks",
+			query:          "&min_conf=5000",
+			expectedStatus: 200,
+			expectedBody:   `{"utxo":[],"count":0,"total":"0"}`,
+		},
+		{
+			name:           "Minimum value",
+			query:          "&min_value=1000000&min_conf=6",
+			expectedStatus: 200,
+			expectedFilter: spec.UTXOFilter{MaxHeight: 995, MinValue: 1000000, Limit: DefaultMaxUTXOs + 1},
+		},
+		{
+			name:           "Invalid minimum value",
+			query:          "&min_value=0.5",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'min_value' must be a non-negative koinu amount"}`,
+		},
+		{
+			name:           "Largest first",
+			query:          "&sort=value_desc",
+			expectedStatus: 200,
+			expectedFilter: spec.UTXOFilter{Limit: DefaultMaxUTXOs + 1, Order: spec.UTXOOrderValueDesc},
+		},
+		{
+			name:           "Smallest first",
+			query:          "&sort=value_asc",
+			expectedStatus: 200,
+			expectedFilter: spec.UTXOFilter{Limit: DefaultMaxUTXOs + 1, Order: spec.UTXOOrderValueAsc},
+		},
+		{
+			name:           "Newest first",
+			query:          "&sort=height_desc&min_conf=6",
+			expectedStatus: 200,
+			expectedFilter: spec.UTXOFilter{MaxHeight: 995, Limit: DefaultMaxUTXOs + 1, Order: spec.UTXOOrderHeightDesc},
+		},
+		{
+			name:           "Default order",
+			query:          "&sort=height",
+			expectedStatus: 200,
+			expectedFilter: spec.UTXOFilter{Limit: DefaultMaxUTXOs + 1},
+		},
+		{
+			name:           "Invalid sort",
+			query:          "&sort=random",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'sort' must be height, height_desc, value_desc or value_asc"}`,
+		},
+		{
+			name:           "Negative confirmations",
+			query:          "&min_conf=-1",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'min_conf' must be a non-negative integer"}`,
+		},
+		{
+			name:           "Inverted window",
+			query:          "&min_conf=10&max_conf=5",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'max_conf' must not be less than 'min_conf'"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{currentHeight: 1000}
+			server := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = mockStore
+
+			req := httptest.NewRequest("GET", "/utxo?address="+validAddress+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			webAPI.getUtxo(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if mockStore.utxoFilter != tt.expectedFilter {
+				t.Errorf("expected filter %+v, got %+v", tt.expectedFilter, mockStore.utxoFilter)
+			}
+			if tt.expectedBody != "" && w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetUtxoIncludeSpent(t *testing.T) {
+	validAddress := "D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS"
+	hash := bytes.Repeat([]byte{0x11}, 20)
+	states := []spec.UTXOState{
+		{UTXO: spec.UTXO{TxID: []byte{1}, VOut: 0, Value: 100000000, Type: doge.ScriptTypeP2PKH, Script: hash}, Height: 900, Spent: 950},
+		{UTXO: spec.UTXO{TxID: []byte{2}, VOut: 1, Value: 50000000, Type: doge.ScriptTypeP2PKH, Script: hash}, Height: 960},
+	}
+	unspent := []spec.UTXO{states[1].UTXO}
+	script := "76a914" + strings.Repeat("11", 20) + "88ac"
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Unspent only", "", 200,
+			`{"utxo":[{"tx":"02","vout":1,"value":"0.5","type":"P2PKH","script":"` + script + `"}],"count":1,"total":"0.5"}`},
+		{"Not included", "&include_spent=false", 200,
+			`{"utxo":[{"tx":"02","vout":1,"value":"0.5","type":"P2PKH","script":"` + script + `"}],"count":1,"total":"0.5"}`},
+		{"Spent and unspent", "&include_spent=true", 200,
+			`{"utxo":[{"tx":"01","vout":0,"value":"1","type":"P2PKH","script":"` + script + `","spent_height":950},` +
+				`{"tx":"02","vout":1,"value":"0.5","type":"P2PKH","script":"` + script + `"}],"count":2,"total":"0.5"}`},
+		{"Invalid", "&include_spent=maybe", 400,
+			`{"error":"bad-request","reason":"'include_spent' must be true or false"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{currentHeight: 1000, utxos: unspent, utxoStates: states}
+			server := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = mockStore
+
+			w := httptest.NewRecorder()
+			webAPI.getUtxo(w, httptest.NewRequest("GET", "/utxo?address="+validAddress+tt.query, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetUtxoFormat(t *testing.T) {
+	validAddress := "D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS"
+	hash := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}
+	utxos := []spec.UTXO{
+		{TxID: []byte{1}, VOut: 0, Value: 100000000, Type: doge.ScriptTypeP2PKH, Script: hash},
+		{TxID: []byte{2}, VOut: 1, Value: 100000000, Type: doge.ScriptTypeP2SH, Script: hash},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		chain          *doge.ChainParams
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Default",
+			query:          "",
+			expectedStatus: 200,
+			expectedBody:   `{"utxo":[{"tx":"01","vout":0,"value":"1","type":"P2PKH","script":"76a9140102030405060708090a0b0c0d0e0f101112131488ac"},{"tx":"02","vout":1,"value":"1","type":"P2SH","script":"a9140102030405060708090a0b0c0d0e0f101112131487"}],"count":2,"total":"2"}`,
+		},
+		{
+			name:           "Full script",
+			query:          "&script=full",
+			expectedStatus: 200,
+			expectedBody:   `{"utxo":[{"tx":"01","vout":0,"value":"1","type":"P2PKH","script":"76a9140102030405060708090a0b0c0d0e0f101112131488ac"},{"tx":"02","vout":1,"value":"1","type":"P2SH","script":"a9140102030405060708090a0b0c0d0e0f101112131487"}],"count":2,"total":"2"}`,
+		},
+		{
+			name:           "Compact script",
+			query:          "&script=compact",
+			expectedStatus: 200,
+			expectedBody:   `{"utxo":[{"tx":"01","vout":0,"value":"1","type":"P2PKH","script":"0102030405060708090a0b0c0d0e0f1011121314"},{"tx":"02","vout":1,"value":"1","type":"P2SH","script":"0102030405060708090a0b0c0d0e0f1011121314"}],"count":2,"total":"2"}`,
+		},
+		{
+			name:           "No script",
+			query:          "&script=none",
+			expectedStatus: 200,
+			expectedBody:   `{"utxo":[{"tx":"01","vout":0,"value":"1","type":"P2PKH"},{"tx":"02","vout":1,"value":"1","type":"P2SH"}],"count":2,"total":"2"}`,
+		},
+		{
+			name:           "Address",
+			query:          "&script=none&include_address=true",
+			expectedStatus: 200,
+			expectedBody:   `{"utxo":[{"tx":"01","vout":0,"value":"1","type":"P2PKH","address":"D5ERdEN1gsouFSs7zsq7VYJxyWP6dP28H1"},{"tx":"02","vout":1,"value":"1","type":"P2SH","address":"9rXbkMyi1S6thykRoXAZcY8fwUKYsy6cXE"}],"count":2,"total":"2"}`,
+		},
+		{
+			name:           "Address on testnet",
+			query:          "&script=none&include_address=true",
+			chain:          &doge.DogeTestNetChain,
+			expectedStatus: 200,
+			expectedBody:   `{"utxo":[{"tx":"01","vout":0,"value":"1","type":"P2PKH","address":"nUHVMF6vcrGd8RSK2hUZjwuGDNmPeNoBRb"},{"tx":"02","vout":1,"value":"1","type":"P2SH","address":"2MsLZ5FqqYpjM1Q1W4X81zMVZTF9gdbhVwd"}],"count":2,"total":"2"}`,
+		},
+		{
+			name:           "Invalid script form",
+			query:          "&script=short",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'script' must be one of: full, compact, none"}`,
+		},
+		{
+			name:           "Invalid address flag",
+			query:          "&include_address=yes",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'include_address' must be true or false"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{utxos: utxos}
+			server := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}, Chain: tt.chain})
+			webAPI := server.(*WebAPI)
+			webAPI.store = mockStore
+
+			address := validAddress
+			if tt.chain != nil {
+				// the same hash on the test chain (other chains' addresses are rejected)
+				decoded, _ := doge.Base58DecodeCheck(validAddress)
+				address = string(doge.Hash160toAddress(decoded[1:], tt.chain.P2PKH_Address_Prefix))
+			}
+			req := httptest.NewRequest("GET", "/utxo?address="+address+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			webAPI.getUtxo(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetUtxoTotal(t *testing.T) {
+	hash := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}
+	query := url.Values{"address": {"D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS"}}
+
+	tests := []struct {
+		name  string
+		utxos []spec.UTXO
+		total spec.BigKoinu
+	}{
+		{name: "No UTXOs", total: bigKoinu(0)},
+		{
+			name: "Mixed values",
+			utxos: []spec.UTXO{
+				{TxID: []byte{1}, Value: 150000000, Type: doge.ScriptTypeP2PKH, Script: hash},
+				{TxID: []byte{2}, Value: 200000000, Type: doge.ScriptTypeP2PKH, Script: hash},
+				{TxID: []byte{3}, Value: 1, Type: doge.ScriptTypeP2PKH, Script: hash},
+			},
+			total: bigKoinu(350000001),
+		},
+		{
+			name: "Sum exceeds int64",
+			utxos: []spec.UTXO{
+				{TxID: []byte{1}, Value: math.MaxInt64, Type: doge.ScriptTypeP2PKH, Script: hash},
+				{TxID: []byte{2}, Value: 1, Type: doge.ScriptTypeP2PKH, Script: hash},
+			},
+			total: bigKoinu(math.MaxInt64).Add(bigKoinu(1)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{utxos: tt.utxos}
+			webAPI := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}}).(*WebAPI)
+
+			payload, err := webAPI.utxos(mockStore, query)
+			if err != nil {
+				t.Fatalf("utxos: %v", err)
+			}
+			res := payload.(UTXOResponse)
+			if res.Count != len(tt.utxos) || len(res.UTXO) != len(tt.utxos) {
+				t.Fatalf("count = %d with %d items, want %d", res.Count, len(res.UTXO), len(tt.utxos))
+			}
+			sum := bigKoinu(0)
+			for _, item := range res.UTXO {
+				sum = sum.AddKoinu(item.Value)
+			}
+			if !res.Total.Equal(sum) || !res.Total.Equal(tt.total) {
+				t.Fatalf("total = %s, want %s (sum of items %s)", res.Total, tt.total, sum)
+			}
+		})
+	}
+}
+
+func TestGetUtxoTruncated(t *testing.T) {
+	hash := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}
+	utxos := []spec.UTXO{
+		{TxID: []byte{1}, Value: 100000000, Type: doge.ScriptTypeP2PKH, Script: hash},
+		{TxID: []byte{2}, Value: 200000000, Type: doge.ScriptTypeP2PKH, Script: hash},
+		{TxID: []byte{3}, Value: 300000000, Type: doge.ScriptTypeP2PKH, Script: hash},
+	}
+
+	tests := []struct {
+		name         string
+		maxUTXOs     int
+		expectedBody string
+	}{
+		{"Over the limit", 2, `{"utxo":[{"tx":"01","vout":0,"value":"1","type":"P2PKH"},{"tx":"02","vout":0,"value":"2","type":"P2PKH"}],"count":2,"total":"3","truncated":true,"total_count":3,"total_value":"6"}`},
+		{"At the limit", 3, `{"utxo":[{"tx":"01","vout":0,"value":"1","type":"P2PKH"},{"tx":"02","vout":0,"value":"2","type":"P2PKH"},{"tx":"03","vout":0,"value":"3","type":"P2PKH"}],"count":3,"total":"6"}`},
+		{"Default limit", 0, `{"utxo":[{"tx":"01","vout":0,"value":"1","type":"P2PKH"},{"tx":"02","vout":0,"value":"2","type":"P2PKH"},{"tx":"03","vout":0,"value":"3","type":"P2PKH"}],"count":3,"total":"6"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{utxos: utxos}
+			webAPI := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}, MaxUTXOs: tt.maxUTXOs}).(*WebAPI)
+			webAPI.store = mockStore
+
+			req := httptest.NewRequest("GET", "/utxo?address=D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS&script=none", nil)
+			w := httptest.NewRecorder()
+			webAPI.getUtxo(w, req)
+
+			if w.Code != 200 {
+				t.Errorf("expected status 200, got %d", w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestDecodeAddress(t *testing.T) {
+	encode := func(version byte, length int) string {
+		return doge.Base58EncodeCheck(append([]byte{version}, bytes.Repeat([]byte{7}, length)...))
+	}
+	p2pkh := doge.DogeMainNetChain.P2PKH_Address_Prefix
+	p2sh := doge.DogeMainNetChain.P2SH_Address_Prefix
+	pkey := doge.DogeMainNetChain.PKey_Prefix
+
+	tests := []struct {
+		name         string
+		address      string
+		expectedKind doge.ScriptType
+		expectedErr  string
+	}{
+		{"P2PKH", encode(p2pkh, 20), doge.ScriptTypeP2PKH, ""},
+		{"Short P2PKH", encode(p2pkh, 19), 0, "invalid Dogecoin address: P2PKH payload is 19 bytes, not 20"},
+		{"Long P2PKH", encode(p2pkh, 21), 0, "invalid Dogecoin address: P2PKH payload is 21 bytes, not 20"},
+		{"P2SH", encode(p2sh, 20), doge.ScriptTypeP2SH, ""},
+		{"P2SH with a 32-byte hash", encode(p2sh, 32), 0, "invalid Dogecoin address: P2SH payload is 32 bytes, not 20"},
+		{"Compressed WIF key", "QNh21LyXyoWs3sA8SffMuuEikgfuWWv6EE3ZmhYCGwmbBsige4RA", 0, "invalid Dogecoin address"},
+		{"Testnet WIF key", "cepMTjtCWZZGvpFtLxRUzM69QkM3oFRRjtkPDQJ42fKfZME5cmBr", 0, "invalid Dogecoin address"},
+		{"Uncompressed public key", encode(pkey, 65), 0, "invalid Dogecoin address"},
+		{"Testnet P2PKH", encode(doge.DogeTestNetChain.P2PKH_Address_Prefix, 20), doge.ScriptTypeP2PKH, ""},
+		{"Unknown version", encode(0x01, 20), 0, "invalid Dogecoin address: unknown version byte 0x01"},
+		{"Version only", encode(p2pkh, 0), 0, "invalid Dogecoin address"},
+		{"Bad checksum", "D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRT", 0, "invalid Dogecoin address"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, payload, err := decodeAddress(tt.address, anyChainAddresses)
+			if tt.expectedErr != "" {
+				if _, reason := errorDetails(err); err == nil || reason != tt.expectedErr {
+					t.Fatalf("expected error %q, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeAddress: %v", err)
+			}
+			if kind != tt.expectedKind || len(payload) == 0 || !bytes.Equal(payload, bytes.Repeat([]byte{7}, len(payload))) {
+				t.Fatalf("decodeAddress = %v %x, want %v", kind, payload, tt.expectedKind)
+			}
+		})
+	}
+
+	// a WIF key isn't an address on another network either
+	_, _, err := decodeAddress("cepMTjtCWZZGvpFtLxRUzM69QkM3oFRRjtkPDQJ42fKfZME5cmBr", []*doge.ChainParams{&doge.DogeMainNetChain})
+	if _, reason := errorDetails(err); err == nil || reason != "invalid Dogecoin address" {
+		t.Errorf("expected error %q for a testnet WIF key on mainnet, got %v", "invalid Dogecoin address", err)
+	}
+}
+
+func TestCheckPayloadLength(t *testing.T) {
+	tests := []struct {
+		kind        doge.ScriptType
+		length      int
+		expectedErr string
+	}{
+		{doge.ScriptTypeP2PKHW, 20, ""},
+		{doge.ScriptTypeP2PKHW, 32, "invalid Dogecoin address: P2PKHW payload is 32 bytes, not 20"},
+		{doge.ScriptTypeP2SHW, 32, ""},
+		{doge.ScriptTypeP2SHW, 20, "invalid Dogecoin address: P2SHW payload is 20 bytes, not 32"},
+		{doge.ScriptTypeMultiSig, 20, "invalid Dogecoin address: MultiSig has no address form"},
+	}
+
+	for _, tt := range tests {
+		err := checkPayloadLength(tt.kind, make([]byte, tt.length))
+		reason := ""
+		if err != nil {
+			_, reason = errorDetails(err)
+		}
+		if reason != tt.expectedErr {
+			t.Errorf("checkPayloadLength(%v, %d bytes) = %q, want %q", tt.kind, tt.length, reason, tt.expectedErr)
+		}
+	}
+}
+
+func TestEncodeAddress(t *testing.T) {
+	main, test, reg := &doge.DogeMainNetChain, &doge.DogeTestNetChain, &doge.DogeRegTestChain
+
+	// round trip: address -> decodeAddress -> (kind, payload) -> encodeAddress
+	roundTrips := []struct {
+		chain   *doge.ChainParams
+		address string
+	}{
+		{main, "DBBSWfQdrDxq7S7YwZ6vi67BXZMvNKkAxe"},
+		{main, "9xUcdo2LAnFpZxzrkCSNq5vtVXJNdt2if3"},
+		{test, "naEWEg9YnCRYzQgjyNkNxVhUmRkDLM4PWd"},
+		{test, "2MyHZxgtTiAtGsPFw1CPqCuHn1J8WQPdw9r"},
+		{reg, "mmZJGTYyMqVoMYQZvY5jzF9uWREKyk1bPj"},
+		{reg, "2MyHZxgtTiAtGsPFw1CPqCuHn1J8WQPdw9r"},
+	}
+	for _, tt := range roundTrips {
+		t.Run(tt.chain.ChainName+" "+tt.address, func(t *testing.T) {
+			kind, payload, err := decodeAddress(tt.address, []*doge.ChainParams{tt.chain})
+			if err != nil {
+				t.Fatalf("decodeAddress: %v", err)
+			}
+			address, err := encodeAddress(tt.chain, kind, payload)
+			if err != nil {
+				t.Fatalf("encodeAddress: %v", err)
+			}
+			if address != tt.address {
+				t.Errorf("expected %s, got %s", tt.address, address)
+			}
+		})
+	}
+
+	failures := []struct {
+		name        string
+		kind        doge.ScriptType
+		length      int
+		expectedErr string
+	}{
+		{"P2PKHW", doge.ScriptTypeP2PKHW, 20, "P2PKHW has no address form on doge_main"},
+		{"P2SHW", doge.ScriptTypeP2SHW, 32, "P2SHW has no address form on doge_main"},
+		{"MultiSig", doge.ScriptTypeMultiSig, 20, "MultiSig has no address form on doge_main"},
+		{"Short P2PKH", doge.ScriptTypeP2PKH, 19, "P2PKH payload is 19 bytes, not 20"},
+		{"P2PK", doge.ScriptTypeP2PK, 33, "P2PK has no address form on doge_main"},
+	}
+	for _, tt := range failures {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := encodeAddress(main, tt.kind, make([]byte, tt.length))
+			if err == nil || err.Error() != tt.expectedErr {
+				t.Errorf("expected error %q, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
 func TestHeightEndpointIntegration(t *testing.T) {
 	mockStore := &MockStore{currentHeight: 123456}
 	mockIndexer := &MockIndexer{}
-	server := New(":0", mockStore, mockIndexer, nil, "")
+	server := New(Options{Bind: ":0", Store: mockStore, Indexer: mockIndexer})
 	webAPI := server.(*WebAPI)
 	webAPI.store = mockStore
 
@@ -609,15 +1506,18 @@ func TestHeightEndpointIntegration(t *testing.T) {
 		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
 	}
 
+	// decode numbers exactly (float64 rounds integers above 2^53)
 	var response map[string]interface{}
-	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+	decoder := json.NewDecoder(w.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&response); err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if height, ok := response["height"].(float64); !ok {
+	if height, ok := response["height"].(json.Number); !ok {
 		t.Errorf("expected height field in response, got %T", response["height"])
-	} else if int64(height) != 123456 {
-		t.Errorf("expected height 123456, got %d", int64(height))
+	} else if n, err := height.Int64(); err != nil || n != 123456 {
+		t.Errorf("expected height 123456, got %s", height)
 	}
 }
 
@@ -628,7 +1528,8 @@ func TestGetRecentBlocks(t *testing.T) {
 			{
 				Height:         123456,
 				Hash:           "abc123",
-				Timestamp:      time.Now(),
+				Timestamp:      time.Date(2025, time.January, 2, 3, 4, 5, 0, time.UTC),
+				ProcessedAt:    time.Date(2026, time.June, 1, 12, 0, 0, 0, time.UTC),
 				TxCount:        150,
 				UTXOCreated:    200,
 				UTXOSpent:      50,
@@ -637,7 +1538,7 @@ func TestGetRecentBlocks(t *testing.T) {
 		},
 	}
 
-	server := New(":0", mockStore, mockIndexer, nil, "")
+	server := New(Options{Bind: ":0", Store: mockStore, Indexer: mockIndexer})
 	webAPI := server.(*WebAPI)
 	webAPI.store = mockStore
 
@@ -659,6 +1560,14 @@ func TestGetRecentBlocks(t *testing.T) {
 		t.Errorf("expected blocks field in response, got %T", response["blocks"])
 	} else if len(blocks) != 1 {
 		t.Errorf("expected 1 block, got %d", len(blocks))
+	} else {
+		block := blocks[0].(map[string]interface{})
+		if block["timestamp"] != "2025-01-02T03:04:05Z" {
+			t.Errorf("expected block timestamp 2025-01-02T03:04:05Z, got %v", block["timestamp"])
+		}
+		if block["processed_at"] != "2026-06-01T12:00:00Z" {
+			t.Errorf("expected processed_at 2026-06-01T12:00:00Z, got %v", block["processed_at"])
+		}
 	}
 }
 
@@ -666,7 +1575,7 @@ func TestGetRecentBlocksOptions(t *testing.T) {
 	mockStore := &MockStore{}
 	mockIndexer := &MockIndexer{}
 
-	server := New(":0", mockStore, mockIndexer, nil, "")
+	server := New(Options{Bind: ":0", Store: mockStore, Indexer: mockIndexer})
 	webAPI := server.(*WebAPI)
 	webAPI.store = mockStore
 
@@ -683,3 +1592,130 @@ func TestGetRecentBlocksOptions(t *testing.T) {
 		t.Errorf("expected Allow header 'GET, OPTIONS', got %q", w.Header().Get("Allow"))
 	}
 }
+
+// MockMempool implements mempool.Monitor for testing
+type MockMempool struct {
+	pending mempool.Balance
+}
+
+func (m *MockMempool) PendingBalance(kind doge.ScriptType, script []byte) mempool.Balance {
+	return m.pending
+}
+
+func TestGetBalanceIncludesMempool(t *testing.T) {
+	mockStore := &MockStore{
+		balance: spec.Balance{Available: bigKoinu(100000000), Incoming: bigKoinu(0), Outgoing: bigKoinu(0)},
+	}
+	pending := &MockMempool{pending: mempool.Balance{Incoming: 25000000, TxCount: 1}}
+	server := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}, Mempool: pending})
+	webAPI := server.(*WebAPI)
+	webAPI.store = mockStore
+
+	req := httptest.NewRequest("GET", "/balance?address=D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS", nil)
+	w := httptest.NewRecorder()
+
+	webAPI.getBalance(w, req)
+
+	expected := `{"incoming":"0","available":"1","outgoing":"0","current":"1","utxo_count":0,"mempool":{"incoming":"0.25","tx_count":1}}`
+	if w.Body.String() != expected {
+		t.Errorf("expected body %q, got %q", expected, w.Body.String())
+	}
+}
+
+func TestGetBalanceConfirmations(t *testing.T) {
+	tests := []struct {
+		name           string
+		configured     int64
+		query          string
+		expectedStatus int
+		expectedConf   int64
+	}{
+		{"Built-in default", 0, "", 200, DefaultConfirmations},
+		{"Configured default", 1, "", 200, 1},
+		{"Query overrides default", 1, "&confirmations=12", 200, 12},
+		{"Query zero", 3, "&confirmations=0", 200, 0},
+		{"Invalid query", 1, "&confirmations=-2", 400, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{balanceConf: -1}
+			server := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}, Confirmations: tt.configured})
+			webAPI := server.(*WebAPI)
+			webAPI.store = mockStore
+
+			req := httptest.NewRequest("GET", "/balance?address=D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			webAPI.getBalance(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if mockStore.balanceConf != tt.expectedConf {
+				t.Errorf("expected GetBalance confirmations %d, got %d", tt.expectedConf, mockStore.balanceConf)
+			}
+		})
+	}
+}
+
+func TestGetBalanceIncludeUnconfirmed(t *testing.T) {
+	address := "D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS"
+	versioned, err := doge.Base58DecodeCheck(address)
+	if err != nil {
+		t.Fatalf("Base58DecodeCheck: %v", err)
+	}
+	hash := versioned[1:]
+	db, err := idxstore.NewIndexStore(":memory:", context.Background(), idxstore.Options{})
+	if err != nil {
+		t.Fatalf("NewIndexStore: %v", err)
+	}
+	defer db.Close()
+
+	// 1 DOGE at 90, 2 at 97, 3 at 100 (the tip), and 4 from 80 spent at 99
+	utxo := func(tx byte, value int64) spec.UTXO {
+		return spec.UTXO{TxID: bytes.Repeat([]byte{tx}, 32), Value: value * 100000000, Type: doge.ScriptTypeP2PKH, Script: hash}
+	}
+	steps := []struct {
+		height int64
+		create spec.UTXO
+	}{{80, utxo(4, 4)}, {90, utxo(1, 1)}, {97, utxo(2, 2)}, {100, utxo(3, 3)}}
+	for _, step := range steps {
+		if err := db.Transact(func(tx spec.StoreTx) error { return tx.CreateUTXOs([]spec.UTXO{step.create}, step.height) }); err != nil {
+			t.Fatalf("CreateUTXOs: %v", err)
+		}
+	}
+	if err := db.Transact(func(tx spec.StoreTx) error {
+		if err := tx.RemoveUTXOs([]spec.OutPointKey{spec.OutPoint(steps[0].create.TxID, 0)}, 99); err != nil {
+			return err
+		}
+		return tx.SetResumePoint(bytes.Repeat([]byte{100}, 32), 100)
+	}); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedBody string
+	}{
+		{"Confirmed only", "", `{"incoming":"5","available":"1","outgoing":"4","current":"6","utxo_count":3}`},
+		{"Not included", "&include_unconfirmed=false", `{"incoming":"5","available":"1","outgoing":"4","current":"6","utxo_count":3}`},
+		{"Both", "&include_unconfirmed=true", `{"incoming":"5","available":"1","outgoing":"4","current":"6","utxo_count":3,"unconfirmed":{"incoming":"3","available":"3","outgoing":"0","current":"6","utxo_count":3}}`},
+		{"Invalid flag", "&include_unconfirmed=yes", `{"error":"bad-request","reason":"'include_unconfirmed' must be true or false"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webAPI := New(Options{Bind: ":0", Store: db, Indexer: &MockIndexer{}}).(*WebAPI)
+			webAPI.store = db
+
+			w := httptest.NewRecorder()
+			webAPI.getBalance(w, httptest.NewRequest("GET", "/balance?address="+address+tt.query, nil))
+
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
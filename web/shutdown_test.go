@@ -0,0 +1,118 @@
+package web
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// slowServer serves `webAPI` on a local port, with a /slow endpoint that
+// blocks until `release` is closed.
+func slowServer(t *testing.T, webAPI *WebAPI) (addr string, started chan struct{}, release chan struct{}, served chan struct{}) {
+	t.Helper()
+	started, release, served = make(chan struct{}), make(chan struct{}), make(chan struct{})
+	api := webAPI.srv.Handler
+	webAPI.srv.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/slow" {
+			api.ServeHTTP(w, r)
+			return
+		}
+		close(started)
+		<-release
+		w.Write([]byte("done"))
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go func() {
+		webAPI.serve(ln)
+		close(served)
+	}()
+	return ln.Addr().String(), started, release, served
+}
+
+func TestStopDrainsInFlightRequests(t *testing.T) {
+	mockStore := &MockStore{resumePoint: []byte{1}}
+	webAPI := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}, ShutdownGrace: 5 * time.Second}).(*WebAPI)
+	webAPI.store = mockStore
+	addr, started, release, served := slowServer(t, webAPI)
+
+	type result struct {
+		status int
+		body   string
+		err    error
+	}
+	results := make(chan result, 1)
+	go func() {
+		res, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		results <- result{status: res.StatusCode, body: string(body)}
+	}()
+	<-started
+
+	webAPI.Stop()
+	<-served // no longer accepting connections
+	if _, err := net.DialTimeout("tcp", addr, time.Second); err == nil {
+		t.Errorf("expected new connections to be refused while draining")
+	}
+
+	// health checks fail while draining, so load balancers stop routing here
+	w := httptest.NewRecorder()
+	webAPI.healthCheck(w, httptest.NewRequest("GET", "/health", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected /health status 503 while draining, got %d", w.Code)
+	}
+	select {
+	case <-webAPI.drained:
+		t.Fatalf("drained before the in-flight request finished")
+	default:
+	}
+
+	close(release)
+	res := <-results
+	if res.err != nil || res.status != http.StatusOK || res.body != "done" {
+		t.Errorf("expected the in-flight request to finish with 200 done, got %d %q %v", res.status, res.body, res.err)
+	}
+	select {
+	case <-webAPI.drained:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("not drained after the in-flight request finished")
+	}
+}
+
+func TestStopClosesRequestsAfterGrace(t *testing.T) {
+	mockStore := &MockStore{resumePoint: []byte{1}}
+	webAPI := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}, ShutdownGrace: 50 * time.Millisecond}).(*WebAPI)
+	webAPI.store = mockStore
+	addr, started, release, _ := slowServer(t, webAPI)
+	defer close(release)
+
+	errs := make(chan error, 1)
+	go func() {
+		res, err := http.Get("http://" + addr + "/slow")
+		if err == nil {
+			res.Body.Close()
+		}
+		errs <- err
+	}()
+	<-started
+
+	webAPI.Stop()
+	select {
+	case <-webAPI.drained:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("not drained after the grace period")
+	}
+	if err := <-errs; err == nil {
+		t.Errorf("expected the request to be cut off after the grace period")
+	}
+}
@@ -0,0 +1,45 @@
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStrictAddress(t *testing.T) {
+	validAddress := "D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS"
+	notFoundBody := `{"error":"not-found","reason":"no UTXOs (spent or unspent) for the address since the trim horizon"}`
+	tests := []struct {
+		name           string
+		path           string
+		store          *MockStore
+		expectedStatus int
+		expectedBody   string // only checked if set
+	}{
+		{"Balance of unseen address", "/balance?address=" + validAddress, &MockStore{unseen: true}, 200, ""},
+		{"Balance of unseen address, strict", "/balance?strict=true&address=" + validAddress, &MockStore{unseen: true}, 404, notFoundBody},
+		{"Balance of seen address, strict", "/balance?strict=true&address=" + validAddress, &MockStore{}, 200, ""},
+		{"Balance of unseen address, not strict", "/balance?strict=false&address=" + validAddress, &MockStore{unseen: true}, 200, ""},
+		{"UTXOs of unseen address", "/utxo?address=" + validAddress, &MockStore{unseen: true}, 200, `{"utxo":[],"count":0,"total":"0"}`},
+		{"UTXOs of unseen address, strict", "/utxo?strict=1&address=" + validAddress, &MockStore{unseen: true}, 404, notFoundBody},
+		{"UTXOs of seen address, strict", "/utxo?strict=true&address=" + validAddress, &MockStore{}, 200, `{"utxo":[],"count":0,"total":"0"}`},
+		{"Invalid strict", "/utxo?strict=yes&address=" + validAddress, &MockStore{}, 400, `{"error":"bad-request","reason":"'strict' must be true or false"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: tt.store, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = tt.store
+
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedBody != "" && w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
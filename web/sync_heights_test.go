@@ -15,9 +15,11 @@ type fakeBlockchain struct {
 	blocks  int64
 	headers int64
 	err     error
+	hashes  map[int64]string // block hashes by height
+	hashErr error
 }
 
-func (f fakeBlockchain) WaitForSync(_ context.Context) bool { return false }
+func (f fakeBlockchain) WaitForSync(_ context.Context) bool                     { return false }
 func (f fakeBlockchain) RetryMode(_ int, _ time.Duration) walkerspec.Blockchain { return f }
 func (f fakeBlockchain) GetBlockHeader(_ string, _ context.Context) (walkerspec.BlockHeader, error) {
 	return walkerspec.BlockHeader{}, nil
@@ -25,9 +27,11 @@ func (f fakeBlockchain) GetBlockHeader(_ string, _ context.Context) (walkerspec.
 func (f fakeBlockchain) GetBlock(_ string, _ context.Context) (doge.Block, int, error) {
 	return doge.Block{}, 0, nil
 }
-func (f fakeBlockchain) GetBlockHash(_ int64, _ context.Context) (string, error) { return "", nil }
-func (f fakeBlockchain) GetBestBlockHash(_ context.Context) (string, error)       { return "", nil }
-func (f fakeBlockchain) GetBlockCount(_ context.Context) (int64, error)           { return 0, nil }
+func (f fakeBlockchain) GetBlockHash(height int64, _ context.Context) (string, error) {
+	return f.hashes[height], f.hashErr
+}
+func (f fakeBlockchain) GetBestBlockHash(_ context.Context) (string, error) { return "", nil }
+func (f fakeBlockchain) GetBlockCount(_ context.Context) (int64, error)     { return 0, nil }
 func (f fakeBlockchain) GetBlockchainInfo(_ context.Context) (walkerspec.BlockchainInfo, error) {
 	if f.err != nil {
 		return walkerspec.BlockchainInfo{}, f.err
@@ -0,0 +1,27 @@
+package web
+
+import (
+	"net/http"
+)
+
+// limitExpensive runs `next` only while fewer than Options.MaxExpensive
+// expensive requests are running, and refuses the request otherwise. These
+// endpoints (range scans, aggregates, exports) can each hold a database
+// connection for seconds, so without a limit a few callers could take the
+// whole pool from cheap lookups like /balance and /utxo.
+func (a *WebAPI) limitExpensive(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next(w, r) // preflights don't query the database
+			return
+		}
+		select {
+		case a.expensive <- struct{}{}:
+			defer func() { <-a.expensive }()
+			next(w, r)
+		default:
+			w.Header().Set("Retry-After", "1")
+			sendError(w, CodeUnavailable, "too many expensive requests, try again later", "GET, POST, OPTIONS", a.corsOrigin)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLimitExpensive(t *testing.T) {
+	mockStore := &MockStore{currentHeight: 100}
+	webAPI := New(Options{Bind: ":0", Store: mockStore, Indexer: &MockIndexer{}, MaxExpensive: 2}).(*WebAPI)
+	webAPI.store = mockStore
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	// two expensive requests in progress
+	webAPI.expensive <- struct{}{}
+	webAPI.expensive <- struct{}{}
+
+	w := get("/diff?from=1&to=2")
+	if w.Code != 503 {
+		t.Fatalf("expected status 503 while saturated, got %d", w.Code)
+	}
+	expected := `{"error":"unavailable","reason":"too many expensive requests, try again later"}`
+	if w.Body.String() != expected {
+		t.Errorf("expected body %q, got %q", expected, w.Body.String())
+	}
+	if w.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected Retry-After: 1, got %q", w.Header().Get("Retry-After"))
+	}
+	for _, path := range []string{"/txtotal", "/txcount?from=1&to=2", "/balance-at?address=D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS&height=50"} {
+		if w := get(path); w.Code != 503 {
+			t.Errorf("%s: expected status 503 while saturated, got %d", path, w.Code)
+		}
+	}
+
+	// cheap lookups are not limited
+	for _, path := range []string{"/balance?address=D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS", "/utxo?address=D7nTLrBUiso28mNBj8MyHoyjdFypz3NzRS", "/height"} {
+		if w := get(path); w.Code != 200 {
+			t.Errorf("%s: expected status 200 while saturated, got %d", path, w.Code)
+		}
+	}
+
+	// one finishes: the next expensive request runs, and releases its slot
+	<-webAPI.expensive
+	for n := 0; n < 3; n++ {
+		if w := get("/diff?from=1&to=2"); w.Code != 200 {
+			t.Fatalf("expected status 200 with a free slot, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	if len(webAPI.expensive) != 1 {
+		t.Errorf("expected 1 slot in use, got %d", len(webAPI.expensive))
+	}
+}
@@ -0,0 +1,32 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/dogeorg/indexer/spec"
+)
+
+type TrimmedResponse struct {
+	TrimmedBelow int64 `json:"trimmed_below"` // spent UTXOs below this height have been deleted (0 if none)
+}
+
+func (a *WebAPI) getTrimmed(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.trimmed(store)
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) trimmed(store spec.Store) (any, error) {
+	height, err := store.GetTrimmedBelow()
+	if err != nil {
+		return nil, err
+	}
+	return TrimmedResponse{TrimmedBelow: height}, nil
+}
@@ -0,0 +1,38 @@
+package web
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetTrimmed(t *testing.T) {
+	tests := []struct {
+		name           string
+		store          *MockStore
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Never trimmed", &MockStore{}, 200, `{"trimmed_below":0}`},
+		{"Trimmed", &MockStore{trimmedBelow: 4000}, 200, `{"trimmed_below":4000}`},
+		{"Store error", &MockStore{trimmedErr: errors.New("db down")}, 500, `{"error":"error","reason":"db down"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: tt.store, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = tt.store
+
+			w := httptest.NewRecorder()
+			webAPI.getTrimmed(w, httptest.NewRequest("GET", "/trimmed", nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+// /tx/<txid> is for explorer transaction pages: the transaction's stored
+// outputs with their spent status, and its inputs if the indexer records them
+// (-explorer). Only indexed outputs are stored (not dust, OP_RETURN or
+// non-standard outputs), and spent ones are deleted once trimmed.
+
+type TxResponse struct {
+	TxID          string         `json:"tx"`            // hex-encoded transaction ID (byte-reversed)
+	Height        int64          `json:"height"`        // block height of the transaction
+	Confirmations int64          `json:"confirmations"` // 1 in the tip block
+	Outputs       []TxOutputItem `json:"outputs"`       // stored outputs, in vout order
+	Inputs        []TxInputItem  `json:"inputs"`        // recorded inputs, in input order (empty unless -explorer)
+}
+
+type TxOutputItem struct {
+	UTXOItem
+	Spent       bool       `json:"spent"`
+	SpentHeight int64      `json:"spent_height,omitempty"` // height of the block that spent it
+	SpentBy     *TxInPoint `json:"spent_by,omitempty"`     // the input that spent it (if recorded)
+}
+
+// TxInPoint identifies a transaction input.
+type TxInPoint struct {
+	TxID string `json:"tx"`  // hex-encoded transaction ID (byte-reversed)
+	VIn  uint32 `json:"vin"` // input number
+}
+
+type TxInputItem struct {
+	VIn      uint32 `json:"vin"`       // input number
+	PrevTxID string `json:"prev_tx"`   // hex-encoded ID of the transaction it spends (byte-reversed)
+	PrevVOut uint32 `json:"prev_vout"` // output number it spends
+}
+
+func (a *WebAPI) getTx(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.tx(store, strings.TrimPrefix(r.URL.Path, "/tx/"), r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+// tx looks up a transaction's outputs and inputs ('script' and
+// 'include_address' format the outputs as in /utxo.)
+func (a *WebAPI) tx(store spec.Store, txid string, query url.Values) (any, error) {
+	if txid == "" {
+		return nil, badRequest("missing txid in the URL: /tx/{txid}")
+	}
+	hash, err := doge.HexDecodeReversed(txid)
+	if err != nil || len(hash) != 32 {
+		return nil, badRequest("txid must be 32 bytes of hex")
+	}
+	format, err := a.utxoFormatParam(query)
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := store.GetTxOutputs(hash)
+	if err != nil {
+		return nil, err
+	}
+	inputs, err := store.GetTxInputs(hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(outputs) == 0 && len(inputs) == 0 {
+		return nil, notFound("transaction has no stored outputs or recorded inputs")
+	}
+	res := TxResponse{TxID: doge.HexEncodeReversed(hash), Outputs: []TxOutputItem{}, Inputs: []TxInputItem{}}
+	res.Height, res.Confirmations, err = store.GetConfirmations(hash)
+	if errors.Is(err, spec.ErrNotFound) && len(inputs) > 0 {
+		// only its inputs are recorded (none of its outputs were indexed)
+		current, err := store.GetCurrentHeight()
+		if err != nil {
+			return nil, err
+		}
+		res.Height = inputs[0].Height
+		res.Confirmations = current - res.Height + 1
+	} else if err != nil {
+		return nil, err
+	}
+	for _, out := range outputs {
+		item := TxOutputItem{UTXOItem: format.item(out.UTXO), Spent: out.Spent != 0, SpentHeight: out.Spent}
+		if out.SpentBy != nil {
+			item.SpentBy = &TxInPoint{TxID: doge.HexEncodeReversed(out.SpentBy), VIn: out.SpentVIn}
+		}
+		res.Outputs = append(res.Outputs, item)
+	}
+	for _, in := range inputs {
+		res.Inputs = append(res.Inputs, TxInputItem{VIn: in.VIn, PrevTxID: doge.HexEncodeReversed(in.Prev.Tx), PrevVOut: in.Prev.VOut})
+	}
+	return res, nil
+}
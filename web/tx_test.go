@@ -0,0 +1,68 @@
+package web
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestGetTx(t *testing.T) {
+	hash := append(bytes.Repeat([]byte{0xAB}, 31), 0x01) // displayed byte-reversed: 01abab...
+	txid := "01" + strings.Repeat("ab", 31)
+	spender := bytes.Repeat([]byte{0xCD}, 32)
+	prev := bytes.Repeat([]byte{0xEF}, 32)
+	script := bytes.Repeat([]byte{0x11}, 20)
+	outputs := []spec.TxOutput{
+		{UTXOState: spec.UTXOState{UTXO: spec.UTXO{TxID: hash, VOut: 0, Value: 100000000, Type: doge.ScriptTypeP2PKH, Script: script}, Height: 995}},
+		{UTXOState: spec.UTXOState{UTXO: spec.UTXO{TxID: hash, VOut: 1, Value: 50000000, Type: doge.ScriptTypeP2PKH, Script: script}, Height: 995, Spent: 998}, SpentBy: spender, SpentVIn: 2},
+		{UTXOState: spec.UTXOState{UTXO: spec.UTXO{TxID: hash, VOut: 3, Value: 1, Type: doge.ScriptTypeP2PKH, Script: script}, Height: 995, Spent: 999}},
+	}
+	inputs := []spec.TxInput{
+		{TxID: hash, VIn: 0, Prev: spec.OutPoint(prev, 4), Height: 995},
+		{TxID: hash, VIn: 1, Prev: spec.OutPoint(prev, 0), Height: 995},
+	}
+	mixed := `{"tx":"` + txid + `","height":995,"confirmations":6,"outputs":[` +
+		`{"tx":"` + txid + `","vout":0,"value":"1","type":"P2PKH","script":"1111111111111111111111111111111111111111","spent":false},` +
+		`{"tx":"` + txid + `","vout":1,"value":"0.5","type":"P2PKH","script":"1111111111111111111111111111111111111111","spent":true,"spent_height":998,"spent_by":{"tx":"` + strings.Repeat("cd", 32) + `","vin":2}},` +
+		`{"tx":"` + txid + `","vout":3,"value":"0.00000001","type":"P2PKH","script":"1111111111111111111111111111111111111111","spent":true,"spent_height":999}],` +
+		`"inputs":[{"vin":0,"prev_tx":"` + strings.Repeat("ef", 32) + `","prev_vout":4},{"vin":1,"prev_tx":"` + strings.Repeat("ef", 32) + `","prev_vout":0}]}`
+	heights := map[string]int64{string(hash): 995} // stored with its outputs
+	tests := []struct {
+		name           string
+		path           string
+		store          *MockStore
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Mixed spent and unspent outputs", "/tx/" + txid + "?script=compact", &MockStore{currentHeight: 1000, txHeights: heights, txOutputs: outputs, txInputs: inputs}, 200, mixed},
+		{"Without recorded inputs", "/tx/" + txid + "?script=none", &MockStore{currentHeight: 1000, txHeights: heights, txOutputs: outputs[:1]}, 200,
+			`{"tx":"` + txid + `","height":995,"confirmations":6,"outputs":[{"tx":"` + txid + `","vout":0,"value":"1","type":"P2PKH","spent":false}],"inputs":[]}`},
+		{"Only recorded inputs", "/tx/" + txid, &MockStore{currentHeight: 995, txInputs: inputs[:1]}, 200,
+			`{"tx":"` + txid + `","height":995,"confirmations":1,"outputs":[],"inputs":[{"vin":0,"prev_tx":"` + strings.Repeat("ef", 32) + `","prev_vout":4}]}`},
+		{"Not found", "/tx/" + txid, &MockStore{currentHeight: 1000}, 404, `{"error":"not-found","reason":"transaction has no stored outputs or recorded inputs"}`},
+		{"Missing txid", "/tx/", &MockStore{}, 400, `{"error":"bad-request","reason":"missing txid in the URL: /tx/{txid}"}`},
+		{"Short txid", "/tx/abcd", &MockStore{}, 400, `{"error":"bad-request","reason":"txid must be 32 bytes of hex"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: tt.store, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = tt.store
+
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/dogeorg/indexer/spec"
+)
+
+const maxTxCountBlocks = 10000 // blocks per /txcount request
+
+type TxCountItem struct {
+	Height  int64 `json:"height"`
+	TxCount int64 `json:"tx_count"`
+}
+
+type TxCountResponse struct {
+	From   int64         `json:"from"`
+	To     int64         `json:"to"`
+	Blocks []TxCountItem `json:"blocks"` // in height order (only blocks indexed since stats were added)
+}
+
+// getTxCount lists the number of transactions in each block between two heights (inclusive.)
+func (a *WebAPI) getTxCount(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.txCount(store, r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) txCount(store spec.Store, query url.Values) (any, error) {
+	from, to, err := heightRangeParam(query, maxTxCountBlocks)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := store.GetBlockStats(from, to)
+	if err != nil {
+		return nil, err
+	}
+	blocks := []TxCountItem{}
+	for _, s := range stats {
+		blocks = append(blocks, TxCountItem{Height: s.Height, TxCount: s.TxCount})
+	}
+	return TxCountResponse{From: from, To: to, Blocks: blocks}, nil
+}
@@ -0,0 +1,74 @@
+package web
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestGetTxCount(t *testing.T) {
+	stats := []spec.BlockStats{{Height: 100, TxCount: 1}, {Height: 101, TxCount: 7}, {Height: 102, TxCount: 3}, {Height: 103, TxCount: 12}}
+	tests := []struct {
+		name           string
+		query          string
+		store          *MockStore
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Range",
+			query:          "?from=101&to=102",
+			store:          &MockStore{blockStats: stats},
+			expectedStatus: 200,
+			expectedBody:   `{"from":101,"to":102,"blocks":[{"height":101,"tx_count":7},{"height":102,"tx_count":3}]}`,
+		},
+		{
+			name:           "Empty range",
+			query:          "?from=500&to=510",
+			store:          &MockStore{blockStats: stats},
+			expectedStatus: 200,
+			expectedBody:   `{"from":500,"to":510,"blocks":[]}`,
+		},
+		{
+			name:           "Range too large",
+			query:          "?from=0&to=10000",
+			store:          &MockStore{},
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"at most 10000 blocks per request"}`,
+		},
+		{
+			name:           "Missing from",
+			query:          "?to=5",
+			store:          &MockStore{},
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'from' must be a block height"}`,
+		},
+		{
+			name:           "Store error",
+			query:          "?from=1&to=2",
+			store:          &MockStore{blockStatsErr: errors.New("db down")},
+			expectedStatus: 500,
+			expectedBody:   `{"error":"error","reason":"db down"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: tt.store, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = tt.store
+
+			w := httptest.NewRecorder()
+			webAPI.getTxCount(w, httptest.NewRequest("GET", "/txcount"+tt.query, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
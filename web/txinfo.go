@@ -0,0 +1,81 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+// Only transactions with indexed outputs are stored, until all their outputs
+// are spent and trimmed (see spec.StoreTx.GetTxHeight.)
+
+type TxInfoResponse struct {
+	TxID          string `json:"tx"`            // hex-encoded transaction ID (byte-reversed)
+	Height        int64  `json:"height"`        // block height of the transaction
+	Confirmations int64  `json:"confirmations"` // 1 in the tip block
+}
+
+type TxTotalResponse struct {
+	Total int64 `json:"tx_total"` // number of indexed transactions
+}
+
+func (a *WebAPI) getTxInfo(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.txInfo(store, r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) txInfo(store spec.Store, query url.Values) (any, error) {
+	hash, err := txidParam(query)
+	if err != nil {
+		return nil, err
+	}
+	height, confirmations, err := store.GetConfirmations(hash)
+	if err != nil {
+		return nil, err // spec.ErrNotFound if not indexed
+	}
+	return TxInfoResponse{TxID: doge.HexEncodeReversed(hash), Height: height, Confirmations: confirmations}, nil
+}
+
+// txidParam decodes the required 'txid' parameter (byte-reversed hex.)
+func txidParam(query url.Values) ([]byte, error) {
+	param := query.Get("txid")
+	if param == "" {
+		return nil, badRequest("missing 'txid' in the URL")
+	}
+	hash, err := doge.HexDecodeReversed(param)
+	if err != nil || len(hash) != 32 {
+		return nil, badRequest("'txid' must be 32 bytes of hex")
+	}
+	return hash, nil
+}
+
+func (a *WebAPI) getTxTotal(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.txTotal(store)
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) txTotal(store spec.Store) (any, error) {
+	total, err := store.GetTxTotal()
+	if err != nil {
+		return nil, err
+	}
+	return TxTotalResponse{Total: total}, nil
+}
@@ -0,0 +1,114 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetTxInfo(t *testing.T) {
+	hash := bytes.Repeat([]byte{0xAB}, 31)
+	hash = append(hash, 0x01) // displayed byte-reversed: 01abab...
+	txid := "01" + strings.Repeat("ab", 31)
+	tests := []struct {
+		name           string
+		query          string
+		store          *MockStore
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Indexed",
+			query:          "?txid=" + txid,
+			store:          &MockStore{currentHeight: 1000, txHeights: map[string]int64{string(hash): 995}},
+			expectedStatus: 200,
+			expectedBody:   `{"tx":"` + txid + `","height":995,"confirmations":6}`,
+		},
+		{
+			name:           "In the tip block",
+			query:          "?txid=" + txid,
+			store:          &MockStore{currentHeight: 1000, txHeights: map[string]int64{string(hash): 1000}},
+			expectedStatus: 200,
+			expectedBody:   `{"tx":"` + txid + `","height":1000,"confirmations":1}`,
+		},
+		{
+			name:           "Not indexed",
+			query:          "?txid=" + txid,
+			store:          &MockStore{currentHeight: 1000},
+			expectedStatus: 404,
+			expectedBody:   `{"error":"not-found","reason":"not-found"}`,
+		},
+		{
+			name:           "Store error",
+			query:          "?txid=" + txid,
+			store:          &MockStore{txErr: errors.New("db down")},
+			expectedStatus: 500,
+			expectedBody:   `{"error":"error","reason":"db down"}`,
+		},
+		{
+			name:           "Missing txid",
+			query:          "",
+			store:          &MockStore{},
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"missing 'txid' in the URL"}`,
+		},
+		{
+			name:           "Short txid",
+			query:          "?txid=abcd",
+			store:          &MockStore{},
+			expectedStatus: 400,
+			expectedBody:   `{"error":"bad-request","reason":"'txid' must be 32 bytes of hex"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: tt.store, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = tt.store
+
+			w := httptest.NewRecorder()
+			webAPI.getTxInfo(w, httptest.NewRequest("GET", "/txinfo"+tt.query, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetTxTotal(t *testing.T) {
+	tests := []struct {
+		name           string
+		store          *MockStore
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Empty", &MockStore{}, 200, `{"tx_total":0}`},
+		{"Indexed", &MockStore{txHeights: map[string]int64{"a": 1, "b": 2, "c": 2}}, 200, `{"tx_total":3}`},
+		{"Store error", &MockStore{txErr: errors.New("db down")}, 500, `{"error":"error","reason":"db down"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: tt.store, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = tt.store
+
+			w := httptest.NewRecorder()
+			webAPI.getTxTotal(w, httptest.NewRequest("GET", "/txtotal", nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
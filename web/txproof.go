@@ -0,0 +1,71 @@
+package web
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+// /txproof tells SPV-style clients which block contains a transaction, so
+// they can fetch the header and verify its inclusion independently. With
+// Core RPC it also includes Core's gettxoutproof (a serialized Merkle block.)
+
+const txProofTimeout = 10 * time.Second // Core RPC calls per request
+
+type TxProofResponse struct {
+	TxID          string `json:"tx"`              // hex-encoded transaction ID (byte-reversed)
+	Height        int64  `json:"height"`          // block height of the transaction
+	Confirmations int64  `json:"confirmations"`   // 1 in the tip block
+	Block         string `json:"block,omitempty"` // block hash, hex (needs Core RPC)
+	Proof         string `json:"proof,omitempty"` // gettxoutproof result, hex (if Core can provide it)
+}
+
+func (a *WebAPI) getTxProof(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.txProof(r.Context(), store, r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) txProof(ctx context.Context, store spec.Store, query url.Values) (any, error) {
+	hash, err := txidParam(query)
+	if err != nil {
+		return nil, err
+	}
+	height, confirmations, err := store.GetConfirmations(hash)
+	if err != nil {
+		return nil, err // spec.ErrNotFound if not indexed
+	}
+	res := TxProofResponse{TxID: doge.HexEncodeReversed(hash), Height: height, Confirmations: confirmations}
+	if a.blockchain == nil {
+		return res, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, txProofTimeout)
+	defer cancel()
+	res.Block, err = a.blockchain.GetBlockHash(height, ctx)
+	if err != nil {
+		return nil, err
+	}
+	// core.CoreRPCClient can make raw requests; the proof is optional because
+	// Core cannot provide it for pruned blocks.
+	if core, ok := a.blockchain.(CoreRequester); ok {
+		var proof string
+		if _, err := core.Request(ctx, "gettxoutproof", []any{[]string{res.TxID}, res.Block}, &proof); err != nil {
+			log.Printf("[API] gettxoutproof %s: %v", res.TxID, err)
+		} else {
+			res.Proof = proof
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,69 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	walkerspec "github.com/dogeorg/dogewalker/spec"
+)
+
+// proofCore is Core RPC that can also make raw requests, like core.CoreRPCClient.
+type proofCore struct {
+	fakeBlockchain
+	*MockCore
+}
+
+func TestGetTxProof(t *testing.T) {
+	hash := bytes.Repeat([]byte{0xAB}, 31)
+	hash = append(hash, 0x01) // displayed byte-reversed: 01abab...
+	txid := "01" + strings.Repeat("ab", 31)
+	block := strings.Repeat("cd", 32)
+	store := &MockStore{currentHeight: 1000, txHeights: map[string]int64{string(hash): 995}}
+	hashes := map[int64]string{995: block}
+	tests := []struct {
+		name           string
+		query          string
+		store          *MockStore
+		blockchain     walkerspec.Blockchain
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Without Core RPC", "?txid=" + txid, store, nil, 200,
+			`{"tx":"` + txid + `","height":995,"confirmations":6}`},
+		{"Block hash", "?txid=" + txid, store, fakeBlockchain{hashes: hashes}, 200,
+			`{"tx":"` + txid + `","height":995,"confirmations":6,"block":"` + block + `"}`},
+		{"Merkle proof", "?txid=" + txid, store, proofCore{fakeBlockchain{hashes: hashes}, &MockCore{}}, 200,
+			`{"tx":"` + txid + `","height":995,"confirmations":6,"block":"` + block + `","proof":"0100cafe"}`},
+		{"Proof unavailable", "?txid=" + txid, store, proofCore{fakeBlockchain{hashes: hashes}, &MockCore{err: errors.New("block not available (pruned data)")}}, 200,
+			`{"tx":"` + txid + `","height":995,"confirmations":6,"block":"` + block + `"}`},
+		{"Core error", "?txid=" + txid, store, fakeBlockchain{hashErr: errors.New("rpc down")}, 500,
+			`{"error":"error","reason":"rpc down"}`},
+		{"Not indexed", "?txid=" + txid, &MockStore{currentHeight: 1000}, fakeBlockchain{hashes: hashes}, 404,
+			`{"error":"not-found","reason":"not-found"}`},
+		{"Store error", "?txid=" + txid, &MockStore{txErr: errors.New("db down")}, nil, 500,
+			`{"error":"error","reason":"db down"}`},
+		{"Missing txid", "", store, nil, 400,
+			`{"error":"bad-request","reason":"missing 'txid' in the URL"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: tt.store, Indexer: &MockIndexer{}, Blockchain: tt.blockchain})
+			webAPI := server.(*WebAPI)
+			webAPI.store = tt.store
+
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/txproof"+tt.query, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
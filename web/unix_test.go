@@ -0,0 +1,56 @@
+package web
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestServeUnixSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "idx") // short path: unix socket paths are limited to ~100 bytes
+	if err != nil {
+		t.Fatalf("MkdirTemp: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "api.sock")
+
+	mockStore := &MockStore{resumePoint: []byte{1}}
+	server := New(Options{Bind: "unix:" + socket, Store: mockStore, Indexer: &MockIndexer{}})
+	webAPI := server.(*WebAPI)
+	webAPI.store = mockStore
+
+	ln, err := listen(webAPI.srv.Addr)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		webAPI.serve(ln)
+		close(done)
+	}()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	res, err := client.Get("http://unix/health")
+	if err != nil {
+		t.Fatalf("GET /health: %v", err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.StatusCode, body)
+	}
+
+	webAPI.srv.Shutdown(context.Background())
+	<-done
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Fatalf("expected the socket file to be removed on shutdown, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/dogeorg/indexer/spec"
+)
+
+// /utxo-age buckets an address's unspent UTXOs by confirmations, so coin
+// control UIs can prefer mature coins without fetching every UTXO.
+
+type UTXOAgeResponse struct {
+	Buckets []UTXOAgeItem `json:"buckets"` // by increasing confirmations, including empty ones
+}
+
+type UTXOAgeItem struct {
+	Confirmations string        `json:"confirmations"`      // the range, e.g. "1-6" or "100+"
+	MinConf       int64         `json:"min_conf"`           // fewest confirmations in the bucket
+	MaxConf       *int64        `json:"max_conf,omitempty"` // most confirmations (none for the last bucket)
+	Count         int64         `json:"utxo_count"`         // number of unspent UTXOs
+	Value         spec.BigKoinu `json:"value"`              // sum of their values
+}
+
+func (a *WebAPI) getUTXOAge(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		store, cancel := a.requestStore(r)
+		defer cancel()
+		payload, err := a.utxoAge(store, r.URL.Query())
+		sendResult(w, payload, err, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
+
+func (a *WebAPI) utxoAge(store spec.Store, query url.Values) (any, error) {
+	kind, hash, err := a.addressParam(query)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSeen(store, kind, hash, query); err != nil {
+		return nil, err
+	}
+	buckets, err := store.GetUTXOAgeHistogram(kind, hash)
+	if err != nil {
+		return nil, err
+	}
+	res := UTXOAgeResponse{Buckets: []UTXOAgeItem{}}
+	for n, b := range buckets {
+		item := UTXOAgeItem{MinConf: b.MinConf, Count: b.Count, Value: b.Value}
+		if n+1 < len(buckets) {
+			maxConf := buckets[n+1].MinConf - 1
+			item.MaxConf = &maxConf
+		}
+		switch {
+		case item.MaxConf == nil:
+			item.Confirmations = fmt.Sprintf("%d+", item.MinConf)
+		case *item.MaxConf == item.MinConf:
+			item.Confirmations = fmt.Sprintf("%d", item.MinConf)
+		default:
+			item.Confirmations = fmt.Sprintf("%d-%d", item.MinConf, *item.MaxConf)
+		}
+		res.Buckets = append(res.Buckets, item)
+	}
+	return res, nil
+}
@@ -0,0 +1,56 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/indexer/spec"
+)
+
+func TestGetUTXOAge(t *testing.T) {
+	address := string(doge.Hash160toAddress(bytes.Repeat([]byte{0x42}, 20), doge.DogeMainNetChain.P2PKH_Address_Prefix))
+	histogram := []spec.UTXOAgeBucket{
+		{MinConf: 0},
+		{MinConf: 1, Count: 2, Value: bigKoinu(150000000)},
+		{MinConf: 7},
+		{MinConf: 100, Count: 1, Value: bigKoinu(1)},
+	}
+	tests := []struct {
+		name           string
+		query          string
+		store          *MockStore
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Buckets", "?address=" + address, &MockStore{ageHistogram: histogram}, 200,
+			`{"buckets":[` +
+				`{"confirmations":"0","min_conf":0,"max_conf":0,"utxo_count":0,"value":"0"},` +
+				`{"confirmations":"1-6","min_conf":1,"max_conf":6,"utxo_count":2,"value":"1.5"},` +
+				`{"confirmations":"7-99","min_conf":7,"max_conf":99,"utxo_count":0,"value":"0"},` +
+				`{"confirmations":"100+","min_conf":100,"utxo_count":1,"value":"0.00000001"}]}`},
+		{"Store error", "?address=" + address, &MockStore{utxoErr: errors.New("db down")}, 500, `{"error":"error","reason":"db down"}`},
+		{"Missing address", "", &MockStore{}, 400, `{"error":"bad-request","reason":"missing 'address' in the URL"}`},
+		{"Invalid address", "?address=nope", &MockStore{}, 400, `{"error":"bad-request","reason":"invalid Dogecoin address"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(Options{Bind: ":0", Store: tt.store, Indexer: &MockIndexer{}})
+			webAPI := server.(*WebAPI)
+			webAPI.store = tt.store
+
+			w := httptest.NewRecorder()
+			webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/utxo-age"+tt.query, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// BuildInfo identifies the running build (main sets it with -ldflags.)
+type BuildInfo struct {
+	Version string `json:"version"` // release version, e.g. "v1.2.0"
+	Commit  string `json:"commit"`  // git commit the binary was built from
+	Date    string `json:"date"`    // build date
+}
+
+func (b BuildInfo) String() string {
+	return fmt.Sprintf("indexer %s (commit %s, built %s)", b.Version, b.Commit, b.Date)
+}
+
+type VersionResponse struct {
+	BuildInfo
+	Chain         string `json:"chain"`          // chain name, as in /height
+	SchemaVersion int    `json:"schema_version"` // database schema (migration) version
+}
+
+func (a *WebAPI) getVersion(w http.ResponseWriter, r *http.Request) {
+	options := "GET, OPTIONS"
+	switch r.Method {
+	case http.MethodGet:
+		sendJson(w, VersionResponse{BuildInfo: a.build, Chain: a.chainName, SchemaVersion: a.schemaVersion}, options, a.corsOrigin)
+	case http.MethodOptions:
+		sendOptions(w, r, options, a.corsOrigin, a.corsMaxAge)
+	}
+}
@@ -0,0 +1,37 @@
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetVersion(t *testing.T) {
+	mockStore := &MockStore{}
+	webAPI := New(Options{
+		Bind:          ":0",
+		Store:         mockStore,
+		Indexer:       &MockIndexer{},
+		ChainName:     "regtest",
+		Build:         BuildInfo{Version: "v1.2.0", Commit: "0123abcd", Date: "2026-10-01"},
+		SchemaVersion: 12,
+	}).(*WebAPI)
+	webAPI.store = mockStore
+
+	w := httptest.NewRecorder()
+	webAPI.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/version", nil))
+
+	if w.Code != 200 {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+	expected := `{"version":"v1.2.0","commit":"0123abcd","date":"2026-10-01","chain":"regtest","schema_version":12}`
+	if w.Body.String() != expected {
+		t.Errorf("expected body %q, got %q", expected, w.Body.String())
+	}
+}
+
+func TestBuildInfoString(t *testing.T) {
+	build := BuildInfo{Version: "dev", Commit: "unknown", Date: "unknown"}
+	if got := build.String(); got != "indexer dev (commit unknown, built unknown)" {
+		t.Errorf("unexpected String(): %q", got)
+	}
+}
@@ -0,0 +1,254 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/doge/koinu"
+	"github.com/dogeorg/dogewalker/walker"
+	"github.com/dogeorg/governor"
+	"github.com/dogeorg/indexer/index"
+)
+
+const (
+	queueSize      = 1000             // deliveries waiting to be sent before new events are dropped
+	requestTimeout = 10 * time.Second // per POST
+	maxAttempts    = 6                // first try plus retries
+	firstRetry     = 5 * time.Second  // retry delay, doubled after each failure
+)
+
+// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the request
+// body, keyed with the webhook secret (only sent if there is a secret.)
+const SignatureHeader = "X-Indexer-Signature"
+
+// Subscription asks for a POST to URL when a block creates or spends UTXOs
+// paying Address (a P2PKH or P2SH address on the indexer's chain.)
+type Subscription struct {
+	Address string `json:"address"`
+	URL     string `json:"url"`
+}
+
+// Event is the JSON body POSTed to a subscription's URL: the net effect of
+// one transaction on the address, sent when its block is indexed.
+type Event struct {
+	Address       string      `json:"address"`
+	TxID          string      `json:"tx"`            // hex-encoded transaction ID (byte-reversed)
+	Height        int64       `json:"height"`        // block height of the transaction
+	Block         string      `json:"block"`         // block hash
+	Confirmations int64       `json:"confirmations"` // always 1: events are sent as blocks are indexed
+	Received      koinu.Koinu `json:"received"`      // sum of the outputs paying the address
+	Spent         koinu.Koinu `json:"spent"`         // sum of the address's UTXOs it spent
+	Amount        koinu.Koinu `json:"amount"`        // received - spent
+}
+
+type delivery struct {
+	url      string
+	body     []byte
+	attempts int // failed attempts so far
+}
+
+/*
+ * Notifier POSTs an Event to each Subscription's URL when the Indexer
+ * indexes a transaction touching its address.
+ *
+ * Deliveries are queued (the Indexer never waits for them) and sent one at
+ * a time; failures (network errors or non-2xx responses) are retried with
+ * exponential backoff, then dropped. Events are not persisted: those queued
+ * when the indexer stops are lost.
+ */
+type Notifier struct {
+	governor.ServiceCtx
+	subs       map[string][]Subscription // scriptKey -> subscriptions
+	secret     []byte
+	client     *http.Client
+	queue      chan delivery
+	firstRetry time.Duration
+
+	mu      sync.Mutex
+	stopped bool // no more retries (after Run returns)
+}
+
+// Ensure Notifier implements governor.Service and index.ActivityListener
+var _ governor.Service = (*Notifier)(nil)
+var _ index.ActivityListener = (*Notifier)(nil)
+
+// NewNotifier creates a Notifier for `subs`, signing requests with `secret`
+// (unsigned if empty); register it with Indexer.AddListener.
+func NewNotifier(subs []Subscription, chain *doge.ChainParams, secret string) (*Notifier, error) {
+	n := &Notifier{
+		subs:       map[string][]Subscription{},
+		secret:     []byte(secret),
+		client:     &http.Client{Timeout: requestTimeout},
+		queue:      make(chan delivery, queueSize),
+		firstRetry: firstRetry,
+	}
+	for _, sub := range subs {
+		kind, hash, err := decodeAddress(sub.Address, chain)
+		if err != nil {
+			return nil, err
+		}
+		if sub.URL == "" {
+			return nil, fmt.Errorf("webhook for %s: missing url", sub.Address)
+		}
+		key := scriptKey(kind, hash)
+		n.subs[key] = append(n.subs[key], sub)
+	}
+	return n, nil
+}
+
+// LoadSubscriptions reads a JSON array of subscriptions from a file.
+func LoadSubscriptions(fileName string) (subs []Subscription, err error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &subs); err != nil {
+		return nil, fmt.Errorf("%s: %w", fileName, err)
+	}
+	return subs, nil
+}
+
+// decodeAddress decodes a P2PKH or P2SH address into the kind and compact script stored in the index.
+func decodeAddress(address string, chain *doge.ChainParams) (doge.ScriptType, []byte, error) {
+	decoded, err := doge.Base58DecodeCheck(address)
+	if err != nil || len(decoded) != 21 {
+		return 0, nil, fmt.Errorf("webhook address %q: not a P2PKH or P2SH address", address)
+	}
+	switch decoded[0] {
+	case chain.P2PKH_Address_Prefix:
+		return doge.ScriptTypeP2PKH, decoded[1:], nil
+	case chain.P2SH_Address_Prefix:
+		return doge.ScriptTypeP2SH, decoded[1:], nil
+	}
+	return 0, nil, fmt.Errorf("webhook address %q: not a P2PKH or P2SH address on this chain", address)
+}
+
+// Run is the entry point for the Notifier service (called by Governor)
+func (n *Notifier) Run() {
+	defer func() {
+		n.mu.Lock()
+		n.stopped = true
+		n.mu.Unlock()
+	}()
+	done := n.Context.Done()
+	for {
+		select {
+		case d := <-n.queue:
+			n.deliver(d)
+		case <-done:
+			return // shutdown
+		}
+	}
+}
+
+// BlockActivity queues an Event for each (subscription, transaction) in the block.
+// Called on the Indexer goroutine: it must not block.
+func (n *Notifier) BlockActivity(height int64, block *walker.ChainBlock, activity []index.Activity) {
+	type txSub struct {
+		tx  string
+		sub Subscription
+	}
+	events := map[txSub]*Event{}
+	var order []txSub // events in activity order
+	for _, a := range activity {
+		for _, sub := range n.subs[scriptKey(a.Type, a.Script)] {
+			key := txSub{string(a.Tx), sub}
+			event, found := events[key]
+			if !found {
+				event = &Event{Address: sub.Address, TxID: doge.HexEncodeReversed(a.Tx), Height: height, Block: block.Hash, Confirmations: 1}
+				events[key] = event
+				order = append(order, key)
+			}
+			if a.Spent {
+				event.Spent += koinu.Koinu(a.Value)
+			} else {
+				event.Received += koinu.Koinu(a.Value)
+			}
+		}
+	}
+	for _, key := range order {
+		event := events[key]
+		event.Amount = event.Received - event.Spent
+		body, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("[Webhook] cannot encode event: %v", err)
+			continue
+		}
+		n.enqueue(delivery{url: key.sub.URL, body: body})
+	}
+}
+
+// BlockIndexed does nothing (see BlockActivity.)
+func (n *Notifier) BlockIndexed(block *walker.ChainBlock) {}
+
+func (n *Notifier) enqueue(d delivery) {
+	select {
+	case n.queue <- d:
+	default:
+		log.Printf("[Webhook] queue full: dropped event for %s", d.url)
+	}
+}
+
+// deliver POSTs an event, scheduling a retry if it fails.
+func (n *Notifier) deliver(d delivery) {
+	err := n.post(d)
+	if err == nil {
+		return
+	}
+	d.attempts++
+	if d.attempts >= maxAttempts {
+		log.Printf("[Webhook] giving up on %s after %d attempts: %v", d.url, d.attempts, err)
+		return
+	}
+	delay := n.firstRetry << (d.attempts - 1)
+	log.Printf("[Webhook] %s failed (retry in %v): %v", d.url, delay, err)
+	time.AfterFunc(delay, func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		if !n.stopped {
+			n.enqueue(d)
+		}
+	})
+}
+
+func (n *Notifier) post(d delivery) error {
+	req, err := http.NewRequestWithContext(n.Context, http.MethodPost, d.url, bytes.NewReader(d.body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(n.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(n.secret, d.body))
+	}
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("status %s", res.Status)
+	}
+	return nil
+}
+
+// Sign returns the SignatureHeader value for `body`, so receivers can verify
+// an event came from the indexer.
+func Sign(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func scriptKey(kind doge.ScriptType, script []byte) string {
+	return string(append([]byte{byte(kind)}, script...))
+}
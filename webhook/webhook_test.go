@@ -0,0 +1,164 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dogeorg/doge"
+	"github.com/dogeorg/dogewalker/walker"
+	"github.com/dogeorg/indexer/index"
+	"github.com/dogeorg/indexer/spec"
+)
+
+var chain = &doge.DogeRegTestChain
+
+type received struct {
+	body      string
+	signature string
+}
+
+// receiver is a webhook endpoint that fails the first `failures` requests.
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+	got      []received
+	done     chan struct{} // closed at the first successful delivery
+}
+
+func newReceiver(failures int) (*receiver, *httptest.Server) {
+	r := &receiver{failures: failures, done: make(chan struct{})}
+	return r, httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.attempts++
+		if r.attempts <= r.failures {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		r.got = append(r.got, received{body: string(body), signature: req.Header.Get(SignatureHeader)})
+		if len(r.got) == 1 {
+			close(r.done)
+		}
+	}))
+}
+
+func (r *receiver) wait(t *testing.T) {
+	t.Helper()
+	select {
+	case <-r.done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no event delivered")
+	}
+}
+
+// startNotifier runs a Notifier for one subscription until the test ends.
+func startNotifier(t *testing.T, url string, secret string) (*Notifier, []byte) {
+	t.Helper()
+	hash := bytes.Repeat([]byte{0x42}, 20)
+	address := string(doge.Hash160toAddress(hash, chain.P2PKH_Address_Prefix))
+	n, err := NewNotifier([]Subscription{{Address: address, URL: url}}, chain, secret)
+	if err != nil {
+		t.Fatalf("NewNotifier: %v", err)
+	}
+	n.firstRetry = time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	n.Context = ctx
+	go n.Run()
+	t.Cleanup(cancel)
+	return n, hash
+}
+
+func TestNotifierDelivers(t *testing.T) {
+	r, server := newReceiver(0)
+	defer server.Close()
+	n, hash := startNotifier(t, server.URL, "")
+	address := string(doge.Hash160toAddress(hash, chain.P2PKH_Address_Prefix))
+
+	tx := bytes.Repeat([]byte{0xAA}, 31)
+	tx = append(tx, 0x01) // displayed byte-reversed: 01aaaa...
+	other := bytes.Repeat([]byte{0x43}, 20)
+	n.BlockActivity(100, &walker.ChainBlock{Hash: "beef"}, []index.Activity{
+		{UTXO: spec.UTXO{TxID: tx, VOut: 0, Value: 150000000, Type: doge.ScriptTypeP2PKH, Script: hash}, Tx: tx},
+		{UTXO: spec.UTXO{TxID: tx, VOut: 1, Value: 900000000, Type: doge.ScriptTypeP2PKH, Script: other}, Tx: tx}, // not subscribed
+		{UTXO: spec.UTXO{TxID: tx, VOut: 2, Value: 1, Type: doge.ScriptTypeP2SH, Script: hash}, Tx: tx},           // same hash, other kind
+		{UTXO: spec.UTXO{TxID: bytes.Repeat([]byte{0xBB}, 32), Value: 50000000, Type: doge.ScriptTypeP2PKH, Script: hash}, Tx: tx, Spent: true},
+	})
+	r.wait(t)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	expected := `{"address":"` + address + `","tx":"01aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa","height":100,"block":"beef","confirmations":1,"received":"1.5","spent":"0.5","amount":"1"}`
+	if len(r.got) != 1 || r.got[0].body != expected {
+		t.Fatalf("expected one event %s, got %+v", expected, r.got)
+	}
+	if r.got[0].signature != "" {
+		t.Errorf("expected no signature without a secret, got %q", r.got[0].signature)
+	}
+}
+
+func TestNotifierRetries(t *testing.T) {
+	r, server := newReceiver(2)
+	defer server.Close()
+	n, hash := startNotifier(t, server.URL, "")
+
+	tx := bytes.Repeat([]byte{0xCC}, 32)
+	n.BlockActivity(7, &walker.ChainBlock{Hash: "cafe"}, []index.Activity{
+		{UTXO: spec.UTXO{TxID: tx, Value: 100000000, Type: doge.ScriptTypeP2PKH, Script: hash}, Tx: tx},
+	})
+	r.wait(t)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.attempts != 3 || len(r.got) != 1 {
+		t.Fatalf("expected delivery on the 3rd attempt, got %d attempts and %d events", r.attempts, len(r.got))
+	}
+}
+
+func TestNotifierSignature(t *testing.T) {
+	r, server := newReceiver(0)
+	defer server.Close()
+	n, hash := startNotifier(t, server.URL, "s3cret")
+
+	tx := bytes.Repeat([]byte{0xDD}, 32)
+	n.BlockActivity(8, &walker.ChainBlock{Hash: "f00d"}, []index.Activity{
+		{UTXO: spec.UTXO{TxID: tx, Value: 1, Type: doge.ScriptTypeP2PKH, Script: hash}, Tx: tx},
+	})
+	r.wait(t)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(r.got[0].body))
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if r.got[0].signature != expected {
+		t.Fatalf("expected signature %q, got %q", expected, r.got[0].signature)
+	}
+	if Sign([]byte("other"), []byte(r.got[0].body)) == expected {
+		t.Fatalf("signature doesn't depend on the secret")
+	}
+}
+
+func TestNewNotifierRejectsBadSubscriptions(t *testing.T) {
+	mainnet := string(doge.Hash160toAddress(bytes.Repeat([]byte{1}, 20), doge.DogeMainNetChain.P2PKH_Address_Prefix))
+	regtest := string(doge.Hash160toAddress(bytes.Repeat([]byte{1}, 20), chain.P2SH_Address_Prefix))
+	for _, sub := range []Subscription{
+		{Address: "not-an-address", URL: "http://example.com"},
+		{Address: mainnet, URL: "http://example.com"}, // wrong chain
+		{Address: regtest}, // no URL
+	} {
+		if _, err := NewNotifier([]Subscription{sub}, chain, ""); err == nil {
+			t.Errorf("expected an error for %+v", sub)
+		}
+	}
+}